@@ -0,0 +1,271 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package aggregation provides a model.BatchProcessor which aggregates
+// transaction and span events into metrics: transaction duration
+// metrics, and service destination metrics.
+package aggregation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/elastic/apm-data/model"
+)
+
+const (
+	// maxDuration is the highest duration recorded in transaction
+	// duration histograms. Longer durations are recorded as maxDuration.
+	maxDuration = time.Hour
+
+	// histogramCountScale is the scale applied to representative counts
+	// before recording them in histograms, so that fractional counts
+	// (resulting from sampling) are not truncated.
+	histogramCountScale = 1000
+
+	transactionMetricsetName        = "transaction"
+	serviceDestinationMetricsetName = "service_destination"
+)
+
+// Config holds configuration for creating an Aggregator.
+type Config struct {
+	// BatchProcessor is a model.BatchProcessor for asynchronously
+	// processing metrics documents.
+	BatchProcessor model.BatchProcessor
+
+	// Interval is the interval between publishing of aggregated metrics.
+	// There may be additional metrics reported at arbitrary times if the
+	// aggregation groups fill up.
+	Interval time.Duration
+
+	// MaxTransactionGroups is the maximum number of distinct transaction
+	// group metrics to store within an aggregation period. Once this
+	// number of groups has been reached, any new aggregation keys will
+	// cause individual metrics documents to be immediately published.
+	MaxTransactionGroups int
+
+	// MaxServiceDestinationGroups is the maximum number of distinct
+	// service destination group metrics to store within an aggregation
+	// period. Once this number of groups has been reached, any new
+	// aggregation keys will cause individual metrics documents to be
+	// immediately published.
+	MaxServiceDestinationGroups int
+
+	// HDRHistogramSignificantFigures is the number of significant
+	// figures to maintain in the transaction duration histograms.
+	// This must be in the range [1,5].
+	HDRHistogramSignificantFigures int
+
+	// Logger holds a logger for the aggregator. If this is nil, then
+	// no logging will be performed.
+	Logger *zap.Logger
+}
+
+// Validate validates the aggregator config.
+func (config Config) Validate() error {
+	if config.BatchProcessor == nil {
+		return errors.New("BatchProcessor unspecified")
+	}
+	if config.Interval <= 0 {
+		return errors.New("Interval unspecified or negative")
+	}
+	if config.MaxTransactionGroups <= 0 {
+		return errors.New("MaxTransactionGroups unspecified or negative")
+	}
+	if config.MaxServiceDestinationGroups <= 0 {
+		return errors.New("MaxServiceDestinationGroups unspecified or negative")
+	}
+	if n := config.HDRHistogramSignificantFigures; n < 1 || n > 5 {
+		return fmt.Errorf("HDRHistogramSignificantFigures (%d) outside range [1,5]", n)
+	}
+	return nil
+}
+
+// Aggregator aggregates transaction and span events into metrics,
+// periodically publishing them with the configured BatchProcessor.
+//
+// Events are aggregated by ProcessBatch, and the aggregated metrics
+// are published every Interval while Run is running, and once more
+// when Stop is called.
+type Aggregator struct {
+	config Config
+
+	stopMu   sync.Mutex
+	stopping chan struct{}
+	stopped  chan struct{}
+
+	mu sync.Mutex
+	// active and inactive hold the groups being aggregated into, and
+	// the groups previously published which may be reused.
+	active, inactive *groups
+
+	histogramPool sync.Pool
+}
+
+// New returns a new Aggregator with the given config.
+func New(config Config) (*Aggregator, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid aggregator config: %w", err)
+	}
+	if config.Logger == nil {
+		config.Logger = zap.NewNop()
+	} else {
+		config.Logger = config.Logger.Named("aggregation")
+	}
+	return &Aggregator{
+		config:   config,
+		stopping: make(chan struct{}),
+		stopped:  make(chan struct{}),
+		active:   newGroups(),
+		inactive: newGroups(),
+	}, nil
+}
+
+// Run runs the Aggregator, periodically publishing and clearing
+// aggregated metrics. Run returns when either a fatal error occurs,
+// or the Aggregator's Stop method is invoked.
+func (a *Aggregator) Run() error {
+	ticker := time.NewTicker(a.config.Interval)
+	defer ticker.Stop()
+	defer close(a.stopped)
+	for stop := false; !stop; {
+		select {
+		case <-a.stopping:
+			stop = true
+		case <-ticker.C:
+		}
+		if err := a.publish(context.Background()); err != nil {
+			a.config.Logger.Warn("publishing metrics failed", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// Stop stops the Aggregator if running, waiting for the current
+// aggregation period's metrics to be published or for ctx to be
+// canceled, whichever happens first.
+func (a *Aggregator) Stop(ctx context.Context) error {
+	a.stopMu.Lock()
+	select {
+	case <-a.stopping:
+	default:
+		close(a.stopping)
+	}
+	a.stopMu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-a.stopped:
+	}
+	return nil
+}
+
+// ProcessBatch aggregates all transactions and spans contained in b.
+// Transactions' dropped span statistics are aggregated as service
+// destination metrics.
+//
+// If an event cannot be aggregated because the maximum number of
+// groups has been reached, a metricset for the event alone is
+// appended to b, to be published immediately.
+//
+// Events in b are not otherwise modified.
+func (a *Aggregator) ProcessBatch(ctx context.Context, b *model.Batch) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for i, n := 0, len(*b); i < n; i++ {
+		event := &(*b)[i]
+		switch event.Processor {
+		case model.TransactionProcessor:
+			if event.Transaction == nil {
+				continue
+			}
+			if metricset, ok := a.processTransaction(event); !ok {
+				*b = append(*b, metricset)
+				event = &(*b)[i]
+			}
+			for _, dss := range event.Transaction.DroppedSpansStats {
+				if metricset, ok := a.processDroppedSpanStats(event, dss); !ok {
+					*b = append(*b, metricset)
+					event = &(*b)[i]
+				}
+			}
+		case model.SpanProcessor:
+			if metricset, ok := a.processSpan(event); !ok {
+				*b = append(*b, metricset)
+			}
+		}
+	}
+	return nil
+}
+
+// publish publishes and clears the aggregated metrics.
+func (a *Aggregator) publish(ctx context.Context) error {
+	// We hold a.mu only long enough to swap the groups. This will
+	// be blocked by ProcessBatch calls, but they should be fast.
+	a.mu.Lock()
+	current := a.active
+	a.active, a.inactive = a.inactive, current
+	a.mu.Unlock()
+
+	size := len(current.transactions) + len(current.serviceDestinations)
+	if size == 0 {
+		a.config.Logger.Debug("no metrics to publish")
+		return nil
+	}
+	batch := make(model.Batch, 0, size)
+	for key, metrics := range current.transactions {
+		batch = append(batch, makeTransactionMetricset(key, metrics))
+		a.histogramPool.Put(metrics.histogram)
+		delete(current.transactions, key)
+	}
+	for key, metrics := range current.serviceDestinations {
+		batch = append(batch, makeServiceDestinationMetricset(key, metrics))
+		delete(current.serviceDestinations, key)
+	}
+	a.config.Logger.Debug("publishing metrics", zap.Int("count", len(batch)))
+	return a.config.BatchProcessor.ProcessBatch(ctx, &batch)
+}
+
+// newHistogram returns a reset histogram for recording transaction
+// durations in microseconds, reusing a previously published one if
+// possible.
+func (a *Aggregator) newHistogram() *hdrHistogram {
+	if h, ok := a.histogramPool.Get().(*hdrHistogram); ok {
+		h.reset()
+		return h
+	}
+	return newHDRHistogram(maxDuration.Microseconds(), a.config.HDRHistogramSignificantFigures)
+}
+
+// groups holds the metrics aggregated within an aggregation period.
+type groups struct {
+	transactions        map[transactionAggregationKey]*transactionMetrics
+	serviceDestinations map[serviceDestinationAggregationKey]*serviceDestinationMetrics
+}
+
+func newGroups() *groups {
+	return &groups{
+		transactions:        make(map[transactionAggregationKey]*transactionMetrics),
+		serviceDestinations: make(map[serviceDestinationAggregationKey]*serviceDestinationMetrics),
+	}
+}
@@ -0,0 +1,329 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregation
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/apm-data/model"
+)
+
+func TestNewAggregatorConfigInvalid(t *testing.T) {
+	report := model.ProcessBatchFunc(func(context.Context, *model.Batch) error { return nil })
+	type test struct {
+		config Config
+		err    string
+	}
+	for _, test := range []test{{
+		config: Config{},
+		err:    "BatchProcessor unspecified",
+	}, {
+		config: Config{BatchProcessor: report},
+		err:    "Interval unspecified or negative",
+	}, {
+		config: Config{BatchProcessor: report, Interval: time.Second},
+		err:    "MaxTransactionGroups unspecified or negative",
+	}, {
+		config: Config{BatchProcessor: report, Interval: time.Second, MaxTransactionGroups: 1},
+		err:    "MaxServiceDestinationGroups unspecified or negative",
+	}, {
+		config: Config{
+			BatchProcessor:                 report,
+			Interval:                       time.Second,
+			MaxTransactionGroups:           1,
+			MaxServiceDestinationGroups:    1,
+			HDRHistogramSignificantFigures: 6,
+		},
+		err: "HDRHistogramSignificantFigures (6) outside range [1,5]",
+	}} {
+		agg, err := New(test.config)
+		require.Error(t, err)
+		assert.Nil(t, agg)
+		assert.EqualError(t, err, "invalid aggregator config: "+test.err)
+	}
+}
+
+func TestAggregateTransactions(t *testing.T) {
+	batches := make(chan model.Batch, 1)
+	agg := newTestAggregator(t, batches, 2, 10)
+
+	timestamp := time.Unix(1000, 500)
+	transaction := func(name, outcome string, duration time.Duration, count float64) model.APMEvent {
+		return model.APMEvent{
+			Timestamp: timestamp,
+			Agent:     model.Agent{Name: "go"},
+			Service:   model.Service{Name: "service", Environment: "production"},
+			Event:     model.Event{Outcome: outcome, Duration: duration},
+			Processor: model.TransactionProcessor,
+			Transaction: &model.Transaction{
+				Name:                name,
+				Type:                "request",
+				RepresentativeCount: count,
+			},
+		}
+	}
+	batch := model.Batch{
+		transaction("T-1", "success", time.Millisecond, 1),
+		transaction("T-1", "success", 2*time.Millisecond, 2),
+		transaction("T-2", "failure", time.Millisecond, 1),
+		// Unsampled transactions are not aggregated.
+		transaction("T-2", "failure", time.Millisecond, 0),
+		// Group limit reached: metrics are returned in the batch.
+		transaction("T-3", "unknown", 3*time.Millisecond, 1),
+	}
+	require.NoError(t, agg.ProcessBatch(context.Background(), &batch))
+	require.Len(t, batch, 6)
+
+	expectedT3 := model.APMEvent{
+		Timestamp: time.Unix(1000, 0),
+		Agent:     model.Agent{Name: "go"},
+		Service:   model.Service{Name: "service", Environment: "production"},
+		Event:     model.Event{Outcome: "unknown"},
+		Processor: model.MetricsetProcessor,
+		Metricset: &model.Metricset{Name: "transaction", DocCount: 1},
+		Transaction: &model.Transaction{
+			Name: "T-3",
+			Type: "request",
+			Root: true,
+			DurationHistogram: model.Histogram{
+				Values: []float64{3007},
+				Counts: []int64{1},
+			},
+			DurationSummary: model.SummaryMetric{Count: 1, Sum: 3000},
+		},
+	}
+	assert.Equal(t, expectedT3, batch[5])
+
+	require.NoError(t, agg.publish(context.Background()))
+	metricsets := expectBatch(t, batches)
+	require.Len(t, metricsets, 2)
+	assert.Equal(t, model.Transaction{
+		Name: "T-1",
+		Type: "request",
+		Root: true,
+		DurationHistogram: model.Histogram{
+			Values: []float64{1003, 2007},
+			Counts: []int64{1, 2},
+		},
+		DurationSummary: model.SummaryMetric{Count: 3, Sum: 5000},
+		SuccessCount:    model.SummaryMetric{Count: 3, Sum: 3},
+	}, *metricsets[0].Transaction)
+	assert.Equal(t, &model.Metricset{Name: "transaction", DocCount: 3}, metricsets[0].Metricset)
+	assert.Equal(t, "T-2", metricsets[1].Transaction.Name)
+	assert.Equal(t, model.SummaryMetric{Count: 1, Sum: 0}, metricsets[1].Transaction.SuccessCount)
+
+	// Nothing is published after metrics have been published.
+	require.NoError(t, agg.publish(context.Background()))
+	select {
+	case batch := <-batches:
+		t.Fatalf("unexpected batch: %v", batch)
+	default:
+	}
+}
+
+func TestAggregateServiceDestinations(t *testing.T) {
+	batches := make(chan model.Batch, 1)
+	agg := newTestAggregator(t, batches, 10, 2)
+
+	timestamp := time.Unix(1000, 500)
+	span := func(resource string, duration time.Duration, count float64, composite *model.Composite) model.APMEvent {
+		return model.APMEvent{
+			Timestamp: timestamp,
+			Agent:     model.Agent{Name: "go"},
+			Service: model.Service{
+				Name:   "service",
+				Target: &model.ServiceTarget{Type: "db", Name: resource},
+			},
+			Event:     model.Event{Outcome: "success", Duration: duration},
+			Processor: model.SpanProcessor,
+			Span: &model.Span{
+				Name:                "SELECT",
+				RepresentativeCount: count,
+				Composite:           composite,
+				DestinationService:  &model.DestinationService{Resource: resource},
+			},
+		}
+	}
+	batch := model.Batch{
+		span("mysql", 10*time.Millisecond, 2, nil),
+		span("mysql", time.Hour, 1, &model.Composite{Count: 3, Sum: 30}),
+		// Spans with no destination service resource are not aggregated.
+		span("", time.Millisecond, 1, nil),
+		{
+			Timestamp: timestamp,
+			Agent:     model.Agent{Name: "go"},
+			Service:   model.Service{Name: "service"},
+			Processor: model.TransactionProcessor,
+			Transaction: &model.Transaction{
+				RepresentativeCount: 2,
+				DroppedSpansStats: []model.DroppedSpanStats{{
+					DestinationServiceResource: "postgres",
+					ServiceTargetType:          "db",
+					ServiceTargetName:          "postgres",
+					Outcome:                    "success",
+					Duration: model.AggregatedDuration{
+						Count: 5,
+						Sum:   50 * time.Millisecond,
+					},
+				}},
+			},
+		},
+		// Group limit reached: metrics are returned in the batch.
+		span("redis", time.Millisecond, 1, nil),
+	}
+	require.NoError(t, agg.ProcessBatch(context.Background(), &batch))
+	require.Len(t, batch, 6)
+	assert.Equal(t, model.APMEvent{
+		Timestamp: time.Unix(1000, 0),
+		Agent:     model.Agent{Name: "go"},
+		Service: model.Service{
+			Name:   "service",
+			Target: &model.ServiceTarget{Type: "db", Name: "redis"},
+		},
+		Event:     model.Event{Outcome: "success"},
+		Processor: model.MetricsetProcessor,
+		Metricset: &model.Metricset{Name: "service_destination", DocCount: 1},
+		Span: &model.Span{
+			Name: "SELECT",
+			DestinationService: &model.DestinationService{
+				Resource: "redis",
+				ResponseTime: model.AggregatedDuration{
+					Count: 1,
+					Sum:   time.Millisecond,
+				},
+			},
+		},
+	}, batch[5])
+
+	require.NoError(t, agg.publish(context.Background()))
+	metricsets := expectBatch(t, batches)
+	require.Len(t, metricsets, 3)
+	assert.Equal(t, "mysql", metricsets[0].Span.DestinationService.Resource)
+	assert.Equal(t, "SELECT", metricsets[0].Span.Name)
+	assert.Equal(t, &model.Metricset{Name: "service_destination", DocCount: 5}, metricsets[0].Metricset)
+	assert.Equal(t, model.AggregatedDuration{
+		Count: 5,
+		Sum:   50 * time.Millisecond,
+	}, metricsets[0].Span.DestinationService.ResponseTime)
+
+	assert.Equal(t, "postgres", metricsets[1].Span.DestinationService.Resource)
+	assert.Equal(t, "", metricsets[1].Span.Name)
+	assert.Equal(t, &model.ServiceTarget{Type: "db", Name: "postgres"}, metricsets[1].Service.Target)
+	assert.Equal(t, model.AggregatedDuration{
+		Count: 10,
+		Sum:   100 * time.Millisecond,
+	}, metricsets[1].Span.DestinationService.ResponseTime)
+
+	// The transaction carrying dropped span statistics is itself
+	// aggregated as transaction metrics.
+	assert.Equal(t, &model.Metricset{Name: "transaction", DocCount: 2}, metricsets[2].Metricset)
+}
+
+func TestAggregatorRunStop(t *testing.T) {
+	batches := make(chan model.Batch, 1)
+	agg := newTestAggregator(t, batches, 10, 10)
+	agg.config.Interval = time.Hour
+
+	go agg.Run()
+	batch := model.Batch{{
+		Timestamp:   time.Now(),
+		Processor:   model.TransactionProcessor,
+		Transaction: &model.Transaction{Name: "T", RepresentativeCount: 1},
+	}}
+	require.NoError(t, agg.ProcessBatch(context.Background(), &batch))
+	require.NoError(t, agg.Stop(context.Background()))
+
+	// Stopping the aggregator publishes the remaining metrics.
+	metricsets := expectBatch(t, batches)
+	require.Len(t, metricsets, 1)
+	assert.Equal(t, "T", metricsets[0].Transaction.Name)
+
+	// Stop is idempotent.
+	require.NoError(t, agg.Stop(context.Background()))
+}
+
+func TestAggregatorStopContextCanceled(t *testing.T) {
+	agg := newTestAggregator(t, make(chan model.Batch), 10, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// Run was never started, so Stop waits until ctx is canceled.
+	assert.Equal(t, context.Canceled, agg.Stop(ctx))
+}
+
+func BenchmarkAggregateTransaction(b *testing.B) {
+	agg := newTestAggregator(b, make(chan model.Batch, 1), 1000, 1000)
+	batch := model.Batch{{
+		Timestamp:   time.Now(),
+		Agent:       model.Agent{Name: "go"},
+		Service:     model.Service{Name: "service"},
+		Event:       model.Event{Outcome: "success", Duration: time.Millisecond},
+		Processor:   model.TransactionProcessor,
+		Transaction: &model.Transaction{Name: "T", Type: "request", RepresentativeCount: 1},
+	}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := agg.ProcessBatch(context.Background(), &batch); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func newTestAggregator(tb testing.TB, batches chan<- model.Batch, maxTransactionGroups, maxServiceDestinationGroups int) *Aggregator {
+	agg, err := New(Config{
+		BatchProcessor: model.ProcessBatchFunc(func(ctx context.Context, batch *model.Batch) error {
+			batches <- *batch
+			return nil
+		}),
+		Interval:                       time.Second,
+		MaxTransactionGroups:           maxTransactionGroups,
+		MaxServiceDestinationGroups:    maxServiceDestinationGroups,
+		HDRHistogramSignificantFigures: 2,
+	})
+	require.NoError(tb, err)
+	return agg
+}
+
+// expectBatch receives a batch from batches, and returns its events
+// sorted by metricset name, and then by transaction name or span
+// destination service resource.
+func expectBatch(t testing.TB, batches <-chan model.Batch) model.Batch {
+	select {
+	case batch := <-batches:
+		sort.Slice(batch, func(i, j int) bool {
+			return sortKey(batch[i]) < sortKey(batch[j])
+		})
+		return batch
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for batch")
+	}
+	panic("unreachable")
+}
+
+func sortKey(event model.APMEvent) string {
+	if event.Span != nil {
+		return event.Metricset.Name + "/" + event.Span.DestinationService.Resource
+	}
+	return event.Metricset.Name + "/" + event.Transaction.Name
+}
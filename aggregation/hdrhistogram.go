@@ -0,0 +1,118 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregation
+
+import (
+	"math"
+	"math/bits"
+)
+
+// hdrHistogram is a minimal HDR histogram, recording integer values
+// with a fixed number of significant figures of precision.
+//
+// The layout and bucketing follow the HdrHistogram algorithm
+// (http://hdrhistogram.org): values are recorded in buckets which
+// double in size, each divided into linear sub-buckets sized so that
+// values are distinguished to the configured number of significant
+// figures. The lowest discernible value is 1.
+type hdrHistogram struct {
+	highestTrackableValue       int64
+	subBucketHalfCountMagnitude int
+	subBucketHalfCount          int
+	subBucketMask               int64
+	counts                      []int64
+}
+
+// newHDRHistogram returns a new hdrHistogram which records values in
+// the range [0, highestTrackableValue] with the given number of
+// significant figures, which must be in the range [1, 5].
+func newHDRHistogram(highestTrackableValue int64, significantFigures int) *hdrHistogram {
+	largestValueWithSingleUnitResolution := 2 * int64(math.Pow10(significantFigures))
+	subBucketCountMagnitude := bits.Len64(uint64(largestValueWithSingleUnitResolution - 1))
+	subBucketHalfCountMagnitude := subBucketCountMagnitude - 1
+	if subBucketHalfCountMagnitude < 1 {
+		subBucketHalfCountMagnitude = 1
+	}
+	subBucketCount := int64(1) << (subBucketHalfCountMagnitude + 1)
+	subBucketHalfCount := int(subBucketCount / 2)
+
+	// Determine the number of buckets required to cover the value range.
+	bucketCount := 1
+	for smallestUntrackableValue := subBucketCount; smallestUntrackableValue <= highestTrackableValue; bucketCount++ {
+		if smallestUntrackableValue > math.MaxInt64/2 {
+			bucketCount++
+			break
+		}
+		smallestUntrackableValue <<= 1
+	}
+	return &hdrHistogram{
+		highestTrackableValue:       highestTrackableValue,
+		subBucketHalfCountMagnitude: subBucketHalfCountMagnitude,
+		subBucketHalfCount:          subBucketHalfCount,
+		subBucketMask:               subBucketCount - 1,
+		counts:                      make([]int64, (bucketCount+1)*subBucketHalfCount),
+	}
+}
+
+// recordValues records n occurrences of v. Values outside the trackable
+// range are clamped to it.
+func (h *hdrHistogram) recordValues(v, n int64) {
+	if v < 0 {
+		v = 0
+	} else if v > h.highestTrackableValue {
+		v = h.highestTrackableValue
+	}
+	h.counts[h.countsIndex(v)] += n
+}
+
+// reset clears all recorded values.
+func (h *hdrHistogram) reset() {
+	for i := range h.counts {
+		h.counts[i] = 0
+	}
+}
+
+// buckets calls f for each non-empty bucket in ascending order, with
+// the highest value equivalent to the bucket's values and its count.
+func (h *hdrHistogram) buckets(f func(value, count int64)) {
+	for i, count := range h.counts {
+		if count == 0 {
+			continue
+		}
+		bucketIndex, subBucketIndex := h.indices(i)
+		lowest := int64(subBucketIndex) << bucketIndex
+		f(lowest+int64(1)<<bucketIndex-1, count)
+	}
+}
+
+func (h *hdrHistogram) countsIndex(v int64) int {
+	pow2Ceiling := bits.Len64(uint64(v | h.subBucketMask))
+	bucketIndex := pow2Ceiling - (h.subBucketHalfCountMagnitude + 1)
+	subBucketIndex := int(v >> bucketIndex)
+	return (bucketIndex+1)<<h.subBucketHalfCountMagnitude + (subBucketIndex - h.subBucketHalfCount)
+}
+
+func (h *hdrHistogram) indices(countsIndex int) (bucketIndex, subBucketIndex int) {
+	bucketIndex = (countsIndex >> h.subBucketHalfCountMagnitude) - 1
+	subBucketIndex = (countsIndex & (h.subBucketHalfCount - 1)) + h.subBucketHalfCount
+	if bucketIndex < 0 {
+		subBucketIndex -= h.subBucketHalfCount
+		bucketIndex = 0
+	}
+	return bucketIndex, subBucketIndex
+}
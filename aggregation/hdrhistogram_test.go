@@ -0,0 +1,78 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHDRHistogram(t *testing.T) {
+	h := newHDRHistogram(3600000000, 2)
+	assert.Len(t, h.counts, 26*128)
+
+	for _, v := range []int64{0, 1, 255, 256, 1000, 1001, 1003, 1004, 3600000000, 1 << 40} {
+		h.recordValues(v, 1)
+	}
+	h.recordValues(-1, 10)
+
+	type bucket struct{ value, count int64 }
+	var buckets []bucket
+	h.buckets(func(value, count int64) {
+		buckets = append(buckets, bucket{value, count})
+	})
+	assert.Equal(t, []bucket{
+		{0, 11},
+		{1, 1},
+		{255, 1},
+		{257, 1},        // [256, 257]
+		{1003, 3},       // [1000, 1003]
+		{1007, 1},       // [1004, 1007]
+		{3607101439, 2}, // [3590324224, 3607101439]
+	}, buckets)
+
+	h.reset()
+	h.buckets(func(value, count int64) {
+		t.Errorf("unexpected bucket %d: %d", value, count)
+	})
+}
+
+func TestHDRHistogramSignificantFigures(t *testing.T) {
+	for figures := 1; figures <= 5; figures++ {
+		h := newHDRHistogram(1000000, figures)
+		for v := int64(1); v <= 1000000; v = v*3 + 1 {
+			h.reset()
+			h.recordValues(v, 1)
+			h.buckets(func(value, count int64) {
+				// The bucket value must be within the relative
+				// precision implied by the significant figures.
+				assert.GreaterOrEqual(t, value, v)
+				assert.LessOrEqual(t, float64(value-v)/float64(v), 1/pow10(figures))
+			})
+		}
+	}
+}
+
+func pow10(n int) float64 {
+	v := 1.0
+	for i := 0; i < n; i++ {
+		v *= 10
+	}
+	return v
+}
@@ -0,0 +1,165 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregation
+
+import (
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/elastic/apm-data/model"
+)
+
+// serviceDestinationAggregationKey holds the fields by which outgoing
+// requests to service destinations are grouped for aggregation.
+type serviceDestinationAggregationKey struct {
+	timestamp time.Time
+
+	agentName          string
+	serviceName        string
+	serviceEnvironment string
+	targetType         string
+	targetName         string
+	resource           string
+	spanName           string
+	eventOutcome       string
+}
+
+// serviceDestinationMetrics holds aggregated service destination
+// metrics: the representative count and the sum of durations of
+// requests to the destination.
+type serviceDestinationMetrics struct {
+	count float64
+	sum   float64 // nanoseconds
+}
+
+// processSpan aggregates event, which must be a span, if it has a
+// destination service resource.
+//
+// If the span cannot be aggregated due to the group limit being
+// reached, a metricset for the span alone is returned with false.
+func (a *Aggregator) processSpan(event *model.APMEvent) (model.APMEvent, bool) {
+	span := event.Span
+	if span == nil || span.DestinationService == nil || span.DestinationService.Resource == "" {
+		return model.APMEvent{}, true
+	}
+	if span.RepresentativeCount <= 0 {
+		return model.APMEvent{}, true
+	}
+	key := serviceDestinationAggregationKey{
+		timestamp:          event.Timestamp.Truncate(a.config.Interval),
+		agentName:          event.Agent.Name,
+		serviceName:        event.Service.Name,
+		serviceEnvironment: event.Service.Environment,
+		resource:           span.DestinationService.Resource,
+		spanName:           span.Name,
+		eventOutcome:       event.Event.Outcome,
+	}
+	if event.Service.Target != nil {
+		key.targetType = event.Service.Target.Type
+		key.targetName = event.Service.Target.Name
+	}
+	count := span.RepresentativeCount
+	duration := float64(event.Event.Duration)
+	if span.Composite != nil {
+		// Composite spans represent multiple similar spans, and their
+		// sum holds the total duration of those spans in milliseconds.
+		count *= float64(span.Composite.Count)
+		duration = span.Composite.Sum * float64(time.Millisecond)
+	}
+	return a.recordServiceDestination(key, count, duration*span.RepresentativeCount)
+}
+
+// processDroppedSpanStats aggregates dss, statistics of spans dropped
+// by an agent, of the transaction event.
+func (a *Aggregator) processDroppedSpanStats(event *model.APMEvent, dss model.DroppedSpanStats) (model.APMEvent, bool) {
+	if dss.DestinationServiceResource == "" || event.Transaction.RepresentativeCount <= 0 {
+		return model.APMEvent{}, true
+	}
+	key := serviceDestinationAggregationKey{
+		timestamp:          event.Timestamp.Truncate(a.config.Interval),
+		agentName:          event.Agent.Name,
+		serviceName:        event.Service.Name,
+		serviceEnvironment: event.Service.Environment,
+		targetType:         dss.ServiceTargetType,
+		targetName:         dss.ServiceTargetName,
+		resource:           dss.DestinationServiceResource,
+		eventOutcome:       dss.Outcome,
+	}
+	count := event.Transaction.RepresentativeCount
+	return a.recordServiceDestination(key,
+		float64(dss.Duration.Count)*count,
+		float64(dss.Duration.Sum)*count,
+	)
+}
+
+func (a *Aggregator) recordServiceDestination(
+	key serviceDestinationAggregationKey, count, duration float64,
+) (model.APMEvent, bool) {
+	metrics, ok := a.active.serviceDestinations[key]
+	if !ok {
+		if len(a.active.serviceDestinations) >= a.config.MaxServiceDestinationGroups {
+			a.config.Logger.Warn("service destination group limit reached, publishing metrics immediately",
+				zap.Int("limit", a.config.MaxServiceDestinationGroups),
+			)
+			return makeServiceDestinationMetricset(key, &serviceDestinationMetrics{
+				count: count,
+				sum:   duration,
+			}), false
+		}
+		metrics = &serviceDestinationMetrics{}
+		a.active.serviceDestinations[key] = metrics
+	}
+	metrics.count += count
+	metrics.sum += duration
+	return model.APMEvent{}, true
+}
+
+func makeServiceDestinationMetricset(key serviceDestinationAggregationKey, metrics *serviceDestinationMetrics) model.APMEvent {
+	count := int64(math.Round(metrics.count))
+	var target *model.ServiceTarget
+	if key.targetType != "" || key.targetName != "" {
+		target = &model.ServiceTarget{Type: key.targetType, Name: key.targetName}
+	}
+	return model.APMEvent{
+		Timestamp: key.timestamp,
+		Agent:     model.Agent{Name: key.agentName},
+		Service: model.Service{
+			Name:        key.serviceName,
+			Environment: key.serviceEnvironment,
+			Target:      target,
+		},
+		Event:     model.Event{Outcome: key.eventOutcome},
+		Processor: model.MetricsetProcessor,
+		Metricset: &model.Metricset{
+			Name:     serviceDestinationMetricsetName,
+			DocCount: count,
+		},
+		Span: &model.Span{
+			Name: key.spanName,
+			DestinationService: &model.DestinationService{
+				Resource: key.resource,
+				ResponseTime: model.AggregatedDuration{
+					Count: int(count),
+					Sum:   time.Duration(math.Round(metrics.sum)),
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,187 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregation
+
+import (
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/elastic/apm-data/model"
+)
+
+// transactionAggregationKey holds the fields by which transactions
+// are grouped for aggregation.
+type transactionAggregationKey struct {
+	timestamp time.Time
+
+	agentName          string
+	serviceName        string
+	serviceVersion     string
+	serviceEnvironment string
+	serviceNodeName    string
+	hostHostname       string
+	hostName           string
+	hostOSPlatform     string
+	containerID        string
+	kubernetesPodName  string
+
+	transactionName   string
+	transactionType   string
+	transactionResult string
+	transactionRoot   bool
+	eventOutcome      string
+}
+
+// transactionMetrics holds aggregated transaction metrics.
+type transactionMetrics struct {
+	// histogram holds transaction durations in microseconds, with
+	// counts scaled by histogramCountScale.
+	histogram *hdrHistogram
+
+	// count and sum hold the total representative count, and the
+	// sum of durations in microseconds scaled by representative count.
+	count float64
+	sum   float64
+
+	// successCount and failureCount hold the total representative
+	// count of transactions with "success" and "failure" outcomes.
+	successCount float64
+	failureCount float64
+}
+
+// processTransaction aggregates event, which must be a transaction.
+//
+// If the transaction cannot be aggregated due to the group limit
+// being reached, a metricset for the transaction alone is returned
+// with false.
+func (a *Aggregator) processTransaction(event *model.APMEvent) (model.APMEvent, bool) {
+	if event.Transaction.RepresentativeCount <= 0 {
+		return model.APMEvent{}, true
+	}
+	key := makeTransactionAggregationKey(event, a.config.Interval)
+	metrics, ok := a.active.transactions[key]
+	if !ok {
+		metrics = &transactionMetrics{}
+		if len(a.active.transactions) >= a.config.MaxTransactionGroups {
+			a.config.Logger.Warn("transaction group limit reached, publishing metrics immediately",
+				zap.Int("limit", a.config.MaxTransactionGroups),
+			)
+			metrics.histogram = newHDRHistogram(maxDuration.Microseconds(), a.config.HDRHistogramSignificantFigures)
+			metrics.record(event)
+			return makeTransactionMetricset(key, metrics), false
+		}
+		metrics.histogram = a.newHistogram()
+		a.active.transactions[key] = metrics
+	}
+	metrics.record(event)
+	return model.APMEvent{}, true
+}
+
+func (m *transactionMetrics) record(event *model.APMEvent) {
+	count := event.Transaction.RepresentativeCount
+	duration := event.Event.Duration
+	if duration > maxDuration {
+		duration = maxDuration
+	}
+	m.histogram.recordValues(duration.Microseconds(), int64(math.Round(count*histogramCountScale)))
+	m.count += count
+	m.sum += float64(event.Event.Duration.Microseconds()) * count
+	switch event.Event.Outcome {
+	case "success":
+		m.successCount += count
+	case "failure":
+		m.failureCount += count
+	}
+}
+
+func makeTransactionAggregationKey(event *model.APMEvent, interval time.Duration) transactionAggregationKey {
+	return transactionAggregationKey{
+		timestamp: event.Timestamp.Truncate(interval),
+
+		agentName:          event.Agent.Name,
+		serviceName:        event.Service.Name,
+		serviceVersion:     event.Service.Version,
+		serviceEnvironment: event.Service.Environment,
+		serviceNodeName:    event.Service.Node.Name,
+		hostHostname:       event.Host.Hostname,
+		hostName:           event.Host.Name,
+		hostOSPlatform:     event.Host.OS.Platform,
+		containerID:        event.Container.ID,
+		kubernetesPodName:  event.Kubernetes.PodName,
+
+		transactionName:   event.Transaction.Name,
+		transactionType:   event.Transaction.Type,
+		transactionResult: event.Transaction.Result,
+		transactionRoot:   event.Parent.ID == "",
+		eventOutcome:      event.Event.Outcome,
+	}
+}
+
+func makeTransactionMetricset(key transactionAggregationKey, metrics *transactionMetrics) model.APMEvent {
+	var histogram model.Histogram
+	var docCount int64
+	metrics.histogram.buckets(func(value, count int64) {
+		count = int64(math.Round(float64(count) / histogramCountScale))
+		if count == 0 {
+			return
+		}
+		histogram.Values = append(histogram.Values, float64(value))
+		histogram.Counts = append(histogram.Counts, count)
+		docCount += count
+	})
+	return model.APMEvent{
+		Timestamp: key.timestamp,
+		Agent:     model.Agent{Name: key.agentName},
+		Service: model.Service{
+			Name:        key.serviceName,
+			Version:     key.serviceVersion,
+			Environment: key.serviceEnvironment,
+			Node:        model.ServiceNode{Name: key.serviceNodeName},
+		},
+		Host: model.Host{
+			Hostname: key.hostHostname,
+			Name:     key.hostName,
+			OS:       model.OS{Platform: key.hostOSPlatform},
+		},
+		Container:  model.Container{ID: key.containerID},
+		Kubernetes: model.Kubernetes{PodName: key.kubernetesPodName},
+		Event:      model.Event{Outcome: key.eventOutcome},
+		Processor:  model.MetricsetProcessor,
+		Metricset: &model.Metricset{
+			Name:     transactionMetricsetName,
+			DocCount: docCount,
+		},
+		Transaction: &model.Transaction{
+			Name:              key.transactionName,
+			Type:              key.transactionType,
+			Result:            key.transactionResult,
+			Root:              key.transactionRoot,
+			DurationHistogram: histogram,
+			DurationSummary: model.SummaryMetric{
+				Count: int64(math.Round(metrics.count)),
+				Sum:   math.Round(metrics.sum),
+			},
+			SuccessCount: model.SummaryMetric{
+				Count: int64(math.Round(metrics.successCount + metrics.failureCount)),
+				Sum:   math.Round(metrics.successCount),
+			},
+		},
+	}
+}
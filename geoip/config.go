@@ -0,0 +1,51 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package geoip
+
+import (
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Config holds configuration for creating a Processor.
+type Config struct {
+	// DatabasePath holds the path to a MaxMind DB format (.mmdb)
+	// database with the GeoIP2 or GeoLite2 City schema.
+	DatabasePath string
+
+	// ReloadInterval is the interval at which the database file is
+	// checked for modifications, and reloaded if it has changed.
+	ReloadInterval time.Duration
+
+	// Logger holds a logger for the processor. If this is nil, then
+	// no logging will be performed.
+	Logger *zap.Logger
+}
+
+// Validate validates the GeoIP config.
+func (config Config) Validate() error {
+	if config.DatabasePath == "" {
+		return errors.New("DatabasePath unspecified")
+	}
+	if config.ReloadInterval <= 0 {
+		return errors.New("ReloadInterval unspecified or negative")
+	}
+	return nil
+}
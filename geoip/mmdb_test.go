@@ -0,0 +1,166 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"net/netip"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestDatabase writes a MaxMind DB format IPv6 database to path,
+// mapping each of the given networks to its record. IPv4 networks are
+// stored in the IPv4-compatible ::/96 subtree.
+func writeTestDatabase(t testing.TB, path string, records map[netip.Prefix]map[string]any) {
+	t.Helper()
+
+	type node struct {
+		children [2]*node
+		data     [2]map[string]any
+	}
+	root := &node{}
+	for prefix, record := range records {
+		addr := prefix.Addr()
+		bits := prefix.Bits()
+		if addr.Is4() {
+			var a16 [16]byte
+			a4 := addr.As4()
+			copy(a16[12:], a4[:])
+			addr = netip.AddrFrom16(a16)
+			bits += 96
+		}
+		ip := addr.As16()
+		n := root
+		for i := 0; i < bits; i++ {
+			bit := (ip[i/8] >> (7 - i%8)) & 1
+			if i == bits-1 {
+				n.data[bit] = record
+				break
+			}
+			if n.children[bit] == nil {
+				n.children[bit] = &node{}
+			}
+			n = n.children[bit]
+		}
+	}
+
+	// Number the nodes breadth-first, so the root is node 0.
+	var nodes []*node
+	index := make(map[*node]int)
+	for queue := []*node{root}; len(queue) > 0; queue = queue[1:] {
+		n := queue[0]
+		index[n] = len(nodes)
+		nodes = append(nodes, n)
+		for _, child := range n.children {
+			if child != nil {
+				queue = append(queue, child)
+			}
+		}
+	}
+
+	var data bytes.Buffer
+	nodeCount := uint32(len(nodes))
+	var tree bytes.Buffer
+	for _, n := range nodes {
+		for bit := 0; bit < 2; bit++ {
+			value := nodeCount // empty
+			if child := n.children[bit]; child != nil {
+				value = uint32(index[child])
+			} else if record := n.data[bit]; record != nil {
+				value = nodeCount + 16 + uint32(data.Len())
+				encodeMMDBValue(&data, record)
+			}
+			tree.Write([]byte{byte(value >> 16), byte(value >> 8), byte(value)})
+		}
+	}
+
+	var out bytes.Buffer
+	out.Write(tree.Bytes())
+	out.Write(make([]byte, 16))
+	out.Write(data.Bytes())
+	out.WriteString("\xAB\xCD\xEFMaxMind.com")
+	encodeMMDBValue(&out, map[string]any{
+		"node_count":                  nodeCount,
+		"record_size":                 uint16(24),
+		"ip_version":                  uint16(6),
+		"database_type":               "GeoIP2-City",
+		"languages":                   []any{"en"},
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(0),
+		"description":                 map[string]any{"en": "test database"},
+	})
+	require.NoError(t, os.WriteFile(path, out.Bytes(), 0644))
+}
+
+// encodeMMDBValue encodes v in the MaxMind DB data section format.
+func encodeMMDBValue(buf *bytes.Buffer, v any) {
+	switch v := v.(type) {
+	case string:
+		writeMMDBControl(buf, 2, len(v))
+		buf.WriteString(v)
+	case float64:
+		writeMMDBControl(buf, 3, 8)
+		binary.Write(buf, binary.BigEndian, math.Float64bits(v))
+	case uint16:
+		writeMMDBControl(buf, 5, 2)
+		binary.Write(buf, binary.BigEndian, v)
+	case uint32:
+		writeMMDBControl(buf, 6, 4)
+		binary.Write(buf, binary.BigEndian, v)
+	case uint64:
+		writeMMDBControl(buf, 9, 8)
+		binary.Write(buf, binary.BigEndian, v)
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		writeMMDBControl(buf, 7, len(v))
+		for _, k := range keys {
+			encodeMMDBValue(buf, k)
+			encodeMMDBValue(buf, v[k])
+		}
+	case []any:
+		writeMMDBControl(buf, 11, len(v))
+		for _, elem := range v {
+			encodeMMDBValue(buf, elem)
+		}
+	default:
+		panic("unsupported type")
+	}
+}
+
+func writeMMDBControl(buf *bytes.Buffer, typ, size int) {
+	if size >= 29 {
+		panic("unsupported size")
+	}
+	if typ <= 7 {
+		buf.WriteByte(byte(typ<<5 | size))
+		return
+	}
+	buf.WriteByte(byte(size))
+	buf.WriteByte(byte(typ - 7))
+}
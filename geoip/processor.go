@@ -0,0 +1,258 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package geoip provides a model.BatchProcessor which enriches events
+// with the geographical location of their client and source IPs, using
+// a local MaxMind DB format database.
+package geoip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+	"go.uber.org/zap"
+
+	"github.com/elastic/apm-data/model"
+)
+
+// Processor is a model.BatchProcessor that sets client.geo and source.geo
+// by looking up the client and source IPs in a MaxMind DB database.
+//
+// Events with no IP, a non-public IP, or with geo fields already set are
+// not modified. The database is loaded into memory, and reloaded by Run
+// when the database file changes.
+type Processor struct {
+	config Config
+
+	stopMu   sync.Mutex
+	started  bool
+	stopping chan struct{}
+	stopped  chan struct{}
+
+	mu      sync.RWMutex
+	reader  *maxminddb.Reader
+	modTime time.Time
+	size    int64
+}
+
+// NewProcessor returns a new Processor with the given config,
+// loading the database from config.DatabasePath.
+func NewProcessor(config Config) (*Processor, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid geoip config: %w", err)
+	}
+	if config.Logger == nil {
+		config.Logger = zap.NewNop()
+	} else {
+		config.Logger = config.Logger.Named("geoip")
+	}
+	p := &Processor{
+		config:   config,
+		stopping: make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	info, err := os.Stat(config.DatabasePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.load(info); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Run runs the Processor, periodically checking the database file for
+// modifications and reloading it. If reloading fails, the previously
+// loaded database continues to be used. Run returns when the Processor's
+// Stop method is invoked, immediately if Stop has already been invoked.
+//
+// Run may be invoked at most once; subsequent calls return an error.
+func (p *Processor) Run() error {
+	p.stopMu.Lock()
+	if p.started {
+		p.stopMu.Unlock()
+		return errors.New("geoip processor already started")
+	}
+	p.started = true
+	p.stopMu.Unlock()
+
+	ticker := time.NewTicker(p.config.ReloadInterval)
+	defer ticker.Stop()
+	defer close(p.stopped)
+	for {
+		select {
+		case <-p.stopping:
+			return nil
+		case <-ticker.C:
+		}
+		if err := p.maybeReload(); err != nil {
+			p.config.Logger.Warn("reloading GeoIP database failed", zap.Error(err))
+		}
+	}
+}
+
+// Stop stops the Processor if running, waiting for Run to return or
+// for ctx to be canceled, whichever happens first. If Run has not been
+// invoked, Stop returns immediately.
+func (p *Processor) Stop(ctx context.Context) error {
+	p.stopMu.Lock()
+	select {
+	case <-p.stopping:
+	default:
+		close(p.stopping)
+	}
+	started := p.started
+	p.stopMu.Unlock()
+	if !started {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.stopped:
+	}
+	return nil
+}
+
+// maybeReload reloads the database if the file's modification
+// time or size has changed since it was last loaded.
+func (p *Processor) maybeReload() error {
+	info, err := os.Stat(p.config.DatabasePath)
+	if err != nil {
+		return err
+	}
+	p.mu.RLock()
+	unchanged := info.ModTime().Equal(p.modTime) && info.Size() == p.size
+	p.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+	if err := p.load(info); err != nil {
+		return err
+	}
+	p.config.Logger.Info("reloaded GeoIP database", zap.String("path", p.config.DatabasePath))
+	return nil
+}
+
+// load reads the database into memory, replacing any previously
+// loaded database. info describes the file as of before reading.
+func (p *Processor) load(info os.FileInfo) error {
+	data, err := os.ReadFile(p.config.DatabasePath)
+	if err != nil {
+		return err
+	}
+	reader, err := maxminddb.FromBytes(data)
+	if err != nil {
+		return fmt.Errorf("failed to load GeoIP database %q: %w", p.config.DatabasePath, err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reader = reader
+	p.modTime = info.ModTime()
+	p.size = info.Size()
+	return nil
+}
+
+// ProcessBatch sets client.geo and source.geo for events in b.
+func (p *Processor) ProcessBatch(ctx context.Context, b *model.Batch) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for i := range *b {
+		event := &(*b)[i]
+		p.lookup(event.Client.IP, &event.Client.Geo)
+		p.lookup(event.Source.IP, &event.Source.Geo)
+	}
+	return nil
+}
+
+// lookup sets geo to the location of ip, if ip is a public address
+// found in the database, and geo is not already set.
+func (p *Processor) lookup(ip netip.Addr, geo *model.Geo) {
+	if !isPublic(ip) || !isEmpty(geo) {
+		return
+	}
+	var record cityRecord
+	_, ok, err := p.reader.LookupNetwork(net.IP(ip.Unmap().AsSlice()), &record)
+	if err != nil {
+		p.config.Logger.Debug("GeoIP lookup failed", zap.Stringer("ip", ip), zap.Error(err))
+		return
+	}
+	if ok {
+		record.setGeo(geo)
+	}
+}
+
+func isPublic(ip netip.Addr) bool {
+	return ip.IsValid() && ip.IsGlobalUnicast() && !ip.IsPrivate()
+}
+
+func isEmpty(geo *model.Geo) bool {
+	return *geo == model.Geo{}
+}
+
+// cityRecord holds the fields of a GeoIP2 City database record
+// which are used for setting geo fields.
+type cityRecord struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Continent struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"continent"`
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	Location struct {
+		Latitude  *float64 `maxminddb:"latitude"`
+		Longitude *float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+}
+
+func (r *cityRecord) setGeo(geo *model.Geo) {
+	geo.ContinentName = r.Continent.Names["en"]
+	geo.CountryISOCode = r.Country.ISOCode
+	geo.CountryName = r.Country.Names["en"]
+	if len(r.Subdivisions) > 0 {
+		// Subdivisions are ordered from largest to smallest;
+		// the region is the largest subdivision.
+		region := r.Subdivisions[0]
+		if region.ISOCode != "" && r.Country.ISOCode != "" {
+			geo.RegionISOCode = r.Country.ISOCode + "-" + region.ISOCode
+		}
+		geo.RegionName = region.Names["en"]
+	}
+	geo.CityName = r.City.Names["en"]
+	if r.Location.Latitude != nil && r.Location.Longitude != nil {
+		geo.Location = &model.Location{
+			Lat: *r.Location.Latitude,
+			Lon: *r.Location.Longitude,
+		}
+	}
+}
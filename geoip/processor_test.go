@@ -0,0 +1,173 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package geoip
+
+import (
+	"context"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/apm-data/model"
+)
+
+var berlinRecord = map[string]any{
+	"city":      map[string]any{"names": map[string]any{"en": "Berlin", "de": "Berlin"}},
+	"continent": map[string]any{"names": map[string]any{"en": "Europe"}},
+	"country": map[string]any{
+		"iso_code": "DE",
+		"names":    map[string]any{"en": "Germany", "de": "Deutschland"},
+	},
+	"subdivisions": []any{
+		map[string]any{"iso_code": "BE", "names": map[string]any{"en": "Land Berlin"}},
+	},
+	"location": map[string]any{"latitude": 52.5196, "longitude": 13.4069},
+}
+
+var berlinGeo = model.Geo{
+	ContinentName:  "Europe",
+	CountryISOCode: "DE",
+	CountryName:    "Germany",
+	RegionISOCode:  "DE-BE",
+	RegionName:     "Land Berlin",
+	CityName:       "Berlin",
+	Location:       &model.Location{Lat: 52.5196, Lon: 13.4069},
+}
+
+func TestProcessor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "GeoIP2-City.mmdb")
+	writeTestDatabase(t, path, map[netip.Prefix]map[string]any{
+		netip.MustParsePrefix("81.2.69.0/24"):    berlinRecord,
+		netip.MustParsePrefix("2a02:8100::/32"):  berlinRecord,
+		netip.MustParsePrefix("175.16.199.0/24"): {"country": map[string]any{"iso_code": "CN"}},
+	})
+	processor, err := NewProcessor(Config{DatabasePath: path, ReloadInterval: time.Minute})
+	require.NoError(t, err)
+
+	existing := model.Geo{CityName: "Existing"}
+	batch := model.Batch{
+		{Client: model.Client{IP: netip.MustParseAddr("81.2.69.142")}},
+		{Source: model.Source{IP: netip.MustParseAddr("2a02:8100::1")}},
+		{Client: model.Client{IP: netip.MustParseAddr("::ffff:175.16.199.1")}},
+		{Client: model.Client{IP: netip.MustParseAddr("81.2.69.1"), Geo: existing}},
+		{Client: model.Client{IP: netip.MustParseAddr("8.8.8.8")}},
+		{Client: model.Client{IP: netip.MustParseAddr("10.0.0.1")}},
+		{Client: model.Client{IP: netip.MustParseAddr("127.0.0.1")}},
+		{},
+	}
+	require.NoError(t, processor.ProcessBatch(context.Background(), &batch))
+	assert.Equal(t, model.Batch{
+		{Client: model.Client{IP: netip.MustParseAddr("81.2.69.142"), Geo: berlinGeo}},
+		{Source: model.Source{IP: netip.MustParseAddr("2a02:8100::1"), Geo: berlinGeo}},
+		{Client: model.Client{IP: netip.MustParseAddr("::ffff:175.16.199.1"), Geo: model.Geo{CountryISOCode: "CN"}}},
+		{Client: model.Client{IP: netip.MustParseAddr("81.2.69.1"), Geo: existing}},
+		{Client: model.Client{IP: netip.MustParseAddr("8.8.8.8")}},
+		{Client: model.Client{IP: netip.MustParseAddr("10.0.0.1")}},
+		{Client: model.Client{IP: netip.MustParseAddr("127.0.0.1")}},
+		{},
+	}, batch)
+}
+
+func TestProcessorReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "GeoIP2-City.mmdb")
+	writeTestDatabase(t, path, map[netip.Prefix]map[string]any{
+		netip.MustParsePrefix("81.2.69.0/24"): {"country": map[string]any{"iso_code": "GB"}},
+	})
+	processor, err := NewProcessor(Config{DatabasePath: path, ReloadInterval: 10 * time.Millisecond})
+	require.NoError(t, err)
+	go processor.Run()
+	defer processor.Stop(context.Background())
+
+	lookup := func() model.Geo {
+		batch := model.Batch{{Client: model.Client{IP: netip.MustParseAddr("81.2.69.142")}}}
+		require.NoError(t, processor.ProcessBatch(context.Background(), &batch))
+		return batch[0].Client.Geo
+	}
+	assert.Equal(t, model.Geo{CountryISOCode: "GB"}, lookup())
+
+	// Writing an invalid database should have no effect.
+	require.NoError(t, os.WriteFile(path, []byte("invalid"), 0644))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, model.Geo{CountryISOCode: "GB"}, lookup())
+
+	writeTestDatabase(t, path, map[netip.Prefix]map[string]any{
+		netip.MustParsePrefix("81.2.69.0/24"): berlinRecord,
+	})
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual(berlinGeo, lookup())
+	}, 10*time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, processor.Stop(context.Background()))
+}
+
+func TestProcessorStopWithoutRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "GeoIP2-City.mmdb")
+	writeTestDatabase(t, path, map[netip.Prefix]map[string]any{
+		netip.MustParsePrefix("81.2.69.0/24"): berlinRecord,
+	})
+	processor, err := NewProcessor(Config{DatabasePath: path, ReloadInterval: time.Minute})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	assert.NoError(t, processor.Stop(ctx))
+	assert.NoError(t, processor.Stop(ctx))
+
+	// Run returns immediately once the processor has been stopped.
+	assert.NoError(t, processor.Run())
+}
+
+func TestProcessorRunTwice(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "GeoIP2-City.mmdb")
+	writeTestDatabase(t, path, map[netip.Prefix]map[string]any{
+		netip.MustParsePrefix("81.2.69.0/24"): berlinRecord,
+	})
+	processor, err := NewProcessor(Config{DatabasePath: path, ReloadInterval: time.Minute})
+	require.NoError(t, err)
+
+	errs := make(chan error, 2)
+	go func() { errs <- processor.Run() }()
+	go func() { errs <- processor.Run() }()
+
+	// Only one of the calls to Run runs the processor; the other
+	// returns an error immediately.
+	assert.EqualError(t, <-errs, "geoip processor already started")
+	assert.NoError(t, processor.Stop(context.Background()))
+	assert.NoError(t, <-errs)
+}
+
+func TestNewProcessorErrors(t *testing.T) {
+	_, err := NewProcessor(Config{ReloadInterval: time.Minute})
+	assert.EqualError(t, err, "invalid geoip config: DatabasePath unspecified")
+
+	_, err = NewProcessor(Config{DatabasePath: "GeoIP2-City.mmdb"})
+	assert.EqualError(t, err, "invalid geoip config: ReloadInterval unspecified or negative")
+
+	path := filepath.Join(t.TempDir(), "GeoIP2-City.mmdb")
+	_, err = NewProcessor(Config{DatabasePath: path, ReloadInterval: time.Minute})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, os.WriteFile(path, []byte("invalid"), 0644))
+	_, err = NewProcessor(Config{DatabasePath: path, ReloadInterval: time.Minute})
+	assert.ErrorContains(t, err, "failed to load GeoIP database")
+}
@@ -0,0 +1,158 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package elasticapm
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/elastic/apm-data/input/elasticapm/internal/decoder"
+	"github.com/elastic/apm-data/input/elasticapm/internal/modeldecoder"
+	"github.com/elastic/apm-data/model"
+)
+
+// DecodeMetadata decodes a single line of intake metadata, with the
+// root key "metadata" (or "m" for RUM v3), into out.
+//
+// If the metadata is invalid, or data holds more than one line, an
+// *InvalidInputError is returned.
+func DecodeMetadata(data []byte, out *model.APMEvent) error {
+	reader, err := newLineReader(data)
+	if err != nil {
+		return err
+	}
+	return readMetadata(reader, out)
+}
+
+// DecodeEvent decodes a single line of intake event data, such as a
+// transaction or span, appending the resulting events to out. Events
+// are decoded on top of metadata, e.g. as decoded by DecodeMetadata.
+//
+// Some lines decode to multiple events, e.g. RUM v3 transactions with
+// nested spans, and some to none, e.g. metricsets with no samples.
+// If the event is invalid, or data holds more than one line, an
+// *InvalidInputError is returned.
+func DecodeEvent(data []byte, metadata model.APMEvent, out *model.Batch) error {
+	reader, err := newLineReader(data)
+	if err != nil {
+		return err
+	}
+	body, err := reader.ReadAhead()
+	if err != nil && err != io.EOF {
+		return reader.wrapError(err)
+	}
+	eventType := identifyEventType(body)
+	input := modeldecoder.Input{Base: copyEvent(metadata)}
+	if err := decodeEvent(reader, eventType, &input, out); err != nil && err != io.EOF {
+		return reader.invalidInputError(reader.wrapError(err), string(eventType))
+	}
+	return nil
+}
+
+// newLineReader returns a streamReader for decoding a single line,
+// optionally terminated by a newline. If data holds more than one
+// line, an *InvalidInputError is returned.
+func newLineReader(data []byte) (*streamReader, error) {
+	if i := bytes.IndexByte(data, '\n'); i != -1 && i != len(data)-1 {
+		return nil, &InvalidInputError{
+			Message:  "data must hold a single line",
+			Document: string(data),
+		}
+	}
+	// Terminate the line, so the end of the stream is not
+	// reached until after the line has been decoded.
+	r := io.MultiReader(bytes.NewReader(data), strings.NewReader("\n"))
+	return &streamReader{streamDecoder: decoder.NewNDJSONStreamDecoder(r, len(data)+1)}, nil
+}
+
+// StreamDecoder decodes events from an ND-JSON intake stream, one line
+// at a time, without the concurrency limiting, batching, or per-stream
+// limits of Processor.HandleStream.
+type StreamDecoder struct {
+	reader       *streamReader
+	baseEvent    model.APMEvent
+	readMetadata bool
+	err          error
+}
+
+// NewStreamDecoder returns a new StreamDecoder for decoding the stream r,
+// which must begin with a metadata line. Stream metadata is decoded on
+// top of baseEvent. Lines longer than maxEventSize bytes are rejected.
+func NewStreamDecoder(r io.Reader, baseEvent model.APMEvent, maxEventSize int) *StreamDecoder {
+	return &StreamDecoder{
+		reader:    &streamReader{streamDecoder: decoder.NewNDJSONStreamDecoder(r, maxEventSize)},
+		baseEvent: baseEvent,
+	}
+}
+
+// Metadata returns baseEvent with the stream's metadata decoded into it,
+// decoding the metadata if it has not already been decoded.
+//
+// If the metadata cannot be decoded, the error is terminal: it will be
+// returned by all subsequent calls to Metadata and Next.
+func (d *StreamDecoder) Metadata() (model.APMEvent, error) {
+	if d.err != nil {
+		return model.APMEvent{}, d.err
+	}
+	if !d.readMetadata {
+		if err := readMetadata(d.reader, &d.baseEvent); err != nil {
+			d.err = err
+			return model.APMEvent{}, err
+		}
+		d.readMetadata = true
+	}
+	return d.baseEvent, nil
+}
+
+// Next decodes the next event line in the stream, appending the resulting
+// events to out. Empty lines are skipped. Next returns io.EOF once the end
+// of the stream is reached.
+//
+// If a line is invalid, Next returns an *InvalidInputError describing it,
+// and Next may be called again to continue decoding from the next line.
+// Any other error is terminal, and will be returned by all subsequent calls.
+func (d *StreamDecoder) Next(out *model.Batch) error {
+	if _, err := d.Metadata(); err != nil {
+		return err
+	}
+	for {
+		if d.reader.IsEOF() {
+			return io.EOF
+		}
+		body, err := d.reader.ReadAhead()
+		if err != nil && err != io.EOF {
+			err := d.reader.wrapError(err)
+			var invalidInput *InvalidInputError
+			if !errors.As(err, &invalidInput) {
+				d.err = err
+			}
+			return err
+		}
+		if len(body) == 0 {
+			continue
+		}
+		eventType := identifyEventType(body)
+		input := modeldecoder.Input{Base: copyEvent(d.baseEvent)}
+		if err := decodeEvent(d.reader, eventType, &input, out); err != nil && err != io.EOF {
+			return d.reader.invalidInputError(d.reader.wrapError(err), string(eventType))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,146 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package elasticapm
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/apm-data/model"
+)
+
+func TestDecodeEvent(t *testing.T) {
+	var metadata model.APMEvent
+	require.NoError(t, DecodeMetadata([]byte(validMetadata), &metadata))
+	assert.Equal(t, "1234_service-12a3", metadata.Service.Name)
+
+	var batch model.Batch
+	require.NoError(t, DecodeEvent([]byte(validError), metadata, &batch))
+	require.NoError(t, DecodeEvent([]byte(validTransaction+"\n"), metadata, &batch))
+	require.Len(t, batch, 2)
+	assert.Equal(t, model.ErrorProcessor, batch[0].Processor)
+	assert.Equal(t, model.TransactionProcessor, batch[1].Processor)
+	for _, event := range batch {
+		assert.Equal(t, "1234_service-12a3", event.Service.Name)
+	}
+
+	// RUM v3 transactions decode to multiple events.
+	var rumMetadata model.APMEvent
+	require.NoError(t, DecodeMetadata([]byte(validRUMv3Metadata), &rumMetadata))
+	batch = batch[:0]
+	require.NoError(t, DecodeEvent([]byte(validRUMv3Transaction), rumMetadata, &batch))
+	assert.Greater(t, len(batch), 1)
+}
+
+func TestDecodeEventErrors(t *testing.T) {
+	var metadata model.APMEvent
+	err := DecodeMetadata([]byte(`{"metadata": {"user": null}}`), &metadata)
+	var invalidInput *InvalidInputError
+	require.True(t, errors.As(err, &invalidInput))
+	assert.Equal(t, "metadata", invalidInput.EventType)
+
+	err = DecodeMetadata([]byte(validError), &metadata)
+	require.True(t, errors.As(err, &invalidInput))
+	assert.Equal(t, `"metadata" or "m" required`, invalidInput.Message)
+
+	var batch model.Batch
+	err = DecodeEvent([]byte(`{"error": {"id": "cdefab0123456789"}}`), metadata, &batch)
+	require.True(t, errors.As(err, &invalidInput))
+	assert.Equal(t, "error", invalidInput.EventType)
+	assert.Equal(t, "error", invalidInput.Field)
+	assert.Empty(t, batch)
+
+	err = DecodeEvent([]byte(`{"tennis-court": {}}`), metadata, &batch)
+	require.True(t, errors.As(err, &invalidInput))
+	assert.Contains(t, invalidInput.Message, "did not recognize object type")
+	assert.Empty(t, batch)
+
+	// Only a single line may be decoded.
+	err = DecodeMetadata([]byte(validMetadata+"\n"+validMetadata), &metadata)
+	require.True(t, errors.As(err, &invalidInput))
+	assert.Equal(t, "data must hold a single line", invalidInput.Message)
+	err = DecodeEvent([]byte(validError+"\n"+validTransaction), metadata, &batch)
+	require.True(t, errors.As(err, &invalidInput))
+	assert.Equal(t, "data must hold a single line", invalidInput.Message)
+	assert.Empty(t, batch)
+}
+
+func TestStreamDecoder(t *testing.T) {
+	payload := strings.Join([]string{
+		validMetadata,
+		validError,
+		"",
+		`{"error": {"id": "cdefab0123456789"}}`,
+		validSpan,
+		`{"span": ` + strings.Repeat("x", 1024*1024) + `}`,
+		validTransaction,
+	}, "\n")
+	baseEvent := model.APMEvent{UserAgent: model.UserAgent{Original: "base"}}
+	d := NewStreamDecoder(strings.NewReader(payload), baseEvent, 100*1024)
+
+	metadata, err := d.Metadata()
+	require.NoError(t, err)
+	assert.Equal(t, "1234_service-12a3", metadata.Service.Name)
+	assert.Equal(t, "base", metadata.UserAgent.Original)
+
+	var batch model.Batch
+	var lines []int
+	for {
+		err := d.Next(&batch)
+		if err == io.EOF {
+			break
+		}
+		var invalidInput *InvalidInputError
+		require.True(t, errors.As(err, &invalidInput) || err == nil, err)
+		if err != nil {
+			lines = append(lines, invalidInput.Line)
+		}
+	}
+	assert.Equal(t, []int{4, 6}, lines)
+
+	processors := make([]model.Processor, len(batch))
+	for i, event := range batch {
+		processors[i] = event.Processor
+		assert.Equal(t, "1234_service-12a3", event.Service.Name)
+	}
+	assert.Equal(t, []model.Processor{
+		model.ErrorProcessor,
+		model.SpanProcessor,
+		model.TransactionProcessor,
+	}, processors)
+}
+
+func TestStreamDecoderInvalidMetadata(t *testing.T) {
+	d := NewStreamDecoder(strings.NewReader(validError+"\n"+validError), model.APMEvent{}, 100*1024)
+	var batch model.Batch
+	err := d.Next(&batch)
+	var invalidInput *InvalidInputError
+	require.True(t, errors.As(err, &invalidInput))
+	assert.Equal(t, `"metadata" or "m" required`, invalidInput.Message)
+
+	// Metadata errors are terminal.
+	assert.Equal(t, err, d.Next(&batch))
+	_, metadataErr := d.Metadata()
+	assert.Equal(t, err, metadataErr)
+	assert.Empty(t, batch)
+}
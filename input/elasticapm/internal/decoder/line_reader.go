@@ -31,6 +31,7 @@ type LineReader struct {
 	br            *bufio.Reader
 	maxLineLength int
 	skip          bool
+	bytesRead     int
 }
 
 func NewLineReader(reader *bufio.Reader, maxLineLength int) *LineReader {
@@ -44,6 +45,7 @@ func NewLineReader(reader *bufio.Reader, maxLineLength int) *LineReader {
 func (lr *LineReader) Reset(br *bufio.Reader) {
 	lr.br = br
 	lr.skip = false
+	lr.bytesRead = 0
 }
 
 // ReadLine reads the next line from the given reader.
@@ -57,6 +59,10 @@ func (lr *LineReader) ReadLine() ([]byte, error) {
 		if err == bufio.ErrBufferFull {
 			prefix = true
 		}
+		lr.bytesRead += len(line)
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			lr.bytesRead--
+		}
 
 		if !lr.skip {
 			if prefix {
@@ -75,3 +81,8 @@ func (lr *LineReader) ReadLine() ([]byte, error) {
 		}
 	}
 }
+
+// BytesRead returns the total number of bytes of lines consumed,
+// excluding newlines. This includes the remainder of lines that
+// exceeded the maximum line length, which is skipped by ReadLine.
+func (lr *LineReader) BytesRead() int { return lr.bytesRead }
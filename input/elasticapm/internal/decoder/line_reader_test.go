@@ -130,6 +130,9 @@ func TestLineReaderTwoLongLines(t *testing.T) {
 		buf, err = lr.ReadLine()
 		assert.Equal(t, io.EOF, err)
 		assert.Equal(t, []byte("line2"), buf)
+
+		// The skipped remainder of long lines is counted.
+		assert.Equal(t, len("line1long-string-with-no-newlines-at-allanother-long-string-with-no-newlines-at-allline2"), lr.BytesRead())
 	}
 }
 
@@ -102,3 +102,9 @@ func (dec *NDJSONStreamDecoder) LatestLine() []byte { return dec.latestLine }
 type JSONDecodeError string
 
 func (s JSONDecodeError) Error() string { return string(s) }
+
+// BytesRead returns the total number of bytes of lines read,
+// excluding newlines. The remainder of a line that exceeded the
+// maximum line length is counted once it has been skipped, by
+// the following call to ReadAhead.
+func (dec *NDJSONStreamDecoder) BytesRead() int { return dec.lineReader.BytesRead() }
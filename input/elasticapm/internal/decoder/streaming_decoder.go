@@ -0,0 +1,322 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decoder
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+const (
+	// iteratorBufferSize holds the size of the buffer used by the JSON
+	// iterator of StreamingNDJSONDecoder.
+	iteratorBufferSize = 4096
+
+	// minPrefixLength holds the minimum length of the line prefix read
+	// by StreamingNDJSONDecoder.ReadAhead, regardless of the maximum
+	// length of LatestLine, so that the line's root key can always be
+	// identified from the prefix.
+	minPrefixLength = 256
+)
+
+// NewStreamingNDJSONDecoder returns a new StreamingNDJSONDecoder which
+// decodes ND-JSON lines from r, with a maximum line length of maxLineLength.
+// At most maxLatestLineLength bytes of each line are returned by LatestLine.
+func NewStreamingNDJSONDecoder(r io.Reader, maxLineLength, maxLatestLineLength int) *StreamingNDJSONDecoder {
+	prefixLength := maxLatestLineLength
+	if prefixLength < minPrefixLength {
+		prefixLength = minPrefixLength
+	}
+	if prefixLength > maxLineLength+1 {
+		// Reading one byte past maxLineLength is enough
+		// to determine that the line is too long.
+		prefixLength = maxLineLength + 1
+	}
+	dec := &StreamingNDJSONDecoder{
+		bufioReader:         bufio.NewReaderSize(r, prefixLength),
+		maxLineLength:       maxLineLength,
+		maxLatestLineLength: maxLatestLineLength,
+		prefixLength:        prefixLength,
+		latestLine:          make([]byte, 0, prefixLength),
+	}
+	dec.line.dec = dec
+	dec.line.done = true
+	dec.iter = jsoniter.Parse(json, &dec.line, iteratorBufferSize)
+	return dec
+}
+
+// StreamingNDJSONDecoder decodes a stream of ND-JSON lines from an io.Reader.
+//
+// Unlike NDJSONStreamDecoder, StreamingNDJSONDecoder does not buffer whole
+// lines before decoding them: each line is tokenised directly from the
+// reader, and the maximum line length is enforced as the line is read.
+// This keeps memory usage bounded regardless of the maximum line length.
+// Only a bounded prefix of each line is retained, for identifying the
+// line's contents and for error reporting.
+type StreamingNDJSONDecoder struct {
+	bufioReader         *bufio.Reader
+	maxLineLength       int
+	maxLatestLineLength int
+	prefixLength        int
+
+	line        streamingLineReader
+	iter        *jsoniter.Iterator
+	isEOF       bool
+	readAhead   bool
+	latestError error
+	latestLine  []byte
+	bytesRead   int
+}
+
+// Reset sets dec's underlying io.Reader to r, and resets any reading/decoding state.
+func (dec *StreamingNDJSONDecoder) Reset(r io.Reader) {
+	dec.bufioReader.Reset(r)
+	dec.line.reset(true)
+	dec.isEOF = false
+	dec.readAhead = false
+	dec.latestError = nil
+	dec.latestLine = dec.latestLine[:0]
+	dec.bytesRead = 0
+}
+
+// Decode decodes the next line into v.
+func (dec *StreamingNDJSONDecoder) Decode(v interface{}) error {
+	if !dec.readAhead {
+		_, _ = dec.ReadAhead() // error checked below
+	}
+	dec.readAhead = false
+	latestError := dec.latestError
+	dec.latestError = nil
+	if len(dec.latestLine) == 0 || (latestError != nil && !dec.isEOF) {
+		return latestError
+	}
+
+	dec.iter.Reset(&dec.line)
+	dec.iter.Error = nil
+	dec.iter.ReadVal(v)
+	switch {
+	case dec.line.tooLong:
+		return ErrLineTooLong
+	case dec.line.err != nil:
+		return dec.line.err
+	case dec.iter.Error == io.EOF:
+		// A complete JSON value never requires reading
+		// past its end, so the line must be truncated.
+		return JSONDecodeError("data read error: unexpected EOF")
+	case dec.iter.Error != nil:
+		return JSONDecodeError("data read error: " + dec.iter.Error.Error())
+	}
+	if !dec.line.done {
+		// Consume the remainder of the line, so the end
+		// of the stream is detected as early as possible.
+		if err := dec.line.discard(); err != nil && err != io.EOF {
+			return err
+		}
+	}
+	if dec.isEOF {
+		return io.EOF
+	}
+	return nil
+}
+
+// ReadAhead reads a prefix of the next NDJSON line, buffering it for a
+// subsequent call to Decode. The returned prefix holds the whole line if
+// the line is no longer than the maxLatestLineLength given to
+// NewStreamingNDJSONDecoder, or 256 bytes if that is greater, so that
+// the line's root key may be identified from the prefix.
+func (dec *StreamingNDJSONDecoder) ReadAhead() ([]byte, error) {
+	line, err := dec.readAhead1()
+	dec.readAhead = true
+	dec.latestError = err
+	if err == io.EOF {
+		dec.isEOF = true
+	}
+	return line, err
+}
+
+func (dec *StreamingNDJSONDecoder) readAhead1() ([]byte, error) {
+	dec.latestLine = dec.latestLine[:0]
+	if !dec.line.done {
+		// Discard the remainder of the previous line.
+		if err := dec.line.discard(); err != nil {
+			dec.bytesRead += dec.line.n
+			dec.line.reset(true)
+			return nil, err
+		}
+	}
+	dec.bytesRead += dec.line.n
+	dec.line.reset(false)
+
+	prefix, err := dec.peekLinePrefix()
+	if len(prefix) > dec.maxLineLength {
+		dec.latestLine = append(dec.latestLine, prefix[:dec.maxLineLength]...)
+		dec.line.tooLong = true
+		return dec.latestLine, ErrLineTooLong
+	}
+	dec.latestLine = append(dec.latestLine, prefix...)
+	if err == io.EOF && len(prefix) == 0 {
+		dec.line.done = true
+		return nil, io.EOF
+	}
+	return dec.latestLine, err
+}
+
+// peekLinePrefix returns up to prefixLength bytes of the next line,
+// excluding the newline, without consuming them. Rather than waiting
+// for prefixLength bytes to be available, peekLinePrefix returns as
+// soon as the end of the line has been buffered.
+func (dec *StreamingNDJSONDecoder) peekLinePrefix() ([]byte, error) {
+	for {
+		buf, _ := dec.bufioReader.Peek(dec.bufioReader.Buffered())
+		if len(buf) > dec.prefixLength {
+			buf = buf[:dec.prefixLength]
+		}
+		if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+			return buf[:i], nil
+		}
+		if len(buf) == dec.prefixLength {
+			return buf, nil
+		}
+		if _, err := dec.bufioReader.Peek(len(buf) + 1); err != nil {
+			buf, _ = dec.bufioReader.Peek(dec.bufioReader.Buffered())
+			return buf, err
+		}
+	}
+}
+
+// IsEOF signals whether the underlying reader reached the end
+func (dec *StreamingNDJSONDecoder) IsEOF() bool { return dec.isEOF }
+
+// LatestLine returns the prefix of the latest line read as []byte,
+// truncated to the maxLatestLineLength given to NewStreamingNDJSONDecoder.
+func (dec *StreamingNDJSONDecoder) LatestLine() []byte {
+	if len(dec.latestLine) == 0 {
+		return nil
+	}
+	if len(dec.latestLine) > dec.maxLatestLineLength {
+		return dec.latestLine[:dec.maxLatestLineLength]
+	}
+	return dec.latestLine
+}
+
+// BytesRead returns the total number of bytes of lines read,
+// excluding newlines. The remainder of a line that exceeded the
+// maximum line length is counted once it has been discarded, by
+// the following call to ReadAhead.
+func (dec *StreamingNDJSONDecoder) BytesRead() int {
+	n := dec.line.n
+	if n < len(dec.latestLine) {
+		n = len(dec.latestLine)
+	}
+	return dec.bytesRead + n
+}
+
+// streamingLineReader is an io.Reader which reads the body of the current
+// line from a StreamingNDJSONDecoder's bufio.Reader, returning io.EOF at
+// the end of the line, or ErrLineTooLong if the line exceeds the maximum
+// line length.
+type streamingLineReader struct {
+	dec *StreamingNDJSONDecoder
+
+	// n holds the number of bytes of the line consumed.
+	n int
+
+	// done reports whether the line has been consumed up to and
+	// including its newline, or to the end of the stream.
+	done bool
+
+	tooLong bool
+	err     error
+}
+
+func (r *streamingLineReader) reset(done bool) {
+	r.n = 0
+	r.done = done
+	r.tooLong = false
+	r.err = nil
+}
+
+func (r *streamingLineReader) Read(p []byte) (int, error) {
+	if r.done || r.tooLong {
+		return 0, io.EOF
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	br := r.dec.bufioReader
+	if _, err := br.Peek(1); err != nil {
+		if err == io.EOF {
+			r.done = true
+			r.dec.isEOF = true
+		} else {
+			r.err = err
+		}
+		return 0, err
+	}
+	buf, _ := br.Peek(br.Buffered())
+	if len(buf) > len(p) {
+		buf = buf[:len(p)]
+	}
+	n, discard := len(buf), len(buf)
+	if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+		n, discard = i, i+1
+		r.done = true
+	}
+	if remaining := r.dec.maxLineLength - r.n; n > remaining {
+		n, discard = remaining, remaining
+		r.done = false
+		r.tooLong = true
+	}
+	copy(p, buf[:n])
+	_, _ = br.Discard(discard)
+	r.n += n
+	if r.tooLong {
+		return n, ErrLineTooLong
+	}
+	return n, nil
+}
+
+// discard consumes the remainder of the line.
+func (r *streamingLineReader) discard() error {
+	br := r.dec.bufioReader
+	for {
+		line, err := br.ReadSlice('\n')
+		n := len(line)
+		if n > 0 && line[n-1] == '\n' {
+			n--
+		}
+		r.n += n
+		switch err {
+		case nil:
+			r.done = true
+			return nil
+		case bufio.ErrBufferFull:
+			continue
+		case io.EOF:
+			r.done = true
+			r.dec.isEOF = true
+		}
+		return err
+	}
+}
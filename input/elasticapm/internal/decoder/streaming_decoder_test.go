@@ -0,0 +1,189 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decoder
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamingNDJSONDecoder(t *testing.T) {
+	lines := []string{
+		`{"key": "value1"}`,
+		`{"key": "value2", "too": "long"}`,
+		`{invalid-json}`,
+		`{"key": "value3"}`,
+	}
+	expected := []struct {
+		errPattern string
+		out        map[string]interface{}
+		isEOF      bool
+		latestLine string
+	}{
+		{
+			out:        map[string]interface{}{"key": "value1"},
+			latestLine: `{"key": "value1"}`,
+		},
+		{
+			out:        nil,
+			errPattern: "Line exceeded permitted length",
+			latestLine: `{"key": "value2", "t`,
+		},
+		{
+			out:        map[string]interface{}{},
+			errPattern: "data read error",
+			latestLine: `{invalid-json}`,
+		},
+		{
+			out:        map[string]interface{}{"key": "value3"},
+			latestLine: `{"key": "value3"}`,
+			errPattern: "EOF",
+			isEOF:      true,
+		},
+	}
+	buf := bytes.NewBufferString(strings.Join(lines, "\n"))
+	n := NewStreamingNDJSONDecoder(buf, 20, 100)
+
+	for idx, test := range expected {
+		t.Run(fmt.Sprintf("%v", idx), func(t *testing.T) {
+			var out map[string]interface{}
+			err := n.Decode(&out)
+			assert.Equal(t, test.out, out, "Failed at idx %v", idx)
+			if test.errPattern == "" {
+				assert.Nil(t, err)
+			} else {
+				require.NotNil(t, err, "Failed at idx %v", idx)
+				assert.Contains(t, err.Error(), test.errPattern, "Failed at idx %v", idx)
+			}
+			assert.Equal(t, test.isEOF, n.IsEOF())
+			assert.Equal(t, []byte(test.latestLine), n.LatestLine(), "Failed at idx %v", idx)
+		})
+	}
+}
+
+func TestStreamingNDJSONDecoderLongLines(t *testing.T) {
+	value := strings.Repeat("x", 1000)
+	lines := []string{
+		`{"key": "` + value + `"}`,
+		`{"a": "b"}   `,
+		``,
+		`{"key": "` + value + value + `"}`,
+		`{"key": "` + value + `"`,
+		`{"c": "d"}`,
+	}
+	r := bytes.NewBufferString(strings.Join(lines, "\n") + "\n")
+	dec := NewStreamingNDJSONDecoder(r, 1500, 16)
+
+	var out map[string]interface{}
+	b, err := dec.ReadAhead()
+	require.NoError(t, err)
+	// The prefix returned by ReadAhead is long enough to identify the
+	// line's root key, but LatestLine is truncated to the given length.
+	assert.Equal(t, lines[0][:minPrefixLength], string(b))
+	assert.Equal(t, `{"key": "xxxxxxx`, string(dec.LatestLine()))
+	require.NoError(t, dec.Decode(&out))
+	assert.Equal(t, map[string]interface{}{"key": value}, out)
+	assert.Equal(t, len(lines[0]), dec.BytesRead())
+
+	out = nil
+	b, err = dec.ReadAhead()
+	require.NoError(t, err)
+	assert.Equal(t, `{"a": "b"}   `, string(b))
+	require.NoError(t, dec.Decode(&out))
+	assert.Equal(t, map[string]interface{}{"a": "b"}, out)
+
+	// Empty lines may be skipped without decoding.
+	b, err = dec.ReadAhead()
+	require.NoError(t, err)
+	assert.Empty(t, b)
+
+	// The line length limit is enforced while decoding.
+	b, err = dec.ReadAhead()
+	require.NoError(t, err)
+	assert.Equal(t, lines[3][:minPrefixLength], string(b))
+	assert.Equal(t, ErrLineTooLong, dec.Decode(&out))
+	assert.Equal(t, `{"key": "xxxxxxx`, string(dec.LatestLine()))
+
+	// Truncated JSON is reported as an error.
+	_, err = dec.ReadAhead()
+	require.NoError(t, err)
+	err = dec.Decode(&out)
+	assert.IsType(t, JSONDecodeError(""), err)
+	assert.Contains(t, err.Error(), "data read error")
+
+	out = nil
+	require.NoError(t, dec.Decode(&out))
+	assert.Equal(t, map[string]interface{}{"c": "d"}, out)
+	assert.False(t, dec.IsEOF())
+
+	_, err = dec.ReadAhead()
+	assert.Equal(t, io.EOF, err)
+	assert.True(t, dec.IsEOF())
+
+	var total int
+	for _, line := range lines {
+		total += len(line)
+	}
+	assert.Equal(t, total, dec.BytesRead())
+}
+
+func TestStreamingNDJSONDecoderReset(t *testing.T) {
+	dec := NewStreamingNDJSONDecoder(strings.NewReader(`{"a": "b"}`), 100, 100)
+	var out map[string]interface{}
+	assert.Equal(t, io.EOF, dec.Decode(&out))
+	assert.True(t, dec.IsEOF())
+
+	dec.Reset(strings.NewReader(`{"c": "d"}` + "\n"))
+	assert.False(t, dec.IsEOF())
+	assert.Zero(t, dec.BytesRead())
+	out = nil
+	require.NoError(t, dec.Decode(&out))
+	assert.Equal(t, map[string]interface{}{"c": "d"}, out)
+}
+
+func TestStreamingNDJSONDecoderPartialReads(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	dec := NewStreamingNDJSONDecoder(iotest.OneByteReader(pr), 100, 100)
+	go pw.Write([]byte(`{"a": "b"}` + "\n"))
+
+	// ReadAhead must return as soon as the line is complete,
+	// without waiting for more data to fill the prefix buffer.
+	done := make(chan struct{})
+	var out map[string]interface{}
+	go func() {
+		defer close(done)
+		_, err := dec.ReadAhead()
+		assert.NoError(t, err)
+		assert.NoError(t, dec.Decode(&out))
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for line to be decoded")
+	}
+	assert.Equal(t, map[string]interface{}{"a": "b"}, out)
+}
@@ -90,6 +90,8 @@ import (
 	"github.com/pkg/errors"
 	"regexp"
 	"unicode/utf8"
+
+	"github.com/elastic/apm-data/input/elasticapm/internal/modeldecoder"
 )
 
 var (
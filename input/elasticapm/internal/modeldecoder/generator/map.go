@@ -84,7 +84,7 @@ for k := range val.%s{
 		// call validation on every item
 		fmt.Fprintf(w, `
 if err := v.validate(); err != nil{
-		return errors.Wrapf(err, "%s")
+		return modeldecoder.WrapRuleErr(err, "%s")
 }
 `[1:], jsonName(f))
 	}
@@ -125,7 +125,7 @@ case %s:
 	}
 	fmt.Fprintf(w, `
 default:
-	return fmt.Errorf("'%s': validation rule '%s(%s)' violated for key %%s",k)
+	return modeldecoder.NewRuleKeyErr("%s", "%s(%s)", k)
 }
 `[1:], jsonName(f), rule.name, rule.value)
 }
@@ -133,7 +133,7 @@ default:
 func mapRuleRequired(w io.Writer, f structField, rule validationRule) {
 	fmt.Fprintf(w, `
 if len(val.%s) == 0{
-	return fmt.Errorf("'%s' required")
+	return modeldecoder.NewRequiredErr("%s")
 }
 `[1:], f.Name(), jsonName(f))
 }
@@ -141,7 +141,7 @@ if len(val.%s) == 0{
 func mapRulePatternKeys(w io.Writer, f structField, rule validationRule) {
 	fmt.Fprintf(w, `
 if k != "" && !%sRegexp.MatchString(k){
-		return fmt.Errorf("'%s': validation rule '%s(%s)' violated")
+		return modeldecoder.NewRuleErr("%s", "%s(%s)")
 }
 `[1:], rule.value, jsonName(f), rule.name, rule.value)
 }
@@ -149,7 +149,7 @@ if k != "" && !%sRegexp.MatchString(k){
 func mapRuleMaxVals(w io.Writer, f structField, rule validationRule) {
 	fmt.Fprintf(w, `
 if utf8.RuneCountInString(t) > %s{
-	return fmt.Errorf("'%s': validation rule '%s(%s)' violated")
+	return modeldecoder.NewRuleErr("%s", "%s(%s)")
 }
 `[1:], rule.value, jsonName(f), rule.name, rule.value)
 }
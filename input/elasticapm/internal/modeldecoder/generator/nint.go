@@ -45,7 +45,7 @@ func generateNullableIntValidation(w io.Writer, fields []structField, f structFi
 func nintRuleMinMax(w io.Writer, f structField, rule validationRule) {
 	fmt.Fprintf(w, `
 if val.%s.IsSet() && val.%s.Val %s %s {
-	return fmt.Errorf("'%s': validation rule '%s(%s)' violated")
+	return modeldecoder.NewRuleErr("%s", "%s(%s)")
 }
 `[1:], f.Name(), f.Name(), ruleMinMaxOperator(rule.name), rule.value, jsonName(f), rule.name, rule.value)
 }
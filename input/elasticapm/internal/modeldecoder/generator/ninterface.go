@@ -87,7 +87,7 @@ func nullableInterfaceRuleTypes(w io.Writer, f structField, rules []validationRu
 case int:
 case json.Number:
 	if _, err := t.Int64(); err != nil{
-		return fmt.Errorf("'%s': validation rule '%s(%s)' violated")
+		return modeldecoder.NewRuleErr("%s", "%s(%s)")
 	}
 `[1:], jsonName(f), rule.name, rule.value)
 		case "string":
@@ -97,14 +97,14 @@ case %s:
 			if maxLengthRule != (validationRule{}) {
 				fmt.Fprintf(w, `
 if utf8.RuneCountInString(t) %s %s{
-	return fmt.Errorf("'%s': validation rule '%s(%s)' violated")
+	return modeldecoder.NewRuleErr("%s", "%s(%s)")
 }
 `[1:], ruleMinMaxOperator(maxLengthRule.name), maxLengthRule.value, jsonName(f), maxLengthRule.name, maxLengthRule.value)
 			}
 			if targetTypeRule.value == "int" {
 				fmt.Fprintf(w, `
 if _, err := strconv.Atoi(t); err != nil{
-	return fmt.Errorf("'%s': validation rule '%s(%s)' violated")
+	return modeldecoder.NewRuleErr("%s", "%s(%s)")
 }
 `[1:], jsonName(f), targetTypeRule.name, targetTypeRule.value)
 			}
@@ -123,7 +123,7 @@ case nil:
 	}
 	fmt.Fprintf(w, `
 default:
-	return fmt.Errorf("'%s': validation rule '%s(%s)' violated ")
+	return modeldecoder.NewRuleErr("%s", "%s(%s)")
 }
 `[1:], jsonName(f), rule.name, rule.value)
 	return nil
@@ -61,7 +61,7 @@ if val.%s.Val != ""{
 		}
 	}
 	if !matchEnum{
-		return fmt.Errorf("'%s': validation rule '%s(%s)' violated")
+		return modeldecoder.NewRuleErr("%s", "%s(%s)")
 	}
 }
 `[1:], f.Name(), rule.value, f.Name(), jsonName(f), rule.name, rule.value)
@@ -70,7 +70,7 @@ if val.%s.Val != ""{
 func nstringRuleMinMax(w io.Writer, f structField, rule validationRule) {
 	fmt.Fprintf(w, `
 if val.%s.IsSet() && utf8.RuneCountInString(val.%s.Val) %s %s{
-	return fmt.Errorf("'%s': validation rule '%s(%s)' violated")
+	return modeldecoder.NewRuleErr("%s", "%s(%s)")
 }
 `[1:], f.Name(), f.Name(), ruleMinMaxOperator(rule.name), rule.value, jsonName(f), rule.name, rule.value)
 }
@@ -78,7 +78,7 @@ if val.%s.IsSet() && utf8.RuneCountInString(val.%s.Val) %s %s{
 func nstringRulePattern(w io.Writer, f structField, rule validationRule) {
 	fmt.Fprintf(w, `
 if val.%s.Val != "" && !%sRegexp.MatchString(val.%s.Val){
-	return fmt.Errorf("'%s': validation rule '%s(%s)' violated")
+	return modeldecoder.NewRuleErr("%s", "%s(%s)")
 }
 `[1:], f.Name(), rule.value, f.Name(), jsonName(f), rule.name, rule.value)
 }
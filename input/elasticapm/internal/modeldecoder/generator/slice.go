@@ -32,7 +32,7 @@ func generateSliceValidation(w io.Writer, fields []structField, f structField, i
 		fmt.Fprintf(w, `
 for _, elem := range val.%s{
 	if err := elem.validate(); err != nil{
-		return errors.Wrapf(err, "%s")
+		return modeldecoder.WrapRuleErr(err, "%s")
 	}
 }
 `[1:], f.Name(), jsonName(f))
@@ -74,7 +74,7 @@ func sliceRuleMinMaxLength(w io.Writer, f structField, rule validationRule) erro
 			fmt.Fprintf(w, `
 for _, elem := range val.%s{
 	if utf8.RuneCountInString(elem) %s %s{
-			return fmt.Errorf("'%s': validation rule '%s(%s)' violated")
+			return modeldecoder.NewRuleErr("%s", "%s(%s)")
 	}
 }
 `[1:], f.Name(), ruleMinMaxOperator(rule.name), rule.value, jsonName(f), rule.name, rule.value)
@@ -88,7 +88,7 @@ func sliceRuleMinVals(w io.Writer, f structField, rule validationRule) error {
 	fmt.Fprintf(w, `
 for _, elem := range val.%s{
 	if elem %s %s{
-		return fmt.Errorf("'%s': validation rule '%s(%s)' violated")
+		return modeldecoder.NewRuleErr("%s", "%s(%s)")
 	}
 }
 `[1:], f.Name(), ruleMinMaxOperator(rule.name), rule.value, jsonName(f), rule.name, rule.value)
@@ -98,7 +98,7 @@ for _, elem := range val.%s{
 func sliceRuleRequired(w io.Writer, f structField, rule validationRule) {
 	fmt.Fprintf(w, `
 if len(val.%s) == 0{
-	return fmt.Errorf("'%s' required")
+	return modeldecoder.NewRequiredErr("%s")
 }
 `[1:], f.Name(), jsonName(f))
 }
@@ -27,7 +27,7 @@ func generateStructValidation(w io.Writer, fields []structField, f structField,
 	if isCustomStruct {
 		fmt.Fprintf(w, `
 		if err := val.%s.validate(); err != nil{
-			return errors.Wrapf(err, "%s")
+			return modeldecoder.WrapRuleErr(err, "%s")
 		}
 		`[1:], f.Name(), jsonName(f))
 	}
@@ -113,7 +113,7 @@ func ruleMinMaxOperator(ruleName string) string {
 func ruleNullableRequired(w io.Writer, f structField) {
 	fmt.Fprintf(w, `
 if !val.%s.IsSet()  {
-	return fmt.Errorf("'%s' required")
+	return modeldecoder.NewRequiredErr("%s")
 }
 `[1:], f.Name(), jsonName(f))
 }
@@ -138,7 +138,7 @@ func ruleRequiredOneOf(w io.Writer, fields []structField, tagValue string) error
 		}
 	}
 	fmt.Fprintf(w, ` {
-  return fmt.Errorf("requires at least one of the fields '%v'")
+  return modeldecoder.NewRequiredAnyOfErr("%v")
 }
 `[1:], tagValue)
 	return nil
@@ -165,7 +165,7 @@ func ruleRequiredIfAny(w io.Writer, fields []structField, field structField, tag
 			return err
 		}
 		fmt.Fprintf(w, ` {
-	return fmt.Errorf("'%s' required when '%s' is set")
+	return modeldecoder.NewRequiredIfAnyErr("%s", "%s")
 }
 `, jsonName(field), jsonName(ifAnyField))
 	}
@@ -18,6 +18,7 @@
 package modeldecoder
 
 import (
+	"github.com/elastic/apm-data/input/elasticapm/internal/modeldecoder/netutil"
 	"github.com/elastic/apm-data/model"
 )
 
@@ -25,4 +26,9 @@ import (
 type Input struct {
 	// Base holds the base for decoding events.
 	Base model.APMEvent
+
+	// ClientAddrResolver holds the resolver for client addresses of
+	// requests captured by backend agents. If this is nil, the client
+	// address is taken from forwarding headers without verification.
+	ClientAddrResolver *netutil.ClientAddrResolver
 }
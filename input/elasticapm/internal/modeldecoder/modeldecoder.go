@@ -18,7 +18,9 @@
 package modeldecoder
 
 import (
+	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -49,6 +51,120 @@ func (e *ValidationError) Unwrap() error {
 	return e.err
 }
 
+// Path returns the JSON path of the field that failed validation,
+// e.g. "transaction.context.request.method". For rules spanning
+// multiple fields, Path returns the path of the enclosing object.
+func (e ValidationError) Path() string {
+	var ruleErr *RuleError
+	if errors.As(e.err, &ruleErr) {
+		return ruleErr.Path()
+	}
+	return ""
+}
+
+// Rule returns the validation rule that was violated, e.g.
+// "maxLength(1024)" or "required".
+func (e ValidationError) Rule() string {
+	var ruleErr *RuleError
+	if errors.As(e.err, &ruleErr) {
+		return ruleErr.Rule()
+	}
+	return ""
+}
+
+// RuleError represents the violation of a validation rule, as returned
+// by the generated validate methods.
+type RuleError struct {
+	objects []string
+	field   string
+	rule    string
+	msg     string
+}
+
+// NewRuleErr returns a RuleError for a field violating the given rule.
+func NewRuleErr(field, rule string) error {
+	return &RuleError{
+		field: field,
+		rule:  rule,
+		msg:   fmt.Sprintf("'%s': validation rule '%s' violated", field, rule),
+	}
+}
+
+// NewRuleKeyErr returns a RuleError for a map field with a value
+// violating the given rule for the given key.
+func NewRuleKeyErr(field, rule, key string) error {
+	return &RuleError{
+		field: field,
+		rule:  rule,
+		msg:   fmt.Sprintf("'%s': validation rule '%s' violated for key %s", field, rule, key),
+	}
+}
+
+// NewRequiredErr returns a RuleError for a missing required field.
+func NewRequiredErr(field string) error {
+	return &RuleError{
+		field: field,
+		rule:  "required",
+		msg:   fmt.Sprintf("'%s' required", field),
+	}
+}
+
+// NewRequiredIfAnyErr returns a RuleError for a missing field that
+// is required when the field ifAny is set.
+func NewRequiredIfAnyErr(field, ifAny string) error {
+	return &RuleError{
+		field: field,
+		rule:  "requiredIfAny(" + ifAny + ")",
+		msg:   fmt.Sprintf("'%s' required when '%s' is set", field, ifAny),
+	}
+}
+
+// NewRequiredAnyOfErr returns a RuleError for an object missing all of
+// the semicolon separated fields, at least one of which is required.
+func NewRequiredAnyOfErr(fields string) error {
+	return &RuleError{
+		rule: "requiredAnyOf(" + fields + ")",
+		msg:  fmt.Sprintf("requires at least one of the fields '%s'", fields),
+	}
+}
+
+// WrapRuleErr adds the enclosing object to the path of a RuleError
+// returned by the validate method of a nested object. Any other error
+// is wrapped with the object name as message.
+func WrapRuleErr(err error, object string) error {
+	ruleErr, ok := err.(*RuleError)
+	if !ok {
+		return errors.Wrap(err, object)
+	}
+	wrapped := *ruleErr
+	wrapped.objects = append([]string{object}, ruleErr.objects...)
+	return &wrapped
+}
+
+func (e *RuleError) Error() string {
+	if len(e.objects) == 0 {
+		return e.msg
+	}
+	return strings.Join(e.objects, ": ") + ": " + e.msg
+}
+
+// Path returns the JSON path of the field violating the rule. For rules
+// spanning multiple fields, Path returns the path of the enclosing object.
+func (e *RuleError) Path() string {
+	if e.field == "" {
+		return strings.Join(e.objects, ".")
+	}
+	if len(e.objects) == 0 {
+		return e.field
+	}
+	return strings.Join(e.objects, ".") + "." + e.field
+}
+
+// Rule returns the violated rule, e.g. "maxLength(1024)".
+func (e *RuleError) Rule() string {
+	return e.rule
+}
+
 var jsoniterErrRegexp = regexp.MustCompile(` but found .*error found in .* bigger context.*`)
 
 // NewDecoderErrFromJSONIter returns a DecoderError where
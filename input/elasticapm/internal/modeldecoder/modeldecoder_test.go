@@ -0,0 +1,80 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package modeldecoder_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/apm-data/input/elasticapm/internal/modeldecoder"
+)
+
+func TestValidationErrorPathRule(t *testing.T) {
+	for _, test := range []struct {
+		err  error
+		msg  string
+		path string
+		rule string
+	}{
+		{
+			err:  modeldecoder.NewRequiredErr("metadata"),
+			msg:  "'metadata' required",
+			path: "metadata",
+			rule: "required",
+		},
+		{
+			err: modeldecoder.WrapRuleErr(modeldecoder.WrapRuleErr(modeldecoder.WrapRuleErr(
+				modeldecoder.NewRuleErr("method", "maxLength(1024)"),
+				"request"), "context"), "transaction"),
+			msg:  "transaction: context: request: 'method': validation rule 'maxLength(1024)' violated",
+			path: "transaction.context.request.method",
+			rule: "maxLength(1024)",
+		},
+		{
+			err: modeldecoder.WrapRuleErr(
+				modeldecoder.NewRuleKeyErr("labels", "inputTypesVals(string;bool;number)", "a"),
+				"metadata"),
+			msg:  "metadata: 'labels': validation rule 'inputTypesVals(string;bool;number)' violated for key a",
+			path: "metadata.labels",
+			rule: "inputTypesVals(string;bool;number)",
+		},
+		{
+			err:  modeldecoder.WrapRuleErr(modeldecoder.NewRequiredAnyOfErr("exception;log"), "error"),
+			msg:  "error: requires at least one of the fields 'exception;log'",
+			path: "error",
+			rule: "requiredAnyOf(exception;log)",
+		},
+		{
+			err:  modeldecoder.WrapRuleErr(modeldecoder.NewRequiredIfAnyErr("start", "timestamp"), "span"),
+			msg:  "span: 'start' required when 'timestamp' is set",
+			path: "span.start",
+			rule: "requiredIfAny(timestamp)",
+		},
+		{
+			err: modeldecoder.WrapRuleErr(errors.New("unexpected"), "span"),
+			msg: "span: unexpected",
+		},
+	} {
+		err := modeldecoder.NewValidationErr(test.err)
+		assert.Equal(t, "validation error: "+test.msg, err.Error())
+		assert.Equal(t, test.path, err.Path(), test.msg)
+		assert.Equal(t, test.rule, err.Rule(), test.msg)
+	}
+}
@@ -32,6 +32,7 @@ import (
 // If the client is able to control the headers, they can control the result of this
 // function. The result should therefore not necessarily be trusted to be correct;
 // that depends on the presence and configuration of proxies in front of apm-server.
+// Use ClientAddrResolver to resolve the client address given a set of trusted proxies.
 func ClientAddrFromHeaders(header http.Header) (ip netip.Addr, port uint16) {
 	for _, parse := range parseHeadersInOrder {
 		if ip, port := parse(header); ip.IsValid() {
@@ -19,6 +19,7 @@ package netutil
 
 import (
 	"net/http"
+	"net/netip"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -99,6 +100,141 @@ func TestClientAddrFromHeaders(t *testing.T) {
 	}
 }
 
+func TestClientAddrResolver(t *testing.T) {
+	resolver := &ClientAddrResolver{
+		TrustedProxies: []netip.Prefix{
+			netip.MustParsePrefix("10.0.0.0/8"),
+			netip.MustParsePrefix("2001:db8::/32"),
+		},
+	}
+	proxy := netip.MustParseAddr("10.0.0.1")
+	for name, tc := range map[string]struct {
+		resolver *ClientAddrResolver
+		header   http.Header
+		remote   netip.Addr
+		ip       string
+		port     uint16
+		proxies  []string
+	}{
+		"no header": {
+			remote: proxy,
+			ip:     "10.0.0.1",
+		},
+		"untrusted remote": {
+			header: http.Header{headerXForwardedFor: []string{"123.0.0.1"}},
+			remote: netip.MustParseAddr("192.0.2.1"),
+			ip:     "192.0.2.1",
+		},
+		"invalid remote": {
+			header: http.Header{headerXForwardedFor: []string{"123.0.0.1"}},
+		},
+		"X-Forwarded-For": {
+			header:  http.Header{headerXForwardedFor: []string{"123.0.0.1"}},
+			remote:  proxy,
+			ip:      "123.0.0.1",
+			proxies: []string{"10.0.0.1"},
+		},
+		"X-Forwarded-For-Spoofed": {
+			header:  http.Header{headerXForwardedFor: []string{"1.2.3.4, 123.0.0.1, 10.1.1.1"}},
+			remote:  proxy,
+			ip:      "123.0.0.1",
+			proxies: []string{"10.0.0.1", "10.1.1.1"},
+		},
+		"X-Forwarded-For-Multiple-Headers": {
+			header:  http.Header{headerXForwardedFor: []string{"1.2.3.4", "123.0.0.1"}},
+			remote:  proxy,
+			ip:      "123.0.0.1",
+			proxies: []string{"10.0.0.1"},
+		},
+		"X-Forwarded-For-All-Trusted": {
+			header:  http.Header{headerXForwardedFor: []string{"10.2.2.2, 10.1.1.1"}},
+			remote:  proxy,
+			ip:      "10.2.2.2",
+			proxies: []string{"10.0.0.1", "10.1.1.1"},
+		},
+		"X-Forwarded-For-Invalid-Hop": {
+			header:  http.Header{headerXForwardedFor: []string{"123.0.0.1, unknown, 10.1.1.1"}},
+			remote:  proxy,
+			ip:      "10.1.1.1",
+			proxies: []string{"10.0.0.1"},
+		},
+		"Forwarded": {
+			header:  http.Header{headerForwarded: []string{`for=1.2.3.4, for="[2001:db8:cafe::17]:4711", for=192.0.2.60;proto=http;by=203.0.113.43`}},
+			remote:  netip.MustParseAddr("2001:db8::1"),
+			ip:      "192.0.2.60",
+			proxies: []string{"2001:db8::1"},
+		},
+		"Forwarded-Precedence": {
+			header: http.Header{
+				headerForwarded:     []string{"for=192.0.2.60"},
+				headerXForwardedFor: []string{"123.0.0.1"},
+			},
+			remote:  proxy,
+			ip:      "192.0.2.60",
+			proxies: []string{"10.0.0.1"},
+		},
+		"X-Real-IP": {
+			header:  http.Header{headerXRealIP: []string{"123.0.0.1:6060"}},
+			remote:  netip.MustParseAddr("::ffff:10.0.0.1"),
+			ip:      "123.0.0.1",
+			port:    6060,
+			proxies: []string{"::ffff:10.0.0.1"},
+		},
+		"Custom-Headers": {
+			resolver: &ClientAddrResolver{
+				TrustedProxies: resolver.TrustedProxies,
+				Headers:        []string{"True-Client-IP"},
+			},
+			header: http.Header{
+				"True-Client-Ip":    []string{"192.0.2.60"},
+				headerXForwardedFor: []string{"123.0.0.1"},
+			},
+			remote:  proxy,
+			ip:      "192.0.2.60",
+			proxies: []string{"10.0.0.1"},
+		},
+		"gRPC Metadata": {
+			header:  http.Header{"x-forwarded-for": []string{"182.0.0.9"}},
+			remote:  proxy,
+			ip:      "182.0.0.9",
+			proxies: []string{"10.0.0.1"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := resolver
+			if tc.resolver != nil {
+				r = tc.resolver
+			}
+			addr := r.Resolve(tc.header, tc.remote, 0)
+			if tc.ip == "" {
+				assert.False(t, addr.IP.IsValid())
+			} else {
+				assert.Equal(t, tc.ip, addr.IP.String())
+			}
+			assert.Equal(t, tc.port, addr.Port)
+			var proxies []string
+			for _, proxy := range addr.Proxies {
+				proxies = append(proxies, proxy.String())
+			}
+			assert.Equal(t, tc.proxies, proxies)
+		})
+	}
+}
+
+func TestClientAddrResolverNil(t *testing.T) {
+	var resolver *ClientAddrResolver
+	remote := netip.MustParseAddr("192.0.2.1")
+
+	addr := resolver.Resolve(http.Header{headerXForwardedFor: []string{"1.2.3.4, 123.0.0.1"}}, remote, 1234)
+	assert.Equal(t, ClientAddr{
+		IP:      netip.MustParseAddr("1.2.3.4"),
+		Proxies: []netip.Addr{remote},
+	}, addr)
+
+	addr = resolver.Resolve(http.Header{}, remote, 1234)
+	assert.Equal(t, ClientAddr{IP: remote, Port: 1234}, addr)
+}
+
 func TestParseForwarded(t *testing.T) {
 	type test struct {
 		name   string
@@ -0,0 +1,152 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package netutil
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// DefaultClientAddrHeaders holds the default forwarding headers consulted
+// by ClientAddrResolver, in order of precedence.
+var DefaultClientAddrHeaders = []string{"Forwarded", "X-Real-IP", "X-Forwarded-For"}
+
+// ClientAddrResolver resolves the address of the client of an HTTP request
+// from forwarding headers, trusting only the hops added by trusted proxies.
+type ClientAddrResolver struct {
+	// TrustedProxies holds the networks of proxies which are trusted
+	// to append the address of their peer to forwarding headers.
+	TrustedProxies []netip.Prefix
+
+	// Headers holds the names of the forwarding headers to consult, in
+	// order of precedence. Only the first header present in a request
+	// is consulted. "Forwarded" is parsed according to RFC 7239, and
+	// other headers are parsed as comma-separated lists of addresses,
+	// like X-Forwarded-For.
+	//
+	// If Headers is empty, DefaultClientAddrHeaders will be used.
+	Headers []string
+}
+
+// ClientAddr holds a resolved client address.
+type ClientAddr struct {
+	// IP holds the client's IP address.
+	IP netip.Addr
+
+	// Port holds the client's port, or zero if it is unknown.
+	Port uint16
+
+	// Proxies holds the addresses of the trusted proxies through which
+	// the request was forwarded, ordered from nearest to furthest. The
+	// first address is that of the request's peer.
+	//
+	// If Proxies is empty, the client is the request's peer.
+	Proxies []netip.Addr
+}
+
+// Resolve resolves the address of the client of a request received from
+// the peer with address remoteIP and port remotePort, and with the given
+// headers.
+//
+// If remoteIP is a trusted proxy, the forwarding chain in the headers is
+// walked from the right, skipping the hops of trusted proxies, and the
+// first untrusted hop is returned. If a hop cannot be parsed, e.g. if a
+// proxy obfuscated it, the last trusted proxy is returned. If remoteIP
+// is not trusted, then the headers are ignored and it is returned.
+//
+// If r is nil, the result of ClientAddrFromHeaders is returned, with the
+// request's peer as the only proxy. This preserves the behaviour for when
+// no proxies have been configured as trusted, but the result cannot be
+// trusted as the client may control the headers.
+func (r *ClientAddrResolver) Resolve(header http.Header, remoteIP netip.Addr, remotePort uint16) ClientAddr {
+	if r == nil {
+		if ip, port := ClientAddrFromHeaders(header); ip.IsValid() {
+			return ClientAddr{IP: ip, Port: port, Proxies: []netip.Addr{remoteIP}}
+		}
+		return ClientAddr{IP: remoteIP, Port: remotePort}
+	}
+	addr := ClientAddr{IP: remoteIP, Port: remotePort}
+	if !r.trusted(remoteIP) {
+		return addr
+	}
+	hops := r.forwardedHops(header)
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip, port := SplitAddrPort(hops[i])
+		if !ip.IsValid() {
+			break
+		}
+		addr.Proxies = append(addr.Proxies, addr.IP)
+		addr.IP, addr.Port = ip.Unmap(), port
+		if !r.trusted(addr.IP) {
+			break
+		}
+	}
+	return addr
+}
+
+func (r *ClientAddrResolver) trusted(ip netip.Addr) bool {
+	if !ip.IsValid() {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, prefix := range r.TrustedProxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedHops returns the unparsed addresses in the forwarding
+// chain of the highest precedence header present in header, ordered
+// from the originating client to the nearest proxy.
+func (r *ClientAddrResolver) forwardedHops(header http.Header) []string {
+	names := r.Headers
+	if len(names) == 0 {
+		names = DefaultClientAddrHeaders
+	}
+	for _, name := range names {
+		values := getHeaderValues(header, name)
+		if len(values) == 0 {
+			continue
+		}
+		var hops []string
+		for _, value := range values {
+			for _, elem := range strings.Split(value, ",") {
+				if strings.EqualFold(name, "Forwarded") {
+					hops = append(hops, parseForwarded(elem).For)
+				} else {
+					hops = append(hops, strings.TrimSpace(elem))
+				}
+			}
+		}
+		return hops
+	}
+	return nil
+}
+
+// getHeaderValues returns all values of the header with the given name,
+// matching either the canonical or the lowercase form of the name.
+func getHeaderValues(header http.Header, name string) []string {
+	if values := header.Values(name); len(values) > 0 {
+		return values
+	}
+	// See getHeader for why lowercase keys are checked.
+	return header[strings.ToLower(name)]
+}
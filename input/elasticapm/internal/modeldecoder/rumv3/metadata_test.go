@@ -32,6 +32,26 @@ import (
 	"github.com/elastic/apm-data/model"
 )
 
+// initUserAgent holds the user agent values which are not set by input.
+var initUserAgent = model.UserAgent{
+	Original: "init",
+	Name:     "init",
+	Version:  "init",
+	OS:       model.OS{Name: "init", Version: "init", Platform: "init", Full: "init", Type: "init"},
+	Device:   model.UserAgentDevice{Name: "init"},
+}
+
+// initGeo holds the geo values which are not set by input.
+var initGeo = model.Geo{
+	ContinentName:  "init",
+	CountryISOCode: "init",
+	CountryName:    "init",
+	RegionISOCode:  "init",
+	RegionName:     "init",
+	CityName:       "init",
+	Location:       &model.Location{Lat: 0.5, Lon: 0.5},
+}
+
 // initializedMetadata returns a model.APMEvent populated with default values
 // in the metadata-derived fields.
 func initializedMetadata() model.APMEvent {
@@ -42,18 +62,20 @@ func initializedMetadata() model.APMEvent {
 	})
 	mapToMetadataModel(&input, &out)
 	// initialize values that are not set by input
-	out.UserAgent = model.UserAgent{Name: "init", Original: "init"}
+	out.UserAgent = initUserAgent
 	out.Client.Domain = "init"
 	out.Client.IP = netip.MustParseAddr("127.0.0.1")
 	out.Client.Port = 1
+	out.Client.Geo = initGeo
 	nat := &model.NAT{IP: netip.MustParseAddr("127.0.0.1")}
-	out.Source = model.Source{IP: out.Client.IP, Port: out.Client.Port, Domain: out.Client.Domain, NAT: nat}
+	out.Source = model.Source{IP: out.Client.IP, Port: out.Client.Port, Domain: out.Client.Domain, NAT: nat, Geo: initGeo}
 	return out
 }
 
 func metadataExceptions(keys ...string) func(key string) bool {
 	missing := []string{
 		"Agent",
+		"Body",
 		"Child",
 		"Cloud",
 		"Container",
@@ -190,17 +212,19 @@ func TestDecodeMetadataMappingToModel(t *testing.T) {
 			},
 			// these values are not set from http headers and
 			// are not expected change with updated input data
-			UserAgent: model.UserAgent{Original: "init", Name: "init"},
+			UserAgent: initUserAgent,
 			Client: model.Client{
 				Domain: "init",
 				IP:     lhost,
 				Port:   1,
+				Geo:    initGeo,
 			},
 			Source: model.Source{
 				Domain: "init",
 				IP:     lhost,
 				Port:   1,
 				NAT:    &model.NAT{IP: lhost},
+				Geo:    initGeo,
 			},
 		}
 	}
@@ -238,12 +262,13 @@ func TestDecodeMetadataMappingToModel(t *testing.T) {
 		modeldecodertest.SetStructValues(&input, defaultVal)
 		mapToMetadataModel(&input, &out1)
 		// initialize values that are not set by input
-		out1.UserAgent = model.UserAgent{Name: "init", Original: "init"}
+		out1.UserAgent = initUserAgent
 		out1.Client.Domain = "init"
 		out1.Client.IP = netip.MustParseAddr("127.0.0.1")
 		out1.Client.Port = 1
+		out1.Client.Geo = initGeo
 		nat := &model.NAT{IP: out1.Client.IP}
-		out1.Source = model.Source{IP: out1.Client.IP, Port: out1.Client.Port, Domain: out1.Client.Domain, NAT: nat}
+		out1.Source = model.Source{IP: out1.Client.IP, Port: out1.Client.Port, Domain: out1.Client.Domain, NAT: nat, Geo: initGeo}
 		assert.Equal(t, expected(defaultVal.Str, defaultVal.IP, defaultVal.N), out1)
 
 		// overwrite model metadata with specified Values
@@ -252,11 +277,12 @@ func TestDecodeMetadataMappingToModel(t *testing.T) {
 		input.Reset()
 		modeldecodertest.SetStructValues(&input, otherVal)
 		mapToMetadataModel(&input, &out2)
-		out2.UserAgent = model.UserAgent{Name: "init", Original: "init"}
+		out2.UserAgent = initUserAgent
 		out2.Client.Domain = "init"
 		out2.Client.IP = netip.MustParseAddr("127.0.0.1")
 		out2.Client.Port = 1
-		out2.Source = model.Source{IP: out2.Client.IP, Port: out2.Client.Port, Domain: out2.Client.Domain, NAT: nat}
+		out2.Client.Geo = initGeo
+		out2.Source = model.Source{IP: out2.Client.IP, Port: out2.Client.Port, Domain: out2.Client.Domain, NAT: nat, Geo: initGeo}
 		assert.Equal(t, expected(otherVal.Str, otherVal.IP, otherVal.N), out2)
 		assert.Equal(t, expected(defaultVal.Str, defaultVal.IP, defaultVal.N), out1)
 	})
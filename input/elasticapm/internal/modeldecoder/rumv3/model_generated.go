@@ -21,11 +21,12 @@ package rumv3
 
 import (
 	"encoding/json"
-	"fmt"
 	"regexp"
 	"unicode/utf8"
 
 	"github.com/pkg/errors"
+
+	"github.com/elastic/apm-data/input/elasticapm/internal/modeldecoder"
 )
 
 var (
@@ -42,10 +43,10 @@ func (val *metadataRoot) Reset() {
 
 func (val *metadataRoot) validate() error {
 	if err := val.Metadata.validate(); err != nil {
-		return errors.Wrapf(err, "m")
+		return modeldecoder.WrapRuleErr(err, "m")
 	}
 	if !val.Metadata.IsSet() {
-		return fmt.Errorf("'m' required")
+		return modeldecoder.NewRequiredErr("m")
 	}
 	return nil
 }
@@ -79,25 +80,25 @@ func (val *metadata) validate() error {
 		case nil:
 		case string:
 			if utf8.RuneCountInString(t) > 1024 {
-				return fmt.Errorf("'l': validation rule 'maxLengthVals(1024)' violated")
+				return modeldecoder.NewRuleErr("l", "maxLengthVals(1024)")
 			}
 		case bool:
 		case json.Number:
 		default:
-			return fmt.Errorf("'l': validation rule 'inputTypesVals(string;bool;number)' violated for key %s", k)
+			return modeldecoder.NewRuleKeyErr("l", "inputTypesVals(string;bool;number)", k)
 		}
 	}
 	if err := val.Service.validate(); err != nil {
-		return errors.Wrapf(err, "se")
+		return modeldecoder.WrapRuleErr(err, "se")
 	}
 	if !val.Service.IsSet() {
-		return fmt.Errorf("'se' required")
+		return modeldecoder.NewRequiredErr("se")
 	}
 	if err := val.User.validate(); err != nil {
-		return errors.Wrapf(err, "u")
+		return modeldecoder.WrapRuleErr(err, "u")
 	}
 	if err := val.Network.validate(); err != nil {
-		return errors.Wrapf(err, "n")
+		return modeldecoder.WrapRuleErr(err, "n")
 	}
 	return nil
 }
@@ -134,37 +135,37 @@ func (val *metadataService) validate() error {
 		return nil
 	}
 	if err := val.Agent.validate(); err != nil {
-		return errors.Wrapf(err, "a")
+		return modeldecoder.WrapRuleErr(err, "a")
 	}
 	if !val.Agent.IsSet() {
-		return fmt.Errorf("'a' required")
+		return modeldecoder.NewRequiredErr("a")
 	}
 	if val.Environment.IsSet() && utf8.RuneCountInString(val.Environment.Val) > 1024 {
-		return fmt.Errorf("'en': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("en", "maxLength(1024)")
 	}
 	if err := val.Framework.validate(); err != nil {
-		return errors.Wrapf(err, "fw")
+		return modeldecoder.WrapRuleErr(err, "fw")
 	}
 	if err := val.Language.validate(); err != nil {
-		return errors.Wrapf(err, "la")
+		return modeldecoder.WrapRuleErr(err, "la")
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'n': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("n", "maxLength(1024)")
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) < 1 {
-		return fmt.Errorf("'n': validation rule 'minLength(1)' violated")
+		return modeldecoder.NewRuleErr("n", "minLength(1)")
 	}
 	if val.Name.Val != "" && !patternAlphaNumericExtRegexp.MatchString(val.Name.Val) {
-		return fmt.Errorf("'n': validation rule 'pattern(patternAlphaNumericExt)' violated")
+		return modeldecoder.NewRuleErr("n", "pattern(patternAlphaNumericExt)")
 	}
 	if !val.Name.IsSet() {
-		return fmt.Errorf("'n' required")
+		return modeldecoder.NewRequiredErr("n")
 	}
 	if err := val.Runtime.validate(); err != nil {
-		return errors.Wrapf(err, "ru")
+		return modeldecoder.WrapRuleErr(err, "ru")
 	}
 	if val.Version.IsSet() && utf8.RuneCountInString(val.Version.Val) > 1024 {
-		return fmt.Errorf("'ve': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("ve", "maxLength(1024)")
 	}
 	return nil
 }
@@ -199,19 +200,19 @@ func (val *metadataServiceAgent) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'n': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("n", "maxLength(1024)")
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) < 1 {
-		return fmt.Errorf("'n': validation rule 'minLength(1)' violated")
+		return modeldecoder.NewRuleErr("n", "minLength(1)")
 	}
 	if !val.Name.IsSet() {
-		return fmt.Errorf("'n' required")
+		return modeldecoder.NewRequiredErr("n")
 	}
 	if val.Version.IsSet() && utf8.RuneCountInString(val.Version.Val) > 1024 {
-		return fmt.Errorf("'ve': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("ve", "maxLength(1024)")
 	}
 	if !val.Version.IsSet() {
-		return fmt.Errorf("'ve' required")
+		return modeldecoder.NewRequiredErr("ve")
 	}
 	return nil
 }
@@ -234,10 +235,10 @@ func (val *metadataServiceFramework) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'n': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("n", "maxLength(1024)")
 	}
 	if val.Version.IsSet() && utf8.RuneCountInString(val.Version.Val) > 1024 {
-		return fmt.Errorf("'ve': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("ve", "maxLength(1024)")
 	}
 	return nil
 }
@@ -260,13 +261,13 @@ func (val *metadataServiceLanguage) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'n': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("n", "maxLength(1024)")
 	}
 	if !val.Name.IsSet() {
-		return fmt.Errorf("'n' required")
+		return modeldecoder.NewRequiredErr("n")
 	}
 	if val.Version.IsSet() && utf8.RuneCountInString(val.Version.Val) > 1024 {
-		return fmt.Errorf("'ve': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("ve", "maxLength(1024)")
 	}
 	return nil
 }
@@ -289,16 +290,16 @@ func (val *metadataServiceRuntime) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'n': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("n", "maxLength(1024)")
 	}
 	if !val.Name.IsSet() {
-		return fmt.Errorf("'n' required")
+		return modeldecoder.NewRequiredErr("n")
 	}
 	if val.Version.IsSet() && utf8.RuneCountInString(val.Version.Val) > 1024 {
-		return fmt.Errorf("'ve': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("ve", "maxLength(1024)")
 	}
 	if !val.Version.IsSet() {
-		return fmt.Errorf("'ve' required")
+		return modeldecoder.NewRequiredErr("ve")
 	}
 	return nil
 }
@@ -323,27 +324,27 @@ func (val *user) validate() error {
 		return nil
 	}
 	if val.Domain.IsSet() && utf8.RuneCountInString(val.Domain.Val) > 1024 {
-		return fmt.Errorf("'ud': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("ud", "maxLength(1024)")
 	}
 	switch t := val.ID.Val.(type) {
 	case string:
 		if utf8.RuneCountInString(t) > 1024 {
-			return fmt.Errorf("'id': validation rule 'maxLength(1024)' violated")
+			return modeldecoder.NewRuleErr("id", "maxLength(1024)")
 		}
 	case int:
 	case json.Number:
 		if _, err := t.Int64(); err != nil {
-			return fmt.Errorf("'id': validation rule 'inputTypes(string;int)' violated")
+			return modeldecoder.NewRuleErr("id", "inputTypes(string;int)")
 		}
 	case nil:
 	default:
-		return fmt.Errorf("'id': validation rule 'inputTypes(string;int)' violated ")
+		return modeldecoder.NewRuleErr("id", "inputTypes(string;int)")
 	}
 	if val.Email.IsSet() && utf8.RuneCountInString(val.Email.Val) > 1024 {
-		return fmt.Errorf("'em': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("em", "maxLength(1024)")
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'un': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("un", "maxLength(1024)")
 	}
 	return nil
 }
@@ -365,7 +366,7 @@ func (val *network) validate() error {
 		return nil
 	}
 	if err := val.Connection.validate(); err != nil {
-		return errors.Wrapf(err, "c")
+		return modeldecoder.WrapRuleErr(err, "c")
 	}
 	return nil
 }
@@ -390,7 +391,7 @@ func (val *networkConnection) validate() error {
 		return nil
 	}
 	if val.Type.IsSet() && utf8.RuneCountInString(val.Type.Val) > 1024 {
-		return fmt.Errorf("'t': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("t", "maxLength(1024)")
 	}
 	return nil
 }
@@ -409,10 +410,10 @@ func (val *errorRoot) Reset() {
 
 func (val *errorRoot) validate() error {
 	if err := val.Error.validate(); err != nil {
-		return errors.Wrapf(err, "e")
+		return modeldecoder.WrapRuleErr(err, "e")
 	}
 	if !val.Error.IsSet() {
-		return fmt.Errorf("'e' required")
+		return modeldecoder.NewRequiredErr("e")
 	}
 	return nil
 }
@@ -446,53 +447,53 @@ func (val *errorEvent) validate() error {
 		return nil
 	}
 	if err := val.Context.validate(); err != nil {
-		return errors.Wrapf(err, "c")
+		return modeldecoder.WrapRuleErr(err, "c")
 	}
 	if val.Culprit.IsSet() && utf8.RuneCountInString(val.Culprit.Val) > 1024 {
-		return fmt.Errorf("'cl': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("cl", "maxLength(1024)")
 	}
 	if err := val.Exception.validate(); err != nil {
-		return errors.Wrapf(err, "ex")
+		return modeldecoder.WrapRuleErr(err, "ex")
 	}
 	if val.ID.IsSet() && utf8.RuneCountInString(val.ID.Val) > 1024 {
-		return fmt.Errorf("'id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("id", "maxLength(1024)")
 	}
 	if !val.ID.IsSet() {
-		return fmt.Errorf("'id' required")
+		return modeldecoder.NewRequiredErr("id")
 	}
 	if err := val.Log.validate(); err != nil {
-		return errors.Wrapf(err, "log")
+		return modeldecoder.WrapRuleErr(err, "log")
 	}
 	if val.ParentID.IsSet() && utf8.RuneCountInString(val.ParentID.Val) > 1024 {
-		return fmt.Errorf("'pid': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("pid", "maxLength(1024)")
 	}
 	if !val.ParentID.IsSet() {
 		if val.TransactionID.IsSet() {
-			return fmt.Errorf("'pid' required when 'xid' is set")
+			return modeldecoder.NewRequiredIfAnyErr("pid", "xid")
 		}
 		if val.TraceID.IsSet() {
-			return fmt.Errorf("'pid' required when 'tid' is set")
+			return modeldecoder.NewRequiredIfAnyErr("pid", "tid")
 		}
 	}
 	if val.TraceID.IsSet() && utf8.RuneCountInString(val.TraceID.Val) > 1024 {
-		return fmt.Errorf("'tid': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("tid", "maxLength(1024)")
 	}
 	if !val.TraceID.IsSet() {
 		if val.TransactionID.IsSet() {
-			return fmt.Errorf("'tid' required when 'xid' is set")
+			return modeldecoder.NewRequiredIfAnyErr("tid", "xid")
 		}
 		if val.ParentID.IsSet() {
-			return fmt.Errorf("'tid' required when 'pid' is set")
+			return modeldecoder.NewRequiredIfAnyErr("tid", "pid")
 		}
 	}
 	if err := val.Transaction.validate(); err != nil {
-		return errors.Wrapf(err, "x")
+		return modeldecoder.WrapRuleErr(err, "x")
 	}
 	if val.TransactionID.IsSet() && utf8.RuneCountInString(val.TransactionID.Val) > 1024 {
-		return fmt.Errorf("'xid': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("xid", "maxLength(1024)")
 	}
 	if !val.Exception.IsSet() && !val.Log.IsSet() {
-		return fmt.Errorf("requires at least one of the fields 'ex;log'")
+		return modeldecoder.NewRequiredAnyOfErr("ex;log")
 	}
 	return nil
 }
@@ -536,32 +537,32 @@ func (val *context) validate() error {
 		return nil
 	}
 	if err := val.Page.validate(); err != nil {
-		return errors.Wrapf(err, "p")
+		return modeldecoder.WrapRuleErr(err, "p")
 	}
 	if err := val.Response.validate(); err != nil {
-		return errors.Wrapf(err, "r")
+		return modeldecoder.WrapRuleErr(err, "r")
 	}
 	if err := val.Request.validate(); err != nil {
-		return errors.Wrapf(err, "q")
+		return modeldecoder.WrapRuleErr(err, "q")
 	}
 	if err := val.Service.validate(); err != nil {
-		return errors.Wrapf(err, "se")
+		return modeldecoder.WrapRuleErr(err, "se")
 	}
 	for k, v := range val.Tags {
 		switch t := v.(type) {
 		case nil:
 		case string:
 			if utf8.RuneCountInString(t) > 1024 {
-				return fmt.Errorf("'g': validation rule 'maxLengthVals(1024)' violated")
+				return modeldecoder.NewRuleErr("g", "maxLengthVals(1024)")
 			}
 		case bool:
 		case json.Number:
 		default:
-			return fmt.Errorf("'g': validation rule 'inputTypesVals(string;bool;number)' violated for key %s", k)
+			return modeldecoder.NewRuleKeyErr("g", "inputTypesVals(string;bool;number)", k)
 		}
 	}
 	if err := val.User.validate(); err != nil {
-		return errors.Wrapf(err, "u")
+		return modeldecoder.WrapRuleErr(err, "u")
 	}
 	return nil
 }
@@ -646,13 +647,13 @@ func (val *contextRequest) validate() error {
 		return nil
 	}
 	if val.HTTPVersion.IsSet() && utf8.RuneCountInString(val.HTTPVersion.Val) > 1024 {
-		return fmt.Errorf("'hve': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("hve", "maxLength(1024)")
 	}
 	if val.Method.IsSet() && utf8.RuneCountInString(val.Method.Val) > 1024 {
-		return fmt.Errorf("'mt': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("mt", "maxLength(1024)")
 	}
 	if !val.Method.IsSet() {
-		return fmt.Errorf("'mt' required")
+		return modeldecoder.NewRequiredErr("mt")
 	}
 	return nil
 }
@@ -680,28 +681,28 @@ func (val *contextService) validate() error {
 		return nil
 	}
 	if err := val.Agent.validate(); err != nil {
-		return errors.Wrapf(err, "a")
+		return modeldecoder.WrapRuleErr(err, "a")
 	}
 	if val.Environment.IsSet() && utf8.RuneCountInString(val.Environment.Val) > 1024 {
-		return fmt.Errorf("'en': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("en", "maxLength(1024)")
 	}
 	if err := val.Framework.validate(); err != nil {
-		return errors.Wrapf(err, "fw")
+		return modeldecoder.WrapRuleErr(err, "fw")
 	}
 	if err := val.Language.validate(); err != nil {
-		return errors.Wrapf(err, "la")
+		return modeldecoder.WrapRuleErr(err, "la")
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'n': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("n", "maxLength(1024)")
 	}
 	if val.Name.Val != "" && !patternAlphaNumericExtRegexp.MatchString(val.Name.Val) {
-		return fmt.Errorf("'n': validation rule 'pattern(patternAlphaNumericExt)' violated")
+		return modeldecoder.NewRuleErr("n", "pattern(patternAlphaNumericExt)")
 	}
 	if err := val.Runtime.validate(); err != nil {
-		return errors.Wrapf(err, "ru")
+		return modeldecoder.WrapRuleErr(err, "ru")
 	}
 	if val.Version.IsSet() && utf8.RuneCountInString(val.Version.Val) > 1024 {
-		return fmt.Errorf("'ve': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("ve", "maxLength(1024)")
 	}
 	return nil
 }
@@ -736,10 +737,10 @@ func (val *contextServiceAgent) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'n': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("n", "maxLength(1024)")
 	}
 	if val.Version.IsSet() && utf8.RuneCountInString(val.Version.Val) > 1024 {
-		return fmt.Errorf("'ve': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("ve", "maxLength(1024)")
 	}
 	return nil
 }
@@ -762,10 +763,10 @@ func (val *contextServiceFramework) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'n': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("n", "maxLength(1024)")
 	}
 	if val.Version.IsSet() && utf8.RuneCountInString(val.Version.Val) > 1024 {
-		return fmt.Errorf("'ve': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("ve", "maxLength(1024)")
 	}
 	return nil
 }
@@ -788,10 +789,10 @@ func (val *contextServiceLanguage) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'n': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("n", "maxLength(1024)")
 	}
 	if val.Version.IsSet() && utf8.RuneCountInString(val.Version.Val) > 1024 {
-		return fmt.Errorf("'ve': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("ve", "maxLength(1024)")
 	}
 	return nil
 }
@@ -814,10 +815,10 @@ func (val *contextServiceRuntime) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'n': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("n", "maxLength(1024)")
 	}
 	if val.Version.IsSet() && utf8.RuneCountInString(val.Version.Val) > 1024 {
-		return fmt.Errorf("'ve': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("ve", "maxLength(1024)")
 	}
 	return nil
 }
@@ -856,35 +857,35 @@ func (val *errorException) validate() error {
 	switch t := val.Code.Val.(type) {
 	case string:
 		if utf8.RuneCountInString(t) > 1024 {
-			return fmt.Errorf("'cd': validation rule 'maxLength(1024)' violated")
+			return modeldecoder.NewRuleErr("cd", "maxLength(1024)")
 		}
 	case int:
 	case json.Number:
 		if _, err := t.Int64(); err != nil {
-			return fmt.Errorf("'cd': validation rule 'inputTypes(string;int)' violated")
+			return modeldecoder.NewRuleErr("cd", "inputTypes(string;int)")
 		}
 	case nil:
 	default:
-		return fmt.Errorf("'cd': validation rule 'inputTypes(string;int)' violated ")
+		return modeldecoder.NewRuleErr("cd", "inputTypes(string;int)")
 	}
 	for _, elem := range val.Cause {
 		if err := elem.validate(); err != nil {
-			return errors.Wrapf(err, "ca")
+			return modeldecoder.WrapRuleErr(err, "ca")
 		}
 	}
 	if val.Module.IsSet() && utf8.RuneCountInString(val.Module.Val) > 1024 {
-		return fmt.Errorf("'mo': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("mo", "maxLength(1024)")
 	}
 	for _, elem := range val.Stacktrace {
 		if err := elem.validate(); err != nil {
-			return errors.Wrapf(err, "st")
+			return modeldecoder.WrapRuleErr(err, "st")
 		}
 	}
 	if val.Type.IsSet() && utf8.RuneCountInString(val.Type.Val) > 1024 {
-		return fmt.Errorf("'t': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("t", "maxLength(1024)")
 	}
 	if !val.Message.IsSet() && !val.Type.IsSet() {
-		return fmt.Errorf("requires at least one of the fields 'mg;t'")
+		return modeldecoder.NewRequiredAnyOfErr("mg;t")
 	}
 	return nil
 }
@@ -915,7 +916,7 @@ func (val *stacktraceFrame) validate() error {
 		return nil
 	}
 	if !val.Filename.IsSet() {
-		return fmt.Errorf("'f' required")
+		return modeldecoder.NewRequiredErr("f")
 	}
 	return nil
 }
@@ -944,20 +945,20 @@ func (val *errorLog) validate() error {
 		return nil
 	}
 	if val.Level.IsSet() && utf8.RuneCountInString(val.Level.Val) > 1024 {
-		return fmt.Errorf("'lv': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("lv", "maxLength(1024)")
 	}
 	if val.LoggerName.IsSet() && utf8.RuneCountInString(val.LoggerName.Val) > 1024 {
-		return fmt.Errorf("'ln': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("ln", "maxLength(1024)")
 	}
 	if !val.Message.IsSet() {
-		return fmt.Errorf("'mg' required")
+		return modeldecoder.NewRequiredErr("mg")
 	}
 	if val.ParamMessage.IsSet() && utf8.RuneCountInString(val.ParamMessage.Val) > 1024 {
-		return fmt.Errorf("'pmg': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("pmg", "maxLength(1024)")
 	}
 	for _, elem := range val.Stacktrace {
 		if err := elem.validate(); err != nil {
-			return errors.Wrapf(err, "st")
+			return modeldecoder.WrapRuleErr(err, "st")
 		}
 	}
 	return nil
@@ -982,10 +983,10 @@ func (val *errorTransactionRef) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'n': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("n", "maxLength(1024)")
 	}
 	if val.Type.IsSet() && utf8.RuneCountInString(val.Type.Val) > 1024 {
-		return fmt.Errorf("'t': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("t", "maxLength(1024)")
 	}
 	return nil
 }
@@ -1004,10 +1005,10 @@ func (val *transactionRoot) Reset() {
 
 func (val *transactionRoot) validate() error {
 	if err := val.Transaction.validate(); err != nil {
-		return errors.Wrapf(err, "x")
+		return modeldecoder.WrapRuleErr(err, "x")
 	}
 	if !val.Transaction.IsSet() {
-		return fmt.Errorf("'x' required")
+		return modeldecoder.NewRequiredErr("x")
 	}
 	return nil
 }
@@ -1054,30 +1055,30 @@ func (val *transaction) validate() error {
 		return nil
 	}
 	if err := val.Context.validate(); err != nil {
-		return errors.Wrapf(err, "c")
+		return modeldecoder.WrapRuleErr(err, "c")
 	}
 	if val.Duration.IsSet() && val.Duration.Val < 0 {
-		return fmt.Errorf("'d': validation rule 'min(0)' violated")
+		return modeldecoder.NewRuleErr("d", "min(0)")
 	}
 	if !val.Duration.IsSet() {
-		return fmt.Errorf("'d' required")
+		return modeldecoder.NewRequiredErr("d")
 	}
 	if val.ID.IsSet() && utf8.RuneCountInString(val.ID.Val) > 1024 {
-		return fmt.Errorf("'id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("id", "maxLength(1024)")
 	}
 	if !val.ID.IsSet() {
-		return fmt.Errorf("'id' required")
+		return modeldecoder.NewRequiredErr("id")
 	}
 	if err := val.Marks.validate(); err != nil {
-		return errors.Wrapf(err, "k")
+		return modeldecoder.WrapRuleErr(err, "k")
 	}
 	for _, elem := range val.Metricsets {
 		if err := elem.validate(); err != nil {
-			return errors.Wrapf(err, "me")
+			return modeldecoder.WrapRuleErr(err, "me")
 		}
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'n': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("n", "maxLength(1024)")
 	}
 	if val.Outcome.Val != "" {
 		var matchEnum bool
@@ -1088,43 +1089,43 @@ func (val *transaction) validate() error {
 			}
 		}
 		if !matchEnum {
-			return fmt.Errorf("'o': validation rule 'enum(enumOutcome)' violated")
+			return modeldecoder.NewRuleErr("o", "enum(enumOutcome)")
 		}
 	}
 	if val.ParentID.IsSet() && utf8.RuneCountInString(val.ParentID.Val) > 1024 {
-		return fmt.Errorf("'pid': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("pid", "maxLength(1024)")
 	}
 	if val.Result.IsSet() && utf8.RuneCountInString(val.Result.Val) > 1024 {
-		return fmt.Errorf("'rt': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("rt", "maxLength(1024)")
 	}
 	if err := val.Session.validate(); err != nil {
-		return errors.Wrapf(err, "ses")
+		return modeldecoder.WrapRuleErr(err, "ses")
 	}
 	if err := val.SpanCount.validate(); err != nil {
-		return errors.Wrapf(err, "yc")
+		return modeldecoder.WrapRuleErr(err, "yc")
 	}
 	if !val.SpanCount.IsSet() {
-		return fmt.Errorf("'yc' required")
+		return modeldecoder.NewRequiredErr("yc")
 	}
 	for _, elem := range val.Spans {
 		if err := elem.validate(); err != nil {
-			return errors.Wrapf(err, "y")
+			return modeldecoder.WrapRuleErr(err, "y")
 		}
 	}
 	if val.TraceID.IsSet() && utf8.RuneCountInString(val.TraceID.Val) > 1024 {
-		return fmt.Errorf("'tid': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("tid", "maxLength(1024)")
 	}
 	if !val.TraceID.IsSet() {
-		return fmt.Errorf("'tid' required")
+		return modeldecoder.NewRequiredErr("tid")
 	}
 	if val.Type.IsSet() && utf8.RuneCountInString(val.Type.Val) > 1024 {
-		return fmt.Errorf("'t': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("t", "maxLength(1024)")
 	}
 	if !val.Type.IsSet() {
-		return fmt.Errorf("'t' required")
+		return modeldecoder.NewRequiredErr("t")
 	}
 	if err := val.UserExperience.validate(); err != nil {
-		return errors.Wrapf(err, "exp")
+		return modeldecoder.WrapRuleErr(err, "exp")
 	}
 	return nil
 }
@@ -1204,13 +1205,13 @@ func (val *transactionMetricset) validate() error {
 		return nil
 	}
 	if err := val.Samples.validate(); err != nil {
-		return errors.Wrapf(err, "sa")
+		return modeldecoder.WrapRuleErr(err, "sa")
 	}
 	if !val.Samples.IsSet() {
-		return fmt.Errorf("'sa' required")
+		return modeldecoder.NewRequiredErr("sa")
 	}
 	if err := val.Span.validate(); err != nil {
-		return errors.Wrapf(err, "y")
+		return modeldecoder.WrapRuleErr(err, "y")
 	}
 	return nil
 }
@@ -1239,10 +1240,10 @@ func (val *transactionMetricsetSamples) validate() error {
 		return nil
 	}
 	if err := val.SpanSelfTimeCount.validate(); err != nil {
-		return errors.Wrapf(err, "ysc")
+		return modeldecoder.WrapRuleErr(err, "ysc")
 	}
 	if err := val.SpanSelfTimeSum.validate(); err != nil {
-		return errors.Wrapf(err, "yss")
+		return modeldecoder.WrapRuleErr(err, "yss")
 	}
 	return nil
 }
@@ -1270,7 +1271,7 @@ func (val *metricsetSampleValue) validate() error {
 		return nil
 	}
 	if !val.Value.IsSet() {
-		return fmt.Errorf("'v' required")
+		return modeldecoder.NewRequiredErr("v")
 	}
 	return nil
 }
@@ -1293,10 +1294,10 @@ func (val *metricsetSpanRef) validate() error {
 		return nil
 	}
 	if val.Subtype.IsSet() && utf8.RuneCountInString(val.Subtype.Val) > 1024 {
-		return fmt.Errorf("'su': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("su", "maxLength(1024)")
 	}
 	if val.Type.IsSet() && utf8.RuneCountInString(val.Type.Val) > 1024 {
-		return fmt.Errorf("'t': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("t", "maxLength(1024)")
 	}
 	return nil
 }
@@ -1319,10 +1320,10 @@ func (val *transactionSession) validate() error {
 		return nil
 	}
 	if !val.ID.IsSet() {
-		return fmt.Errorf("'id' required")
+		return modeldecoder.NewRequiredErr("id")
 	}
 	if val.Sequence.IsSet() && val.Sequence.Val < 1 {
-		return fmt.Errorf("'seq': validation rule 'min(1)' violated")
+		return modeldecoder.NewRuleErr("seq", "min(1)")
 	}
 	return nil
 }
@@ -1345,7 +1346,7 @@ func (val *transactionSpanCount) validate() error {
 		return nil
 	}
 	if !val.Started.IsSet() {
-		return fmt.Errorf("'sd' required")
+		return modeldecoder.NewRequiredErr("sd")
 	}
 	return nil
 }
@@ -1382,28 +1383,28 @@ func (val *span) validate() error {
 		return nil
 	}
 	if val.Action.IsSet() && utf8.RuneCountInString(val.Action.Val) > 1024 {
-		return fmt.Errorf("'ac': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("ac", "maxLength(1024)")
 	}
 	if err := val.Context.validate(); err != nil {
-		return errors.Wrapf(err, "c")
+		return modeldecoder.WrapRuleErr(err, "c")
 	}
 	if val.Duration.IsSet() && val.Duration.Val < 0 {
-		return fmt.Errorf("'d': validation rule 'min(0)' violated")
+		return modeldecoder.NewRuleErr("d", "min(0)")
 	}
 	if !val.Duration.IsSet() {
-		return fmt.Errorf("'d' required")
+		return modeldecoder.NewRequiredErr("d")
 	}
 	if val.ID.IsSet() && utf8.RuneCountInString(val.ID.Val) > 1024 {
-		return fmt.Errorf("'id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("id", "maxLength(1024)")
 	}
 	if !val.ID.IsSet() {
-		return fmt.Errorf("'id' required")
+		return modeldecoder.NewRequiredErr("id")
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'n': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("n", "maxLength(1024)")
 	}
 	if !val.Name.IsSet() {
-		return fmt.Errorf("'n' required")
+		return modeldecoder.NewRequiredErr("n")
 	}
 	if val.Outcome.Val != "" {
 		var matchEnum bool
@@ -1414,25 +1415,25 @@ func (val *span) validate() error {
 			}
 		}
 		if !matchEnum {
-			return fmt.Errorf("'o': validation rule 'enum(enumOutcome)' violated")
+			return modeldecoder.NewRuleErr("o", "enum(enumOutcome)")
 		}
 	}
 	for _, elem := range val.Stacktrace {
 		if err := elem.validate(); err != nil {
-			return errors.Wrapf(err, "st")
+			return modeldecoder.WrapRuleErr(err, "st")
 		}
 	}
 	if !val.Start.IsSet() {
-		return fmt.Errorf("'s' required")
+		return modeldecoder.NewRequiredErr("s")
 	}
 	if val.Subtype.IsSet() && utf8.RuneCountInString(val.Subtype.Val) > 1024 {
-		return fmt.Errorf("'su': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("su", "maxLength(1024)")
 	}
 	if val.Type.IsSet() && utf8.RuneCountInString(val.Type.Val) > 1024 {
-		return fmt.Errorf("'t': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("t", "maxLength(1024)")
 	}
 	if !val.Type.IsSet() {
-		return fmt.Errorf("'t' required")
+		return modeldecoder.NewRequiredErr("t")
 	}
 	return nil
 }
@@ -1462,25 +1463,25 @@ func (val *spanContext) validate() error {
 		return nil
 	}
 	if err := val.Destination.validate(); err != nil {
-		return errors.Wrapf(err, "dt")
+		return modeldecoder.WrapRuleErr(err, "dt")
 	}
 	if err := val.HTTP.validate(); err != nil {
-		return errors.Wrapf(err, "h")
+		return modeldecoder.WrapRuleErr(err, "h")
 	}
 	if err := val.Service.validate(); err != nil {
-		return errors.Wrapf(err, "se")
+		return modeldecoder.WrapRuleErr(err, "se")
 	}
 	for k, v := range val.Tags {
 		switch t := v.(type) {
 		case nil:
 		case string:
 			if utf8.RuneCountInString(t) > 1024 {
-				return fmt.Errorf("'g': validation rule 'maxLengthVals(1024)' violated")
+				return modeldecoder.NewRuleErr("g", "maxLengthVals(1024)")
 			}
 		case bool:
 		case json.Number:
 		default:
-			return fmt.Errorf("'g': validation rule 'inputTypesVals(string;bool;number)' violated for key %s", k)
+			return modeldecoder.NewRuleKeyErr("g", "inputTypesVals(string;bool;number)", k)
 		}
 	}
 	return nil
@@ -1514,10 +1515,10 @@ func (val *spanContextDestination) validate() error {
 		return nil
 	}
 	if val.Address.IsSet() && utf8.RuneCountInString(val.Address.Val) > 1024 {
-		return fmt.Errorf("'ad': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("ad", "maxLength(1024)")
 	}
 	if err := val.Service.validate(); err != nil {
-		return errors.Wrapf(err, "se")
+		return modeldecoder.WrapRuleErr(err, "se")
 	}
 	return nil
 }
@@ -1544,16 +1545,16 @@ func (val *spanContextDestinationService) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'n': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("n", "maxLength(1024)")
 	}
 	if val.Resource.IsSet() && utf8.RuneCountInString(val.Resource.Val) > 1024 {
-		return fmt.Errorf("'rc': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("rc", "maxLength(1024)")
 	}
 	if !val.Resource.IsSet() {
-		return fmt.Errorf("'rc' required")
+		return modeldecoder.NewRequiredErr("rc")
 	}
 	if val.Type.IsSet() && utf8.RuneCountInString(val.Type.Val) > 1024 {
-		return fmt.Errorf("'t': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("t", "maxLength(1024)")
 	}
 	return nil
 }
@@ -1578,10 +1579,10 @@ func (val *spanContextHTTP) validate() error {
 		return nil
 	}
 	if val.Method.IsSet() && utf8.RuneCountInString(val.Method.Val) > 1024 {
-		return fmt.Errorf("'mt': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("mt", "maxLength(1024)")
 	}
 	if err := val.Response.validate(); err != nil {
-		return errors.Wrapf(err, "r")
+		return modeldecoder.WrapRuleErr(err, "r")
 	}
 	return nil
 }
@@ -1628,13 +1629,13 @@ func (val *spanContextService) validate() error {
 		return nil
 	}
 	if err := val.Agent.validate(); err != nil {
-		return errors.Wrapf(err, "a")
+		return modeldecoder.WrapRuleErr(err, "a")
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'n': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("n", "maxLength(1024)")
 	}
 	if val.Name.Val != "" && !patternAlphaNumericExtRegexp.MatchString(val.Name.Val) {
-		return fmt.Errorf("'n': validation rule 'pattern(patternAlphaNumericExt)' violated")
+		return modeldecoder.NewRuleErr("n", "pattern(patternAlphaNumericExt)")
 	}
 	return nil
 }
@@ -1662,16 +1663,16 @@ func (val *transactionUserExperience) validate() error {
 		return nil
 	}
 	if val.CumulativeLayoutShift.IsSet() && val.CumulativeLayoutShift.Val < 0 {
-		return fmt.Errorf("'cls': validation rule 'min(0)' violated")
+		return modeldecoder.NewRuleErr("cls", "min(0)")
 	}
 	if val.FirstInputDelay.IsSet() && val.FirstInputDelay.Val < 0 {
-		return fmt.Errorf("'fid': validation rule 'min(0)' violated")
+		return modeldecoder.NewRuleErr("fid", "min(0)")
 	}
 	if val.TotalBlockingTime.IsSet() && val.TotalBlockingTime.Val < 0 {
-		return fmt.Errorf("'tbt': validation rule 'min(0)' violated")
+		return modeldecoder.NewRuleErr("tbt", "min(0)")
 	}
 	if err := val.Longtask.validate(); err != nil {
-		return errors.Wrapf(err, "lt")
+		return modeldecoder.WrapRuleErr(err, "lt")
 	}
 	return nil
 }
@@ -1698,22 +1699,22 @@ func (val *longtaskMetrics) validate() error {
 		return nil
 	}
 	if val.Count.IsSet() && val.Count.Val < 0 {
-		return fmt.Errorf("'count': validation rule 'min(0)' violated")
+		return modeldecoder.NewRuleErr("count", "min(0)")
 	}
 	if !val.Count.IsSet() {
-		return fmt.Errorf("'count' required")
+		return modeldecoder.NewRequiredErr("count")
 	}
 	if val.Max.IsSet() && val.Max.Val < 0 {
-		return fmt.Errorf("'max': validation rule 'min(0)' violated")
+		return modeldecoder.NewRuleErr("max", "min(0)")
 	}
 	if !val.Max.IsSet() {
-		return fmt.Errorf("'max' required")
+		return modeldecoder.NewRequiredErr("max")
 	}
 	if val.Sum.IsSet() && val.Sum.Val < 0 {
-		return fmt.Errorf("'sum': validation rule 'min(0)' violated")
+		return modeldecoder.NewRuleErr("sum", "min(0)")
 	}
 	if !val.Sum.IsSet() {
-		return fmt.Errorf("'sum' required")
+		return modeldecoder.NewRequiredErr("sum")
 	}
 	return nil
 }
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/netip"
 	"net/textproto"
 	"regexp"
 	"strconv"
@@ -164,7 +165,7 @@ func DecodeNestedError(d decoder.Decoder, input *modeldecoder.Input, batch *mode
 		return modeldecoder.NewValidationErr(err)
 	}
 	event := input.Base
-	mapToErrorModel(&root.Error, &event)
+	mapToErrorModel(&root.Error, input.ClientAddrResolver, &event)
 	*batch = append(*batch, event)
 	return err
 }
@@ -222,7 +223,7 @@ func DecodeNestedTransaction(d decoder.Decoder, input *modeldecoder.Input, batch
 		return modeldecoder.NewValidationErr(err)
 	}
 	event := input.Base
-	mapToTransactionModel(&root.Transaction, &event)
+	mapToTransactionModel(&root.Transaction, input.ClientAddrResolver, &event)
 	*batch = append(*batch, event)
 	return err
 }
@@ -347,23 +348,35 @@ func mapToCloudModel(from contextCloud, cloud *model.Cloud) {
 	}
 }
 
-func mapToClientModel(from contextRequest, source *model.Source, client *model.Client) {
+// mapToClientModel maps the client and source addresses of an event from
+// the request context, resolving forwarded requests with resolver. If the
+// request was forwarded through trusted proxies, their addresses are
+// returned; without a resolver, the forwarding chain cannot be trusted and
+// no proxies are returned.
+func mapToClientModel(from contextRequest, resolver *netutil.ClientAddrResolver, source *model.Source, client *model.Client) []netip.Addr {
 	// http.Request.Headers and http.Request.Socket are only set for backend events.
 	if !source.IP.IsValid() {
 		ip, port := netutil.SplitAddrPort(from.Socket.RemoteAddress.Val)
 		source.IP, source.Port = ip, int(port)
 	}
+	var proxies []netip.Addr
 	if !client.IP.IsValid() {
 		client.IP = source.IP
-		if ip, port := netutil.ClientAddrFromHeaders(from.Headers.Val); ip.IsValid() {
-			source.NAT = &model.NAT{IP: source.IP}
-			client.IP, client.Port = ip, int(port)
+		// If the request was forwarded, the nearest proxy is recorded
+		// as the translated source address.
+		if addr := resolver.Resolve(from.Headers.Val, source.IP, uint16(source.Port)); len(addr.Proxies) > 0 {
+			source.NAT = &model.NAT{IP: addr.Proxies[0]}
+			client.IP, client.Port = addr.IP, int(addr.Port)
 			source.IP, source.Port = client.IP, client.Port
+			if resolver != nil {
+				proxies = addr.Proxies
+			}
 		}
 	}
+	return proxies
 }
 
-func mapToErrorModel(from *errorEvent, event *model.APMEvent) {
+func mapToErrorModel(from *errorEvent, resolver *netutil.ClientAddrResolver, event *model.APMEvent) {
 	out := &model.Error{}
 	event.Error = out
 	event.Processor = model.ErrorProcessor
@@ -373,7 +386,7 @@ func mapToErrorModel(from *errorEvent, event *model.APMEvent) {
 	mapToAgentModel(from.Context.Service.Agent, &event.Agent)
 	overwriteUserInMetadataModel(from.Context.User, event)
 	mapToUserAgentModel(from.Context.Request.Headers, &event.UserAgent)
-	mapToClientModel(from.Context.Request, &event.Source, &event.Client)
+	proxies := mapToClientModel(from.Context.Request, resolver, &event.Source, &event.Client)
 
 	// map errorEvent specific data
 
@@ -382,7 +395,7 @@ func mapToErrorModel(from *errorEvent, event *model.APMEvent) {
 			modeldecoderutil.MergeLabels(from.Context.Tags, event)
 		}
 		if from.Context.Request.IsSet() {
-			event.HTTP.Request = &model.HTTPRequest{}
+			event.HTTP.Request = &model.HTTPRequest{Proxies: proxies}
 			mapToRequestModel(from.Context.Request, event.HTTP.Request)
 			if from.Context.Request.HTTPVersion.IsSet() {
 				event.HTTP.Version = from.Context.Request.HTTPVersion.Val
@@ -1143,7 +1156,7 @@ func mapToStracktraceModel(from []stacktraceFrame, out model.Stacktrace) {
 	}
 }
 
-func mapToTransactionModel(from *transaction, event *model.APMEvent) {
+func mapToTransactionModel(from *transaction, resolver *netutil.ClientAddrResolver, event *model.APMEvent) {
 	out := &model.Transaction{}
 	event.Processor = model.TransactionProcessor
 	event.Transaction = out
@@ -1153,7 +1166,7 @@ func mapToTransactionModel(from *transaction, event *model.APMEvent) {
 	mapToAgentModel(from.Context.Service.Agent, &event.Agent)
 	overwriteUserInMetadataModel(from.Context.User, event)
 	mapToUserAgentModel(from.Context.Request.Headers, &event.UserAgent)
-	mapToClientModel(from.Context.Request, &event.Source, &event.Client)
+	proxies := mapToClientModel(from.Context.Request, resolver, &event.Source, &event.Client)
 	mapToFAASModel(from.FAAS, &event.FAAS)
 	mapToCloudModel(from.Context.Cloud, &event.Cloud)
 	mapToDroppedSpansModel(from.DroppedSpanStats, event.Transaction)
@@ -1187,7 +1200,7 @@ func mapToTransactionModel(from *transaction, event *model.APMEvent) {
 			}
 		}
 		if from.Context.Request.IsSet() {
-			event.HTTP.Request = &model.HTTPRequest{}
+			event.HTTP.Request = &model.HTTPRequest{Proxies: proxies}
 			mapToRequestModel(from.Context.Request, event.HTTP.Request)
 			if from.Context.Request.HTTPVersion.IsSet() {
 				event.HTTP.Version = from.Context.Request.HTTPVersion.Val
@@ -30,6 +30,7 @@ import (
 	"github.com/elastic/apm-data/input/elasticapm/internal/decoder"
 	"github.com/elastic/apm-data/input/elasticapm/internal/modeldecoder"
 	"github.com/elastic/apm-data/input/elasticapm/internal/modeldecoder/modeldecodertest"
+	"github.com/elastic/apm-data/input/elasticapm/internal/modeldecoder/netutil"
 	"github.com/elastic/apm-data/model"
 )
 
@@ -71,6 +72,51 @@ func TestDecodeNestedError(t *testing.T) {
 		assert.Contains(t, err.Error(), "decode")
 	})
 
+	t.Run("client-ip-trusted-proxy", func(t *testing.T) {
+		gatewayIP := netip.MustParseAddr("192.168.0.1")
+		proxyIP := netip.MustParseAddr("71.0.54.1")
+		input := modeldecoder.Input{ClientAddrResolver: &netutil.ClientAddrResolver{
+			TrustedProxies: []netip.Prefix{netip.MustParsePrefix("192.168.0.0/16")},
+		}}
+		decode := func(remoteAddr string) model.APMEvent {
+			str := `{"error":{"id":"a-b-c","log":{"message":"abc"},"context":{"request":{"method":"GET",` +
+				`"headers":{"X-Forwarded-For":"1.2.3.4, 71.0.54.1"},"socket":{"remote_address":"` + remoteAddr + `"}}}}}`
+			var batch model.Batch
+			require.NoError(t, DecodeNestedError(decoder.NewJSONDecoder(strings.NewReader(str)), &input, &batch))
+			require.Len(t, batch, 1)
+			return batch[0]
+		}
+
+		out := decode(gatewayIP.String())
+		assert.Equal(t, proxyIP, out.Client.IP, out.Client.IP.String())
+		assert.Equal(t, proxyIP, out.Source.IP, out.Source.IP.String())
+		assert.Equal(t, &model.NAT{IP: gatewayIP}, out.Source.NAT)
+		assert.Equal(t, []netip.Addr{gatewayIP}, out.HTTP.Request.Proxies)
+
+		// The full chain of trusted proxies is recorded.
+		input.ClientAddrResolver.TrustedProxies = append(
+			input.ClientAddrResolver.TrustedProxies, netip.PrefixFrom(proxyIP, 32),
+		)
+		out = decode(gatewayIP.String())
+		assert.Equal(t, netip.MustParseAddr("1.2.3.4"), out.Client.IP, out.Client.IP.String())
+		assert.Equal(t, &model.NAT{IP: gatewayIP}, out.Source.NAT)
+		assert.Equal(t, []netip.Addr{gatewayIP, proxyIP}, out.HTTP.Request.Proxies)
+
+		// Headers are ignored if the peer is not a trusted proxy.
+		out = decode("8.8.8.8")
+		assert.Equal(t, netip.MustParseAddr("8.8.8.8"), out.Client.IP, out.Client.IP.String())
+		assert.Nil(t, out.Source.NAT)
+		assert.Nil(t, out.HTTP.Request.Proxies)
+
+		// Without a resolver the forwarding headers are used as-is,
+		// but the untrusted chain of proxies is not recorded.
+		input.ClientAddrResolver = nil
+		out = decode(gatewayIP.String())
+		assert.Equal(t, netip.MustParseAddr("1.2.3.4"), out.Client.IP, out.Client.IP.String())
+		assert.Equal(t, &model.NAT{IP: gatewayIP}, out.Source.NAT)
+		assert.Nil(t, out.HTTP.Request.Proxies)
+	})
+
 	t.Run("validate", func(t *testing.T) {
 		var out model.Batch
 		err := DecodeNestedError(decoder.NewJSONDecoder(strings.NewReader(`{}`)), &modeldecoder.Input{}, &out)
@@ -90,7 +136,7 @@ func TestDecodeMapToErrorModel(t *testing.T) {
 		_, out := initializedInputMetadata(modeldecodertest.DefaultValues())
 		otherVal := modeldecodertest.NonDefaultValues()
 		modeldecodertest.SetStructValues(&input, otherVal)
-		mapToErrorModel(&input, &out)
+		mapToErrorModel(&input, nil, &out)
 		input.Reset()
 
 		// ensure event Metadata are updated where expected
@@ -114,7 +160,7 @@ func TestDecodeMapToErrorModel(t *testing.T) {
 		input.Context.Request.Headers.Set(http.Header{})
 		input.Context.Request.Headers.Val.Add("x-real-ip", gatewayIP.String())
 		input.Context.Request.Socket.RemoteAddress.Set(randomIP.String())
-		mapToErrorModel(&input, &out)
+		mapToErrorModel(&input, nil, &out)
 		assert.Equal(t, gatewayIP, out.Client.IP, out.Client.IP.String())
 	})
 
@@ -122,7 +168,7 @@ func TestDecodeMapToErrorModel(t *testing.T) {
 		var input errorEvent
 		var out model.APMEvent
 		input.Context.Request.Socket.RemoteAddress.Set(randomIP.String())
-		mapToErrorModel(&input, &out)
+		mapToErrorModel(&input, nil, &out)
 		assert.Equal(t, randomIP, out.Client.IP, out.Client.IP.String())
 	})
 
@@ -155,7 +201,7 @@ func TestDecodeMapToErrorModel(t *testing.T) {
 		var out1, out2 model.APMEvent
 		defaultVal := modeldecodertest.DefaultValues()
 		modeldecodertest.SetStructValues(&input, defaultVal)
-		mapToErrorModel(&input, &out1)
+		mapToErrorModel(&input, nil, &out1)
 		input.Reset()
 		modeldecodertest.AssertStructValues(t, out1.Error, exceptions, defaultVal)
 
@@ -163,7 +209,7 @@ func TestDecodeMapToErrorModel(t *testing.T) {
 		// ensure memory is not shared by reusing input model
 		otherVal := modeldecodertest.NonDefaultValues()
 		modeldecodertest.SetStructValues(&input, otherVal)
-		mapToErrorModel(&input, &out2)
+		mapToErrorModel(&input, nil, &out2)
 		modeldecodertest.AssertStructValues(t, out2.Error, exceptions, otherVal)
 		modeldecodertest.AssertStructValues(t, out1.Error, exceptions, defaultVal)
 	})
@@ -173,7 +219,7 @@ func TestDecodeMapToErrorModel(t *testing.T) {
 		input.Context.Request.Headers.Set(http.Header{"a": []string{"b"}, "c": []string{"d", "e"}})
 		input.Context.Response.Headers.Set(http.Header{"f": []string{"g"}})
 		var out model.APMEvent
-		mapToErrorModel(&input, &out)
+		mapToErrorModel(&input, nil, &out)
 		assert.Equal(t, map[string]any{"a": []string{"b"}, "c": []string{"d", "e"}}, out.HTTP.Request.Headers)
 		assert.Equal(t, map[string]any{"f": []string{"g"}}, out.HTTP.Response.Headers)
 	})
@@ -182,7 +228,7 @@ func TestDecodeMapToErrorModel(t *testing.T) {
 		var input errorEvent
 		input.Context.Page.URL.Set("https://my.site.test:9201")
 		var out model.APMEvent
-		mapToErrorModel(&input, &out)
+		mapToErrorModel(&input, nil, &out)
 		assert.Equal(t, "https://my.site.test:9201", out.URL.Full)
 	})
 
@@ -190,7 +236,7 @@ func TestDecodeMapToErrorModel(t *testing.T) {
 		var input errorEvent
 		input.Context.Page.Referer.Set("https://my.site.test:9201")
 		var out model.APMEvent
-		mapToErrorModel(&input, &out)
+		mapToErrorModel(&input, nil, &out)
 		assert.Equal(t, "https://my.site.test:9201", out.HTTP.Request.Referrer)
 	})
 
@@ -198,7 +244,7 @@ func TestDecodeMapToErrorModel(t *testing.T) {
 		var input errorEvent
 		var out model.APMEvent
 		input.Exception.Code.Set(123.456)
-		mapToErrorModel(&input, &out)
+		mapToErrorModel(&input, nil, &out)
 		assert.Equal(t, "123", out.Error.Exception.Code)
 	})
 
@@ -206,14 +252,14 @@ func TestDecodeMapToErrorModel(t *testing.T) {
 		var input errorEvent
 		var out model.APMEvent
 		input.Transaction.Name.Set("My Transaction")
-		mapToErrorModel(&input, &out)
+		mapToErrorModel(&input, nil, &out)
 		assert.Equal(t, "My Transaction", out.Transaction.Name)
 	})
 	t.Run("transaction-id-empty-transaction", func(t *testing.T) {
 		var input errorEvent
 		var out model.APMEvent
 		input.TransactionID.Set("12341231")
-		mapToErrorModel(&input, &out)
+		mapToErrorModel(&input, nil, &out)
 		assert.Equal(t, "12341231", out.Transaction.ID)
 	})
 }
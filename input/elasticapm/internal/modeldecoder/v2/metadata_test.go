@@ -50,11 +50,22 @@ func isIgnoredPrefix(key string) bool {
 func isUnmappedMetadataField(key string) bool {
 	switch key {
 	case
+		"Body",
 		"Child",
 		"Child.ID",
 		"Client.Domain",
 		"Client.IP",
 		"Client.Port",
+		"Client.Geo",
+		"Client.Geo.ContinentName",
+		"Client.Geo.CountryISOCode",
+		"Client.Geo.CountryName",
+		"Client.Geo.RegionISOCode",
+		"Client.Geo.RegionName",
+		"Client.Geo.CityName",
+		"Client.Geo.Location",
+		"Client.Geo.Location.Lat",
+		"Client.Geo.Location.Lon",
 		"Cloud.Origin",
 		"Container.Runtime",
 		"Container.ImageName",
@@ -124,6 +135,15 @@ func isUnmappedMetadataField(key string) bool {
 		"UserAgent",
 		"UserAgent.Name",
 		"UserAgent.Original",
+		"UserAgent.Version",
+		"UserAgent.OS",
+		"UserAgent.OS.Full",
+		"UserAgent.OS.Name",
+		"UserAgent.OS.Platform",
+		"UserAgent.OS.Type",
+		"UserAgent.OS.Version",
+		"UserAgent.Device",
+		"UserAgent.Device.Name",
 		"Event",
 		"Event.Duration",
 		"Event.Outcome",
@@ -152,6 +172,16 @@ func isUnmappedMetadataField(key string) bool {
 		"Source.IP",
 		"Source.Port",
 		"Source.NAT",
+		"Source.Geo",
+		"Source.Geo.ContinentName",
+		"Source.Geo.CountryISOCode",
+		"Source.Geo.CountryName",
+		"Source.Geo.RegionISOCode",
+		"Source.Geo.RegionName",
+		"Source.Geo.CityName",
+		"Source.Geo.Location",
+		"Source.Geo.Location.Lat",
+		"Source.Geo.Location.Lon",
 		"Trace",
 		"Trace.ID",
 		"URL",
@@ -21,12 +21,13 @@ package v2
 
 import (
 	"encoding/json"
-	"fmt"
 	"regexp"
 	"strconv"
 	"unicode/utf8"
 
 	"github.com/pkg/errors"
+
+	"github.com/elastic/apm-data/input/elasticapm/internal/modeldecoder"
 )
 
 var (
@@ -44,10 +45,10 @@ func (val *metadataRoot) Reset() {
 
 func (val *metadataRoot) validate() error {
 	if err := val.Metadata.validate(); err != nil {
-		return errors.Wrapf(err, "metadata")
+		return modeldecoder.WrapRuleErr(err, "metadata")
 	}
 	if !val.Metadata.IsSet() {
-		return fmt.Errorf("'metadata' required")
+		return modeldecoder.NewRequiredErr("metadata")
 	}
 	return nil
 }
@@ -80,38 +81,38 @@ func (val *metadata) validate() error {
 		return nil
 	}
 	if err := val.Cloud.validate(); err != nil {
-		return errors.Wrapf(err, "cloud")
+		return modeldecoder.WrapRuleErr(err, "cloud")
 	}
 	for k, v := range val.Labels {
 		switch t := v.(type) {
 		case nil:
 		case string:
 			if utf8.RuneCountInString(t) > 1024 {
-				return fmt.Errorf("'labels': validation rule 'maxLengthVals(1024)' violated")
+				return modeldecoder.NewRuleErr("labels", "maxLengthVals(1024)")
 			}
 		case bool:
 		case json.Number:
 		default:
-			return fmt.Errorf("'labels': validation rule 'inputTypesVals(string;bool;number)' violated for key %s", k)
+			return modeldecoder.NewRuleKeyErr("labels", "inputTypesVals(string;bool;number)", k)
 		}
 	}
 	if err := val.Process.validate(); err != nil {
-		return errors.Wrapf(err, "process")
+		return modeldecoder.WrapRuleErr(err, "process")
 	}
 	if err := val.Service.validate(); err != nil {
-		return errors.Wrapf(err, "service")
+		return modeldecoder.WrapRuleErr(err, "service")
 	}
 	if !val.Service.IsSet() {
-		return fmt.Errorf("'service' required")
+		return modeldecoder.NewRequiredErr("service")
 	}
 	if err := val.System.validate(); err != nil {
-		return errors.Wrapf(err, "system")
+		return modeldecoder.WrapRuleErr(err, "system")
 	}
 	if err := val.User.validate(); err != nil {
-		return errors.Wrapf(err, "user")
+		return modeldecoder.WrapRuleErr(err, "user")
 	}
 	if err := val.Network.validate(); err != nil {
-		return errors.Wrapf(err, "network")
+		return modeldecoder.WrapRuleErr(err, "network")
 	}
 	return nil
 }
@@ -158,31 +159,31 @@ func (val *metadataCloud) validate() error {
 		return nil
 	}
 	if err := val.Account.validate(); err != nil {
-		return errors.Wrapf(err, "account")
+		return modeldecoder.WrapRuleErr(err, "account")
 	}
 	if val.AvailabilityZone.IsSet() && utf8.RuneCountInString(val.AvailabilityZone.Val) > 1024 {
-		return fmt.Errorf("'availability_zone': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("availability_zone", "maxLength(1024)")
 	}
 	if err := val.Instance.validate(); err != nil {
-		return errors.Wrapf(err, "instance")
+		return modeldecoder.WrapRuleErr(err, "instance")
 	}
 	if err := val.Machine.validate(); err != nil {
-		return errors.Wrapf(err, "machine")
+		return modeldecoder.WrapRuleErr(err, "machine")
 	}
 	if err := val.Project.validate(); err != nil {
-		return errors.Wrapf(err, "project")
+		return modeldecoder.WrapRuleErr(err, "project")
 	}
 	if val.Provider.IsSet() && utf8.RuneCountInString(val.Provider.Val) > 1024 {
-		return fmt.Errorf("'provider': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("provider", "maxLength(1024)")
 	}
 	if !val.Provider.IsSet() {
-		return fmt.Errorf("'provider' required")
+		return modeldecoder.NewRequiredErr("provider")
 	}
 	if val.Region.IsSet() && utf8.RuneCountInString(val.Region.Val) > 1024 {
-		return fmt.Errorf("'region': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("region", "maxLength(1024)")
 	}
 	if err := val.Service.validate(); err != nil {
-		return errors.Wrapf(err, "service")
+		return modeldecoder.WrapRuleErr(err, "service")
 	}
 	return nil
 }
@@ -220,10 +221,10 @@ func (val *metadataCloudAccount) validate() error {
 		return nil
 	}
 	if val.ID.IsSet() && utf8.RuneCountInString(val.ID.Val) > 1024 {
-		return fmt.Errorf("'id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("id", "maxLength(1024)")
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("name", "maxLength(1024)")
 	}
 	return nil
 }
@@ -246,10 +247,10 @@ func (val *metadataCloudInstance) validate() error {
 		return nil
 	}
 	if val.ID.IsSet() && utf8.RuneCountInString(val.ID.Val) > 1024 {
-		return fmt.Errorf("'id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("id", "maxLength(1024)")
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("name", "maxLength(1024)")
 	}
 	return nil
 }
@@ -271,7 +272,7 @@ func (val *metadataCloudMachine) validate() error {
 		return nil
 	}
 	if val.Type.IsSet() && utf8.RuneCountInString(val.Type.Val) > 1024 {
-		return fmt.Errorf("'type': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("type", "maxLength(1024)")
 	}
 	return nil
 }
@@ -294,10 +295,10 @@ func (val *metadataCloudProject) validate() error {
 		return nil
 	}
 	if val.ID.IsSet() && utf8.RuneCountInString(val.ID.Val) > 1024 {
-		return fmt.Errorf("'id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("id", "maxLength(1024)")
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("name", "maxLength(1024)")
 	}
 	return nil
 }
@@ -319,7 +320,7 @@ func (val *metadataCloudService) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("name", "maxLength(1024)")
 	}
 	return nil
 }
@@ -344,10 +345,10 @@ func (val *metadataProcess) validate() error {
 		return nil
 	}
 	if !val.Pid.IsSet() {
-		return fmt.Errorf("'pid' required")
+		return modeldecoder.NewRequiredErr("pid")
 	}
 	if val.Title.IsSet() && utf8.RuneCountInString(val.Title.Val) > 1024 {
-		return fmt.Errorf("'title': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("title", "maxLength(1024)")
 	}
 	return nil
 }
@@ -377,40 +378,40 @@ func (val *metadataService) validate() error {
 		return nil
 	}
 	if err := val.Agent.validate(); err != nil {
-		return errors.Wrapf(err, "agent")
+		return modeldecoder.WrapRuleErr(err, "agent")
 	}
 	if !val.Agent.IsSet() {
-		return fmt.Errorf("'agent' required")
+		return modeldecoder.NewRequiredErr("agent")
 	}
 	if val.Environment.IsSet() && utf8.RuneCountInString(val.Environment.Val) > 1024 {
-		return fmt.Errorf("'environment': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("environment", "maxLength(1024)")
 	}
 	if err := val.Framework.validate(); err != nil {
-		return errors.Wrapf(err, "framework")
+		return modeldecoder.WrapRuleErr(err, "framework")
 	}
 	if err := val.Language.validate(); err != nil {
-		return errors.Wrapf(err, "language")
+		return modeldecoder.WrapRuleErr(err, "language")
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("name", "maxLength(1024)")
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) < 1 {
-		return fmt.Errorf("'name': validation rule 'minLength(1)' violated")
+		return modeldecoder.NewRuleErr("name", "minLength(1)")
 	}
 	if val.Name.Val != "" && !patternAlphaNumericExtRegexp.MatchString(val.Name.Val) {
-		return fmt.Errorf("'name': validation rule 'pattern(patternAlphaNumericExt)' violated")
+		return modeldecoder.NewRuleErr("name", "pattern(patternAlphaNumericExt)")
 	}
 	if !val.Name.IsSet() {
-		return fmt.Errorf("'name' required")
+		return modeldecoder.NewRequiredErr("name")
 	}
 	if err := val.Node.validate(); err != nil {
-		return errors.Wrapf(err, "node")
+		return modeldecoder.WrapRuleErr(err, "node")
 	}
 	if err := val.Runtime.validate(); err != nil {
-		return errors.Wrapf(err, "runtime")
+		return modeldecoder.WrapRuleErr(err, "runtime")
 	}
 	if val.Version.IsSet() && utf8.RuneCountInString(val.Version.Val) > 1024 {
-		return fmt.Errorf("'version': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("version", "maxLength(1024)")
 	}
 	return nil
 }
@@ -449,22 +450,22 @@ func (val *metadataServiceAgent) validate() error {
 		return nil
 	}
 	if val.EphemeralID.IsSet() && utf8.RuneCountInString(val.EphemeralID.Val) > 1024 {
-		return fmt.Errorf("'ephemeral_id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("ephemeral_id", "maxLength(1024)")
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("name", "maxLength(1024)")
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) < 1 {
-		return fmt.Errorf("'name': validation rule 'minLength(1)' violated")
+		return modeldecoder.NewRuleErr("name", "minLength(1)")
 	}
 	if !val.Name.IsSet() {
-		return fmt.Errorf("'name' required")
+		return modeldecoder.NewRequiredErr("name")
 	}
 	if val.Version.IsSet() && utf8.RuneCountInString(val.Version.Val) > 1024 {
-		return fmt.Errorf("'version': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("version", "maxLength(1024)")
 	}
 	if !val.Version.IsSet() {
-		return fmt.Errorf("'version' required")
+		return modeldecoder.NewRequiredErr("version")
 	}
 	return nil
 }
@@ -487,10 +488,10 @@ func (val *metadataServiceFramework) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("name", "maxLength(1024)")
 	}
 	if val.Version.IsSet() && utf8.RuneCountInString(val.Version.Val) > 1024 {
-		return fmt.Errorf("'version': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("version", "maxLength(1024)")
 	}
 	return nil
 }
@@ -513,13 +514,13 @@ func (val *metadataServiceLanguage) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("name", "maxLength(1024)")
 	}
 	if !val.Name.IsSet() {
-		return fmt.Errorf("'name' required")
+		return modeldecoder.NewRequiredErr("name")
 	}
 	if val.Version.IsSet() && utf8.RuneCountInString(val.Version.Val) > 1024 {
-		return fmt.Errorf("'version': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("version", "maxLength(1024)")
 	}
 	return nil
 }
@@ -541,7 +542,7 @@ func (val *metadataServiceNode) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'configured_name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("configured_name", "maxLength(1024)")
 	}
 	return nil
 }
@@ -564,16 +565,16 @@ func (val *metadataServiceRuntime) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("name", "maxLength(1024)")
 	}
 	if !val.Name.IsSet() {
-		return fmt.Errorf("'name' required")
+		return modeldecoder.NewRequiredErr("name")
 	}
 	if val.Version.IsSet() && utf8.RuneCountInString(val.Version.Val) > 1024 {
-		return fmt.Errorf("'version': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("version", "maxLength(1024)")
 	}
 	if !val.Version.IsSet() {
-		return fmt.Errorf("'version' required")
+		return modeldecoder.NewRequiredErr("version")
 	}
 	return nil
 }
@@ -601,25 +602,25 @@ func (val *metadataSystem) validate() error {
 		return nil
 	}
 	if val.Architecture.IsSet() && utf8.RuneCountInString(val.Architecture.Val) > 1024 {
-		return fmt.Errorf("'architecture': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("architecture", "maxLength(1024)")
 	}
 	if val.ConfiguredHostname.IsSet() && utf8.RuneCountInString(val.ConfiguredHostname.Val) > 1024 {
-		return fmt.Errorf("'configured_hostname': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("configured_hostname", "maxLength(1024)")
 	}
 	if err := val.Container.validate(); err != nil {
-		return errors.Wrapf(err, "container")
+		return modeldecoder.WrapRuleErr(err, "container")
 	}
 	if val.DetectedHostname.IsSet() && utf8.RuneCountInString(val.DetectedHostname.Val) > 1024 {
-		return fmt.Errorf("'detected_hostname': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("detected_hostname", "maxLength(1024)")
 	}
 	if val.DeprecatedHostname.IsSet() && utf8.RuneCountInString(val.DeprecatedHostname.Val) > 1024 {
-		return fmt.Errorf("'hostname': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("hostname", "maxLength(1024)")
 	}
 	if err := val.Kubernetes.validate(); err != nil {
-		return errors.Wrapf(err, "kubernetes")
+		return modeldecoder.WrapRuleErr(err, "kubernetes")
 	}
 	if val.Platform.IsSet() && utf8.RuneCountInString(val.Platform.Val) > 1024 {
-		return fmt.Errorf("'platform': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("platform", "maxLength(1024)")
 	}
 	return nil
 }
@@ -647,7 +648,7 @@ func (val *metadataSystemContainer) validate() error {
 		return nil
 	}
 	if val.ID.IsSet() && utf8.RuneCountInString(val.ID.Val) > 1024 {
-		return fmt.Errorf("'id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("id", "maxLength(1024)")
 	}
 	return nil
 }
@@ -671,13 +672,13 @@ func (val *metadataSystemKubernetes) validate() error {
 		return nil
 	}
 	if val.Namespace.IsSet() && utf8.RuneCountInString(val.Namespace.Val) > 1024 {
-		return fmt.Errorf("'namespace': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("namespace", "maxLength(1024)")
 	}
 	if err := val.Node.validate(); err != nil {
-		return errors.Wrapf(err, "node")
+		return modeldecoder.WrapRuleErr(err, "node")
 	}
 	if err := val.Pod.validate(); err != nil {
-		return errors.Wrapf(err, "pod")
+		return modeldecoder.WrapRuleErr(err, "pod")
 	}
 	return nil
 }
@@ -705,7 +706,7 @@ func (val *metadataSystemKubernetesNode) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("name", "maxLength(1024)")
 	}
 	return nil
 }
@@ -728,10 +729,10 @@ func (val *metadataSystemKubernetesPod) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("name", "maxLength(1024)")
 	}
 	if val.UID.IsSet() && utf8.RuneCountInString(val.UID.Val) > 1024 {
-		return fmt.Errorf("'uid': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("uid", "maxLength(1024)")
 	}
 	return nil
 }
@@ -756,27 +757,27 @@ func (val *user) validate() error {
 		return nil
 	}
 	if val.Domain.IsSet() && utf8.RuneCountInString(val.Domain.Val) > 1024 {
-		return fmt.Errorf("'domain': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("domain", "maxLength(1024)")
 	}
 	switch t := val.ID.Val.(type) {
 	case string:
 		if utf8.RuneCountInString(t) > 1024 {
-			return fmt.Errorf("'id': validation rule 'maxLength(1024)' violated")
+			return modeldecoder.NewRuleErr("id", "maxLength(1024)")
 		}
 	case int:
 	case json.Number:
 		if _, err := t.Int64(); err != nil {
-			return fmt.Errorf("'id': validation rule 'inputTypes(string;int)' violated")
+			return modeldecoder.NewRuleErr("id", "inputTypes(string;int)")
 		}
 	case nil:
 	default:
-		return fmt.Errorf("'id': validation rule 'inputTypes(string;int)' violated ")
+		return modeldecoder.NewRuleErr("id", "inputTypes(string;int)")
 	}
 	if val.Email.IsSet() && utf8.RuneCountInString(val.Email.Val) > 1024 {
-		return fmt.Errorf("'email': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("email", "maxLength(1024)")
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'username': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("username", "maxLength(1024)")
 	}
 	return nil
 }
@@ -798,7 +799,7 @@ func (val *network) validate() error {
 		return nil
 	}
 	if err := val.Connection.validate(); err != nil {
-		return errors.Wrapf(err, "connection")
+		return modeldecoder.WrapRuleErr(err, "connection")
 	}
 	return nil
 }
@@ -823,7 +824,7 @@ func (val *networkConnection) validate() error {
 		return nil
 	}
 	if val.Type.IsSet() && utf8.RuneCountInString(val.Type.Val) > 1024 {
-		return fmt.Errorf("'type': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("type", "maxLength(1024)")
 	}
 	return nil
 }
@@ -842,10 +843,10 @@ func (val *errorRoot) Reset() {
 
 func (val *errorRoot) validate() error {
 	if err := val.Error.validate(); err != nil {
-		return errors.Wrapf(err, "error")
+		return modeldecoder.WrapRuleErr(err, "error")
 	}
 	if !val.Error.IsSet() {
-		return fmt.Errorf("'error' required")
+		return modeldecoder.NewRequiredErr("error")
 	}
 	return nil
 }
@@ -879,53 +880,53 @@ func (val *errorEvent) validate() error {
 		return nil
 	}
 	if err := val.Context.validate(); err != nil {
-		return errors.Wrapf(err, "context")
+		return modeldecoder.WrapRuleErr(err, "context")
 	}
 	if val.Culprit.IsSet() && utf8.RuneCountInString(val.Culprit.Val) > 1024 {
-		return fmt.Errorf("'culprit': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("culprit", "maxLength(1024)")
 	}
 	if err := val.Exception.validate(); err != nil {
-		return errors.Wrapf(err, "exception")
+		return modeldecoder.WrapRuleErr(err, "exception")
 	}
 	if val.ID.IsSet() && utf8.RuneCountInString(val.ID.Val) > 1024 {
-		return fmt.Errorf("'id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("id", "maxLength(1024)")
 	}
 	if !val.ID.IsSet() {
-		return fmt.Errorf("'id' required")
+		return modeldecoder.NewRequiredErr("id")
 	}
 	if err := val.Log.validate(); err != nil {
-		return errors.Wrapf(err, "log")
+		return modeldecoder.WrapRuleErr(err, "log")
 	}
 	if val.ParentID.IsSet() && utf8.RuneCountInString(val.ParentID.Val) > 1024 {
-		return fmt.Errorf("'parent_id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("parent_id", "maxLength(1024)")
 	}
 	if !val.ParentID.IsSet() {
 		if val.TransactionID.IsSet() {
-			return fmt.Errorf("'parent_id' required when 'transaction_id' is set")
+			return modeldecoder.NewRequiredIfAnyErr("parent_id", "transaction_id")
 		}
 		if val.TraceID.IsSet() {
-			return fmt.Errorf("'parent_id' required when 'trace_id' is set")
+			return modeldecoder.NewRequiredIfAnyErr("parent_id", "trace_id")
 		}
 	}
 	if val.TraceID.IsSet() && utf8.RuneCountInString(val.TraceID.Val) > 1024 {
-		return fmt.Errorf("'trace_id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("trace_id", "maxLength(1024)")
 	}
 	if !val.TraceID.IsSet() {
 		if val.TransactionID.IsSet() {
-			return fmt.Errorf("'trace_id' required when 'transaction_id' is set")
+			return modeldecoder.NewRequiredIfAnyErr("trace_id", "transaction_id")
 		}
 		if val.ParentID.IsSet() {
-			return fmt.Errorf("'trace_id' required when 'parent_id' is set")
+			return modeldecoder.NewRequiredIfAnyErr("trace_id", "parent_id")
 		}
 	}
 	if err := val.Transaction.validate(); err != nil {
-		return errors.Wrapf(err, "transaction")
+		return modeldecoder.WrapRuleErr(err, "transaction")
 	}
 	if val.TransactionID.IsSet() && utf8.RuneCountInString(val.TransactionID.Val) > 1024 {
-		return fmt.Errorf("'transaction_id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("transaction_id", "maxLength(1024)")
 	}
 	if !val.Exception.IsSet() && !val.Log.IsSet() {
-		return fmt.Errorf("requires at least one of the fields 'exception;log'")
+		return modeldecoder.NewRequiredAnyOfErr("exception;log")
 	}
 	return nil
 }
@@ -971,38 +972,38 @@ func (val *context) validate() error {
 		return nil
 	}
 	if err := val.Cloud.validate(); err != nil {
-		return errors.Wrapf(err, "cloud")
+		return modeldecoder.WrapRuleErr(err, "cloud")
 	}
 	if err := val.Message.validate(); err != nil {
-		return errors.Wrapf(err, "message")
+		return modeldecoder.WrapRuleErr(err, "message")
 	}
 	if err := val.Page.validate(); err != nil {
-		return errors.Wrapf(err, "page")
+		return modeldecoder.WrapRuleErr(err, "page")
 	}
 	if err := val.Response.validate(); err != nil {
-		return errors.Wrapf(err, "response")
+		return modeldecoder.WrapRuleErr(err, "response")
 	}
 	if err := val.Request.validate(); err != nil {
-		return errors.Wrapf(err, "request")
+		return modeldecoder.WrapRuleErr(err, "request")
 	}
 	if err := val.Service.validate(); err != nil {
-		return errors.Wrapf(err, "service")
+		return modeldecoder.WrapRuleErr(err, "service")
 	}
 	for k, v := range val.Tags {
 		switch t := v.(type) {
 		case nil:
 		case string:
 			if utf8.RuneCountInString(t) > 1024 {
-				return fmt.Errorf("'tags': validation rule 'maxLengthVals(1024)' violated")
+				return modeldecoder.NewRuleErr("tags", "maxLengthVals(1024)")
 			}
 		case bool:
 		case json.Number:
 		default:
-			return fmt.Errorf("'tags': validation rule 'inputTypesVals(string;bool;number)' violated for key %s", k)
+			return modeldecoder.NewRuleKeyErr("tags", "inputTypesVals(string;bool;number)", k)
 		}
 	}
 	if err := val.User.validate(); err != nil {
-		return errors.Wrapf(err, "user")
+		return modeldecoder.WrapRuleErr(err, "user")
 	}
 	return nil
 }
@@ -1045,7 +1046,7 @@ func (val *contextCloud) validate() error {
 		return nil
 	}
 	if err := val.Origin.validate(); err != nil {
-		return errors.Wrapf(err, "origin")
+		return modeldecoder.WrapRuleErr(err, "origin")
 	}
 	return nil
 }
@@ -1073,10 +1074,10 @@ func (val *contextCloudOrigin) validate() error {
 		return nil
 	}
 	if err := val.Account.validate(); err != nil {
-		return errors.Wrapf(err, "account")
+		return modeldecoder.WrapRuleErr(err, "account")
 	}
 	if err := val.Service.validate(); err != nil {
-		return errors.Wrapf(err, "service")
+		return modeldecoder.WrapRuleErr(err, "service")
 	}
 	return nil
 }
@@ -1146,10 +1147,10 @@ func (val *contextMessage) validate() error {
 		return nil
 	}
 	if err := val.Age.validate(); err != nil {
-		return errors.Wrapf(err, "age")
+		return modeldecoder.WrapRuleErr(err, "age")
 	}
 	if err := val.Queue.validate(); err != nil {
-		return errors.Wrapf(err, "queue")
+		return modeldecoder.WrapRuleErr(err, "queue")
 	}
 	return nil
 }
@@ -1196,7 +1197,7 @@ func (val *contextMessageQueue) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("name", "maxLength(1024)")
 	}
 	return nil
 }
@@ -1278,22 +1279,22 @@ func (val *contextRequest) validate() error {
 	case map[string]interface{}:
 	case nil:
 	default:
-		return fmt.Errorf("'body': validation rule 'inputTypes(string;object)' violated ")
+		return modeldecoder.NewRuleErr("body", "inputTypes(string;object)")
 	}
 	if val.HTTPVersion.IsSet() && utf8.RuneCountInString(val.HTTPVersion.Val) > 1024 {
-		return fmt.Errorf("'http_version': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("http_version", "maxLength(1024)")
 	}
 	if val.Method.IsSet() && utf8.RuneCountInString(val.Method.Val) > 1024 {
-		return fmt.Errorf("'method': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("method", "maxLength(1024)")
 	}
 	if !val.Method.IsSet() {
-		return fmt.Errorf("'method' required")
+		return modeldecoder.NewRequiredErr("method")
 	}
 	if err := val.Socket.validate(); err != nil {
-		return errors.Wrapf(err, "socket")
+		return modeldecoder.WrapRuleErr(err, "socket")
 	}
 	if err := val.URL.validate(); err != nil {
-		return errors.Wrapf(err, "url")
+		return modeldecoder.WrapRuleErr(err, "url")
 	}
 	return nil
 }
@@ -1348,42 +1349,42 @@ func (val *contextRequestURL) validate() error {
 		return nil
 	}
 	if val.Full.IsSet() && utf8.RuneCountInString(val.Full.Val) > 1024 {
-		return fmt.Errorf("'full': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("full", "maxLength(1024)")
 	}
 	if val.Hash.IsSet() && utf8.RuneCountInString(val.Hash.Val) > 1024 {
-		return fmt.Errorf("'hash': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("hash", "maxLength(1024)")
 	}
 	if val.Hostname.IsSet() && utf8.RuneCountInString(val.Hostname.Val) > 1024 {
-		return fmt.Errorf("'hostname': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("hostname", "maxLength(1024)")
 	}
 	if val.Path.IsSet() && utf8.RuneCountInString(val.Path.Val) > 1024 {
-		return fmt.Errorf("'pathname': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("pathname", "maxLength(1024)")
 	}
 	switch t := val.Port.Val.(type) {
 	case string:
 		if utf8.RuneCountInString(t) > 1024 {
-			return fmt.Errorf("'port': validation rule 'maxLength(1024)' violated")
+			return modeldecoder.NewRuleErr("port", "maxLength(1024)")
 		}
 		if _, err := strconv.Atoi(t); err != nil {
-			return fmt.Errorf("'port': validation rule 'targetType(int)' violated")
+			return modeldecoder.NewRuleErr("port", "targetType(int)")
 		}
 	case int:
 	case json.Number:
 		if _, err := t.Int64(); err != nil {
-			return fmt.Errorf("'port': validation rule 'inputTypes(string;int)' violated")
+			return modeldecoder.NewRuleErr("port", "inputTypes(string;int)")
 		}
 	case nil:
 	default:
-		return fmt.Errorf("'port': validation rule 'inputTypes(string;int)' violated ")
+		return modeldecoder.NewRuleErr("port", "inputTypes(string;int)")
 	}
 	if val.Protocol.IsSet() && utf8.RuneCountInString(val.Protocol.Val) > 1024 {
-		return fmt.Errorf("'protocol': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("protocol", "maxLength(1024)")
 	}
 	if val.Raw.IsSet() && utf8.RuneCountInString(val.Raw.Val) > 1024 {
-		return fmt.Errorf("'raw': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("raw", "maxLength(1024)")
 	}
 	if val.Search.IsSet() && utf8.RuneCountInString(val.Search.Val) > 1024 {
-		return fmt.Errorf("'search': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("search", "maxLength(1024)")
 	}
 	return nil
 }
@@ -1415,37 +1416,37 @@ func (val *contextService) validate() error {
 		return nil
 	}
 	if err := val.Agent.validate(); err != nil {
-		return errors.Wrapf(err, "agent")
+		return modeldecoder.WrapRuleErr(err, "agent")
 	}
 	if val.Environment.IsSet() && utf8.RuneCountInString(val.Environment.Val) > 1024 {
-		return fmt.Errorf("'environment': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("environment", "maxLength(1024)")
 	}
 	if err := val.Framework.validate(); err != nil {
-		return errors.Wrapf(err, "framework")
+		return modeldecoder.WrapRuleErr(err, "framework")
 	}
 	if err := val.Language.validate(); err != nil {
-		return errors.Wrapf(err, "language")
+		return modeldecoder.WrapRuleErr(err, "language")
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("name", "maxLength(1024)")
 	}
 	if val.Name.Val != "" && !patternAlphaNumericExtRegexp.MatchString(val.Name.Val) {
-		return fmt.Errorf("'name': validation rule 'pattern(patternAlphaNumericExt)' violated")
+		return modeldecoder.NewRuleErr("name", "pattern(patternAlphaNumericExt)")
 	}
 	if err := val.Node.validate(); err != nil {
-		return errors.Wrapf(err, "node")
+		return modeldecoder.WrapRuleErr(err, "node")
 	}
 	if err := val.Origin.validate(); err != nil {
-		return errors.Wrapf(err, "origin")
+		return modeldecoder.WrapRuleErr(err, "origin")
 	}
 	if err := val.Runtime.validate(); err != nil {
-		return errors.Wrapf(err, "runtime")
+		return modeldecoder.WrapRuleErr(err, "runtime")
 	}
 	if err := val.Target.validate(); err != nil {
-		return errors.Wrapf(err, "target")
+		return modeldecoder.WrapRuleErr(err, "target")
 	}
 	if val.Version.IsSet() && utf8.RuneCountInString(val.Version.Val) > 1024 {
-		return fmt.Errorf("'version': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("version", "maxLength(1024)")
 	}
 	return nil
 }
@@ -1490,13 +1491,13 @@ func (val *contextServiceAgent) validate() error {
 		return nil
 	}
 	if val.EphemeralID.IsSet() && utf8.RuneCountInString(val.EphemeralID.Val) > 1024 {
-		return fmt.Errorf("'ephemeral_id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("ephemeral_id", "maxLength(1024)")
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("name", "maxLength(1024)")
 	}
 	if val.Version.IsSet() && utf8.RuneCountInString(val.Version.Val) > 1024 {
-		return fmt.Errorf("'version': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("version", "maxLength(1024)")
 	}
 	return nil
 }
@@ -1519,10 +1520,10 @@ func (val *contextServiceFramework) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("name", "maxLength(1024)")
 	}
 	if val.Version.IsSet() && utf8.RuneCountInString(val.Version.Val) > 1024 {
-		return fmt.Errorf("'version': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("version", "maxLength(1024)")
 	}
 	return nil
 }
@@ -1545,10 +1546,10 @@ func (val *contextServiceLanguage) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("name", "maxLength(1024)")
 	}
 	if val.Version.IsSet() && utf8.RuneCountInString(val.Version.Val) > 1024 {
-		return fmt.Errorf("'version': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("version", "maxLength(1024)")
 	}
 	return nil
 }
@@ -1570,7 +1571,7 @@ func (val *contextServiceNode) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'configured_name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("configured_name", "maxLength(1024)")
 	}
 	return nil
 }
@@ -1614,10 +1615,10 @@ func (val *contextServiceRuntime) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("name", "maxLength(1024)")
 	}
 	if val.Version.IsSet() && utf8.RuneCountInString(val.Version.Val) > 1024 {
-		return fmt.Errorf("'version': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("version", "maxLength(1024)")
 	}
 	return nil
 }
@@ -1640,7 +1641,7 @@ func (val *contextServiceTarget) validate() error {
 		return nil
 	}
 	if !val.Type.IsSet() && !val.Name.IsSet() {
-		return fmt.Errorf("requires at least one of the fields 'type;name'")
+		return modeldecoder.NewRequiredAnyOfErr("type;name")
 	}
 	return nil
 }
@@ -1679,35 +1680,35 @@ func (val *errorException) validate() error {
 	switch t := val.Code.Val.(type) {
 	case string:
 		if utf8.RuneCountInString(t) > 1024 {
-			return fmt.Errorf("'code': validation rule 'maxLength(1024)' violated")
+			return modeldecoder.NewRuleErr("code", "maxLength(1024)")
 		}
 	case int:
 	case json.Number:
 		if _, err := t.Int64(); err != nil {
-			return fmt.Errorf("'code': validation rule 'inputTypes(string;int)' violated")
+			return modeldecoder.NewRuleErr("code", "inputTypes(string;int)")
 		}
 	case nil:
 	default:
-		return fmt.Errorf("'code': validation rule 'inputTypes(string;int)' violated ")
+		return modeldecoder.NewRuleErr("code", "inputTypes(string;int)")
 	}
 	for _, elem := range val.Cause {
 		if err := elem.validate(); err != nil {
-			return errors.Wrapf(err, "cause")
+			return modeldecoder.WrapRuleErr(err, "cause")
 		}
 	}
 	if val.Module.IsSet() && utf8.RuneCountInString(val.Module.Val) > 1024 {
-		return fmt.Errorf("'module': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("module", "maxLength(1024)")
 	}
 	for _, elem := range val.Stacktrace {
 		if err := elem.validate(); err != nil {
-			return errors.Wrapf(err, "stacktrace")
+			return modeldecoder.WrapRuleErr(err, "stacktrace")
 		}
 	}
 	if val.Type.IsSet() && utf8.RuneCountInString(val.Type.Val) > 1024 {
-		return fmt.Errorf("'type': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("type", "maxLength(1024)")
 	}
 	if !val.Message.IsSet() && !val.Type.IsSet() {
-		return fmt.Errorf("requires at least one of the fields 'message;type'")
+		return modeldecoder.NewRequiredAnyOfErr("message;type")
 	}
 	return nil
 }
@@ -1742,7 +1743,7 @@ func (val *stacktraceFrame) validate() error {
 		return nil
 	}
 	if !val.Classname.IsSet() && !val.Filename.IsSet() {
-		return fmt.Errorf("requires at least one of the fields 'classname;filename'")
+		return modeldecoder.NewRequiredAnyOfErr("classname;filename")
 	}
 	return nil
 }
@@ -1771,20 +1772,20 @@ func (val *errorLog) validate() error {
 		return nil
 	}
 	if val.Level.IsSet() && utf8.RuneCountInString(val.Level.Val) > 1024 {
-		return fmt.Errorf("'level': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("level", "maxLength(1024)")
 	}
 	if val.LoggerName.IsSet() && utf8.RuneCountInString(val.LoggerName.Val) > 1024 {
-		return fmt.Errorf("'logger_name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("logger_name", "maxLength(1024)")
 	}
 	if !val.Message.IsSet() {
-		return fmt.Errorf("'message' required")
+		return modeldecoder.NewRequiredErr("message")
 	}
 	if val.ParamMessage.IsSet() && utf8.RuneCountInString(val.ParamMessage.Val) > 1024 {
-		return fmt.Errorf("'param_message': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("param_message", "maxLength(1024)")
 	}
 	for _, elem := range val.Stacktrace {
 		if err := elem.validate(); err != nil {
-			return errors.Wrapf(err, "stacktrace")
+			return modeldecoder.WrapRuleErr(err, "stacktrace")
 		}
 	}
 	return nil
@@ -1809,10 +1810,10 @@ func (val *errorTransactionRef) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("name", "maxLength(1024)")
 	}
 	if val.Type.IsSet() && utf8.RuneCountInString(val.Type.Val) > 1024 {
-		return fmt.Errorf("'type': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("type", "maxLength(1024)")
 	}
 	return nil
 }
@@ -1831,10 +1832,10 @@ func (val *metricsetRoot) Reset() {
 
 func (val *metricsetRoot) validate() error {
 	if err := val.Metricset.validate(); err != nil {
-		return errors.Wrapf(err, "metricset")
+		return modeldecoder.WrapRuleErr(err, "metricset")
 	}
 	if !val.Metricset.IsSet() {
-		return fmt.Errorf("'metricset' required")
+		return modeldecoder.NewRequiredErr("metricset")
 	}
 	return nil
 }
@@ -1869,40 +1870,40 @@ func (val *metricset) validate() error {
 		return nil
 	}
 	if len(val.Samples) == 0 {
-		return fmt.Errorf("'samples' required")
+		return modeldecoder.NewRequiredErr("samples")
 	}
 	for k, v := range val.Samples {
 		if err := v.validate(); err != nil {
-			return errors.Wrapf(err, "samples")
+			return modeldecoder.WrapRuleErr(err, "samples")
 		}
 		if k != "" && !patternNoAsteriskQuoteRegexp.MatchString(k) {
-			return fmt.Errorf("'samples': validation rule 'patternKeys(patternNoAsteriskQuote)' violated")
+			return modeldecoder.NewRuleErr("samples", "patternKeys(patternNoAsteriskQuote)")
 		}
 	}
 	if err := val.Span.validate(); err != nil {
-		return errors.Wrapf(err, "span")
+		return modeldecoder.WrapRuleErr(err, "span")
 	}
 	for k, v := range val.Tags {
 		switch t := v.(type) {
 		case nil:
 		case string:
 			if utf8.RuneCountInString(t) > 1024 {
-				return fmt.Errorf("'tags': validation rule 'maxLengthVals(1024)' violated")
+				return modeldecoder.NewRuleErr("tags", "maxLengthVals(1024)")
 			}
 		case bool:
 		case json.Number:
 		default:
-			return fmt.Errorf("'tags': validation rule 'inputTypesVals(string;bool;number)' violated for key %s", k)
+			return modeldecoder.NewRuleKeyErr("tags", "inputTypesVals(string;bool;number)", k)
 		}
 	}
 	if err := val.Transaction.validate(); err != nil {
-		return errors.Wrapf(err, "transaction")
+		return modeldecoder.WrapRuleErr(err, "transaction")
 	}
 	if err := val.Service.validate(); err != nil {
-		return errors.Wrapf(err, "service")
+		return modeldecoder.WrapRuleErr(err, "service")
 	}
 	if err := val.FAAS.validate(); err != nil {
-		return errors.Wrapf(err, "faas")
+		return modeldecoder.WrapRuleErr(err, "faas")
 	}
 	return nil
 }
@@ -1941,21 +1942,21 @@ func (val *metricsetSampleValue) validate() error {
 	}
 	if !(len(val.Values) > 0) {
 		if len(val.Counts) > 0 {
-			return fmt.Errorf("'values' required when 'counts' is set")
+			return modeldecoder.NewRequiredIfAnyErr("values", "counts")
 		}
 	}
 	for _, elem := range val.Counts {
 		if elem < 0 {
-			return fmt.Errorf("'counts': validation rule 'minVals(0)' violated")
+			return modeldecoder.NewRuleErr("counts", "minVals(0)")
 		}
 	}
 	if !(len(val.Counts) > 0) {
 		if len(val.Values) > 0 {
-			return fmt.Errorf("'counts' required when 'values' is set")
+			return modeldecoder.NewRequiredIfAnyErr("counts", "values")
 		}
 	}
 	if !val.Value.IsSet() && !(len(val.Values) > 0) {
-		return fmt.Errorf("requires at least one of the fields 'value;values'")
+		return modeldecoder.NewRequiredAnyOfErr("value;values")
 	}
 	return nil
 }
@@ -1978,10 +1979,10 @@ func (val *metricsetSpanRef) validate() error {
 		return nil
 	}
 	if val.Subtype.IsSet() && utf8.RuneCountInString(val.Subtype.Val) > 1024 {
-		return fmt.Errorf("'subtype': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("subtype", "maxLength(1024)")
 	}
 	if val.Type.IsSet() && utf8.RuneCountInString(val.Type.Val) > 1024 {
-		return fmt.Errorf("'type': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("type", "maxLength(1024)")
 	}
 	return nil
 }
@@ -2004,10 +2005,10 @@ func (val *metricsetTransactionRef) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("name", "maxLength(1024)")
 	}
 	if val.Type.IsSet() && utf8.RuneCountInString(val.Type.Val) > 1024 {
-		return fmt.Errorf("'type': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("type", "maxLength(1024)")
 	}
 	return nil
 }
@@ -2030,10 +2031,10 @@ func (val *metricsetServiceRef) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("name", "maxLength(1024)")
 	}
 	if val.Version.IsSet() && utf8.RuneCountInString(val.Version.Val) > 1024 {
-		return fmt.Errorf("'version': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("version", "maxLength(1024)")
 	}
 	return nil
 }
@@ -2060,7 +2061,7 @@ func (val *faas) validate() error {
 		return nil
 	}
 	if err := val.Trigger.validate(); err != nil {
-		return errors.Wrapf(err, "trigger")
+		return modeldecoder.WrapRuleErr(err, "trigger")
 	}
 	return nil
 }
@@ -2102,10 +2103,10 @@ func (val *spanRoot) Reset() {
 
 func (val *spanRoot) validate() error {
 	if err := val.Span.validate(); err != nil {
-		return errors.Wrapf(err, "span")
+		return modeldecoder.WrapRuleErr(err, "span")
 	}
 	if !val.Span.IsSet() {
-		return fmt.Errorf("'span' required")
+		return modeldecoder.NewRequiredErr("span")
 	}
 	return nil
 }
@@ -2155,36 +2156,36 @@ func (val *span) validate() error {
 		return nil
 	}
 	if val.Action.IsSet() && utf8.RuneCountInString(val.Action.Val) > 1024 {
-		return fmt.Errorf("'action': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("action", "maxLength(1024)")
 	}
 	for _, elem := range val.ChildIDs {
 		if utf8.RuneCountInString(elem) > 1024 {
-			return fmt.Errorf("'child_ids': validation rule 'maxLength(1024)' violated")
+			return modeldecoder.NewRuleErr("child_ids", "maxLength(1024)")
 		}
 	}
 	if err := val.Composite.validate(); err != nil {
-		return errors.Wrapf(err, "composite")
+		return modeldecoder.WrapRuleErr(err, "composite")
 	}
 	if err := val.Context.validate(); err != nil {
-		return errors.Wrapf(err, "context")
+		return modeldecoder.WrapRuleErr(err, "context")
 	}
 	if val.Duration.IsSet() && val.Duration.Val < 0 {
-		return fmt.Errorf("'duration': validation rule 'min(0)' violated")
+		return modeldecoder.NewRuleErr("duration", "min(0)")
 	}
 	if !val.Duration.IsSet() {
-		return fmt.Errorf("'duration' required")
+		return modeldecoder.NewRequiredErr("duration")
 	}
 	if val.ID.IsSet() && utf8.RuneCountInString(val.ID.Val) > 1024 {
-		return fmt.Errorf("'id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("id", "maxLength(1024)")
 	}
 	if !val.ID.IsSet() {
-		return fmt.Errorf("'id' required")
+		return modeldecoder.NewRequiredErr("id")
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("name", "maxLength(1024)")
 	}
 	if !val.Name.IsSet() {
-		return fmt.Errorf("'name' required")
+		return modeldecoder.NewRequiredErr("name")
 	}
 	if val.Outcome.Val != "" {
 		var matchEnum bool
@@ -2195,48 +2196,48 @@ func (val *span) validate() error {
 			}
 		}
 		if !matchEnum {
-			return fmt.Errorf("'outcome': validation rule 'enum(enumOutcome)' violated")
+			return modeldecoder.NewRuleErr("outcome", "enum(enumOutcome)")
 		}
 	}
 	if err := val.OTel.validate(); err != nil {
-		return errors.Wrapf(err, "otel")
+		return modeldecoder.WrapRuleErr(err, "otel")
 	}
 	if val.ParentID.IsSet() && utf8.RuneCountInString(val.ParentID.Val) > 1024 {
-		return fmt.Errorf("'parent_id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("parent_id", "maxLength(1024)")
 	}
 	if !val.ParentID.IsSet() {
-		return fmt.Errorf("'parent_id' required")
+		return modeldecoder.NewRequiredErr("parent_id")
 	}
 	for _, elem := range val.Stacktrace {
 		if err := elem.validate(); err != nil {
-			return errors.Wrapf(err, "stacktrace")
+			return modeldecoder.WrapRuleErr(err, "stacktrace")
 		}
 	}
 	if val.Subtype.IsSet() && utf8.RuneCountInString(val.Subtype.Val) > 1024 {
-		return fmt.Errorf("'subtype': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("subtype", "maxLength(1024)")
 	}
 	if val.TraceID.IsSet() && utf8.RuneCountInString(val.TraceID.Val) > 1024 {
-		return fmt.Errorf("'trace_id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("trace_id", "maxLength(1024)")
 	}
 	if !val.TraceID.IsSet() {
-		return fmt.Errorf("'trace_id' required")
+		return modeldecoder.NewRequiredErr("trace_id")
 	}
 	if val.TransactionID.IsSet() && utf8.RuneCountInString(val.TransactionID.Val) > 1024 {
-		return fmt.Errorf("'transaction_id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("transaction_id", "maxLength(1024)")
 	}
 	if val.Type.IsSet() && utf8.RuneCountInString(val.Type.Val) > 1024 {
-		return fmt.Errorf("'type': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("type", "maxLength(1024)")
 	}
 	if !val.Type.IsSet() {
-		return fmt.Errorf("'type' required")
+		return modeldecoder.NewRequiredErr("type")
 	}
 	for _, elem := range val.Links {
 		if err := elem.validate(); err != nil {
-			return errors.Wrapf(err, "links")
+			return modeldecoder.WrapRuleErr(err, "links")
 		}
 	}
 	if !val.Start.IsSet() && !val.Timestamp.IsSet() {
-		return fmt.Errorf("requires at least one of the fields 'start;timestamp'")
+		return modeldecoder.NewRequiredAnyOfErr("start;timestamp")
 	}
 	return nil
 }
@@ -2269,19 +2270,19 @@ func (val *spanComposite) validate() error {
 		return nil
 	}
 	if val.Count.IsSet() && val.Count.Val < 2 {
-		return fmt.Errorf("'count': validation rule 'min(2)' violated")
+		return modeldecoder.NewRuleErr("count", "min(2)")
 	}
 	if !val.Count.IsSet() {
-		return fmt.Errorf("'count' required")
+		return modeldecoder.NewRequiredErr("count")
 	}
 	if val.Sum.IsSet() && val.Sum.Val < 0 {
-		return fmt.Errorf("'sum': validation rule 'min(0)' violated")
+		return modeldecoder.NewRuleErr("sum", "min(0)")
 	}
 	if !val.Sum.IsSet() {
-		return fmt.Errorf("'sum' required")
+		return modeldecoder.NewRequiredErr("sum")
 	}
 	if !val.CompressionStrategy.IsSet() {
-		return fmt.Errorf("'compression_strategy' required")
+		return modeldecoder.NewRequiredErr("compression_strategy")
 	}
 	return nil
 }
@@ -2310,31 +2311,31 @@ func (val *spanContext) validate() error {
 		return nil
 	}
 	if err := val.Database.validate(); err != nil {
-		return errors.Wrapf(err, "db")
+		return modeldecoder.WrapRuleErr(err, "db")
 	}
 	if err := val.Destination.validate(); err != nil {
-		return errors.Wrapf(err, "destination")
+		return modeldecoder.WrapRuleErr(err, "destination")
 	}
 	if err := val.HTTP.validate(); err != nil {
-		return errors.Wrapf(err, "http")
+		return modeldecoder.WrapRuleErr(err, "http")
 	}
 	if err := val.Message.validate(); err != nil {
-		return errors.Wrapf(err, "message")
+		return modeldecoder.WrapRuleErr(err, "message")
 	}
 	if err := val.Service.validate(); err != nil {
-		return errors.Wrapf(err, "service")
+		return modeldecoder.WrapRuleErr(err, "service")
 	}
 	for k, v := range val.Tags {
 		switch t := v.(type) {
 		case nil:
 		case string:
 			if utf8.RuneCountInString(t) > 1024 {
-				return fmt.Errorf("'tags': validation rule 'maxLengthVals(1024)' violated")
+				return modeldecoder.NewRuleErr("tags", "maxLengthVals(1024)")
 			}
 		case bool:
 		case json.Number:
 		default:
-			return fmt.Errorf("'tags': validation rule 'inputTypesVals(string;bool;number)' violated for key %s", k)
+			return modeldecoder.NewRuleKeyErr("tags", "inputTypesVals(string;bool;number)", k)
 		}
 	}
 	return nil
@@ -2377,7 +2378,7 @@ func (val *spanContextDatabase) validate() error {
 		return nil
 	}
 	if val.Link.IsSet() && utf8.RuneCountInString(val.Link.Val) > 1024 {
-		return fmt.Errorf("'link': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("link", "maxLength(1024)")
 	}
 	return nil
 }
@@ -2401,10 +2402,10 @@ func (val *spanContextDestination) validate() error {
 		return nil
 	}
 	if val.Address.IsSet() && utf8.RuneCountInString(val.Address.Val) > 1024 {
-		return fmt.Errorf("'address': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("address", "maxLength(1024)")
 	}
 	if err := val.Service.validate(); err != nil {
-		return errors.Wrapf(err, "service")
+		return modeldecoder.WrapRuleErr(err, "service")
 	}
 	return nil
 }
@@ -2431,16 +2432,16 @@ func (val *spanContextDestinationService) validate() error {
 		return nil
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("name", "maxLength(1024)")
 	}
 	if val.Resource.IsSet() && utf8.RuneCountInString(val.Resource.Val) > 1024 {
-		return fmt.Errorf("'resource': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("resource", "maxLength(1024)")
 	}
 	if !val.Resource.IsSet() {
-		return fmt.Errorf("'resource' required")
+		return modeldecoder.NewRequiredErr("resource")
 	}
 	if val.Type.IsSet() && utf8.RuneCountInString(val.Type.Val) > 1024 {
-		return fmt.Errorf("'type': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("type", "maxLength(1024)")
 	}
 	return nil
 }
@@ -2466,13 +2467,13 @@ func (val *spanContextHTTP) validate() error {
 		return nil
 	}
 	if val.Method.IsSet() && utf8.RuneCountInString(val.Method.Val) > 1024 {
-		return fmt.Errorf("'method': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("method", "maxLength(1024)")
 	}
 	if err := val.Request.validate(); err != nil {
-		return errors.Wrapf(err, "request")
+		return modeldecoder.WrapRuleErr(err, "request")
 	}
 	if err := val.Response.validate(); err != nil {
-		return errors.Wrapf(err, "response")
+		return modeldecoder.WrapRuleErr(err, "response")
 	}
 	return nil
 }
@@ -2565,16 +2566,16 @@ func (val *spanLink) validate() error {
 		return nil
 	}
 	if val.SpanID.IsSet() && utf8.RuneCountInString(val.SpanID.Val) > 1024 {
-		return fmt.Errorf("'span_id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("span_id", "maxLength(1024)")
 	}
 	if !val.SpanID.IsSet() {
-		return fmt.Errorf("'span_id' required")
+		return modeldecoder.NewRequiredErr("span_id")
 	}
 	if val.TraceID.IsSet() && utf8.RuneCountInString(val.TraceID.Val) > 1024 {
-		return fmt.Errorf("'trace_id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("trace_id", "maxLength(1024)")
 	}
 	if !val.TraceID.IsSet() {
-		return fmt.Errorf("'trace_id' required")
+		return modeldecoder.NewRequiredErr("trace_id")
 	}
 	return nil
 }
@@ -2593,10 +2594,10 @@ func (val *transactionRoot) Reset() {
 
 func (val *transactionRoot) validate() error {
 	if err := val.Transaction.validate(); err != nil {
-		return errors.Wrapf(err, "transaction")
+		return modeldecoder.WrapRuleErr(err, "transaction")
 	}
 	if !val.Transaction.IsSet() {
-		return fmt.Errorf("'transaction' required")
+		return modeldecoder.NewRequiredErr("transaction")
 	}
 	return nil
 }
@@ -2646,36 +2647,36 @@ func (val *transaction) validate() error {
 		return nil
 	}
 	if err := val.Context.validate(); err != nil {
-		return errors.Wrapf(err, "context")
+		return modeldecoder.WrapRuleErr(err, "context")
 	}
 	for _, elem := range val.DroppedSpanStats {
 		if err := elem.validate(); err != nil {
-			return errors.Wrapf(err, "dropped_spans_stats")
+			return modeldecoder.WrapRuleErr(err, "dropped_spans_stats")
 		}
 	}
 	if val.Duration.IsSet() && val.Duration.Val < 0 {
-		return fmt.Errorf("'duration': validation rule 'min(0)' violated")
+		return modeldecoder.NewRuleErr("duration", "min(0)")
 	}
 	if !val.Duration.IsSet() {
-		return fmt.Errorf("'duration' required")
+		return modeldecoder.NewRequiredErr("duration")
 	}
 	if err := val.FAAS.validate(); err != nil {
-		return errors.Wrapf(err, "faas")
+		return modeldecoder.WrapRuleErr(err, "faas")
 	}
 	if val.ID.IsSet() && utf8.RuneCountInString(val.ID.Val) > 1024 {
-		return fmt.Errorf("'id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("id", "maxLength(1024)")
 	}
 	if !val.ID.IsSet() {
-		return fmt.Errorf("'id' required")
+		return modeldecoder.NewRequiredErr("id")
 	}
 	if err := val.Marks.validate(); err != nil {
-		return errors.Wrapf(err, "marks")
+		return modeldecoder.WrapRuleErr(err, "marks")
 	}
 	if val.Name.IsSet() && utf8.RuneCountInString(val.Name.Val) > 1024 {
-		return fmt.Errorf("'name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("name", "maxLength(1024)")
 	}
 	if err := val.OTel.validate(); err != nil {
-		return errors.Wrapf(err, "otel")
+		return modeldecoder.WrapRuleErr(err, "otel")
 	}
 	if val.Outcome.Val != "" {
 		var matchEnum bool
@@ -2686,42 +2687,42 @@ func (val *transaction) validate() error {
 			}
 		}
 		if !matchEnum {
-			return fmt.Errorf("'outcome': validation rule 'enum(enumOutcome)' violated")
+			return modeldecoder.NewRuleErr("outcome", "enum(enumOutcome)")
 		}
 	}
 	if val.ParentID.IsSet() && utf8.RuneCountInString(val.ParentID.Val) > 1024 {
-		return fmt.Errorf("'parent_id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("parent_id", "maxLength(1024)")
 	}
 	if val.Result.IsSet() && utf8.RuneCountInString(val.Result.Val) > 1024 {
-		return fmt.Errorf("'result': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("result", "maxLength(1024)")
 	}
 	if err := val.Session.validate(); err != nil {
-		return errors.Wrapf(err, "session")
+		return modeldecoder.WrapRuleErr(err, "session")
 	}
 	if err := val.SpanCount.validate(); err != nil {
-		return errors.Wrapf(err, "span_count")
+		return modeldecoder.WrapRuleErr(err, "span_count")
 	}
 	if !val.SpanCount.IsSet() {
-		return fmt.Errorf("'span_count' required")
+		return modeldecoder.NewRequiredErr("span_count")
 	}
 	if val.TraceID.IsSet() && utf8.RuneCountInString(val.TraceID.Val) > 1024 {
-		return fmt.Errorf("'trace_id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("trace_id", "maxLength(1024)")
 	}
 	if !val.TraceID.IsSet() {
-		return fmt.Errorf("'trace_id' required")
+		return modeldecoder.NewRequiredErr("trace_id")
 	}
 	if val.Type.IsSet() && utf8.RuneCountInString(val.Type.Val) > 1024 {
-		return fmt.Errorf("'type': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("type", "maxLength(1024)")
 	}
 	if !val.Type.IsSet() {
-		return fmt.Errorf("'type' required")
+		return modeldecoder.NewRequiredErr("type")
 	}
 	if err := val.UserExperience.validate(); err != nil {
-		return errors.Wrapf(err, "experience")
+		return modeldecoder.WrapRuleErr(err, "experience")
 	}
 	for _, elem := range val.Links {
 		if err := elem.validate(); err != nil {
-			return errors.Wrapf(err, "links")
+			return modeldecoder.WrapRuleErr(err, "links")
 		}
 	}
 	return nil
@@ -2769,13 +2770,13 @@ func (val *transactionDroppedSpanStats) validate() error {
 		return nil
 	}
 	if val.DestinationServiceResource.IsSet() && utf8.RuneCountInString(val.DestinationServiceResource.Val) > 1024 {
-		return fmt.Errorf("'destination_service_resource': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("destination_service_resource", "maxLength(1024)")
 	}
 	if val.ServiceTargetType.IsSet() && utf8.RuneCountInString(val.ServiceTargetType.Val) > 512 {
-		return fmt.Errorf("'service_target_type': validation rule 'maxLength(512)' violated")
+		return modeldecoder.NewRuleErr("service_target_type", "maxLength(512)")
 	}
 	if val.ServiceTargetName.IsSet() && utf8.RuneCountInString(val.ServiceTargetName.Val) > 512 {
-		return fmt.Errorf("'service_target_name': validation rule 'maxLength(512)' violated")
+		return modeldecoder.NewRuleErr("service_target_name", "maxLength(512)")
 	}
 	if val.Outcome.Val != "" {
 		var matchEnum bool
@@ -2786,11 +2787,11 @@ func (val *transactionDroppedSpanStats) validate() error {
 			}
 		}
 		if !matchEnum {
-			return fmt.Errorf("'outcome': validation rule 'enum(enumOutcome)' violated")
+			return modeldecoder.NewRuleErr("outcome", "enum(enumOutcome)")
 		}
 	}
 	if err := val.Duration.validate(); err != nil {
-		return errors.Wrapf(err, "duration")
+		return modeldecoder.WrapRuleErr(err, "duration")
 	}
 	return nil
 }
@@ -2816,10 +2817,10 @@ func (val *transactionDroppedSpansDuration) validate() error {
 		return nil
 	}
 	if val.Count.IsSet() && val.Count.Val < 1 {
-		return fmt.Errorf("'count': validation rule 'min(1)' violated")
+		return modeldecoder.NewRuleErr("count", "min(1)")
 	}
 	if err := val.Sum.validate(); err != nil {
-		return errors.Wrapf(err, "sum")
+		return modeldecoder.WrapRuleErr(err, "sum")
 	}
 	return nil
 }
@@ -2844,7 +2845,7 @@ func (val *transactionDroppedSpansDurationSum) validate() error {
 		return nil
 	}
 	if val.Us.IsSet() && val.Us.Val < 0 {
-		return fmt.Errorf("'us': validation rule 'min(0)' violated")
+		return modeldecoder.NewRuleErr("us", "min(0)")
 	}
 	return nil
 }
@@ -2909,13 +2910,13 @@ func (val *transactionSession) validate() error {
 		return nil
 	}
 	if val.ID.IsSet() && utf8.RuneCountInString(val.ID.Val) > 1024 {
-		return fmt.Errorf("'id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("id", "maxLength(1024)")
 	}
 	if !val.ID.IsSet() {
-		return fmt.Errorf("'id' required")
+		return modeldecoder.NewRequiredErr("id")
 	}
 	if val.Sequence.IsSet() && val.Sequence.Val < 1 {
-		return fmt.Errorf("'sequence': validation rule 'min(1)' violated")
+		return modeldecoder.NewRuleErr("sequence", "min(1)")
 	}
 	return nil
 }
@@ -2938,7 +2939,7 @@ func (val *transactionSpanCount) validate() error {
 		return nil
 	}
 	if !val.Started.IsSet() {
-		return fmt.Errorf("'started' required")
+		return modeldecoder.NewRequiredErr("started")
 	}
 	return nil
 }
@@ -2963,16 +2964,16 @@ func (val *transactionUserExperience) validate() error {
 		return nil
 	}
 	if val.CumulativeLayoutShift.IsSet() && val.CumulativeLayoutShift.Val < 0 {
-		return fmt.Errorf("'cls': validation rule 'min(0)' violated")
+		return modeldecoder.NewRuleErr("cls", "min(0)")
 	}
 	if val.FirstInputDelay.IsSet() && val.FirstInputDelay.Val < 0 {
-		return fmt.Errorf("'fid': validation rule 'min(0)' violated")
+		return modeldecoder.NewRuleErr("fid", "min(0)")
 	}
 	if err := val.Longtask.validate(); err != nil {
-		return errors.Wrapf(err, "longtask")
+		return modeldecoder.WrapRuleErr(err, "longtask")
 	}
 	if val.TotalBlockingTime.IsSet() && val.TotalBlockingTime.Val < 0 {
-		return fmt.Errorf("'tbt': validation rule 'min(0)' violated")
+		return modeldecoder.NewRuleErr("tbt", "min(0)")
 	}
 	return nil
 }
@@ -2999,22 +3000,22 @@ func (val *longtaskMetrics) validate() error {
 		return nil
 	}
 	if val.Count.IsSet() && val.Count.Val < 0 {
-		return fmt.Errorf("'count': validation rule 'min(0)' violated")
+		return modeldecoder.NewRuleErr("count", "min(0)")
 	}
 	if !val.Count.IsSet() {
-		return fmt.Errorf("'count' required")
+		return modeldecoder.NewRequiredErr("count")
 	}
 	if val.Max.IsSet() && val.Max.Val < 0 {
-		return fmt.Errorf("'max': validation rule 'min(0)' violated")
+		return modeldecoder.NewRuleErr("max", "min(0)")
 	}
 	if !val.Max.IsSet() {
-		return fmt.Errorf("'max' required")
+		return modeldecoder.NewRequiredErr("max")
 	}
 	if val.Sum.IsSet() && val.Sum.Val < 0 {
-		return fmt.Errorf("'sum': validation rule 'min(0)' violated")
+		return modeldecoder.NewRuleErr("sum", "min(0)")
 	}
 	if !val.Sum.IsSet() {
-		return fmt.Errorf("'sum' required")
+		return modeldecoder.NewRequiredErr("sum")
 	}
 	return nil
 }
@@ -3033,10 +3034,10 @@ func (val *logRoot) Reset() {
 
 func (val *logRoot) validate() error {
 	if err := val.Log.validate(); err != nil {
-		return errors.Wrapf(err, "log")
+		return modeldecoder.WrapRuleErr(err, "log")
 	}
 	if !val.Log.IsSet() {
-		return fmt.Errorf("'log' required")
+		return modeldecoder.NewRequiredErr("log")
 	}
 	return nil
 }
@@ -3074,44 +3075,44 @@ func (val *log) validate() error {
 		return nil
 	}
 	if val.TraceID.IsSet() && utf8.RuneCountInString(val.TraceID.Val) > 1024 {
-		return fmt.Errorf("'trace.id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("trace.id", "maxLength(1024)")
 	}
 	if val.TransactionID.IsSet() && utf8.RuneCountInString(val.TransactionID.Val) > 1024 {
-		return fmt.Errorf("'transaction.id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("transaction.id", "maxLength(1024)")
 	}
 	if val.SpanID.IsSet() && utf8.RuneCountInString(val.SpanID.Val) > 1024 {
-		return fmt.Errorf("'span.id': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("span.id", "maxLength(1024)")
 	}
 	if err := val.FAAS.validate(); err != nil {
-		return errors.Wrapf(err, "faas")
+		return modeldecoder.WrapRuleErr(err, "faas")
 	}
 	for k, v := range val.Labels {
 		switch t := v.(type) {
 		case nil:
 		case string:
 			if utf8.RuneCountInString(t) > 1024 {
-				return fmt.Errorf("'labels': validation rule 'maxLengthVals(1024)' violated")
+				return modeldecoder.NewRuleErr("labels", "maxLengthVals(1024)")
 			}
 		case bool:
 		case json.Number:
 		default:
-			return fmt.Errorf("'labels': validation rule 'inputTypesVals(string;bool;number)' violated for key %s", k)
+			return modeldecoder.NewRuleKeyErr("labels", "inputTypesVals(string;bool;number)", k)
 		}
 	}
 	if err := val.EcsLogEventFields.validate(); err != nil {
-		return errors.Wrapf(err, "ecslogeventfields")
+		return modeldecoder.WrapRuleErr(err, "ecslogeventfields")
 	}
 	if err := val.EcsLogServiceFields.validate(); err != nil {
-		return errors.Wrapf(err, "ecslogservicefields")
+		return modeldecoder.WrapRuleErr(err, "ecslogservicefields")
 	}
 	if err := val.EcsLogLogFields.validate(); err != nil {
-		return errors.Wrapf(err, "ecsloglogfields")
+		return modeldecoder.WrapRuleErr(err, "ecsloglogfields")
 	}
 	if err := val.EcsLogErrorFields.validate(); err != nil {
-		return errors.Wrapf(err, "ecslogerrorfields")
+		return modeldecoder.WrapRuleErr(err, "ecslogerrorfields")
 	}
 	if err := val.EcsLogProcessFields.validate(); err != nil {
-		return errors.Wrapf(err, "ecslogprocessfields")
+		return modeldecoder.WrapRuleErr(err, "ecslogprocessfields")
 	}
 	return nil
 }
@@ -3154,7 +3155,7 @@ func (val *EcsLogEventFields) validate() error {
 		return nil
 	}
 	if val.EventDataset.IsSet() && utf8.RuneCountInString(val.EventDataset.Val) > 1024 {
-		return fmt.Errorf("'event.dataset': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("event.dataset", "maxLength(1024)")
 	}
 	return nil
 }
@@ -3188,16 +3189,16 @@ func (val *EcsLogServiceFields) validate() error {
 		return nil
 	}
 	if val.ServiceName.IsSet() && utf8.RuneCountInString(val.ServiceName.Val) > 1024 {
-		return fmt.Errorf("'service.name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("service.name", "maxLength(1024)")
 	}
 	if val.ServiceVersion.IsSet() && utf8.RuneCountInString(val.ServiceVersion.Val) > 1024 {
-		return fmt.Errorf("'service.version': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("service.version", "maxLength(1024)")
 	}
 	if val.ServiceEnvironment.IsSet() && utf8.RuneCountInString(val.ServiceEnvironment.Val) > 1024 {
-		return fmt.Errorf("'service.environment': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("service.environment", "maxLength(1024)")
 	}
 	if val.ServiceNodeName.IsSet() && utf8.RuneCountInString(val.ServiceNodeName.Val) > 1024 {
-		return fmt.Errorf("'service.node.name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("service.node.name", "maxLength(1024)")
 	}
 	return nil
 }
@@ -3243,13 +3244,13 @@ func (val *EcsLogLogFields) validate() error {
 		return nil
 	}
 	if val.Level.IsSet() && utf8.RuneCountInString(val.Level.Val) > 1024 {
-		return fmt.Errorf("'log.level': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("log.level", "maxLength(1024)")
 	}
 	if val.Logger.IsSet() && utf8.RuneCountInString(val.Logger.Val) > 1024 {
-		return fmt.Errorf("'log.logger': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("log.logger", "maxLength(1024)")
 	}
 	if val.OriginFileName.IsSet() && utf8.RuneCountInString(val.OriginFileName.Val) > 1024 {
-		return fmt.Errorf("'log.origin.file.name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("log.origin.file.name", "maxLength(1024)")
 	}
 	return nil
 }
@@ -3343,7 +3344,7 @@ func (val *EcsLogProcessFields) validate() error {
 		return nil
 	}
 	if val.ProcessThreadName.IsSet() && utf8.RuneCountInString(val.ProcessThreadName.Val) > 1024 {
-		return fmt.Errorf("'process.thread.name': validation rule 'maxLength(1024)' violated")
+		return modeldecoder.NewRuleErr("process.thread.name", "maxLength(1024)")
 	}
 	return nil
 }
@@ -30,8 +30,10 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/elastic/apm-data/input/elasticapm/internal/decoder"
+	"github.com/elastic/apm-data/input/elasticapm/internal/modeldecoder"
 	"github.com/elastic/apm-data/input/elasticapm/internal/modeldecoder/modeldecodertest"
 	"github.com/elastic/apm-data/input/elasticapm/internal/modeldecoder/nullable"
+	"github.com/elastic/apm-data/model"
 )
 
 //
@@ -664,3 +666,76 @@ func testValidation(t *testing.T, eventType string, testcases []testcase, keys .
 		})
 	}
 }
+
+func TestValidationErrorPathRule(t *testing.T) {
+	longString := strings.Repeat("x", 1025)
+	for _, test := range []struct {
+		decode func(decoder.Decoder, *modeldecoder.Input, *model.Batch) error
+		data   string
+		path   string
+		rule   string
+	}{{
+		decode: DecodeNestedTransaction,
+		data:   `{"transaction":{"trace_id":"a","type":"t","duration":0,"span_count":{"started":0}}}`,
+		path:   "transaction.id", rule: "required",
+	}, {
+		decode: DecodeNestedTransaction,
+		data:   `{"transaction":{"id":"a","trace_id":"a","type":"` + longString + `","duration":0,"span_count":{"started":0}}}`,
+		path:   "transaction.type", rule: "maxLength(1024)",
+	}, {
+		decode: DecodeNestedTransaction,
+		data:   `{"transaction":{"id":"a","trace_id":"a","type":"t","duration":-1,"span_count":{"started":0}}}`,
+		path:   "transaction.duration", rule: "min(0)",
+	}, {
+		decode: DecodeNestedTransaction,
+		data:   `{"transaction":{"id":"a","trace_id":"a","type":"t","duration":0,"span_count":{"started":0},"outcome":"x"}}`,
+		path:   "transaction.outcome", rule: "enum(enumOutcome)",
+	}, {
+		decode: DecodeNestedTransaction,
+		data:   `{"transaction":{"id":"a","trace_id":"a","type":"t","duration":0,"span_count":{"started":0},"context":{"tags":{"a":[1]}}}}`,
+		path:   "transaction.context.tags", rule: "inputTypesVals(string;bool;number)",
+	}, {
+		decode: DecodeNestedTransaction,
+		data:   `{"transaction":{"id":"a","trace_id":"a","type":"t","duration":0,"span_count":{"started":0},"context":{"tags":{"a":"` + longString + `"}}}}`,
+		path:   "transaction.context.tags", rule: "maxLengthVals(1024)",
+	}, {
+		decode: DecodeNestedTransaction,
+		data:   `{"transaction":{"id":"a","trace_id":"a","type":"t","duration":0,"span_count":{"started":0},"context":{"service":{"name":"a*"}}}}`,
+		path:   "transaction.context.service.name", rule: "pattern(patternAlphaNumericExt)",
+	}, {
+		decode: DecodeNestedError,
+		data:   `{"error":{"id":"a"}}`,
+		path:   "error", rule: "requiredAnyOf(exception;log)",
+	}, {
+		decode: DecodeNestedError,
+		data:   `{"error":{"id":"a","trace_id":"b","log":{"message":"m"}}}`,
+		path:   "error.parent_id", rule: "requiredIfAny(trace_id)",
+	}, {
+		decode: DecodeNestedError,
+		data:   `{"error":{"id":"a","exception":{"message":"m","code":true}}}`,
+		path:   "error.exception.code", rule: "inputTypes(string;int)",
+	}, {
+		decode: DecodeNestedTransaction,
+		data:   `{"transaction":{"id":"a","trace_id":"a","type":"t","duration":0,"span_count":{"started":0},"context":{"request":{"method":"GET","url":{"port":"abc"}}}}}`,
+		path:   "transaction.context.request.url.port", rule: "targetType(int)",
+	}, {
+		decode: DecodeNestedMetricset,
+		data:   `{"metricset":{"samples":{"a*":{"value":1}}}}`,
+		path:   "metricset.samples", rule: "patternKeys(patternNoAsteriskQuote)",
+	}, {
+		decode: DecodeNestedMetricset,
+		data:   `{"metricset":{"samples":{"a":{"type":"histogram","values":[1],"counts":[-1]}}}}`,
+		// Map keys are not included in the path.
+		path: "metricset.samples.counts", rule: "minVals(0)",
+	}} {
+		t.Run(test.path+"/"+test.rule, func(t *testing.T) {
+			var batch model.Batch
+			dec := decoder.NewJSONDecoder(strings.NewReader(test.data))
+			err := test.decode(dec, &modeldecoder.Input{}, &batch)
+			var validationErr modeldecoder.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, test.path, validationErr.Path())
+			assert.Equal(t, test.rule, validationErr.Rule())
+		})
+	}
+}
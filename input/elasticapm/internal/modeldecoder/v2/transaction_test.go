@@ -92,7 +92,7 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 		_, out := initializedInputMetadata(modeldecodertest.DefaultValues())
 		otherVal := modeldecodertest.NonDefaultValues()
 		modeldecodertest.SetStructValues(&input, otherVal)
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 		input.Reset()
 
 		// ensure event Metadata are updated where expected
@@ -121,7 +121,7 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 		origin.Region.Set("us-east-1")
 		origin.Service.Name.Set("serviceName")
 		input.Context.Cloud.Origin = origin
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 		assert.Equal(t, "accountID", out.Cloud.Origin.AccountID)
 		assert.Equal(t, "aws", out.Cloud.Origin.Provider)
 		assert.Equal(t, "us-east-1", out.Cloud.Origin.Region)
@@ -136,7 +136,7 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 		origin.Name.Set("name")
 		origin.Version.Set("1.0")
 		input.Context.Service.Origin = origin
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 		assert.Equal(t, "abc123", out.Service.Origin.ID)
 		assert.Equal(t, "name", out.Service.Origin.Name)
 		assert.Equal(t, "1.0", out.Service.Origin.Version)
@@ -149,7 +149,7 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 		target.Name.Set("testdb")
 		target.Type.Set("oracle")
 		input.Context.Service.Target = target
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 		assert.Equal(t, "testdb", out.Service.Target.Name)
 		assert.Equal(t, "oracle", out.Service.Target.Type)
 	})
@@ -164,7 +164,7 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 		input.FAAS.Trigger.RequestID.Set("abc123")
 		input.FAAS.Name.Set("faasName")
 		input.FAAS.Version.Set("1.0.0")
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 		assert.Equal(t, "faasID", out.FAAS.ID)
 		assert.True(t, *out.FAAS.Coldstart)
 		assert.Equal(t, "execution", out.FAAS.Execution)
@@ -190,7 +190,7 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 		mysqlDss.Duration.Sum.Us.Set(durationSumUs)
 		input.DroppedSpanStats = append(input.DroppedSpanStats, esDss, mysqlDss)
 
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 		expected := model.APMEvent{Transaction: &model.Transaction{
 			DroppedSpansStats: []model.DroppedSpanStats{
 				{
@@ -224,13 +224,13 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 		input.Context.Request.Socket.RemoteAddress.Set(randomIP.String())
 		// from headers (case insensitive)
 		input.Context.Request.Headers.Val.Add("x-Real-ip", gatewayIP.String())
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 		assert.Equal(t, gatewayIP.String(), out.Client.IP.String())
 		// ignore if set in event already
 		out = model.APMEvent{
 			Client: model.Client{IP: netip.MustParseAddr("192.17.1.1")},
 		}
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 		assert.Equal(t, "192.17.1.1", out.Client.IP.String())
 	})
 
@@ -241,7 +241,7 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 		input.Context.Request.Headers.Set(http.Header{})
 		input.Context.Request.Headers.Val.Add("x-Real-ip", "192.13.14:8097")
 		input.Context.Request.Socket.RemoteAddress.Set(randomIP.String())
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 		// ensure client ip is populated from socket
 		assert.Equal(t, randomIP.String(), out.Client.IP.String())
 	})
@@ -251,7 +251,7 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 		var input transaction
 		_, out := initializedInputMetadata(modeldecodertest.DefaultValues())
 		input.Context.User.Email.Set("test@user.com")
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 		assert.Equal(t, "test@user.com", out.User.Email)
 		assert.Zero(t, out.User.ID)
 		assert.Zero(t, out.User.Name)
@@ -295,7 +295,7 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 		defaultVal := modeldecodertest.DefaultValues()
 		modeldecodertest.SetStructValues(&input, defaultVal)
 		input.OTel.Reset()
-		mapToTransactionModel(&input, &out1)
+		mapToTransactionModel(&input, nil, &out1)
 		input.Reset()
 		modeldecodertest.AssertStructValues(t, out1.Transaction, exceptions, defaultVal)
 
@@ -303,7 +303,7 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 		out1.Timestamp = reqTime
 		defaultVal.Update(time.Time{})
 		modeldecodertest.SetStructValues(&input, defaultVal)
-		mapToTransactionModel(&input, &out1)
+		mapToTransactionModel(&input, nil, &out1)
 		defaultVal.Update(reqTime)
 		input.Reset()
 		modeldecodertest.AssertStructValues(t, out1.Transaction, exceptions, defaultVal)
@@ -313,7 +313,7 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 		otherVal := modeldecodertest.NonDefaultValues()
 		modeldecodertest.SetStructValues(&input, otherVal)
 		input.OTel.Reset()
-		mapToTransactionModel(&input, &out2)
+		mapToTransactionModel(&input, nil, &out2)
 		modeldecodertest.AssertStructValues(t, out2.Transaction, exceptions, otherVal)
 		modeldecodertest.AssertStructValues(t, out1.Transaction, exceptions, defaultVal)
 	})
@@ -323,7 +323,7 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 		input.Context.Request.Headers.Set(http.Header{"a": []string{"b"}, "c": []string{"d", "e"}})
 		input.Context.Response.Headers.Set(http.Header{"f": []string{"g"}})
 		var out model.APMEvent
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 		assert.Equal(t, map[string]any{"a": []string{"b"}, "c": []string{"d", "e"}}, out.HTTP.Request.Headers)
 		assert.Equal(t, map[string]any{"f": []string{"g"}}, out.HTTP.Response.Headers)
 	})
@@ -336,7 +336,7 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 			"c": "d",
 		})
 		var out model.APMEvent
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 		assert.Equal(t, map[string]interface{}{"a": 123.456, "c": "d"}, out.HTTP.Request.Body)
 	})
 
@@ -344,7 +344,7 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 		var input transaction
 		var out model.APMEvent
 		input.Context.Page.URL.Set("https://my.site.test:9201")
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 		assert.Equal(t, "https://my.site.test:9201", out.URL.Full)
 	})
 
@@ -352,7 +352,7 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 		var input transaction
 		var out model.APMEvent
 		input.Context.Page.Referer.Set("https://my.site.test:9201")
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 		assert.Equal(t, "https://my.site.test:9201", out.HTTP.Request.Referrer)
 	})
 
@@ -363,17 +363,17 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 		input.OTel.Reset()
 		// sample rate is set to > 0
 		input.SampleRate.Set(0.25)
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 		assert.Equal(t, 4.0, out.Transaction.RepresentativeCount)
 		// sample rate is not set -> Representative Count should be 1 by default
 		out.Transaction.RepresentativeCount = 0.0 //reset to zero value
 		input.SampleRate.Reset()
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 		assert.Equal(t, 1.0, out.Transaction.RepresentativeCount)
 		// sample rate is set to 0
 		out.Transaction.RepresentativeCount = 0.0 //reset to zero value
 		input.SampleRate.Set(0)
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 		assert.Equal(t, 0.0, out.Transaction.RepresentativeCount)
 	})
 
@@ -384,22 +384,22 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 		// set from input, ignore status code
 		input.Outcome.Set("failure")
 		input.Context.Response.StatusCode.Set(http.StatusBadRequest)
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 		assert.Equal(t, "failure", out.Event.Outcome)
 		// derive from other fields - success
 		input.Outcome.Reset()
 		input.Context.Response.StatusCode.Set(http.StatusBadRequest)
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 		assert.Equal(t, "success", out.Event.Outcome)
 		// derive from other fields - failure
 		input.Outcome.Reset()
 		input.Context.Response.StatusCode.Set(http.StatusInternalServerError)
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 		assert.Equal(t, "failure", out.Event.Outcome)
 		// derive from other fields - unknown
 		input.Outcome.Reset()
 		input.Context.Response.StatusCode.Reset()
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 		assert.Equal(t, "unknown", out.Event.Outcome)
 	})
 
@@ -408,12 +408,12 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 		var out model.APMEvent
 		modeldecodertest.SetStructValues(&input, modeldecodertest.DefaultValues())
 		input.Session.ID.Reset()
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 		assert.Equal(t, model.Session{}, out.Session)
 
 		input.Session.ID.Set("session_id")
 		input.Session.Sequence.Set(123)
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 		assert.Equal(t, model.Session{
 			ID:       "session_id",
 			Sequence: 123,
@@ -447,7 +447,7 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 			input.OTel.SpanKind.Reset()
 			input.Type.Reset()
 
-			mapToTransactionModel(&input, &event)
+			mapToTransactionModel(&input, nil, &event)
 			assert.Equal(t, expected, event.URL)
 			assert.Equal(t, "SERVER", event.Span.Kind)
 		})
@@ -467,7 +467,7 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 			modeldecodertest.SetStructValues(&input, modeldecodertest.DefaultValues())
 			input.OTel.Attributes = attrs
 			input.OTel.SpanKind.Reset()
-			mapToTransactionModel(&input, &event)
+			mapToTransactionModel(&input, nil, &event)
 
 			require.NotNil(t, event.HTTP)
 			require.NotNil(t, event.HTTP.Request)
@@ -500,7 +500,7 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 			input.OTel.Attributes = attrs
 			input.OTel.SpanKind.Reset()
 
-			mapToTransactionModel(&input, &event)
+			mapToTransactionModel(&input, nil, &event)
 			assert.Equal(t, "request", event.Transaction.Type)
 			assert.Equal(t, "Unavailable", event.Transaction.Result)
 			assert.Equal(t, model.Client{
@@ -522,7 +522,7 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 			input.OTel.Attributes = attrs
 			input.OTel.SpanKind.Reset()
 
-			mapToTransactionModel(&input, &event)
+			mapToTransactionModel(&input, nil, &event)
 			assert.Equal(t, "messaging", event.Transaction.Type)
 			assert.Equal(t, "CONSUMER", event.Span.Kind)
 			assert.Equal(t, &model.Message{
@@ -544,7 +544,7 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 			modeldecodertest.SetStructValues(&input, modeldecodertest.DefaultValues())
 			input.OTel.Attributes = attrs
 			input.OTel.SpanKind.Reset()
-			mapToTransactionModel(&input, &event)
+			mapToTransactionModel(&input, nil, &event)
 
 			expected := model.Network{
 				Connection: model.NetworkConnection{
@@ -573,7 +573,7 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 			input.OTel.Attributes = attrs
 			input.Type.Reset()
 
-			mapToTransactionModel(&input, &event)
+			mapToTransactionModel(&input, nil, &event)
 			assert.Equal(t, model.Labels{}, event.Labels)
 			assert.Equal(t, model.NumericLabels{"double_attr": {Value: 123.456}}, event.NumericLabels)
 		})
@@ -584,7 +584,7 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 			modeldecodertest.SetStructValues(&input, modeldecodertest.DefaultValues())
 			input.OTel.SpanKind.Set("CLIENT")
 
-			mapToTransactionModel(&input, &event)
+			mapToTransactionModel(&input, nil, &event)
 			assert.Equal(t, "CLIENT", event.Span.Kind)
 		})
 	})
@@ -597,7 +597,7 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 			"e": true,
 		}
 		var out model.APMEvent
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 		assert.Equal(t, model.Labels{
 			"a": {Value: "b"},
 			"e": {Value: "true"},
@@ -617,7 +617,7 @@ func TestDecodeMapToTransactionModel(t *testing.T) {
 			TraceID: nullable.String{Val: "trace2"},
 		}}
 		var out model.APMEvent
-		mapToTransactionModel(&input, &out)
+		mapToTransactionModel(&input, nil, &out)
 
 		assert.Equal(t, []model.SpanLink{{
 			Span:  model.Span{ID: "span1"},
@@ -23,13 +23,17 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"net/netip"
 	"sync"
+	"time"
 
 	"go.elastic.co/apm/v2"
 	"go.uber.org/zap"
 
 	"github.com/elastic/apm-data/input/elasticapm/internal/decoder"
 	"github.com/elastic/apm-data/input/elasticapm/internal/modeldecoder"
+	"github.com/elastic/apm-data/input/elasticapm/internal/modeldecoder/netutil"
 	"github.com/elastic/apm-data/input/elasticapm/internal/modeldecoder/rumv3"
 	v2 "github.com/elastic/apm-data/input/elasticapm/internal/modeldecoder/v2"
 	"github.com/elastic/apm-data/model"
@@ -41,6 +45,10 @@ var (
 	// ErrQueueFull may be returned by HandleStream when the internal
 	// queue is full.
 	ErrQueueFull = errors.New("queue is full")
+
+	// ErrRateLimited may be returned by HandleStream when the configured
+	// RateLimiter does not allow a batch of events to be processed.
+	ErrRateLimited = errors.New("rate limit exceeded")
 )
 
 const (
@@ -54,6 +62,8 @@ const (
 
 	v2MetadataKey    = "metadata"
 	rumv3MetadataKey = "m"
+
+	defaultMaxErrorDocumentSize = 4 * 1024
 )
 
 // Processor decodes a streams and is safe for concurrent use. The processor
@@ -67,6 +77,24 @@ type Processor struct {
 	sem              chan struct{}
 	logger           *zap.Logger
 	MaxEventSize     int
+
+	maxEventsPerStream     int
+	maxStreamSize          int
+	maxSpansPerTransaction int
+	maxLabelsPerEvent      int
+	rateLimiter            RateLimiter
+	rateLimitKey           func(*model.APMEvent) string
+	streamingDecode        bool
+	maxErrorDocumentSize   int
+	clientAddrResolver     *netutil.ClientAddrResolver
+}
+
+// RateLimiter is an interface for token-bucket rate limiters, keyed
+// by a caller-supplied key such as a service name or client IP.
+type RateLimiter interface {
+	// AllowN reports whether n events may happen at time now for key,
+	// consuming the tokens if so.
+	AllowN(key string, now time.Time, n int) bool
 }
 
 // Config holds configuration for Processor constructors.
@@ -74,6 +102,66 @@ type Config struct {
 	// MaxEventSize holds the maximum event size, in bytes.
 	MaxEventSize int
 
+	// StreamingDecode, if true, decodes events directly from the stream
+	// rather than buffering each event in full before decoding it. This
+	// keeps memory usage per stream independent of MaxEventSize, which is
+	// then enforced incrementally as each event is decoded.
+	StreamingDecode bool
+
+	// MaxErrorDocumentSize holds the maximum size, in bytes, of the
+	// documents recorded in errors when StreamingDecode is true. If
+	// MaxErrorDocumentSize is zero, a default of 4KiB will be used.
+	MaxErrorDocumentSize int
+
+	// MaxEventsPerStream holds the maximum number of events accepted
+	// from a single stream, excluding metadata. Events beyond the limit
+	// are rejected with a LimitExceededError. Zero means no limit.
+	MaxEventsPerStream int
+
+	// MaxStreamSize holds the maximum total size of a stream, in bytes,
+	// including metadata and excluding line delimiters. Reading stops
+	// at the first event exceeding the limit, which is rejected with a
+	// LimitExceededError and returned by HandleStream. Zero means no limit.
+	MaxStreamSize int
+
+	// MaxSpansPerTransaction holds the maximum number of spans accepted
+	// for a single transaction within a stream. Spans beyond the limit
+	// are rejected with a LimitExceededError. Zero means no limit.
+	MaxSpansPerTransaction int
+
+	// MaxLabelsPerEvent holds the maximum number of distinct label keys,
+	// including numeric labels and global labels from metadata, that an
+	// event may have. Events with more labels are rejected with a
+	// LimitExceededError. Zero means no limit.
+	MaxLabelsPerEvent int
+
+	// RateLimiter holds an optional RateLimiter, which HandleStream
+	// will consult for each batch of decoded events. If the limiter
+	// does not allow the batch, HandleStream returns ErrRateLimited.
+	// If RateLimiter is nil, then no rate limiting will be performed.
+	RateLimiter RateLimiter
+
+	// RateLimitKey returns the RateLimiter key for a stream, given
+	// the base event with the stream's metadata decoded into it.
+	// If RateLimitKey is nil, the service name will be used.
+	RateLimitKey func(*model.APMEvent) string
+
+	// TrustedProxies holds the networks of proxies trusted to set the
+	// forwarding headers of requests captured by backend agents. If
+	// TrustedProxies is non-empty, the client address is resolved by
+	// walking the forwarding chain from the right, stopping at the first
+	// address which is not in TrustedProxies, and the trusted proxies are
+	// recorded in http.request.proxies. If TrustedProxies is empty, the
+	// first address in the forwarding headers is used, which may have
+	// been set by the client.
+	TrustedProxies []netip.Prefix
+
+	// ClientAddrHeaders holds the forwarding headers from which client
+	// addresses are resolved when TrustedProxies is non-empty, in order
+	// of precedence. If ClientAddrHeaders is empty, the Forwarded,
+	// X-Real-IP, and X-Forwarded-For headers are used, in that order.
+	ClientAddrHeaders []string
+
 	// Semaphore holds a channel to which Processor.HandleStream
 	// will send an item before proceeding, to limit concurrency.
 	Semaphore chan struct{}
@@ -89,37 +177,87 @@ func NewProcessor(cfg Config) *Processor {
 	if cfg.Logger == nil {
 		cfg.Logger = zap.NewNop()
 	}
+	if cfg.MaxErrorDocumentSize <= 0 {
+		cfg.MaxErrorDocumentSize = defaultMaxErrorDocumentSize
+	}
+	if cfg.RateLimitKey == nil {
+		cfg.RateLimitKey = serviceNameRateLimitKey
+	}
+	var clientAddrResolver *netutil.ClientAddrResolver
+	if len(cfg.TrustedProxies) > 0 {
+		clientAddrResolver = &netutil.ClientAddrResolver{
+			TrustedProxies: cfg.TrustedProxies,
+			Headers:        cfg.ClientAddrHeaders,
+		}
+	}
 	return &Processor{
-		MaxEventSize: cfg.MaxEventSize,
-		sem:          cfg.Semaphore,
-		logger:       cfg.Logger,
+		MaxEventSize:           cfg.MaxEventSize,
+		maxEventsPerStream:     cfg.MaxEventsPerStream,
+		maxStreamSize:          cfg.MaxStreamSize,
+		maxSpansPerTransaction: cfg.MaxSpansPerTransaction,
+		maxLabelsPerEvent:      cfg.MaxLabelsPerEvent,
+		rateLimiter:            cfg.RateLimiter,
+		rateLimitKey:           cfg.RateLimitKey,
+		streamingDecode:        cfg.StreamingDecode,
+		maxErrorDocumentSize:   cfg.MaxErrorDocumentSize,
+		clientAddrResolver:     clientAddrResolver,
+		sem:                    cfg.Semaphore,
+		logger:                 cfg.Logger,
 	}
 }
 
-func (p *Processor) readMetadata(reader *streamReader, out *model.APMEvent) error {
+// ClientAddr holds a client address resolved by Processor.ResolveClientAddr.
+type ClientAddr = netutil.ClientAddr
+
+// ResolveClientAddr resolves the address of the client of an intake
+// request received from remoteAddr, with the given headers, according
+// to the processor's TrustedProxies and ClientAddrHeaders config.
+//
+// Events from RUM agents do not report the HTTP request context, so
+// their client address must be resolved from the intake request itself.
+// Callers may use ResolveClientAddr to do so, recording the result in
+// the base event given to HandleStream.
+func (p *Processor) ResolveClientAddr(header http.Header, remoteAddr netip.AddrPort) ClientAddr {
+	return p.clientAddrResolver.Resolve(header, remoteAddr.Addr(), remoteAddr.Port())
+}
+
+// readMetadata reads and decodes the metadata line of a stream into out.
+func readMetadata(reader *streamReader, out *model.APMEvent) error {
 	body, err := reader.ReadAhead()
 	if err != nil {
 		if err == io.EOF {
 			return &InvalidInputError{
 				Message:  "EOF while reading metadata",
 				Document: string(reader.LatestLine()),
+				Line:     reader.line,
 			}
 		}
 		return reader.wrapError(err)
 	}
-	switch key := p.identifyEventType(body); string(key) {
+	switch key := identifyEventType(body); string(key) {
 	case v2MetadataKey:
 		if err := v2.DecodeNestedMetadata(reader, out); err != nil {
-			return reader.wrapError(err)
+			return reader.invalidInputError(reader.wrapError(err), string(key))
 		}
 	case rumv3MetadataKey:
 		if err := rumv3.DecodeNestedMetadata(reader, out); err != nil {
-			return reader.wrapError(err)
+			return reader.invalidInputError(reader.wrapError(err), string(key))
 		}
 	default:
 		return &InvalidInputError{
-			Message:  fmt.Sprintf("%q or %q required", v2MetadataKey, rumv3MetadataKey),
+			Message:   fmt.Sprintf("%q or %q required", v2MetadataKey, rumv3MetadataKey),
+			Document:  string(reader.LatestLine()),
+			Line:      reader.line,
+			EventType: string(key),
+		}
+	}
+	if reader.IsEOF() {
+		// When decoding directly from the stream, the end of
+		// the stream is only detected once metadata is decoded.
+		return &InvalidInputError{
+			Message:  "EOF while reading metadata",
 			Document: string(reader.LatestLine()),
+			Line:     reader.line,
 		}
 	}
 	return nil
@@ -131,7 +269,7 @@ func (p *Processor) readMetadata(reader *streamReader, out *model.APMEvent) erro
 // - the input is in JSON format
 // - every valid ndjson line only has one root key
 // - the bytes that we must match on are ASCII
-func (p *Processor) identifyEventType(body []byte) []byte {
+func identifyEventType(body []byte) []byte {
 	// find event type, trim spaces and account for single and double quotes
 	var quote byte
 	var key []byte
@@ -166,6 +304,11 @@ func (p *Processor) readBatch(
 	origLen := len(*batch)
 	for i := 0; i < batchSize && !reader.IsEOF(); i++ {
 		body, err := reader.ReadAhead()
+		// Lines which exceed the maximum event size still count
+		// towards the stream size, including any skipped remainder.
+		if err := p.checkStreamSize(reader, result); err != nil {
+			return len(*batch) - origLen, err
+		}
 		if err != nil && err != io.EOF {
 			err := reader.wrapError(err)
 			var invalidInput *InvalidInputError
@@ -180,33 +323,35 @@ func (p *Processor) readBatch(
 			// required for backwards compatibility - sending empty lines was permitted in previous versions
 			continue
 		}
+		reader.events++
+		if p.maxEventsPerStream > 0 && reader.events > p.maxEventsPerStream {
+			result.addError(&LimitExceededError{
+				Limit:    LimitEventsPerStream,
+				Message:  fmt.Sprintf("stream exceeded the permitted number of %d events", p.maxEventsPerStream),
+				Document: string(reader.LatestLine()),
+				Line:     reader.line,
+			})
+			continue
+		}
 		// We copy the event for each iteration of the batch, as to avoid
 		// shallow copies of Labels and NumericLabels.
-		input := modeldecoder.Input{Base: copyEvent(baseEvent)}
-		switch eventType := p.identifyEventType(body); string(eventType) {
-		case errorEventType:
-			err = v2.DecodeNestedError(reader, &input, batch)
-		case metricsetEventType:
-			err = v2.DecodeNestedMetricset(reader, &input, batch)
-		case spanEventType:
-			err = v2.DecodeNestedSpan(reader, &input, batch)
-		case transactionEventType:
-			err = v2.DecodeNestedTransaction(reader, &input, batch)
-		case logEventType:
-			err = v2.DecodeNestedLog(reader, &input, batch)
-		case rumv3ErrorEventType:
-			err = rumv3.DecodeNestedError(reader, &input, batch)
-		case rumv3TransactionEventType:
-			err = rumv3.DecodeNestedTransaction(reader, &input, batch)
-		default:
-			err = fmt.Errorf("%w: %q", errUnrecognizedObject, eventType)
+		input := modeldecoder.Input{
+			Base:               copyEvent(baseEvent),
+			ClientAddrResolver: p.clientAddrResolver,
 		}
+		decodedLen := len(*batch)
+		eventType := identifyEventType(body)
+		err = decodeEvent(reader, eventType, &input, batch)
 		if err != nil && err != io.EOF {
-			result.addError(&InvalidInputError{
-				Message:  err.Error(),
-				Document: string(reader.LatestLine()),
-			})
+			result.addError(reader.invalidInputError(reader.wrapError(err), string(eventType)))
 		}
+		// When decoding directly from the stream, the full size
+		// of the event is only known once it has been decoded.
+		if err := p.checkStreamSize(reader, result); err != nil {
+			*batch = (*batch)[:decodedLen]
+			return len(*batch) - origLen, err
+		}
+		p.enforceEventLimits(batch, decodedLen, reader, result)
 	}
 	if reader.IsEOF() {
 		return len(*batch) - origLen, io.EOF
@@ -214,12 +359,103 @@ func (p *Processor) readBatch(
 	return len(*batch) - origLen, nil
 }
 
+// decodeEvent decodes the latest line read from reader, with the root
+// key eventType, appending the resulting events to batch.
+func decodeEvent(reader *streamReader, eventType []byte, input *modeldecoder.Input, batch *model.Batch) error {
+	switch string(eventType) {
+	case errorEventType:
+		return v2.DecodeNestedError(reader, input, batch)
+	case metricsetEventType:
+		return v2.DecodeNestedMetricset(reader, input, batch)
+	case spanEventType:
+		return v2.DecodeNestedSpan(reader, input, batch)
+	case transactionEventType:
+		return v2.DecodeNestedTransaction(reader, input, batch)
+	case logEventType:
+		return v2.DecodeNestedLog(reader, input, batch)
+	case rumv3ErrorEventType:
+		return rumv3.DecodeNestedError(reader, input, batch)
+	case rumv3TransactionEventType:
+		return rumv3.DecodeNestedTransaction(reader, input, batch)
+	default:
+		return fmt.Errorf("%w: %q", errUnrecognizedObject, eventType)
+	}
+}
+
+// checkStreamSize returns a LimitExceededError, and records it in result,
+// if the stream has exceeded the maximum stream size.
+func (p *Processor) checkStreamSize(reader *streamReader, result *Result) error {
+	if p.maxStreamSize <= 0 || reader.BytesRead() <= p.maxStreamSize {
+		return nil
+	}
+	err := &LimitExceededError{
+		Limit:    LimitStreamSize,
+		Message:  fmt.Sprintf("stream exceeded the permitted size of %d bytes", p.maxStreamSize),
+		Document: string(reader.LatestLine()),
+		Line:     reader.line,
+	}
+	result.addError(err)
+	return err
+}
+
+// enforceEventLimits removes events decoded into (*batch)[from:] which
+// exceed the per-event or per-transaction limits, recording an error in
+// result for each one.
+func (p *Processor) enforceEventLimits(batch *model.Batch, from int, reader *streamReader, result *Result) {
+	if p.maxLabelsPerEvent <= 0 && p.maxSpansPerTransaction <= 0 {
+		return
+	}
+	accepted := (*batch)[:from]
+	for _, event := range (*batch)[from:] {
+		if err := p.checkEventLimits(&event, reader); err != nil {
+			result.addError(err)
+			continue
+		}
+		accepted = append(accepted, event)
+	}
+	*batch = accepted
+}
+
+func (p *Processor) checkEventLimits(event *model.APMEvent, reader *streamReader) error {
+	if p.maxLabelsPerEvent > 0 {
+		if n := len(event.Labels) + len(event.NumericLabels); n > p.maxLabelsPerEvent {
+			return &LimitExceededError{
+				Limit:    LimitLabelsPerEvent,
+				Message:  fmt.Sprintf("event has %d labels, exceeding the permitted number of %d", n, p.maxLabelsPerEvent),
+				Document: string(reader.LatestLine()),
+				Line:     reader.line,
+			}
+		}
+	}
+	if p.maxSpansPerTransaction > 0 && event.Processor == model.SpanProcessor {
+		if event.Transaction != nil && event.Transaction.ID != "" {
+			if reader.spansPerTransaction == nil {
+				reader.spansPerTransaction = make(map[string]int)
+			}
+			reader.spansPerTransaction[event.Transaction.ID]++
+			if reader.spansPerTransaction[event.Transaction.ID] > p.maxSpansPerTransaction {
+				return &LimitExceededError{
+					Limit: LimitSpansPerTransaction,
+					Message: fmt.Sprintf(
+						"transaction %q exceeded the permitted number of %d spans",
+						event.Transaction.ID, p.maxSpansPerTransaction,
+					),
+					Document: string(reader.LatestLine()),
+					Line:     reader.line,
+				}
+			}
+		}
+	}
+	return nil
+}
+
 // HandleStream processes a stream of events in batches of batchSize at a time,
 // updating result as events are accepted, or per-event errors occur.
 //
 // HandleStream will return an error when a terminal stream-level error occurs,
-// such as the rate limit being exceeded, or due to authorization errors. In
-// this case the result will only cover the subset of events accepted.
+// such as the rate limit (ErrRateLimited) or the stream size limit being exceeded, or due to
+// authorization errors. In this case the result will only cover the subset
+// of events accepted.
 //
 // Callers must not access result concurrently with HandleStream.
 func (p *Processor) HandleStream(
@@ -257,7 +493,7 @@ func (p *Processor) HandleStream(
 	}()
 
 	// The first item is the metadata object.
-	if err := p.readMetadata(sr, &baseEvent); err != nil {
+	if err := readMetadata(sr, &baseEvent); err != nil {
 		// no point in continuing if we couldn't read the metadata
 		if _, ok := err.(*InvalidInputError); ok {
 			return err
@@ -265,9 +501,15 @@ func (p *Processor) HandleStream(
 		return &InvalidInputError{
 			Message:  err.Error(),
 			Document: string(sr.LatestLine()),
+			Line:     sr.line,
 		}
 	}
 
+	var rateLimitKey string
+	if p.rateLimiter != nil {
+		rateLimitKey = p.rateLimitKey(&baseEvent)
+	}
+
 	sp, ctx := apm.StartSpan(ctx, "Stream", "Reporter")
 	defer sp.End()
 
@@ -277,7 +519,7 @@ func (p *Processor) HandleStream(
 	}
 	first := true
 	for {
-		err := p.handleStream(ctx, async, baseEvent, batchSize, sr, processor, result, first)
+		err := p.handleStream(ctx, async, baseEvent, batchSize, sr, processor, result, first, rateLimitKey)
 		if err != nil {
 			if errors.Is(err, io.EOF) {
 				return nil
@@ -299,6 +541,7 @@ func (p *Processor) handleStream(
 	processor model.BatchProcessor,
 	result *Result,
 	first bool,
+	rateLimitKey string,
 ) (readErr error) {
 	// Async requests will re-aquire the semaphore if it has more events than
 	// `batchSize`. In that event, the semaphore will be acquired again. If
@@ -330,6 +573,14 @@ func (p *Processor) handleStream(
 		p.batchPool.Put(&batch)
 		return readErr
 	}
+	if p.rateLimiter != nil && !p.rateLimiter.AllowN(rateLimitKey, time.Now(), n) {
+		p.batchPool.Put(&batch)
+		result.RateLimited += n
+		// Reset n so the semaphore is released for asynchronous requests,
+		// as the batch will not be processed.
+		n = 0
+		return ErrRateLimited
+	}
 	// Async requests are processed in the background and once the batch has
 	// been processed, the semaphore is released.
 	if async {
@@ -360,10 +611,13 @@ func (p *Processor) getStreamReader(r io.Reader) *streamReader {
 		sr.Reset(r)
 		return sr
 	}
-	return &streamReader{
-		processor:           p,
-		NDJSONStreamDecoder: decoder.NewNDJSONStreamDecoder(r, p.MaxEventSize),
+	var dec streamDecoder
+	if p.streamingDecode {
+		dec = decoder.NewStreamingNDJSONDecoder(r, p.MaxEventSize, p.maxErrorDocumentSize)
+	} else {
+		dec = decoder.NewNDJSONStreamDecoder(r, p.MaxEventSize)
 	}
+	return &streamReader{processor: p, streamDecoder: dec}
 }
 
 func (p *Processor) semAcquire(ctx context.Context, async bool) error {
@@ -384,24 +638,75 @@ func (p *Processor) semAcquire(ctx context.Context, async bool) error {
 
 func (p *Processor) semRelease() { <-p.sem }
 
-// streamReader wraps NDJSONStreamReader, converting errors to stream errors.
+// streamDecoder is implemented by decoder.NDJSONStreamDecoder and
+// decoder.StreamingNDJSONDecoder.
+type streamDecoder interface {
+	decoder.Decoder
+	ReadAhead() ([]byte, error)
+	LatestLine() []byte
+	IsEOF() bool
+	BytesRead() int
+	Reset(io.Reader)
+}
+
+// streamReader wraps a streamDecoder, converting errors to stream errors.
 type streamReader struct {
 	processor *Processor
-	*decoder.NDJSONStreamDecoder
+	streamDecoder
+
+	// line holds the 1-based number of the latest line read.
+	line int
+
+	// events and spansPerTransaction track the stream's
+	// usage of the per-stream limits.
+	events              int
+	spansPerTransaction map[string]int
 }
 
 // release releases the streamReader, adding it to its Processor's sync.Pool.
 // The streamReader must not be used after release returns.
 func (sr *streamReader) release() {
 	sr.Reset(nil)
+	sr.line = 0
+	sr.events = 0
+	for k := range sr.spansPerTransaction {
+		delete(sr.spansPerTransaction, k)
+	}
 	sr.processor.streamReaderPool.Put(sr)
 }
 
+// ReadAhead reads the next line, incrementing the line number.
+func (sr *streamReader) ReadAhead() ([]byte, error) {
+	sr.line++
+	return sr.streamDecoder.ReadAhead()
+}
+
+// invalidInputError returns an InvalidInputError describing err, which
+// occurred while decoding the latest line with the root key eventType.
+func (sr *streamReader) invalidInputError(err error, eventType string) *InvalidInputError {
+	invalidInput, ok := err.(*InvalidInputError)
+	if !ok {
+		invalidInput = &InvalidInputError{
+			Message:  err.Error(),
+			Document: string(sr.LatestLine()),
+			Line:     sr.line,
+		}
+	}
+	invalidInput.EventType = eventType
+	var validationErr modeldecoder.ValidationError
+	if errors.As(err, &validationErr) {
+		invalidInput.Field = validationErr.Path()
+		invalidInput.Rule = validationErr.Rule()
+	}
+	return invalidInput
+}
+
 func (sr *streamReader) wrapError(err error) error {
 	if _, ok := err.(decoder.JSONDecodeError); ok {
 		return &InvalidInputError{
 			Message:  err.Error(),
 			Document: string(sr.LatestLine()),
+			Line:     sr.line,
 		}
 	}
 
@@ -414,11 +719,18 @@ func (sr *streamReader) wrapError(err error) error {
 			TooLarge: true,
 			Message:  "event exceeded the permitted size",
 			Document: string(sr.LatestLine()),
+			Line:     sr.line,
 		}
 	}
 	return err
 }
 
+// serviceNameRateLimitKey is the default Config.RateLimitKey,
+// keying rate limits by the stream's service name.
+func serviceNameRateLimitKey(event *model.APMEvent) string {
+	return event.Service.Name
+}
+
 // copyEvent returns a shallow copy of the APMEvent with a deep copy of the
 // labels and numeric labels.
 func copyEvent(e model.APMEvent) model.APMEvent {
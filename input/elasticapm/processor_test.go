@@ -21,7 +21,12 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
 	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -124,8 +129,10 @@ func TestHandleStreamErrors(t *testing.T) {
 		invalid: 1,
 		errors: []error{
 			&InvalidInputError{
-				Message:  `decode error: data read error: v2.transactionRoot.Transaction: v2.transaction.ID: ReadString: expects " or n,`,
-				Document: invalidEvent,
+				Message:   `decode error: data read error: v2.transactionRoot.Transaction: v2.transaction.ID: ReadString: expects " or n,`,
+				Document:  invalidEvent,
+				Line:      2,
+				EventType: "transaction",
 			},
 		},
 	}, {
@@ -134,30 +141,40 @@ func TestHandleStreamErrors(t *testing.T) {
 		invalid: 1,
 		errors: []error{
 			&InvalidInputError{
-				Message:  `did not recognize object type: "invalid-json"`,
-				Document: invalidJSONEvent,
+				Message:   `did not recognize object type: "invalid-json"`,
+				Document:  invalidJSONEvent,
+				Line:      2,
+				EventType: "invalid-json",
 			},
 		},
 	}, {
 		name:    "InvalidJSONMetadata",
 		payload: invalidJSONMetadata + "\n",
 		err: &InvalidInputError{
-			Message:  "decode error: data read error: v2.metadataRoot.Metadata: v2.metadata.readFieldHash: expect :,",
-			Document: invalidJSONMetadata,
+			Message:   "decode error: data read error: v2.metadataRoot.Metadata: v2.metadata.readFieldHash: expect :,",
+			Document:  invalidJSONMetadata,
+			Line:      1,
+			EventType: "metadata",
 		},
 	}, {
 		name:    "InvalidMetadata",
 		payload: invalidMetadata + "\n",
 		err: &InvalidInputError{
-			Message:  "validation error: 'metadata' required",
-			Document: invalidMetadata,
+			Message:   "validation error: 'metadata' required",
+			Document:  invalidMetadata,
+			Line:      1,
+			EventType: "metadata",
+			Field:     "metadata",
+			Rule:      "required",
 		},
 	}, {
 		name:    "InvalidMetadata2",
 		payload: invalidMetadata2 + "\n",
 		err: &InvalidInputError{
-			Message:  `"metadata" or "m" required`,
-			Document: invalidMetadata2,
+			Message:   `"metadata" or "m" required`,
+			Document:  invalidMetadata2,
+			Line:      1,
+			EventType: "not",
 		},
 	}, {
 		name:    "UnrecognizedEvent",
@@ -165,8 +182,10 @@ func TestHandleStreamErrors(t *testing.T) {
 		invalid: 1,
 		errors: []error{
 			&InvalidInputError{
-				Message:  `did not recognize object type: "tennis-court"`,
-				Document: invalidEventType,
+				Message:   `did not recognize object type: "tennis-court"`,
+				Document:  invalidEventType,
+				Line:      2,
+				EventType: "tennis-court",
 			},
 		},
 	}, {
@@ -178,6 +197,7 @@ func TestHandleStreamErrors(t *testing.T) {
 				TooLarge: true,
 				Message:  "event exceeded the permitted size",
 				Document: tooLargeEvent[:len(validMetadata)+1],
+				Line:     2,
 			},
 		},
 	}} {
@@ -201,6 +221,182 @@ func TestHandleStreamErrors(t *testing.T) {
 	}
 }
 
+func TestHandleStreamCollectAllErrors(t *testing.T) {
+	const invalidError = `{"error": {"id": "cdefab0123456789"}}`
+	payload := []string{validMetadata}
+	var expected []error
+	for i := 0; i < 7; i++ {
+		payload = append(payload, invalidError)
+		expected = append(expected, &InvalidInputError{
+			Message:   "validation error: error: requires at least one of the fields 'exception;log'",
+			Document:  invalidError,
+			Line:      i + 2,
+			EventType: "error",
+			Field:     "error",
+			Rule:      "requiredAnyOf(exception;log)",
+		})
+	}
+
+	result := Result{CollectAllErrors: true}
+	p := NewProcessor(Config{
+		MaxEventSize: 100 * 1024,
+		Semaphore:    make(chan struct{}, 1),
+	})
+	err := p.HandleStream(
+		context.Background(), false, model.APMEvent{},
+		strings.NewReader(strings.Join(payload, "\n")), 10,
+		nopBatchProcessor{}, &result,
+	)
+	require.NoError(t, err)
+	assert.Equal(t, expected, result.Errors)
+	assert.Equal(t, 7, result.Invalid)
+}
+
+func TestHandleStreamLimits(t *testing.T) {
+	var (
+		metadata     = `{"metadata": {"service": {"name": "svc", "agent": {"name": "go", "version": "1.0"}}}}`
+		error1       = `{"error": {"id": "cdefab0123456789", "exception": {"message": "boom"}}}`
+		error2       = `{"error": {"id": "cdefab0123456780", "exception": {"message": "boom"}}}`
+		labelledErr  = `{"error": {"id": "cdefab0123456789", "exception": {"message": "boom"}, "context": {"tags": {"a": "1", "b": "2", "c": 3}}}}`
+		span1        = `{"span": {"id": "0000000000000001", "trace_id": "0123456789abcdef0123456789abcdef", "parent_id": "abcdef0123456789", "transaction_id": "abcdef0123456789", "name": "s", "type": "db", "start": 0, "duration": 1}}`
+		span2        = `{"span": {"id": "0000000000000002", "trace_id": "0123456789abcdef0123456789abcdef", "parent_id": "abcdef0123456789", "transaction_id": "abcdef0123456789", "name": "s", "type": "db", "start": 0, "duration": 1}}`
+		otherTxSpan  = `{"span": {"id": "0000000000000003", "trace_id": "0123456789abcdef0123456789abcdef", "parent_id": "abcdef0123456780", "transaction_id": "abcdef0123456780", "name": "s", "type": "db", "start": 0, "duration": 1}}`
+		streamPrefix = metadata + "\n" + error1 + "\n"
+	)
+
+	for _, test := range []struct {
+		name     string
+		config   Config
+		payload  []string
+		accepted int
+		errors   []error // per-event errors
+		err      error   // stream-level error
+	}{{
+		name:     "MaxEventsPerStream",
+		config:   Config{MaxEventsPerStream: 1},
+		payload:  []string{metadata, error1, error2},
+		accepted: 1,
+		errors: []error{&LimitExceededError{
+			Limit:    LimitEventsPerStream,
+			Message:  "stream exceeded the permitted number of 1 events",
+			Document: error2,
+			Line:     3,
+		}},
+	}, {
+		name:     "MaxStreamSize",
+		config:   Config{MaxStreamSize: len(streamPrefix)},
+		payload:  []string{metadata, error1, error2, error1},
+		accepted: 1,
+		err: &LimitExceededError{
+			Limit:    LimitStreamSize,
+			Message:  fmt.Sprintf("stream exceeded the permitted size of %d bytes", len(streamPrefix)),
+			Document: error2,
+			Line:     3,
+		},
+		errors: []error{&LimitExceededError{
+			Limit:    LimitStreamSize,
+			Message:  fmt.Sprintf("stream exceeded the permitted size of %d bytes", len(streamPrefix)),
+			Document: error2,
+			Line:     3,
+		}},
+	}, {
+		name:     "MaxSpansPerTransaction",
+		config:   Config{MaxSpansPerTransaction: 1},
+		payload:  []string{metadata, span1, otherTxSpan, span2},
+		accepted: 2,
+		errors: []error{&LimitExceededError{
+			Limit:    LimitSpansPerTransaction,
+			Message:  `transaction "abcdef0123456789" exceeded the permitted number of 1 spans`,
+			Document: span2,
+			Line:     4,
+		}},
+	}, {
+		name:     "MaxLabelsPerEvent",
+		config:   Config{MaxLabelsPerEvent: 2},
+		payload:  []string{metadata, error1, labelledErr},
+		accepted: 1,
+		errors: []error{&LimitExceededError{
+			Limit:    LimitLabelsPerEvent,
+			Message:  "event has 3 labels, exceeding the permitted number of 2",
+			Document: labelledErr,
+			Line:     3,
+		}},
+	}} {
+		t.Run(test.name, func(t *testing.T) {
+			var actualResult Result
+			test.config.MaxEventSize = 100 * 1024
+			test.config.Semaphore = make(chan struct{}, 1)
+			p := NewProcessor(test.config)
+			err := p.HandleStream(
+				context.Background(), false, model.APMEvent{},
+				strings.NewReader(strings.Join(test.payload, "\n")+"\n"), 10,
+				nopBatchProcessor{}, &actualResult,
+			)
+			assert.Equal(t, test.err, err)
+			assert.Equal(t, test.accepted, actualResult.Accepted)
+			assert.Equal(t, test.errors, actualResult.Errors)
+			assert.Equal(t, len(test.errors), actualResult.LimitExceeded)
+			assert.Zero(t, actualResult.Invalid)
+		})
+	}
+}
+
+func TestHandleStreamRateLimited(t *testing.T) {
+	payload, err := os.ReadFile("internal/modeldecoder/v2/testdata/ratelimit.ndjson")
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		name        string
+		tokens      int
+		key         func(*model.APMEvent) string
+		expectedKey string
+		accepted    int
+		rateLimited int
+		err         error
+	}{{
+		name:        "NotLimited",
+		tokens:      100,
+		expectedKey: "1234_service-12a3",
+		accepted:    19,
+	}, {
+		name:        "LimitedAfterFirstBatch",
+		tokens:      15,
+		expectedKey: "1234_service-12a3",
+		accepted:    10,
+		rateLimited: 9,
+		err:         ErrRateLimited,
+	}, {
+		name:   "ClientIP",
+		tokens: 100,
+		key: func(event *model.APMEvent) string {
+			return event.Client.IP.String()
+		},
+		expectedKey: "10.1.2.3",
+		accepted:    19,
+	}} {
+		t.Run(test.name, func(t *testing.T) {
+			limiter := &tokenBucket{tokens: test.tokens}
+			p := NewProcessor(Config{
+				MaxEventSize: 100 * 1024,
+				Semaphore:    make(chan struct{}, 1),
+				RateLimiter:  limiter,
+				RateLimitKey: test.key,
+			})
+			var result Result
+			baseEvent := model.APMEvent{Client: model.Client{IP: netip.MustParseAddr("10.1.2.3")}}
+			err := p.HandleStream(
+				context.Background(), false, baseEvent,
+				bytes.NewReader(payload), 10, nopBatchProcessor{}, &result,
+			)
+			assert.Equal(t, test.err, err)
+			assert.Equal(t, test.accepted, result.Accepted)
+			assert.Equal(t, test.rateLimited, result.RateLimited)
+			assert.Equal(t, []string{test.expectedKey}, limiter.keys)
+			assert.Len(t, p.sem, 0)
+		})
+	}
+}
+
 func TestHandleStream(t *testing.T) {
 	var events []model.APMEvent
 	batchProcessor := model.ProcessBatchFunc(func(ctx context.Context, batch *model.Batch) error {
@@ -242,6 +438,152 @@ func TestHandleStream(t *testing.T) {
 	}, processors)
 }
 
+func TestHandleStreamStreamingDecode(t *testing.T) {
+	files, err := filepath.Glob("internal/modeldecoder/*/testdata/*.ndjson")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	// Metricset samples are decoded from a map, in no particular order.
+	sortMetricsetSamples := func(events []model.APMEvent) {
+		for _, event := range events {
+			if event.Metricset != nil {
+				samples := event.Metricset.Samples
+				sort.Slice(samples, func(i, j int) bool {
+					return samples[i].Name < samples[j].Name
+				})
+			}
+		}
+	}
+
+	handleStream := func(t *testing.T, cfg Config, payload []byte) ([]model.APMEvent, Result, error) {
+		var events []model.APMEvent
+		batchProcessor := model.ProcessBatchFunc(func(ctx context.Context, batch *model.Batch) error {
+			events = append(events, (*batch)...)
+			return nil
+		})
+		cfg.MaxEventSize = 300 * 1024
+		cfg.Semaphore = make(chan struct{}, 1)
+		var result Result
+		baseEvent := model.APMEvent{Timestamp: time.Unix(123, 0).UTC()}
+		err := NewProcessor(cfg).HandleStream(
+			context.Background(), false, baseEvent,
+			bytes.NewReader(payload), 10, batchProcessor, &result,
+		)
+		return events, result, err
+	}
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			payload, err := os.ReadFile(file)
+			require.NoError(t, err)
+
+			expectedEvents, expectedResult, expectedErr := handleStream(t, Config{}, payload)
+			events, result, err := handleStream(t, Config{
+				StreamingDecode:      true,
+				MaxErrorDocumentSize: 64,
+			}, payload)
+			assert.Equal(t, expectedErr == nil, err == nil)
+			sortMetricsetSamples(expectedEvents)
+			sortMetricsetSamples(events)
+			assert.Equal(t, expectedEvents, events)
+			assert.Equal(t, expectedResult.Accepted, result.Accepted)
+			assert.Equal(t, expectedResult.Invalid, result.Invalid)
+			assert.Equal(t, expectedResult.TooLarge, result.TooLarge)
+			for _, err := range result.Errors {
+				var invalidInput *InvalidInputError
+				if errors.As(err, &invalidInput) {
+					assert.LessOrEqual(t, len(invalidInput.Document), 64)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleStreamStreamingDecodeTooLarge(t *testing.T) {
+	tooLargeEvent := `{"error": {"id": "cdefab0123456789", "exception": {"message": "` + strings.Repeat("x", len(validMetadata)) + `"}}}`
+	payload := strings.Join([]string{validMetadata, tooLargeEvent, validError, ""}, "\n")
+
+	var result Result
+	p := NewProcessor(Config{
+		MaxEventSize:         len(validMetadata) + 1,
+		Semaphore:            make(chan struct{}, 1),
+		StreamingDecode:      true,
+		MaxErrorDocumentSize: 32,
+	})
+	err := p.HandleStream(
+		context.Background(), false, model.APMEvent{},
+		strings.NewReader(payload), 10, nopBatchProcessor{}, &result,
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Accepted)
+	assert.Equal(t, 1, result.TooLarge)
+	assert.Equal(t, []error{&InvalidInputError{
+		TooLarge:  true,
+		Message:   "event exceeded the permitted size",
+		Document:  tooLargeEvent[:32],
+		Line:      2,
+		EventType: "error",
+	}}, result.Errors)
+}
+
+func TestHandleStreamStreamingDecodeSmallErrorDocuments(t *testing.T) {
+	// The event type must be identified regardless of the maximum
+	// size of documents recorded in errors.
+	payload := strings.Join([]string{validMetadata, validTransaction, ""}, "\n")
+	for _, size := range []int{1, 8, 12} {
+		var result Result
+		p := NewProcessor(Config{
+			MaxEventSize:         100 * 1024,
+			Semaphore:            make(chan struct{}, 1),
+			StreamingDecode:      true,
+			MaxErrorDocumentSize: size,
+		})
+		err := p.HandleStream(
+			context.Background(), false, model.APMEvent{},
+			strings.NewReader(payload), 10, nopBatchProcessor{}, &result,
+		)
+		require.NoError(t, err, size)
+		assert.Equal(t, 1, result.Accepted, size)
+		assert.Empty(t, result.Errors, size)
+	}
+}
+
+func TestHandleStreamTooLargeLinesStreamSize(t *testing.T) {
+	// Lines exceeding the maximum event size are skipped, but their
+	// full size must still count towards the maximum stream size.
+	tooLargeEvent := `{"error": {"id": "cdefab0123456789", "exception": {"message": "` + strings.Repeat("x", 5000) + `"}}}`
+	metadata := `{"metadata": {"service": {"name": "svc", "agent": {"name": "go", "version": "1.0"}}}}`
+	lines := []string{metadata}
+	for i := 0; i < 100; i++ {
+		lines = append(lines, tooLargeEvent)
+	}
+	payload := strings.Join(lines, "\n") + "\n"
+	require.Greater(t, len(payload), 500*1000)
+
+	for _, streamingDecode := range []bool{false, true} {
+		t.Run(fmt.Sprintf("StreamingDecode=%v", streamingDecode), func(t *testing.T) {
+			var result Result
+			p := NewProcessor(Config{
+				MaxEventSize:    1000,
+				MaxStreamSize:   20000,
+				Semaphore:       make(chan struct{}, 1),
+				StreamingDecode: streamingDecode,
+			})
+			err := p.HandleStream(
+				context.Background(), false, model.APMEvent{},
+				strings.NewReader(payload), 10, nopBatchProcessor{}, &result,
+			)
+			var limitErr *LimitExceededError
+			require.ErrorAs(t, err, &limitErr)
+			assert.Equal(t, LimitStreamSize, limitErr.Limit)
+			assert.Zero(t, result.Accepted)
+			// Only the lines read before the stream size limit was
+			// exceeded are reported as too large.
+			assert.Equal(t, 20000/len(tooLargeEvent)+1, result.TooLarge)
+		})
+	}
+}
+
 func TestHandleStreamRUMv3(t *testing.T) {
 	var events []model.APMEvent
 	batchProcessor := model.ProcessBatchFunc(func(ctx context.Context, batch *model.Batch) error {
@@ -322,6 +664,86 @@ func TestHandleStreamBaseEvent(t *testing.T) {
 	assert.Equal(t, requestTimestamp.Add(50*time.Millisecond), events[0].Timestamp) // span's start is "50"
 }
 
+func TestHandleStreamTrustedProxies(t *testing.T) {
+	transaction := `{"transaction":{"id":"945254c567a5417e","trace_id":"0123456789abcdef0123456789abcdef","type":"request","duration":32.592981,"span_count":{"started":0},` +
+		`"context":{"request":{"method":"GET","url":{"raw":"/"},"socket":{"remote_address":"10.0.0.1"},` +
+		`"headers":{"X-Forwarded-For":"1.2.3.4, 192.0.2.60, 10.0.0.2"}}}}}`
+	payload := validMetadata + "\n" + transaction + "\n"
+
+	for name, test := range map[string]struct {
+		config Config
+		client string
+		nat    string
+	}{
+		"Untrusted": {
+			client: "1.2.3.4",
+			nat:    "10.0.0.1",
+		},
+		"Trusted": {
+			config: Config{TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}},
+			client: "192.0.2.60",
+			nat:    "10.0.0.1",
+		},
+		"TrustedHeaders": {
+			config: Config{
+				TrustedProxies:    []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+				ClientAddrHeaders: []string{"X-Real-IP"},
+			},
+			client: "10.0.0.1",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var events []model.APMEvent
+			batchProcessor := model.ProcessBatchFunc(func(ctx context.Context, batch *model.Batch) error {
+				events = append(events, (*batch)...)
+				return nil
+			})
+			config := test.config
+			config.MaxEventSize = 100 * 1024
+			config.Semaphore = make(chan struct{}, 1)
+			err := NewProcessor(config).HandleStream(
+				context.Background(), false, model.APMEvent{},
+				strings.NewReader(payload), 10, batchProcessor,
+				&Result{},
+			)
+			require.NoError(t, err)
+			require.Len(t, events, 1)
+			assert.Equal(t, test.client, events[0].Client.IP.String())
+			if test.nat == "" {
+				assert.Nil(t, events[0].Source.NAT)
+			} else {
+				require.NotNil(t, events[0].Source.NAT)
+				assert.Equal(t, test.nat, events[0].Source.NAT.IP.String())
+			}
+		})
+	}
+}
+
+func TestResolveClientAddr(t *testing.T) {
+	header := http.Header{"X-Forwarded-For": []string{"1.2.3.4, 192.0.2.60"}}
+	remoteAddr := netip.MustParseAddrPort("10.0.0.1:1234")
+
+	// Without trusted proxies, forwarding headers are used as-is.
+	p := NewProcessor(Config{})
+	assert.Equal(t, ClientAddr{
+		IP:      netip.MustParseAddr("1.2.3.4"),
+		Proxies: []netip.Addr{remoteAddr.Addr()},
+	}, p.ResolveClientAddr(header, remoteAddr))
+
+	p = NewProcessor(Config{TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}})
+	assert.Equal(t, ClientAddr{
+		IP:      netip.MustParseAddr("192.0.2.60"),
+		Proxies: []netip.Addr{remoteAddr.Addr()},
+	}, p.ResolveClientAddr(header, remoteAddr))
+
+	// Headers are ignored if the peer is not a trusted proxy.
+	remoteAddr = netip.MustParseAddrPort("192.0.2.1:1234")
+	assert.Equal(t, ClientAddr{
+		IP:   remoteAddr.Addr(),
+		Port: remoteAddr.Port(),
+	}, p.ResolveClientAddr(header, remoteAddr))
+}
+
 func TestLabelLeak(t *testing.T) {
 	payload := `{"metadata": {"service": {"name": "testsvc", "environment": "staging", "version": null, "agent": {"name": "python", "version": "6.9.1"}, "language": {"name": "python", "version": "3.10.4"}, "runtime": {"name": "CPython", "version": "3.10.4"}, "framework": {"name": "flask", "version": "2.1.1"}}, "process": {"pid": 2112739, "ppid": 2112738, "argv": ["/home/stuart/workspace/sdh/581/venv/lib/python3.10/site-packages/flask/__main__.py", "run"], "title": null}, "system": {"hostname": "slaptop", "architecture": "x86_64", "platform": "linux"}, "labels": {"ci_commit": "unknown", "numeric": 1}}}
 {"transaction": {"id": "88dee29a6571b948", "trace_id": "ba7f5d18ac4c7f39d1ff070c79b2bea5", "name": "GET /withlabels", "type": "request", "duration": 1.6199999999999999, "result": "HTTP 2xx", "timestamp": 1652185276804681, "outcome": "success", "sampled": true, "span_count": {"started": 0, "dropped": 0}, "sample_rate": 1.0, "context": {"request": {"env": {"REMOTE_ADDR": "127.0.0.1", "SERVER_NAME": "127.0.0.1", "SERVER_PORT": "5000"}, "method": "GET", "socket": {"remote_address": "127.0.0.1"}, "cookies": {}, "headers": {"host": "localhost:5000", "user-agent": "curl/7.81.0", "accept": "*/*", "app-os": "Android", "content-type": "application/json; charset=utf-8", "content-length": "29"}, "url": {"full": "http://localhost:5000/withlabels?second_with_labels", "protocol": "http:", "hostname": "localhost", "pathname": "/withlabels", "port": "5000", "search": "?second_with_labels"}}, "response": {"status_code": 200, "headers": {"Content-Type": "application/json", "Content-Length": "14"}}, "tags": {"appOs": "Android", "email_set": "hello@hello.com", "time_set": 1652185276}}}}
@@ -519,3 +941,19 @@ func (p *accountProcessor) ProcessBatch(ctx context.Context, b *model.Batch) err
 	atomic.AddUint64(&p.processed, 1)
 	return nil
 }
+
+type tokenBucket struct {
+	tokens int
+	keys   []string
+}
+
+func (b *tokenBucket) AllowN(key string, now time.Time, n int) bool {
+	if len(b.keys) == 0 || b.keys[len(b.keys)-1] != key {
+		b.keys = append(b.keys, key)
+	}
+	if n > b.tokens {
+		return false
+	}
+	b.tokens -= n
+	return true
+}
@@ -33,11 +33,23 @@ type Result struct {
 	// to being invalid, excluding those that are counted by TooLarge.
 	Invalid int
 
+	// LimitExceeded holds the number of events that were rejected
+	// due to exceeding one of the per-stream limits.
+	LimitExceeded int
+
+	// RateLimited holds the number of events that were rejected
+	// due to the rate limit being exceeded.
+	RateLimited int
+
 	// Errors holds a limited number of errors that occurred while
 	// processing the event stream. If the limit is reached, the
 	// counters above are still incremented.
 	Errors      []error
 	errorsSpace [5]error
+
+	// CollectAllErrors may be set by callers before handling a stream
+	// to record every error in Errors, rather than a limited number.
+	CollectAllErrors bool
 }
 
 func (r *Result) addError(err error) {
@@ -49,7 +61,11 @@ func (r *Result) addError(err error) {
 			r.Invalid++
 		}
 	}
-	if len(r.Errors) < len(r.errorsSpace) {
+	var limitExceeded *LimitExceededError
+	if errors.As(err, &limitExceeded) {
+		r.LimitExceeded++
+	}
+	if r.CollectAllErrors || len(r.Errors) < len(r.errorsSpace) {
 		if r.Errors == nil {
 			r.Errors = r.errorsSpace[:0]
 		}
@@ -57,12 +73,61 @@ func (r *Result) addError(err error) {
 	}
 }
 
+// InvalidInputError is recorded in Result for events that were rejected
+// due to being invalid or too large.
 type InvalidInputError struct {
 	TooLarge bool
 	Message  string
 	Document string
+
+	// Line holds the 1-based line number of Document within the stream.
+	Line int
+
+	// EventType holds the root key of Document, e.g. "transaction",
+	// if the line could be read.
+	EventType string
+
+	// Field holds the JSON path of the field that failed validation,
+	// e.g. "transaction.context.request.method", if any.
+	Field string
+
+	// Rule holds the validation rule that Field violated,
+	// e.g. "maxLength(1024)", if any.
+	Rule string
 }
 
 func (e *InvalidInputError) Error() string {
 	return e.Message
 }
+
+// Limit identifies a per-stream limit enforced by Processor.
+type Limit string
+
+const (
+	// LimitEventsPerStream identifies Config.MaxEventsPerStream.
+	LimitEventsPerStream Limit = "max_events_per_stream"
+
+	// LimitStreamSize identifies Config.MaxStreamSize.
+	LimitStreamSize Limit = "max_stream_size"
+
+	// LimitSpansPerTransaction identifies Config.MaxSpansPerTransaction.
+	LimitSpansPerTransaction Limit = "max_spans_per_transaction"
+
+	// LimitLabelsPerEvent identifies Config.MaxLabelsPerEvent.
+	LimitLabelsPerEvent Limit = "max_labels_per_event"
+)
+
+// LimitExceededError is recorded in Result for events that were rejected
+// due to exceeding a per-stream limit.
+type LimitExceededError struct {
+	Limit    Limit
+	Message  string
+	Document string
+
+	// Line holds the 1-based line number of Document within the stream.
+	Line int
+}
+
+func (e *LimitExceededError) Error() string {
+	return e.Message
+}
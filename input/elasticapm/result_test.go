@@ -18,6 +18,7 @@
 package elasticapm
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -32,6 +33,7 @@ func TestResultErrors(t *testing.T) {
 	err6 := &InvalidInputError{Message: "err6"}
 	err7 := &InvalidInputError{Message: "err7", TooLarge: true}
 	err8 := &InvalidInputError{Message: "err8", TooLarge: true}
+	err9 := &LimitExceededError{Message: "err9", Limit: LimitEventsPerStream}
 
 	result := Result{}
 	result.addError(err1)
@@ -45,8 +47,22 @@ func TestResultErrors(t *testing.T) {
 	result.addError(err6)
 	result.addError(err7)
 	result.addError(err8)
+	result.addError(err9)
 
 	assert.Equal(t, []error{err1, err2, err3, err4, err5}, result.Errors)
 	assert.Equal(t, 6, result.Invalid)
 	assert.Equal(t, 2, result.TooLarge)
+	assert.Equal(t, 1, result.LimitExceeded)
+}
+
+func TestResultCollectAllErrors(t *testing.T) {
+	result := Result{CollectAllErrors: true}
+	var expected []error
+	for i := 0; i < 10; i++ {
+		err := &InvalidInputError{Message: fmt.Sprintf("err%d", i)}
+		result.addError(err)
+		expected = append(expected, err)
+	}
+	assert.Equal(t, expected, result.Errors)
+	assert.Equal(t, 10, result.Invalid)
 }
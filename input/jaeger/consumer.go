@@ -0,0 +1,187 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package jaeger provides support for consuming Jaeger span batches,
+// translating them to the Elastic APM data model.
+//
+// Batches are first converted to OpenTelemetry traces, and then
+// translated with the same rules used for OTLP, including the special
+// handling of Jaeger sampling tags and span logs.
+package jaeger
+
+import (
+	"context"
+	"sync/atomic"
+
+	jaegermodel "github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+	jaegerthrift "github.com/jaegertracing/jaeger/thrift-gen/jaeger"
+	jaegertranslator "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/translator/jaeger"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+
+	"github.com/elastic/apm-data/input/otlp"
+	"github.com/elastic/apm-data/model"
+)
+
+var _ api_v2.CollectorServiceServer = (*Consumer)(nil)
+
+// ConsumerConfig holds configuration for Consumer.
+type ConsumerConfig struct {
+	// Processor holds the model.BatchProcessor which will be invoked
+	// with event batches when consuming Jaeger batches.
+	Processor model.BatchProcessor
+
+	// Logger holds a logger for the consumer. If this is nil, then
+	// no logging will be performed.
+	//
+	// The logger is passed to the underlying OTLP consumer, which
+	// names it "otel".
+	Logger *zap.Logger
+}
+
+// Consumer transforms Jaeger span batches to the Elastic APM data model,
+// sending each batch to the configured BatchProcessor.
+//
+// Consumer implements api_v2.CollectorServiceServer, and so may be
+// registered directly with a gRPC server.
+type Consumer struct {
+	stats consumerStats
+
+	logger *zap.Logger
+	otlp   *otlp.Consumer
+}
+
+// NewConsumer returns a new Consumer with the given configuration.
+func NewConsumer(config ConsumerConfig) *Consumer {
+	logger := config.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Consumer{
+		logger: logger,
+		otlp: otlp.NewConsumer(otlp.ConsumerConfig{
+			Processor: config.Processor,
+			Logger:    config.Logger,
+		}),
+	}
+}
+
+// ConsumerStats holds a snapshot of statistics about data consumption.
+type ConsumerStats struct {
+	// BatchesReceived records the number of Jaeger batches received.
+	BatchesReceived int64
+
+	// BatchesFailed records the number of Jaeger batches which could
+	// not be translated or processed.
+	BatchesFailed int64
+
+	// SpansReceived records the number of Jaeger spans received.
+	SpansReceived int64
+
+	// SpansAccepted records the number of Jaeger spans which were
+	// translated and processed successfully.
+	SpansAccepted int64
+
+	// SpansRejected records the number of Jaeger spans which could
+	// not be translated, and were not sent to the processor.
+	SpansRejected int64
+}
+
+// consumerStats holds the current statistics, which must be accessed and
+// modified using atomic operations.
+type consumerStats struct {
+	batchesReceived int64
+	batchesFailed   int64
+	spansReceived   int64
+	spansAccepted   int64
+	spansRejected   int64
+}
+
+// Stats returns a snapshot of the current statistics about data consumption.
+func (c *Consumer) Stats() ConsumerStats {
+	return ConsumerStats{
+		BatchesReceived: atomic.LoadInt64(&c.stats.batchesReceived),
+		BatchesFailed:   atomic.LoadInt64(&c.stats.batchesFailed),
+		SpansReceived:   atomic.LoadInt64(&c.stats.spansReceived),
+		SpansAccepted:   atomic.LoadInt64(&c.stats.spansAccepted),
+		SpansRejected:   atomic.LoadInt64(&c.stats.spansRejected),
+	}
+}
+
+// PostSpans consumes the Jaeger batch in req, as sent by Jaeger agents
+// and clients over gRPC.
+func (c *Consumer) PostSpans(ctx context.Context, req *api_v2.PostSpansRequest) (*api_v2.PostSpansResponse, error) {
+	if err := c.ConsumeBatch(ctx, &req.Batch); err != nil {
+		return nil, err
+	}
+	return &api_v2.PostSpansResponse{}, nil
+}
+
+// ConsumeBatch consumes a Jaeger protobuf model batch.
+func (c *Consumer) ConsumeBatch(ctx context.Context, batch *jaegermodel.Batch) error {
+	_, err := c.ConsumeBatchWithResult(ctx, batch)
+	return err
+}
+
+// ConsumeBatchWithResult consumes a Jaeger protobuf model batch like
+// ConsumeBatch, additionally returning the number of spans that were
+// accepted or rejected. If the batch cannot be translated, all of its
+// spans are rejected; if processing fails, none are accepted.
+func (c *Consumer) ConsumeBatchWithResult(ctx context.Context, batch *jaegermodel.Batch) (otlp.ConsumeResult, error) {
+	c.received(len(batch.Spans))
+	traces, err := jaegertranslator.ProtoToTraces([]*jaegermodel.Batch{batch})
+	return c.consumeTraces(ctx, traces, len(batch.Spans), err)
+}
+
+// ConsumeThriftBatch consumes a Jaeger Thrift batch, as sent by Jaeger
+// agents and clients over HTTP or UDP.
+func (c *Consumer) ConsumeThriftBatch(ctx context.Context, batch *jaegerthrift.Batch) error {
+	_, err := c.ConsumeThriftBatchWithResult(ctx, batch)
+	return err
+}
+
+// ConsumeThriftBatchWithResult consumes a Jaeger Thrift batch like
+// ConsumeThriftBatch, additionally returning the number of spans that
+// were accepted or rejected, as described for ConsumeBatchWithResult.
+func (c *Consumer) ConsumeThriftBatchWithResult(ctx context.Context, batch *jaegerthrift.Batch) (otlp.ConsumeResult, error) {
+	c.received(len(batch.Spans))
+	traces, err := jaegertranslator.ThriftToTraces(batch)
+	return c.consumeTraces(ctx, traces, len(batch.Spans), err)
+}
+
+func (c *Consumer) received(spans int) {
+	atomic.AddInt64(&c.stats.batchesReceived, 1)
+	atomic.AddInt64(&c.stats.spansReceived, int64(spans))
+}
+
+func (c *Consumer) consumeTraces(ctx context.Context, traces ptrace.Traces, spans int, err error) (otlp.ConsumeResult, error) {
+	var result otlp.ConsumeResult
+	if err != nil {
+		result = otlp.ConsumeResult{Rejected: int64(spans), Errors: []error{err}}
+		atomic.AddInt64(&c.stats.spansRejected, result.Rejected)
+	} else {
+		result, err = c.otlp.ConsumeTracesWithResult(ctx, traces)
+	}
+	if err != nil {
+		atomic.AddInt64(&c.stats.batchesFailed, 1)
+		c.logger.Debug("failed to consume batch", zap.Error(err))
+		return result, err
+	}
+	atomic.AddInt64(&c.stats.spansAccepted, result.Accepted)
+	return result, nil
+}
@@ -0,0 +1,170 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package jaeger_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	jaegermodel "github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+	jaegerthrift "github.com/jaegertracing/jaeger/thrift-gen/jaeger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/apm-data/input/jaeger"
+	"github.com/elastic/apm-data/input/otlp"
+	"github.com/elastic/apm-data/model"
+)
+
+func TestPostSpans(t *testing.T) {
+	var batches []model.Batch
+	consumer := jaeger.NewConsumer(jaeger.ConsumerConfig{
+		Processor: model.ProcessBatchFunc(func(ctx context.Context, batch *model.Batch) error {
+			batches = append(batches, *batch)
+			return nil
+		}),
+	})
+
+	traceID := jaegermodel.NewTraceID(0, 0x46467830)
+	resp, err := consumer.PostSpans(context.Background(), &api_v2.PostSpansRequest{
+		Batch: jaegermodel.Batch{
+			Process: jaegermodel.NewProcess("service-a", []jaegermodel.KeyValue{
+				jaegermodel.String("jaeger.version", "Go-2.30.0"),
+				jaegermodel.String("hostname", "host-a"),
+			}),
+			Spans: []*jaegermodel.Span{{
+				TraceID:       traceID,
+				SpanID:        0x41414646,
+				OperationName: "GET /",
+				StartTime:     time.Unix(1576500418, 0),
+				Duration:      time.Second,
+				Tags: []jaegermodel.KeyValue{
+					jaegermodel.String("span.kind", "server"),
+					jaegermodel.String("sampler.type", "probabilistic"),
+					jaegermodel.Float64("sampler.param", 0.5),
+				},
+			}, {
+				TraceID:       traceID,
+				SpanID:        0x42424242,
+				OperationName: "SELECT",
+				StartTime:     time.Unix(1576500418, 0),
+				Duration:      time.Millisecond,
+				References:    []jaegermodel.SpanRef{jaegermodel.NewChildOfRef(traceID, 0x41414646)},
+				Tags: []jaegermodel.KeyValue{
+					jaegermodel.String("span.kind", "client"),
+					jaegermodel.String("db.system", "mysql"),
+				},
+			}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &api_v2.PostSpansResponse{}, resp)
+
+	require.Len(t, batches, 1)
+	require.Len(t, batches[0], 2)
+	tx, span := batches[0][0], batches[0][1]
+	assert.Equal(t, model.TransactionProcessor, tx.Processor)
+	assert.Equal(t, "Jaeger/Go", tx.Agent.Name)
+	assert.Equal(t, "2.30.0", tx.Agent.Version)
+	assert.Equal(t, "service-a", tx.Service.Name)
+	assert.Equal(t, "host-a", tx.Host.Hostname)
+	assert.Equal(t, 2.0, tx.Transaction.RepresentativeCount)
+	assert.Empty(t, tx.Labels)
+
+	assert.Equal(t, model.SpanProcessor, span.Processor)
+	assert.Equal(t, "db", span.Span.Type)
+	assert.Equal(t, "mysql", span.Span.Subtype)
+	assert.Equal(t, tx.Transaction.ID, span.Parent.ID)
+
+	assert.Equal(t, jaeger.ConsumerStats{
+		BatchesReceived: 1,
+		SpansReceived:   2,
+		SpansAccepted:   2,
+	}, consumer.Stats())
+}
+
+func TestConsumeThriftBatch(t *testing.T) {
+	var batches []model.Batch
+	consumer := jaeger.NewConsumer(jaeger.ConsumerConfig{
+		Processor: model.ProcessBatchFunc(func(ctx context.Context, batch *model.Batch) error {
+			batches = append(batches, *batch)
+			return nil
+		}),
+	})
+
+	spanKind := "client"
+	result, err := consumer.ConsumeThriftBatchWithResult(context.Background(), &jaegerthrift.Batch{
+		Process: &jaegerthrift.Process{ServiceName: "service-b"},
+		Spans: []*jaegerthrift.Span{{
+			TraceIdLow:    0x46467830,
+			SpanId:        0x43434343,
+			ParentSpanId:  0x41414646,
+			OperationName: "GET",
+			StartTime:     1576500418000000,
+			Duration:      1000,
+			Tags: []*jaegerthrift.Tag{{
+				Key:   "span.kind",
+				VType: jaegerthrift.TagType_STRING,
+				VStr:  &spanKind,
+			}},
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, otlp.ConsumeResult{Accepted: 1}, result)
+
+	require.Len(t, batches, 1)
+	require.Len(t, batches[0], 1)
+	span := batches[0][0]
+	assert.Equal(t, model.SpanProcessor, span.Processor)
+	assert.Equal(t, "service-b", span.Service.Name)
+	assert.Equal(t, "0000000041414646", span.Parent.ID)
+	assert.Equal(t, "0000000043434343", span.Span.ID)
+	assert.Equal(t, time.Millisecond, span.Event.Duration)
+
+	assert.Equal(t, jaeger.ConsumerStats{
+		BatchesReceived: 1,
+		SpansReceived:   1,
+		SpansAccepted:   1,
+	}, consumer.Stats())
+}
+
+func TestConsumeBatchProcessorError(t *testing.T) {
+	processorErr := errors.New("processor failed")
+	consumer := jaeger.NewConsumer(jaeger.ConsumerConfig{
+		Processor: model.ProcessBatchFunc(func(ctx context.Context, batch *model.Batch) error {
+			return processorErr
+		}),
+	})
+
+	result, err := consumer.ConsumeBatchWithResult(context.Background(), &jaegermodel.Batch{
+		Spans: []*jaegermodel.Span{{
+			TraceID: jaegermodel.NewTraceID(0, 1),
+			SpanID:  1,
+		}},
+	})
+	assert.Equal(t, processorErr, err)
+	assert.Equal(t, otlp.ConsumeResult{}, result)
+	assert.Equal(t, jaeger.ConsumerStats{
+		BatchesReceived: 1,
+		BatchesFailed:   1,
+		SpansReceived:   1,
+	}, consumer.Stats())
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/elastic/apm-data/model"
 	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.uber.org/zap"
 )
 
@@ -31,6 +32,17 @@ type ConsumerConfig struct {
 	// with event batches when consuming OTLP payloads.
 	Processor model.BatchProcessor
 
+	// CumulativeToDelta holds configuration for converting cumulative
+	// monotonic Sum and Histogram data points to deltas. If this is nil,
+	// then data points are recorded as they are given, regardless of
+	// their aggregation temporality.
+	CumulativeToDelta *CumulativeToDeltaConfig
+
+	// DropSpanEvents, if non-nil, is called with the instrumentation
+	// scope of each span. If it returns true, the span's events are
+	// dropped rather than converted to log and error events.
+	DropSpanEvents func(pcommon.InstrumentationScope) bool
+
 	// Logger holds a logger for the consumer. If this is nil, then
 	// no logging will be performed.
 	Logger *zap.Logger
@@ -42,6 +54,7 @@ type Consumer struct {
 	stats consumerStats
 
 	config ConsumerConfig
+	deltas *cumulativeToDelta
 }
 
 // NewConsumer returns a new Consumer with the given configuration.
@@ -51,7 +64,11 @@ func NewConsumer(config ConsumerConfig) *Consumer {
 	} else {
 		config.Logger = config.Logger.Named("otel")
 	}
-	return &Consumer{config: config}
+	c := &Consumer{config: config}
+	if config.CumulativeToDelta != nil {
+		c.deltas = newCumulativeToDelta(*config.CumulativeToDelta)
+	}
+	return c
 }
 
 // ConsumerStats holds a snapshot of statistics about data consumption.
@@ -59,18 +76,61 @@ type ConsumerStats struct {
 	// UnsupportedMetricsDropped records the number of unsupported metrics
 	// that have been dropped by the consumer.
 	UnsupportedMetricsDropped int64
+
+	// SpansAccepted records the number of spans that have been
+	// accepted by the consumer. Spans are never rejected.
+	SpansAccepted int64
+
+	// MetricDataPointsAccepted and MetricDataPointsRejected record the
+	// number of metric data points that have been accepted and rejected
+	// by the consumer.
+	MetricDataPointsAccepted int64
+	MetricDataPointsRejected int64
+
+	// LogRecordsAccepted records the number of log records that have
+	// been accepted by the consumer. Log records are never rejected.
+	LogRecordsAccepted int64
+
+	// InvalidTimestamps records the number of spans, metric data points
+	// and log records that have been accepted with a missing timestamp,
+	// or with an end timestamp preceding the start timestamp.
+	InvalidTimestamps int64
+
+	// TruncatedFields records the number of string values that have
+	// been truncated to the maximum keyword length.
+	TruncatedFields int64
 }
 
 // consumerStats holds the current statistics, which must be accessed and
 // modified using atomic operations.
 type consumerStats struct {
 	unsupportedMetricsDropped int64
+	spansAccepted             int64
+	metricDataPointsAccepted  int64
+	metricDataPointsRejected  int64
+	logRecordsAccepted        int64
+	invalidTimestamps         int64
+	truncatedFields           int64
 }
 
 // Stats returns a snapshot of the current statistics about data consumption.
 func (c *Consumer) Stats() ConsumerStats {
 	return ConsumerStats{
 		UnsupportedMetricsDropped: atomic.LoadInt64(&c.stats.unsupportedMetricsDropped),
+		SpansAccepted:             atomic.LoadInt64(&c.stats.spansAccepted),
+		MetricDataPointsAccepted:  atomic.LoadInt64(&c.stats.metricDataPointsAccepted),
+		MetricDataPointsRejected:  atomic.LoadInt64(&c.stats.metricDataPointsRejected),
+		LogRecordsAccepted:        atomic.LoadInt64(&c.stats.logRecordsAccepted),
+		InvalidTimestamps:         atomic.LoadInt64(&c.stats.invalidTimestamps),
+		TruncatedFields:           atomic.LoadInt64(&c.stats.truncatedFields),
+	}
+}
+
+// checkTimestamp records timestamp as invalid in the consumer stats if
+// it is unset.
+func (c *Consumer) checkTimestamp(timestamp pcommon.Timestamp) {
+	if timestamp == 0 {
+		atomic.AddInt64(&c.stats.invalidTimestamps, 1)
 	}
 }
 
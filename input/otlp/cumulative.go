@@ -0,0 +1,264 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp
+
+import (
+	"container/list"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+const (
+	defaultMaxCumulativeStreams   = 10000
+	defaultMaxCumulativeStaleness = 5 * time.Minute
+)
+
+// CumulativeToDeltaConfig holds configuration for converting cumulative
+// monotonic Sum and Histogram data points to deltas.
+type CumulativeToDeltaConfig struct {
+	// MaxStreams holds the maximum number of metric streams for which
+	// state is kept. A stream is identified by its resource, scope,
+	// metric name, and data point attributes.
+	//
+	// Stale streams are evicted as new streams are seen. When the limit
+	// is reached, the least recently seen stream is evicted.
+	//
+	// If MaxStreams is zero or negative, it defaults to 10000.
+	MaxStreams int
+
+	// MaxStaleness holds the duration after which a stream which has
+	// not been seen is considered stale. The next data point of a stale
+	// stream is treated as its first.
+	//
+	// If MaxStaleness is zero or negative, it defaults to 5 minutes.
+	MaxStaleness time.Duration
+}
+
+// cumulativeToDelta converts cumulative data points to deltas, keeping
+// the previous data point of each stream.
+//
+// The first data point of a stream, and data points which are not newer
+// than the previous one, produce no delta. A reset is detected when a
+// data point's start timestamp differs from the previous data point's,
+// or when its value is lower; the delta is then the data point's value.
+type cumulativeToDelta struct {
+	maxStreams   int
+	maxStaleness time.Duration
+	now          func() time.Time
+
+	mu sync.Mutex
+
+	// ll holds streams ordered from the most to the least recently
+	// seen, and streams holds the elements of ll keyed by identity.
+	ll      *list.List
+	streams map[string]*list.Element
+}
+
+type cumulativeStream struct {
+	key       string
+	lastSeen  time.Time
+	start     pcommon.Timestamp
+	timestamp pcommon.Timestamp
+
+	// value holds the previous value of a Sum data point.
+	value float64
+
+	// bounds and counts hold the previous explicit bounds and bucket
+	// counts of a Histogram data point.
+	bounds []float64
+	counts []uint64
+}
+
+func newCumulativeToDelta(config CumulativeToDeltaConfig) *cumulativeToDelta {
+	if config.MaxStreams <= 0 {
+		config.MaxStreams = defaultMaxCumulativeStreams
+	}
+	if config.MaxStaleness <= 0 {
+		config.MaxStaleness = defaultMaxCumulativeStaleness
+	}
+	return &cumulativeToDelta{
+		maxStreams:   config.MaxStreams,
+		maxStaleness: config.MaxStaleness,
+		now:          time.Now,
+		ll:           list.New(),
+		streams:      make(map[string]*list.Element),
+	}
+}
+
+// sum returns the delta of a cumulative Sum data point, reporting false
+// if there is none.
+func (c *cumulativeToDelta) sum(key string, dp pmetric.NumberDataPoint, value float64) (float64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stream, isNew := c.stream(key)
+	if !isNew && !stream.advance(dp.StartTimestamp(), dp.Timestamp()) {
+		return 0, false
+	}
+	reset := stream.start != dp.StartTimestamp() || value < stream.value
+	delta := value - stream.value
+	if reset {
+		delta = value
+	}
+	stream.start = dp.StartTimestamp()
+	stream.timestamp = dp.Timestamp()
+	stream.value = value
+	return delta, !isNew
+}
+
+// histogram returns the delta bucket counts of a cumulative Histogram
+// data point, reporting false if there are none.
+//
+// If the data point's explicit bounds differ from the previous data
+// point's, the stream is restarted from the data point.
+func (c *cumulativeToDelta) histogram(key string, dp pmetric.HistogramDataPoint) ([]uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stream, isNew := c.stream(key)
+	if !isNew && !stream.advance(dp.StartTimestamp(), dp.Timestamp()) {
+		return nil, false
+	}
+	bounds := dp.ExplicitBounds()
+	counts := dp.BucketCounts()
+	if !isNew && !equalBounds(stream.bounds, bounds) {
+		isNew = true
+	}
+	reset := stream.start != dp.StartTimestamp() || len(stream.counts) != counts.Len()
+	var delta []uint64
+	if !isNew {
+		delta = make([]uint64, counts.Len())
+		for i := range delta {
+			count := counts.At(i)
+			if !reset && count < stream.counts[i] {
+				reset = true
+			}
+			delta[i] = count - stream.counts[i]
+		}
+		if reset {
+			delta = counts.AsRaw()
+		}
+	}
+	stream.start = dp.StartTimestamp()
+	stream.timestamp = dp.Timestamp()
+	stream.bounds = bounds.AsRaw()
+	stream.counts = counts.AsRaw()
+	return delta, !isNew
+}
+
+// stream returns the state for key, creating it if it does not exist
+// or is stale, and reporting whether it was created.
+func (c *cumulativeToDelta) stream(key string) (*cumulativeStream, bool) {
+	now := c.now()
+	if elem, ok := c.streams[key]; ok {
+		c.ll.MoveToFront(elem)
+		stream := elem.Value.(*cumulativeStream)
+		isNew := now.Sub(stream.lastSeen) >= c.maxStaleness
+		if isNew {
+			*stream = cumulativeStream{key: key}
+		}
+		stream.lastSeen = now
+		return stream, isNew
+	}
+	c.evict(now)
+	stream := &cumulativeStream{key: key, lastSeen: now}
+	c.streams[key] = c.ll.PushFront(stream)
+	return stream, true
+}
+
+// evict deletes stale streams, followed by the least recently seen
+// streams while the number of streams is at the limit.
+func (c *cumulativeToDelta) evict(now time.Time) {
+	for c.ll.Len() > 0 {
+		oldest := c.ll.Back()
+		stream := oldest.Value.(*cumulativeStream)
+		if c.ll.Len() < c.maxStreams && now.Sub(stream.lastSeen) < c.maxStaleness {
+			return
+		}
+		c.ll.Remove(oldest)
+		delete(c.streams, stream.key)
+	}
+}
+
+// advance reports whether a data point with the given start timestamp
+// and timestamp follows the previous data point of the stream. Data
+// points of the same series which are out of order or duplicated are
+// not converted.
+func (s *cumulativeStream) advance(start, timestamp pcommon.Timestamp) bool {
+	return start != s.start || timestamp > s.timestamp
+}
+
+func equalBounds(a []float64, b pcommon.Float64Slice) bool {
+	if len(a) != b.Len() {
+		return false
+	}
+	for i, v := range a {
+		if v != b.At(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// cumulativeStreamPrefix returns the identity of a resource and
+// instrumentation scope, for prefixing cumulativeStreamKey.
+func cumulativeStreamPrefix(resource pcommon.Resource, scope pcommon.InstrumentationScope) string {
+	var b strings.Builder
+	writeAttributesSignature(&b, resource.Attributes())
+	b.WriteString(scope.Name())
+	b.WriteByte(0)
+	b.WriteString(scope.Version())
+	b.WriteByte(0)
+	writeAttributesSignature(&b, scope.Attributes())
+	return b.String()
+}
+
+// cumulativeStreamKey returns the identity of a metric stream.
+func cumulativeStreamKey(prefix, metricName string, attributes pcommon.Map) string {
+	var b strings.Builder
+	b.WriteString(prefix)
+	b.WriteString(metricName)
+	b.WriteByte(0)
+	writeAttributesSignature(&b, attributes)
+	return b.String()
+}
+
+// writeAttributesSignature writes the attributes to b, sorted by key
+// so that the signature does not depend on their order. Values are
+// qualified by their type, so that e.g. "1" and 1 are distinguished.
+func writeAttributesSignature(b *strings.Builder, attributes pcommon.Map) {
+	keys := make([]string, 0, attributes.Len())
+	attributes.Range(func(k string, _ pcommon.Value) bool {
+		keys = append(keys, k)
+		return true
+	})
+	sort.Strings(keys)
+	for _, k := range keys {
+		v, _ := attributes.Get(k)
+		b.WriteString(k)
+		b.WriteByte(0)
+		b.WriteString(v.Type().String())
+		b.WriteByte(0)
+		b.WriteString(v.AsString())
+		b.WriteByte(0)
+	}
+	b.WriteByte(0)
+}
@@ -0,0 +1,220 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	"github.com/elastic/apm-data/input/otlp"
+	"github.com/elastic/apm-data/model"
+)
+
+func TestConsumeMetricsCumulativeToDelta(t *testing.T) {
+	start0 := time.Unix(100, 0)
+	start1 := time.Unix(200, 0)
+	consume := newCumulativeToDeltaConsumer(t, otlp.CumulativeToDeltaConfig{})
+
+	// The first data point of a stream establishes a baseline.
+	assert.Empty(t, consume(newCumulativeSum("sum", start0, time.Unix(110, 0), 10)))
+	assert.Equal(t, []float64{5}, sampleValues(consume(newCumulativeSum("sum", start0, time.Unix(120, 0), 15))))
+
+	// Out of order and duplicate data points are dropped.
+	assert.Empty(t, consume(newCumulativeSum("sum", start0, time.Unix(115, 0), 12)))
+	assert.Empty(t, consume(newCumulativeSum("sum", start0, time.Unix(120, 0), 15)))
+
+	// Resets are detected by a change of start timestamp, or by the
+	// value decreasing.
+	assert.Equal(t, []float64{3}, sampleValues(consume(newCumulativeSum("sum", start1, time.Unix(210, 0), 3))))
+	assert.Equal(t, []float64{2}, sampleValues(consume(newCumulativeSum("sum", start1, time.Unix(220, 0), 2))))
+	assert.Equal(t, []float64{4}, sampleValues(consume(newCumulativeSum("sum", start1, time.Unix(230, 0), 6))))
+
+	// Streams are identified by data point attributes, among others.
+	metrics := newCumulativeSum("sum", start0, time.Unix(240, 0), 100)
+	dp := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0)
+	dp.Attributes().PutStr("k", "v")
+	assert.Empty(t, consume(metrics))
+
+	// Attribute values of different types are distinguished.
+	metrics = newCumulativeSum("sum", start0, time.Unix(240, 0), 100)
+	dp = metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0)
+	dp.Attributes().PutStr("n", "1")
+	assert.Empty(t, consume(metrics))
+	metrics = newCumulativeSum("sum", start0, time.Unix(250, 0), 110)
+	dp = metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0)
+	dp.Attributes().PutInt("n", 1)
+	assert.Empty(t, consume(metrics))
+
+	// Delta and non-monotonic sums are recorded as given.
+	metrics = newCumulativeSum("sum", start1, time.Unix(250, 0), 7)
+	metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().SetIsMonotonic(false)
+	assert.Equal(t, []float64{7}, sampleValues(consume(metrics)))
+	metrics = newCumulativeSum("sum", start1, time.Unix(250, 0), 8)
+	metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+	assert.Equal(t, []float64{8}, sampleValues(consume(metrics)))
+}
+
+func TestConsumeMetricsCumulativeToDeltaHistogram(t *testing.T) {
+	start0 := time.Unix(100, 0)
+	start1 := time.Unix(200, 0)
+	consume := newCumulativeToDeltaConsumer(t, otlp.CumulativeToDeltaConfig{})
+
+	assert.Empty(t, consume(newCumulativeHistogram(start0, time.Unix(110, 0), []float64{1, 2}, 1, 2, 3)))
+	assert.Equal(t, []model.Histogram{{
+		Values: []float64{0.5, 2},
+		Counts: []int64{2, 1},
+	}}, sampleHistograms(consume(newCumulativeHistogram(start0, time.Unix(120, 0), []float64{1, 2}, 3, 2, 4))))
+
+	// A bucket count decreasing indicates a reset.
+	assert.Equal(t, []model.Histogram{{
+		Values: []float64{0.5, 1.5, 2},
+		Counts: []int64{1, 1, 1},
+	}}, sampleHistograms(consume(newCumulativeHistogram(start0, time.Unix(130, 0), []float64{1, 2}, 1, 1, 1))))
+
+	// As does a change of start timestamp.
+	assert.Equal(t, []model.Histogram{{
+		Values: []float64{0.5, 1.5, 2},
+		Counts: []int64{2, 2, 2},
+	}}, sampleHistograms(consume(newCumulativeHistogram(start1, time.Unix(210, 0), []float64{1, 2}, 2, 2, 2))))
+
+	// A change of bounds establishes a new baseline.
+	assert.Empty(t, consume(newCumulativeHistogram(start1, time.Unix(220, 0), []float64{1, 5}, 3, 3, 3)))
+	assert.Equal(t, []model.Histogram{{
+		Values: []float64{3},
+		Counts: []int64{1},
+	}}, sampleHistograms(consume(newCumulativeHistogram(start1, time.Unix(230, 0), []float64{1, 5}, 3, 4, 3))))
+}
+
+func TestConsumeMetricsCumulativeToDeltaStaleness(t *testing.T) {
+	start := time.Unix(100, 0)
+	consume := newCumulativeToDeltaConsumer(t, otlp.CumulativeToDeltaConfig{
+		MaxStaleness: time.Millisecond,
+	})
+	assert.Empty(t, consume(newCumulativeSum("sum", start, time.Unix(110, 0), 10)))
+	time.Sleep(10 * time.Millisecond)
+
+	// The stream is stale, so the data point establishes a new baseline.
+	assert.Empty(t, consume(newCumulativeSum("sum", start, time.Unix(120, 0), 15)))
+}
+
+func TestConsumeMetricsCumulativeToDeltaMaxStreams(t *testing.T) {
+	start := time.Unix(100, 0)
+	consume := newCumulativeToDeltaConsumer(t, otlp.CumulativeToDeltaConfig{
+		MaxStreams: 1,
+	})
+	assert.Empty(t, consume(newCumulativeSum("a", start, time.Unix(110, 0), 10)))
+	assert.Equal(t, []float64{5}, sampleValues(consume(newCumulativeSum("a", start, time.Unix(120, 0), 15))))
+
+	// Stream "a" is evicted to make room for stream "b".
+	assert.Empty(t, consume(newCumulativeSum("b", start, time.Unix(130, 0), 1)))
+	assert.Empty(t, consume(newCumulativeSum("a", start, time.Unix(140, 0), 20)))
+	assert.Equal(t, []float64{5}, sampleValues(consume(newCumulativeSum("a", start, time.Unix(150, 0), 25))))
+}
+
+func TestConsumeMetricsCumulativeToDeltaLeastRecentlySeen(t *testing.T) {
+	start := time.Unix(100, 0)
+	consume := newCumulativeToDeltaConsumer(t, otlp.CumulativeToDeltaConfig{
+		MaxStreams: 2,
+	})
+	assert.Empty(t, consume(newCumulativeSum("a", start, time.Unix(110, 0), 10)))
+	assert.Empty(t, consume(newCumulativeSum("b", start, time.Unix(110, 0), 10)))
+	assert.Equal(t, []float64{5}, sampleValues(consume(newCumulativeSum("a", start, time.Unix(120, 0), 15))))
+
+	// Stream "b" is the least recently seen, so it is evicted to make
+	// room for stream "c", while stream "a" is retained.
+	assert.Empty(t, consume(newCumulativeSum("c", start, time.Unix(130, 0), 1)))
+	assert.Equal(t, []float64{5}, sampleValues(consume(newCumulativeSum("a", start, time.Unix(140, 0), 20))))
+	assert.Empty(t, consume(newCumulativeSum("b", start, time.Unix(150, 0), 20)))
+}
+
+func TestConsumeMetricsCumulativeDisabled(t *testing.T) {
+	metrics := newCumulativeSum("sum", time.Unix(100, 0), time.Unix(110, 0), 10)
+	events, _ := transformMetrics(t, metrics)
+	assert.Equal(t, []float64{10}, sampleValues(events))
+}
+
+// newCumulativeToDeltaConsumer returns a function which consumes metrics
+// with a Consumer converting cumulative metrics to deltas, and returns
+// the resulting events.
+func newCumulativeToDeltaConsumer(t *testing.T, config otlp.CumulativeToDeltaConfig) func(pmetric.Metrics) []model.APMEvent {
+	var batches []*model.Batch
+	consumer := otlp.NewConsumer(otlp.ConsumerConfig{
+		Processor:         batchRecorderBatchProcessor(&batches),
+		CumulativeToDelta: &config,
+	})
+	return func(metrics pmetric.Metrics) []model.APMEvent {
+		batches = batches[:0]
+		require.NoError(t, consumer.ConsumeMetrics(context.Background(), metrics))
+		require.Len(t, batches, 1)
+		return *batches[0]
+	}
+}
+
+func newCumulativeSum(name string, start, timestamp time.Time, value float64) pmetric.Metrics {
+	metrics := pmetric.NewMetrics()
+	metric := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	metric.SetName(name)
+	sum := metric.SetEmptySum()
+	sum.SetIsMonotonic(true)
+	sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	dp := sum.DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(timestamp))
+	dp.SetDoubleValue(value)
+	return metrics
+}
+
+func newCumulativeHistogram(start, timestamp time.Time, bounds []float64, counts ...uint64) pmetric.Metrics {
+	metrics := pmetric.NewMetrics()
+	metric := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	metric.SetName("histogram")
+	histogram := metric.SetEmptyHistogram()
+	histogram.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	dp := histogram.DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(timestamp))
+	dp.ExplicitBounds().FromRaw(bounds)
+	dp.BucketCounts().FromRaw(counts)
+	return metrics
+}
+
+func sampleValues(events []model.APMEvent) []float64 {
+	var values []float64
+	for _, event := range events {
+		for _, sample := range event.Metricset.Samples {
+			values = append(values, sample.Value)
+		}
+	}
+	return values
+}
+
+func sampleHistograms(events []model.APMEvent) []model.Histogram {
+	var histograms []model.Histogram
+	for _, event := range events {
+		for _, sample := range event.Metricset.Samples {
+			histograms = append(histograms, sample.Histogram)
+		}
+	}
+	return histograms
+}
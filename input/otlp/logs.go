@@ -36,16 +36,27 @@ package otlp
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
+	semconv "go.opentelemetry.io/collector/semconv/v1.5.0"
 	"go.uber.org/zap"
 
 	"github.com/elastic/apm-data/model"
 )
 
 func (c *Consumer) ConsumeLogs(ctx context.Context, logs plog.Logs) error {
+	_, err := c.ConsumeLogsWithResult(ctx, logs)
+	return err
+}
+
+// ConsumeLogsWithResult consumes OpenTelemetry log data like ConsumeLogs,
+// additionally returning the number of log records that were accepted.
+// Log records are never rejected, but none are accepted if processing
+// fails.
+func (c *Consumer) ConsumeLogsWithResult(ctx context.Context, logs plog.Logs) (ConsumeResult, error) {
 	receiveTimestamp := time.Now()
 	c.config.Logger.Debug("consuming logs", zap.Stringer("logs", logsStringer(logs)))
 	resourceLogs := logs.ResourceLogs()
@@ -53,14 +64,19 @@ func (c *Consumer) ConsumeLogs(ctx context.Context, logs plog.Logs) error {
 	for i := 0; i < resourceLogs.Len(); i++ {
 		c.convertResourceLogs(resourceLogs.At(i), receiveTimestamp, &batch)
 	}
-	return c.config.Processor.ProcessBatch(ctx, &batch)
+	if err := c.config.Processor.ProcessBatch(ctx, &batch); err != nil {
+		return ConsumeResult{}, err
+	}
+	result := ConsumeResult{Accepted: int64(logs.LogRecordCount())}
+	atomic.AddInt64(&c.stats.logRecordsAccepted, result.Accepted)
+	return result, nil
 }
 
 func (c *Consumer) convertResourceLogs(resourceLogs plog.ResourceLogs, receiveTimestamp time.Time, out *model.Batch) {
 	var timeDelta time.Duration
 	resource := resourceLogs.Resource()
 	baseEvent := model.APMEvent{Processor: model.LogProcessor}
-	translateResourceMetadata(resource, &baseEvent)
+	translateResourceMetadata(resource, &baseEvent, &c.stats.truncatedFields)
 
 	if exportTimestamp, ok := exportTimestamp(resource); ok {
 		timeDelta = receiveTimestamp.Sub(exportTimestamp)
@@ -91,13 +107,22 @@ func (c *Consumer) convertLogRecord(
 ) model.APMEvent {
 	event := baseEvent
 	initEventLabels(&event)
-	event.Timestamp = record.Timestamp().AsTime().Add(timeDelta)
+	timestamp := record.Timestamp()
+	if timestamp == 0 {
+		c.checkTimestamp(record.ObservedTimestamp())
+		// Per the OpenTelemetry logs data model, the observed
+		// timestamp should be used if the timestamp is unknown.
+		timestamp = record.ObservedTimestamp()
+	}
+	event.Timestamp = timestamp.AsTime().Add(timeDelta)
 	event.Event.Severity = int64(record.SeverityNumber())
 	event.Log.Level = record.SeverityText()
 	if body := record.Body(); body.Type() != pcommon.ValueTypeEmpty {
-		event.Message = body.AsString()
 		if body.Type() == pcommon.ValueTypeMap {
-			setLabels(body.Map(), &event)
+			event.Body = make(map[string]any, body.Map().Len())
+			flattenMap("", body.Map(), event.Body)
+		} else {
+			event.Message = body.AsString()
 		}
 	}
 	if traceID := record.TraceID(); !traceID.IsEmpty() {
@@ -109,15 +134,74 @@ func (c *Consumer) convertLogRecord(
 		}
 		event.Span.ID = spanID.HexString()
 	}
-	if attrs := record.Attributes(); attrs.Len() > 0 {
-		setLabels(attrs, &event)
+
+	var exceptionEscaped bool
+	var exceptionMessage, exceptionStacktrace, exceptionType string
+	record.Attributes().Range(func(k string, v pcommon.Value) bool {
+		switch k {
+		case semconv.AttributeExceptionMessage:
+			exceptionMessage = v.AsString()
+		case semconv.AttributeExceptionStacktrace:
+			exceptionStacktrace = v.AsString()
+		case semconv.AttributeExceptionType:
+			exceptionType = v.AsString()
+		case "exception.escaped":
+			exceptionEscaped = v.Bool()
+		case semconv.AttributeCodeFunction:
+			event.Log.Origin.FunctionName = v.AsString()
+		case semconv.AttributeCodeFilepath:
+			event.Log.Origin.File.Name = v.AsString()
+		case semconv.AttributeCodeLineNumber:
+			if v.Type() != pcommon.ValueTypeInt {
+				setLabel(k, &event, ifaceAttributeValue(v, &c.stats.truncatedFields))
+				break
+			}
+			event.Log.Origin.File.Line = int(v.Int())
+		case semconv.AttributeThreadName:
+			event.Process.Thread.Name = v.AsString()
+		case semconv.AttributeThreadID:
+			if v.Type() != pcommon.ValueTypeInt {
+				setLabel(k, &event, ifaceAttributeValue(v, &c.stats.truncatedFields))
+				break
+			}
+			event.Process.Thread.ID = int(v.Int())
+		case "log.logger":
+			event.Log.Logger = v.AsString()
+		case "event.name":
+			event.Event.Action = v.AsString()
+		default:
+			// Other attributes, including event.domain, are recorded
+			// as labels. event.domain does not correspond to any of
+			// ECS' event.category values, so it is not mapped there.
+			setLabel(k, &event, ifaceAttributeValue(v, &c.stats.truncatedFields))
+		}
+		return true
+	})
+	if exceptionMessage != "" || exceptionType != "" {
+		// Per OpenTelemetry semantic conventions, log records
+		// describing exceptions have the same exception.* attributes
+		// as exception span events.
+		event.Processor = model.ErrorProcessor
+		event.Error = convertOpenTelemetryExceptionSpanEvent(
+			exceptionType, exceptionMessage, exceptionStacktrace,
+			exceptionEscaped, event.Service.Language.Name,
+		)
 	}
 	return event
 }
 
-func setLabels(m pcommon.Map, event *model.APMEvent) {
+// flattenMap stores the values of m in out, recursing into nested
+// maps and joining their keys with dots.
+func flattenMap(prefix string, m pcommon.Map, out map[string]any) {
 	m.Range(func(k string, v pcommon.Value) bool {
-		setLabel(k, event, ifaceAttributeValue(v))
+		if prefix != "" {
+			k = prefix + "." + k
+		}
+		if v.Type() == pcommon.ValueTypeMap {
+			flattenMap(k, v.Map(), out)
+		} else {
+			out[k] = v.AsRaw()
+		}
 		return true
 	})
 }
@@ -36,10 +36,12 @@ package otlp_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
 	semconv "go.opentelemetry.io/collector/semconv/v1.5.0"
@@ -110,7 +112,92 @@ func TestConsumerConsumeLogs(t *testing.T) {
 	test("int_body", 1234, "1234")
 	test("float_body", 1234.1234, "1234.1234")
 	test("bool_body", true, "true")
-	// TODO(marclop): How to test map body
+}
+
+func TestConsumerConsumeLogsMapBody(t *testing.T) {
+	record := newLogRecord(nil)
+	body := record.Body().SetEmptyMap()
+	body.PutStr("message", "hello")
+	nested := body.PutEmptyMap("nested")
+	nested.PutInt("count", 2)
+	nested.PutEmptyMap("inner").PutBool("ok", true)
+	nested.PutEmptySlice("values").AppendEmpty().SetStr("a")
+
+	processed := consumeLogRecords(t, record)
+	require.Len(t, processed, 1)
+	assert.Empty(t, processed[0].Message)
+	assert.Equal(t, map[string]any{
+		"message":         "hello",
+		"nested.count":    int64(2),
+		"nested.inner.ok": true,
+		"nested.values":   []any{"a"},
+	}, processed[0].Body)
+	assert.Empty(t, processed[0].Labels)
+}
+
+func TestConsumerConsumeLogsSemanticConventions(t *testing.T) {
+	record := newLogRecord("a log message")
+	attrs := record.Attributes()
+	attrs.PutStr("code.function", "handle")
+	attrs.PutStr("code.filepath", "server.go")
+	attrs.PutInt("code.lineno", 42)
+	attrs.PutStr("thread.name", "main")
+	attrs.PutInt("thread.id", 7)
+	attrs.PutStr("log.logger", "com.example.Server")
+	attrs.PutStr("event.name", "click")
+	attrs.PutStr("event.domain", "browser")
+	attrs.PutStr("other", "label")
+
+	processed := consumeLogRecords(t, record)
+	require.Len(t, processed, 1)
+	event := processed[0]
+	assert.Equal(t, model.LogProcessor, event.Processor)
+	assert.Equal(t, "a log message", event.Message)
+	assert.Equal(t, model.Log{
+		Level:  "Info",
+		Logger: "com.example.Server",
+		Origin: model.LogOrigin{
+			FunctionName: "handle",
+			File:         model.LogOriginFile{Name: "server.go", Line: 42},
+		},
+	}, event.Log)
+	assert.Equal(t, model.ProcessThread{ID: 7, Name: "main"}, event.Process.Thread)
+	assert.Equal(t, "click", event.Event.Action)
+	assert.Equal(t, model.Labels{
+		"event.domain": {Value: "browser"},
+		"other":        {Value: "label"},
+	}, event.Labels)
+	assert.Empty(t, event.NumericLabels)
+}
+
+func TestConsumerConsumeLogsException(t *testing.T) {
+	record := newLogRecord("an exception occurred")
+	record.Attributes().PutStr("exception.type", "java.lang.NullPointerException")
+	record.Attributes().PutStr("exception.message", "boom")
+	record.Attributes().PutBool("exception.escaped", true)
+
+	processed := consumeLogRecords(t, record)
+	require.Len(t, processed, 1)
+	event := processed[0]
+	assert.Equal(t, model.ErrorProcessor, event.Processor)
+	assert.Equal(t, "an exception occurred", event.Message)
+	require.NotNil(t, event.Error)
+	require.NotNil(t, event.Error.Exception)
+	assert.Equal(t, "java.lang.NullPointerException", event.Error.Exception.Type)
+	assert.Equal(t, "boom", event.Error.Exception.Message)
+	assert.Equal(t, newBool(false), event.Error.Exception.Handled)
+	assert.Empty(t, event.Labels)
+}
+
+func TestConsumerConsumeLogsObservedTimestamp(t *testing.T) {
+	observed := time.Unix(123, 0).UTC()
+	record := newLogRecord("a log message")
+	record.SetTimestamp(0)
+	record.SetObservedTimestamp(pcommon.NewTimestampFromTime(observed))
+
+	processed := consumeLogRecords(t, record)
+	require.Len(t, processed, 1)
+	assert.Equal(t, observed, processed[0].Timestamp)
 }
 
 func TestConsumerConsumeLogsLabels(t *testing.T) {
@@ -156,6 +243,52 @@ func TestConsumerConsumeLogsLabels(t *testing.T) {
 	assert.Equal(t, model.NumericLabels{"key4": {Value: 4}}, processed[2].NumericLabels)
 }
 
+func TestConsumerConsumeLogsWithResult(t *testing.T) {
+	logs := plog.NewLogs()
+	records := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	newLogRecord("one").CopyTo(records.AppendEmpty())
+	record := newLogRecord("two")
+	record.SetTimestamp(0)
+	record.CopyTo(records.AppendEmpty())
+
+	var batches []*model.Batch
+	consumer := otlp.NewConsumer(otlp.ConsumerConfig{Processor: batchRecorderBatchProcessor(&batches)})
+	result, err := consumer.ConsumeLogsWithResult(context.Background(), logs)
+	require.NoError(t, err)
+	assert.Equal(t, otlp.ConsumeResult{Accepted: 2}, result)
+
+	stats := consumer.Stats()
+	assert.Equal(t, int64(2), stats.LogRecordsAccepted)
+	assert.Equal(t, int64(1), stats.InvalidTimestamps)
+
+	// Log records are not accepted if processing fails.
+	consumer = otlp.NewConsumer(otlp.ConsumerConfig{
+		Processor: model.ProcessBatchFunc(func(context.Context, *model.Batch) error {
+			return errors.New("processor failed")
+		}),
+	})
+	result, err = consumer.ConsumeLogsWithResult(context.Background(), logs)
+	assert.EqualError(t, err, "processor failed")
+	assert.Equal(t, otlp.ConsumeResult{}, result)
+	assert.Zero(t, consumer.Stats().LogRecordsAccepted)
+}
+
+func consumeLogRecords(t *testing.T, records ...plog.LogRecord) model.Batch {
+	logs := plog.NewLogs()
+	resourceLogs := logs.ResourceLogs().AppendEmpty()
+	resourceLogs.Resource().Attributes().PutStr(semconv.AttributeTelemetrySDKLanguage, "java")
+	scopeLogs := resourceLogs.ScopeLogs().AppendEmpty()
+	for _, record := range records {
+		record.CopyTo(scopeLogs.LogRecords().AppendEmpty())
+	}
+
+	var batches []*model.Batch
+	consumer := otlp.NewConsumer(otlp.ConsumerConfig{Processor: batchRecorderBatchProcessor(&batches)})
+	require.NoError(t, consumer.ConsumeLogs(context.Background(), logs))
+	require.Len(t, batches, 1)
+	return *batches[0]
+}
+
 func newLogRecord(body interface{}) plog.LogRecord {
 	otelLogRecord := plog.NewLogRecord()
 	otelLogRecord.SetTraceID(pcommon.TraceID{1})
@@ -173,10 +306,6 @@ func newLogRecord(body interface{}) plog.LogRecord {
 		otelLogRecord.Body().SetDouble(b)
 	case bool:
 		otelLogRecord.Body().SetBool(b)
-		// case map[string]string:
-		// TODO(marclop) figure out how to set the body since it cannot be set
-		// as a map.
-		// otelLogRecord.Body()
 	}
 	return otelLogRecord
 }
@@ -38,105 +38,105 @@ var (
 	serviceNameInvalidRegexp = regexp.MustCompile("[^a-zA-Z0-9 _-]")
 )
 
-func translateResourceMetadata(resource pcommon.Resource, out *model.APMEvent) {
+func translateResourceMetadata(resource pcommon.Resource, out *model.APMEvent, truncated *int64) {
 	var exporterVersion string
 	resource.Attributes().Range(func(k string, v pcommon.Value) bool {
 		switch k {
 		// service.*
 		case semconv.AttributeServiceName:
-			out.Service.Name = cleanServiceName(v.Str())
+			out.Service.Name = cleanServiceName(v.Str(), truncated)
 		case semconv.AttributeServiceVersion:
-			out.Service.Version = truncate(v.Str())
+			out.Service.Version = truncate(v.Str(), truncated)
 		case semconv.AttributeServiceInstanceID:
-			out.Service.Node.Name = truncate(v.Str())
+			out.Service.Node.Name = truncate(v.Str(), truncated)
 
 		// deployment.*
 		case semconv.AttributeDeploymentEnvironment:
-			out.Service.Environment = truncate(v.Str())
+			out.Service.Environment = truncate(v.Str(), truncated)
 
 		// telemetry.sdk.*
 		case semconv.AttributeTelemetrySDKName:
-			out.Agent.Name = truncate(v.Str())
+			out.Agent.Name = truncate(v.Str(), truncated)
 		case semconv.AttributeTelemetrySDKVersion:
-			out.Agent.Version = truncate(v.Str())
+			out.Agent.Version = truncate(v.Str(), truncated)
 		case semconv.AttributeTelemetrySDKLanguage:
-			out.Service.Language.Name = truncate(v.Str())
+			out.Service.Language.Name = truncate(v.Str(), truncated)
 
 		// cloud.*
 		case semconv.AttributeCloudProvider:
-			out.Cloud.Provider = truncate(v.Str())
+			out.Cloud.Provider = truncate(v.Str(), truncated)
 		case semconv.AttributeCloudAccountID:
-			out.Cloud.AccountID = truncate(v.Str())
+			out.Cloud.AccountID = truncate(v.Str(), truncated)
 		case semconv.AttributeCloudRegion:
-			out.Cloud.Region = truncate(v.Str())
+			out.Cloud.Region = truncate(v.Str(), truncated)
 		case semconv.AttributeCloudAvailabilityZone:
-			out.Cloud.AvailabilityZone = truncate(v.Str())
+			out.Cloud.AvailabilityZone = truncate(v.Str(), truncated)
 		case semconv.AttributeCloudPlatform:
-			out.Cloud.ServiceName = truncate(v.Str())
+			out.Cloud.ServiceName = truncate(v.Str(), truncated)
 
 		// container.*
 		case semconv.AttributeContainerName:
-			out.Container.Name = truncate(v.Str())
+			out.Container.Name = truncate(v.Str(), truncated)
 		case semconv.AttributeContainerID:
-			out.Container.ID = truncate(v.Str())
+			out.Container.ID = truncate(v.Str(), truncated)
 		case semconv.AttributeContainerImageName:
-			out.Container.ImageName = truncate(v.Str())
+			out.Container.ImageName = truncate(v.Str(), truncated)
 		case semconv.AttributeContainerImageTag:
-			out.Container.ImageTag = truncate(v.Str())
+			out.Container.ImageTag = truncate(v.Str(), truncated)
 		case "container.runtime":
-			out.Container.Runtime = truncate(v.Str())
+			out.Container.Runtime = truncate(v.Str(), truncated)
 
 		// k8s.*
 		case semconv.AttributeK8SNamespaceName:
-			out.Kubernetes.Namespace = truncate(v.Str())
+			out.Kubernetes.Namespace = truncate(v.Str(), truncated)
 		case semconv.AttributeK8SNodeName:
-			out.Kubernetes.NodeName = truncate(v.Str())
+			out.Kubernetes.NodeName = truncate(v.Str(), truncated)
 		case semconv.AttributeK8SPodName:
-			out.Kubernetes.PodName = truncate(v.Str())
+			out.Kubernetes.PodName = truncate(v.Str(), truncated)
 		case semconv.AttributeK8SPodUID:
-			out.Kubernetes.PodUID = truncate(v.Str())
+			out.Kubernetes.PodUID = truncate(v.Str(), truncated)
 
 		// host.*
 		case semconv.AttributeHostName:
-			out.Host.Hostname = truncate(v.Str())
+			out.Host.Hostname = truncate(v.Str(), truncated)
 		case semconv.AttributeHostID:
-			out.Host.ID = truncate(v.Str())
+			out.Host.ID = truncate(v.Str(), truncated)
 		case semconv.AttributeHostType:
-			out.Host.Type = truncate(v.Str())
+			out.Host.Type = truncate(v.Str(), truncated)
 		case "host.arch":
-			out.Host.Architecture = truncate(v.Str())
+			out.Host.Architecture = truncate(v.Str(), truncated)
 
 		// process.*
 		case semconv.AttributeProcessPID:
 			out.Process.Pid = int(v.Int())
 		case semconv.AttributeProcessCommandLine:
-			out.Process.CommandLine = truncate(v.Str())
+			out.Process.CommandLine = truncate(v.Str(), truncated)
 		case semconv.AttributeProcessExecutablePath:
-			out.Process.Executable = truncate(v.Str())
+			out.Process.Executable = truncate(v.Str(), truncated)
 		case "process.runtime.name":
-			out.Service.Runtime.Name = truncate(v.Str())
+			out.Service.Runtime.Name = truncate(v.Str(), truncated)
 		case "process.runtime.version":
-			out.Service.Runtime.Version = truncate(v.Str())
+			out.Service.Runtime.Version = truncate(v.Str(), truncated)
 
 		// os.*
 		case semconv.AttributeOSType:
-			out.Host.OS.Platform = strings.ToLower(truncate(v.Str()))
+			out.Host.OS.Platform = strings.ToLower(truncate(v.Str(), truncated))
 		case semconv.AttributeOSDescription:
-			out.Host.OS.Full = truncate(v.Str())
+			out.Host.OS.Full = truncate(v.Str(), truncated)
 		case semconv.AttributeOSName:
-			out.Host.OS.Name = truncate(v.Str())
+			out.Host.OS.Name = truncate(v.Str(), truncated)
 		case semconv.AttributeOSVersion:
-			out.Host.OS.Version = truncate(v.Str())
+			out.Host.OS.Version = truncate(v.Str(), truncated)
 
 		// device.*
 		case semconv.AttributeDeviceID:
-			out.Device.ID = truncate(v.Str())
+			out.Device.ID = truncate(v.Str(), truncated)
 		case semconv.AttributeDeviceModelIdentifier:
-			out.Device.Model.Identifier = truncate(v.Str())
+			out.Device.Model.Identifier = truncate(v.Str(), truncated)
 		case semconv.AttributeDeviceModelName:
-			out.Device.Model.Name = truncate(v.Str())
+			out.Device.Model.Name = truncate(v.Str(), truncated)
 		case "device.manufacturer":
-			out.Device.Manufacturer = truncate(v.Str())
+			out.Device.Manufacturer = truncate(v.Str(), truncated)
 
 		// Legacy OpenCensus attributes.
 		case "opencensus.exporterversion":
@@ -154,7 +154,7 @@ func translateResourceMetadata(resource pcommon.Resource, out *model.APMEvent) {
 			if out.NumericLabels == nil {
 				out.NumericLabels = make(model.NumericLabels)
 			}
-			setLabel(replaceDots(k), out, ifaceAttributeValue(v))
+			setLabel(replaceDots(k), out, ifaceAttributeValue(v, truncated))
 		}
 		return true
 	})
@@ -233,14 +233,14 @@ func translateResourceMetadata(resource pcommon.Resource, out *model.APMEvent) {
 	}
 }
 
-func cleanServiceName(name string) string {
-	return serviceNameInvalidRegexp.ReplaceAllString(truncate(name), "_")
+func cleanServiceName(name string, truncated *int64) string {
+	return serviceNameInvalidRegexp.ReplaceAllString(truncate(name, truncated), "_")
 }
 
-func ifaceAttributeValue(v pcommon.Value) interface{} {
+func ifaceAttributeValue(v pcommon.Value, truncated *int64) interface{} {
 	switch v.Type() {
 	case pcommon.ValueTypeStr:
-		return truncate(v.Str())
+		return truncate(v.Str(), truncated)
 	case pcommon.ValueTypeBool:
 		return strconv.FormatBool(v.Bool())
 	case pcommon.ValueTypeInt:
@@ -248,15 +248,15 @@ func ifaceAttributeValue(v pcommon.Value) interface{} {
 	case pcommon.ValueTypeDouble:
 		return v.Double()
 	case pcommon.ValueTypeSlice:
-		return ifaceAttributeValueSlice(v.Slice())
+		return ifaceAttributeValueSlice(v.Slice(), truncated)
 	}
 	return nil
 }
 
-func ifaceAttributeValueSlice(slice pcommon.Slice) []interface{} {
+func ifaceAttributeValueSlice(slice pcommon.Slice, truncated *int64) []interface{} {
 	values := make([]interface{}, slice.Len())
 	for i := range values {
-		values[i] = ifaceAttributeValue(slice.At(i))
+		values[i] = ifaceAttributeValue(slice.At(i), truncated)
 	}
 	return values
 }
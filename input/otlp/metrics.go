@@ -53,32 +53,53 @@ import (
 // ConsumeMetrics consumes OpenTelemetry metrics data, converting into
 // the Elastic APM metrics model and sending to the reporter.
 func (c *Consumer) ConsumeMetrics(ctx context.Context, metrics pmetric.Metrics) error {
+	_, err := c.ConsumeMetricsWithResult(ctx, metrics)
+	return err
+}
+
+// ConsumeMetricsWithResult consumes OpenTelemetry metrics data like
+// ConsumeMetrics, additionally returning the number of data points
+// that were accepted and rejected.
+//
+// Data points that cannot be represented in the Elastic APM metrics
+// model are rejected. Metrics of an unsupported type have no data
+// points, and so are dropped without rejecting any data points.
+func (c *Consumer) ConsumeMetricsWithResult(ctx context.Context, metrics pmetric.Metrics) (ConsumeResult, error) {
 	receiveTimestamp := time.Now()
 	c.config.Logger.Debug("consuming metrics", zap.Stringer("metrics", metricsStringer(metrics)))
-	batch := c.convertMetrics(metrics, receiveTimestamp)
-	return c.config.Processor.ProcessBatch(ctx, batch)
+	var result ConsumeResult
+	batch := c.convertMetrics(metrics, receiveTimestamp, &result)
+	atomic.AddInt64(&c.stats.metricDataPointsRejected, result.Rejected)
+	if err := c.config.Processor.ProcessBatch(ctx, batch); err != nil {
+		// Data points are only accepted if processing succeeds,
+		// but those rejected during conversion remain rejected.
+		result.Accepted = 0
+		return result, err
+	}
+	atomic.AddInt64(&c.stats.metricDataPointsAccepted, result.Accepted)
+	return result, nil
 }
 
-func (c *Consumer) convertMetrics(metrics pmetric.Metrics, receiveTimestamp time.Time) *model.Batch {
+func (c *Consumer) convertMetrics(metrics pmetric.Metrics, receiveTimestamp time.Time, result *ConsumeResult) *model.Batch {
 	batch := model.Batch{}
 	resourceMetrics := metrics.ResourceMetrics()
 	for i := 0; i < resourceMetrics.Len(); i++ {
-		c.convertResourceMetrics(resourceMetrics.At(i), receiveTimestamp, &batch)
+		c.convertResourceMetrics(resourceMetrics.At(i), receiveTimestamp, &batch, result)
 	}
 	return &batch
 }
 
-func (c *Consumer) convertResourceMetrics(resourceMetrics pmetric.ResourceMetrics, receiveTimestamp time.Time, out *model.Batch) {
+func (c *Consumer) convertResourceMetrics(resourceMetrics pmetric.ResourceMetrics, receiveTimestamp time.Time, out *model.Batch, result *ConsumeResult) {
 	var baseEvent model.APMEvent
 	var timeDelta time.Duration
 	resource := resourceMetrics.Resource()
-	translateResourceMetadata(resource, &baseEvent)
+	translateResourceMetadata(resource, &baseEvent, &c.stats.truncatedFields)
 	if exportTimestamp, ok := exportTimestamp(resource); ok {
 		timeDelta = receiveTimestamp.Sub(exportTimestamp)
 	}
 	scopeMetrics := resourceMetrics.ScopeMetrics()
 	for i := 0; i < scopeMetrics.Len(); i++ {
-		c.convertScopeMetrics(scopeMetrics.At(i), baseEvent, timeDelta, out)
+		c.convertScopeMetrics(scopeMetrics.At(i), resource, baseEvent, timeDelta, out, result)
 	}
 }
 
@@ -287,17 +308,23 @@ func (b *apmMetricsBuilder) emit(ms metricsets) {
 
 func (c *Consumer) convertScopeMetrics(
 	in pmetric.ScopeMetrics,
+	resource pcommon.Resource,
 	baseEvent model.APMEvent,
 	timeDelta time.Duration,
 	out *model.Batch,
+	result *ConsumeResult,
 ) {
 	ms := make(metricsets)
+	var streamPrefix string
+	if c.deltas != nil {
+		streamPrefix = cumulativeStreamPrefix(resource, in.Scope())
+	}
 	otelMetrics := in.Metrics()
 	var unsupported int64
 	builder := newAPMMetricsBuilder()
 	for i := 0; i < otelMetrics.Len(); i++ {
 		builder.accumulate(otelMetrics.At(i))
-		if !c.addMetric(otelMetrics.At(i), ms) {
+		if !c.addMetric(otelMetrics.At(i), streamPrefix, ms, result) {
 			unsupported++
 		}
 	}
@@ -314,7 +341,7 @@ func (c *Consumer) convertScopeMetrics(
 		if ms.attributes.Len() > 0 {
 			initEventLabels(&event)
 			ms.attributes.Range(func(k string, v pcommon.Value) bool {
-				setLabel(k, &event, ifaceAttributeValue(v))
+				setLabel(k, &event, ifaceAttributeValue(v, &c.stats.truncatedFields))
 				return true
 			})
 			if len(event.Labels) == 0 {
@@ -331,9 +358,21 @@ func (c *Consumer) convertScopeMetrics(
 	}
 }
 
-func (c *Consumer) addMetric(metric pmetric.Metric, ms metricsets) bool {
+// addMetric adds the metric's data points to ms, reporting whether all
+// data points were supported. If cumulative-to-delta conversion is
+// enabled, streamPrefix identifies the metric's resource and scope.
+// Accepted and rejected data points are recorded in result.
+func (c *Consumer) addMetric(metric pmetric.Metric, streamPrefix string, ms metricsets, result *ConsumeResult) bool {
 	// TODO(axw) support units
 	anyDropped := false
+	accept := func(timestamp pcommon.Timestamp) {
+		c.checkTimestamp(timestamp)
+		result.Accepted++
+	}
+	reject := func() {
+		anyDropped = true
+		result.reject(fmt.Errorf("unsupported data point for %s metric %q", metric.Type(), metric.Name()))
+	}
 	switch metric.Type() {
 	case pmetric.MetricTypeGauge:
 		dps := metric.Gauge().DataPoints()
@@ -341,33 +380,72 @@ func (c *Consumer) addMetric(metric pmetric.Metric, ms metricsets) bool {
 			dp := dps.At(i)
 			if sample, ok := numberSample(dp, model.MetricTypeGauge); ok {
 				sample.Name = metric.Name()
+				accept(dp.Timestamp())
 				ms.upsert(dp.Timestamp().AsTime(), dp.Attributes(), sample)
 			} else {
-				anyDropped = true
+				reject()
 			}
 		}
 		return !anyDropped
 	case pmetric.MetricTypeSum:
-		dps := metric.Sum().DataPoints()
+		sum := metric.Sum()
+		toDelta := c.deltas != nil && sum.IsMonotonic() &&
+			sum.AggregationTemporality() == pmetric.AggregationTemporalityCumulative
+		dps := sum.DataPoints()
 		for i := 0; i < dps.Len(); i++ {
 			dp := dps.At(i)
 			if sample, ok := numberSample(dp, model.MetricTypeCounter); ok {
+				if toDelta {
+					key := cumulativeStreamKey(streamPrefix, metric.Name(), dp.Attributes())
+					if sample.Value, ok = c.deltas.sum(key, dp, sample.Value); !ok {
+						accept(dp.Timestamp())
+						continue
+					}
+				}
 				sample.Name = metric.Name()
+				accept(dp.Timestamp())
 				ms.upsert(dp.Timestamp().AsTime(), dp.Attributes(), sample)
 			} else {
-				anyDropped = true
+				reject()
 			}
 		}
 		return !anyDropped
 	case pmetric.MetricTypeHistogram:
-		dps := metric.Histogram().DataPoints()
+		histogram := metric.Histogram()
+		toDelta := c.deltas != nil &&
+			histogram.AggregationTemporality() == pmetric.AggregationTemporalityCumulative
+		dps := histogram.DataPoints()
 		for i := 0; i < dps.Len(); i++ {
 			dp := dps.At(i)
-			if sample, ok := histogramSample(dp.BucketCounts(), dp.ExplicitBounds()); ok {
+			bucketCounts := dp.BucketCounts()
+			if toDelta && bucketCounts.Len() == dp.ExplicitBounds().Len()+1 {
+				key := cumulativeStreamKey(streamPrefix, metric.Name(), dp.Attributes())
+				delta, ok := c.deltas.histogram(key, dp)
+				if !ok {
+					accept(dp.Timestamp())
+					continue
+				}
+				bucketCounts = pcommon.NewUInt64Slice()
+				bucketCounts.FromRaw(delta)
+			}
+			if sample, ok := histogramSample(bucketCounts, dp.ExplicitBounds()); ok {
 				sample.Name = metric.Name()
+				accept(dp.Timestamp())
 				ms.upsert(dp.Timestamp().AsTime(), dp.Attributes(), sample)
 			} else {
-				anyDropped = true
+				reject()
+			}
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		dps := metric.ExponentialHistogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			if sample, ok := exponentialHistogramSample(dp); ok {
+				sample.Name = metric.Name()
+				accept(dp.Timestamp())
+				ms.upsert(dp.Timestamp().AsTime(), dp.Attributes(), sample)
+			} else {
+				reject()
 			}
 		}
 	case pmetric.MetricTypeSummary:
@@ -376,11 +454,14 @@ func (c *Consumer) addMetric(metric pmetric.Metric, ms metricsets) bool {
 			dp := dps.At(i)
 			sample := summarySample(dp)
 			sample.Name = metric.Name()
+			accept(dp.Timestamp())
 			ms.upsert(dp.Timestamp().AsTime(), dp.Attributes(), sample)
 		}
 	default:
-		// Unsupported metric: report that it has been dropped.
-		anyDropped = true
+		// Unsupported metric: report that it has been dropped. The
+		// only such metric type is Empty, which has no data points,
+		// so no data points are rejected.
+		return false
 	}
 	return !anyDropped
 }
@@ -479,6 +560,68 @@ func histogramSample(bucketCounts pcommon.UInt64Slice, explicitBounds pcommon.Fl
 	}, true
 }
 
+func exponentialHistogramSample(dp pmetric.ExponentialHistogramDataPoint) (model.MetricsetSample, bool) {
+	// (From opentelemetry-proto/opentelemetry/proto/metrics/v1/metrics.proto)
+	//
+	// The histogram bucket identified by `index`, a signed integer,
+	// contains values that are greater than (base^index) and
+	// less than or equal to (base^(index+1)).
+	//
+	// base = (2^(2^-scale))
+	//
+	// Negative buckets hold the corresponding negative values, and values
+	// close to zero are counted in the zero bucket.
+	//
+	// For consistency with histogramSample, we use the midpoint between
+	// each bucket's boundaries as its value. Values must be increasing,
+	// so we record the negative buckets in reverse order, followed by the
+	// zero bucket and the positive buckets.
+	scale := float64(dp.Scale())
+	negative := dp.Negative().BucketCounts()
+	positive := dp.Positive().BucketCounts()
+	size := negative.Len() + positive.Len() + 1
+	values := make([]float64, 0, size)
+	counts := make([]int64, 0, size)
+	appendBucket := func(index int, count uint64, sign float64) bool {
+		if count == 0 {
+			return true
+		}
+		lower := math.Exp2(float64(index) / math.Exp2(scale))
+		upper := math.Exp2(float64(index+1) / math.Exp2(scale))
+		value := lower + (upper-lower)/2.0
+		if math.IsInf(value, 0) || math.IsNaN(value) {
+			return false
+		}
+		values = append(values, sign*value)
+		counts = append(counts, int64(count))
+		return true
+	}
+
+	offset := int(dp.Negative().Offset())
+	for i := negative.Len() - 1; i >= 0; i-- {
+		if !appendBucket(offset+i, negative.At(i), -1) {
+			return model.MetricsetSample{}, false
+		}
+	}
+	if count := dp.ZeroCount(); count > 0 {
+		values = append(values, 0)
+		counts = append(counts, int64(count))
+	}
+	offset = int(dp.Positive().Offset())
+	for i := 0; i < positive.Len(); i++ {
+		if !appendBucket(offset+i, positive.At(i), 1) {
+			return model.MetricsetSample{}, false
+		}
+	}
+	return model.MetricsetSample{
+		Type: model.MetricTypeHistogram,
+		Histogram: model.Histogram{
+			Counts: counts,
+			Values: values,
+		},
+	}, true
+}
+
 type metricsets map[metricsetKey]metricset
 
 type metricsetKey struct {
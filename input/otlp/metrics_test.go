@@ -36,6 +36,7 @@ package otlp_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"net/netip"
@@ -212,6 +213,82 @@ func TestConsumeMetrics(t *testing.T) {
 	eventsMatch(t, expected, events)
 }
 
+func TestConsumeMetricsExponentialHistogram(t *testing.T) {
+	timestamp := time.Unix(123, 0).UTC()
+	metrics := pmetric.NewMetrics()
+	resourceMetrics := metrics.ResourceMetrics().AppendEmpty()
+	scopeMetrics := resourceMetrics.ScopeMetrics().AppendEmpty()
+	metricSlice := scopeMetrics.Metrics()
+	appendDataPoint := func(name string) pmetric.ExponentialHistogramDataPoint {
+		metric := metricSlice.AppendEmpty()
+		metric.SetName(name)
+		dp := metric.SetEmptyExponentialHistogram().DataPoints().AppendEmpty()
+		dp.SetTimestamp(pcommon.NewTimestampFromTime(timestamp))
+		return dp
+	}
+
+	// base = 2: buckets (1,2], (2,4], (4,8]
+	dp := appendDataPoint("histogram_scale_0")
+	dp.SetZeroCount(4)
+	dp.Positive().SetOffset(0)
+	dp.Positive().BucketCounts().Append(1, 0, 2)
+	dp.Negative().SetOffset(1)
+	dp.Negative().BucketCounts().Append(3, 5)
+
+	// base = sqrt(2): buckets (0.5,0.707...], (0.707...,1]
+	dp = appendDataPoint("histogram_scale_1")
+	dp.SetScale(1)
+	dp.Positive().SetOffset(-2)
+	dp.Positive().BucketCounts().Append(1, 2)
+
+	// base = 4: buckets (1/16,1/4]
+	dp = appendDataPoint("histogram_scale_-1")
+	dp.SetScale(-1)
+	dp.Positive().SetOffset(-2)
+	dp.Positive().BucketCounts().Append(6)
+
+	// Bucket boundaries overflow float64.
+	dp = appendDataPoint("invalid_histogram")
+	dp.Positive().SetOffset(2000)
+	dp.Positive().BucketCounts().Append(1)
+
+	events, stats := transformMetrics(t, metrics)
+	assert.Equal(t, int64(1), stats.UnsupportedMetricsDropped)
+	eventsMatch(t, []model.APMEvent{{
+		Agent:     model.Agent{Name: "otlp", Version: "unknown"},
+		Service:   model.Service{Name: "unknown", Language: model.Language{Name: "unknown"}},
+		Timestamp: timestamp,
+		Processor: model.MetricsetProcessor,
+		Metricset: &model.Metricset{
+			Samples: []model.MetricsetSample{{
+				Name: "histogram_scale_0",
+				Type: "histogram",
+				Histogram: model.Histogram{
+					Counts: []int64{5, 3, 4, 1, 2},
+					Values: []float64{-6, -3, 0, 1.5, 6},
+				},
+			}, {
+				Name: "histogram_scale_1",
+				Type: "histogram",
+				Histogram: model.Histogram{
+					Counts: []int64{1, 2},
+					Values: []float64{
+						0.5 + (math.Sqrt2/2-0.5)/2,
+						math.Sqrt2/2 + (1-math.Sqrt2/2)/2,
+					},
+				},
+			}, {
+				Name: "histogram_scale_-1",
+				Type: "histogram",
+				Histogram: model.Histogram{
+					Counts: []int64{6},
+					Values: []float64{0.15625},
+				},
+			}},
+		},
+	}}, events)
+}
+
 func TestConsumeMetricsNaN(t *testing.T) {
 	timestamp := time.Unix(123, 0).UTC()
 	metrics := pmetric.NewMetrics()
@@ -233,6 +310,59 @@ func TestConsumeMetricsNaN(t *testing.T) {
 	assert.Empty(t, events)
 }
 
+func TestConsumeMetricsWithResult(t *testing.T) {
+	timestamp := time.Unix(123, 0).UTC()
+	metrics := pmetric.NewMetrics()
+	metricSlice := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+
+	gauge := metricSlice.AppendEmpty()
+	gauge.SetName("gauge")
+	dps := gauge.SetEmptyGauge().DataPoints()
+	dp := dps.AppendEmpty()
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(timestamp))
+	dp.SetDoubleValue(1)
+	dp = dps.AppendEmpty()
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(timestamp))
+	dp.SetDoubleValue(math.NaN())
+	dp = dps.AppendEmpty() // no timestamp
+	dp.SetDoubleValue(2)
+	dp.Attributes().PutStr("k", "v")
+
+	metricSlice.AppendEmpty().SetName("empty")
+
+	var batches []*model.Batch
+	consumer := otlp.NewConsumer(otlp.ConsumerConfig{Processor: batchRecorderBatchProcessor(&batches)})
+	result, err := consumer.ConsumeMetricsWithResult(context.Background(), metrics)
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+	assert.Len(t, *batches[0], 2)
+
+	// Rejections are counted in data points: the empty
+	// metric is dropped, but has no data points to reject.
+	assert.Equal(t, int64(2), result.Accepted)
+	assert.Equal(t, int64(1), result.Rejected)
+	assert.Equal(t, `unsupported data point for Gauge metric "gauge"`, result.ErrorMessage())
+
+	stats := consumer.Stats()
+	assert.Equal(t, int64(2), stats.UnsupportedMetricsDropped)
+	assert.Equal(t, int64(2), stats.MetricDataPointsAccepted)
+	assert.Equal(t, int64(1), stats.MetricDataPointsRejected)
+	assert.Equal(t, int64(1), stats.InvalidTimestamps)
+
+	// Data points are not accepted if processing fails,
+	// but rejected data points are still reported.
+	consumer = otlp.NewConsumer(otlp.ConsumerConfig{
+		Processor: model.ProcessBatchFunc(func(context.Context, *model.Batch) error {
+			return errors.New("processor failed")
+		}),
+	})
+	result, err = consumer.ConsumeMetricsWithResult(context.Background(), metrics)
+	assert.EqualError(t, err, "processor failed")
+	assert.Zero(t, result.Accepted)
+	assert.Equal(t, int64(1), result.Rejected)
+	assert.Zero(t, consumer.Stats().MetricDataPointsAccepted)
+}
+
 func TestConsumeMetricsHostCPU(t *testing.T) {
 	metrics := pmetric.NewMetrics()
 	resourceMetrics := metrics.ResourceMetrics().AppendEmpty()
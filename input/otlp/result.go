@@ -0,0 +1,68 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp
+
+import (
+	"strings"
+)
+
+// maxResultErrors holds the maximum number of errors recorded in a
+// ConsumeResult.
+const maxResultErrors = 5
+
+// ConsumeResult holds the result of consuming an OTLP payload.
+//
+// Counts are in terms of the payload's items: spans for traces, data
+// points for metrics, and log records for logs. This corresponds to
+// the partial success responses of the OTLP export services, e.g.
+// ExportTracePartialSuccess.
+type ConsumeResult struct {
+	// Accepted holds the number of items that were accepted. If the
+	// processor fails to process the items, none are accepted.
+	Accepted int64
+
+	// Rejected holds the number of items that were rejected, and
+	// not sent to the processor.
+	Rejected int64
+
+	// Errors holds a limited number of errors describing why items
+	// were rejected. If the limit is reached, Rejected is still
+	// incremented.
+	Errors []error
+}
+
+// ErrorMessage returns a message describing why items were rejected,
+// suitable for use as the error_message of an OTLP partial success
+// response. If no items were rejected, ErrorMessage returns "".
+func (r *ConsumeResult) ErrorMessage() string {
+	var sb strings.Builder
+	for i, err := range r.Errors {
+		if i > 0 {
+			sb.WriteString("; ")
+		}
+		sb.WriteString(err.Error())
+	}
+	return sb.String()
+}
+
+func (r *ConsumeResult) reject(err error) {
+	r.Rejected++
+	if len(r.Errors) < maxResultErrors {
+		r.Errors = append(r.Errors, err)
+	}
+}
@@ -0,0 +1,110 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp
+
+import (
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	semconv "go.opentelemetry.io/collector/semconv/v1.5.0"
+)
+
+// Attribute names introduced by the stable HTTP, network, database and
+// messaging semantic conventions, which supersede the semconv v1.5.0
+// names understood by TranslateTransaction and TranslateSpan.
+const (
+	attributeHTTPRequestMethod        = "http.request.method"        // v1.20.0
+	attributeHTTPResponseStatusCode   = "http.response.status_code"  // v1.20.0
+	attributeURLFull                  = "url.full"                   // v1.20.0
+	attributeURLPath                  = "url.path"                   // v1.20.0
+	attributeURLQuery                 = "url.query"                  // v1.20.0
+	attributeURLScheme                = "url.scheme"                 // v1.20.0
+	attributeServerAddress            = "server.address"             // v1.20.0
+	attributeServerPort               = "server.port"                // v1.20.0
+	attributeClientAddress            = "client.address"             // v1.20.0
+	attributeUserAgentOriginal        = "user_agent.original"        // v1.20.0
+	attributeNetworkProtocolVersion   = "network.protocol.version"   // v1.20.0
+	attributeMessagingDestinationName = "messaging.destination.name" // v1.20.0
+	attributeDBQueryText              = "db.query.text"              // v1.26.0
+)
+
+// transactionAttributeNames maps attribute names from newer semantic
+// conventions to their semconv v1.5.0 equivalents, for server and
+// consumer spans translated by TranslateTransaction.
+var transactionAttributeNames = map[string]string{
+	attributeHTTPRequestMethod:        semconv.AttributeHTTPMethod,
+	attributeHTTPResponseStatusCode:   semconv.AttributeHTTPStatusCode,
+	attributeURLFull:                  semconv.AttributeHTTPURL,
+	attributeURLPath:                  semconv.AttributeHTTPTarget,
+	attributeURLScheme:                semconv.AttributeHTTPScheme,
+	attributeServerAddress:            semconv.AttributeNetHostName,
+	attributeServerPort:               semconv.AttributeNetHostPort,
+	attributeClientAddress:            semconv.AttributeHTTPClientIP,
+	attributeUserAgentOriginal:        semconv.AttributeHTTPUserAgent,
+	attributeMessagingDestinationName: semconv.AttributeMessagingDestination,
+}
+
+// spanAttributeNames maps attribute names from newer semantic
+// conventions to their semconv v1.5.0 equivalents, for client and
+// producer spans translated by TranslateSpan. The server.* attributes
+// describe the peer from the client's point of view.
+var spanAttributeNames = map[string]string{
+	attributeHTTPRequestMethod:        semconv.AttributeHTTPMethod,
+	attributeHTTPResponseStatusCode:   semconv.AttributeHTTPStatusCode,
+	attributeURLFull:                  semconv.AttributeHTTPURL,
+	attributeURLPath:                  semconv.AttributeHTTPTarget,
+	attributeURLScheme:                semconv.AttributeHTTPScheme,
+	attributeServerAddress:            semconv.AttributeNetPeerName,
+	attributeServerPort:               semconv.AttributeNetPeerPort,
+	attributeDBQueryText:              semconv.AttributeDBStatement,
+	attributeMessagingDestinationName: semconv.AttributeMessagingDestination,
+}
+
+// Superseded semconv v1.5.0 attribute names, mapped to the names of
+// the attributes superseding them.
+var (
+	transactionSupersededNames = supersededAttributeNames(transactionAttributeNames)
+	spanSupersededNames        = supersededAttributeNames(spanAttributeNames)
+)
+
+func supersededAttributeNames(names map[string]string) map[string]string {
+	superseded := map[string]string{
+		semconv.AttributeHTTPFlavor: attributeNetworkProtocolVersion,
+	}
+	for name, oldName := range names {
+		superseded[oldName] = name
+	}
+	return superseded
+}
+
+// resolveAttributeName returns the semconv v1.5.0 name for the attribute
+// k, or k itself if it has no entry in names.
+//
+// If k is a semconv v1.5.0 name superseded by an attribute which is also
+// present in attributes, resolveAttributeName returns false and k should
+// be ignored, so the newer attribute takes precedence regardless of the
+// order of attributes.
+func resolveAttributeName(k string, names, superseded map[string]string, attributes pcommon.Map) (string, bool) {
+	if name, ok := names[k]; ok {
+		return name, true
+	}
+	if name, ok := superseded[k]; ok {
+		if _, ok := attributes.Get(name); ok {
+			return "", false
+		}
+	}
+	return k, true
+}
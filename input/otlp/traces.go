@@ -42,6 +42,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"go.opentelemetry.io/collector/pdata/pcommon"
@@ -73,6 +74,15 @@ const (
 // ConsumeTraces consumes OpenTelemetry trace data,
 // converting into Elastic APM events and reporting to the Elastic APM schema.
 func (c *Consumer) ConsumeTraces(ctx context.Context, traces ptrace.Traces) error {
+	_, err := c.ConsumeTracesWithResult(ctx, traces)
+	return err
+}
+
+// ConsumeTracesWithResult consumes OpenTelemetry trace data like
+// ConsumeTraces, additionally returning the number of spans that
+// were accepted. Spans are never rejected, but none are accepted
+// if processing fails.
+func (c *Consumer) ConsumeTracesWithResult(ctx context.Context, traces ptrace.Traces) (ConsumeResult, error) {
 	receiveTimestamp := time.Now()
 	c.config.Logger.Debug("consuming traces", zap.Stringer("traces", tracesStringer(traces)))
 
@@ -81,7 +91,12 @@ func (c *Consumer) ConsumeTraces(ctx context.Context, traces ptrace.Traces) erro
 	for i := 0; i < resourceSpans.Len(); i++ {
 		c.convertResourceSpans(resourceSpans.At(i), receiveTimestamp, &batch)
 	}
-	return c.config.Processor.ProcessBatch(ctx, &batch)
+	if err := c.config.Processor.ProcessBatch(ctx, &batch); err != nil {
+		return ConsumeResult{}, err
+	}
+	result := ConsumeResult{Accepted: int64(traces.SpanCount())}
+	atomic.AddInt64(&c.stats.spansAccepted, result.Accepted)
+	return result, nil
 }
 
 func (c *Consumer) convertResourceSpans(
@@ -92,7 +107,7 @@ func (c *Consumer) convertResourceSpans(
 	var baseEvent model.APMEvent
 	var timeDelta time.Duration
 	resource := resourceSpans.Resource()
-	translateResourceMetadata(resource, &baseEvent)
+	translateResourceMetadata(resource, &baseEvent, &c.stats.truncatedFields)
 	if exportTimestamp, ok := exportTimestamp(resource); ok {
 		timeDelta = receiveTimestamp.Sub(exportTimestamp)
 	}
@@ -127,6 +142,9 @@ func (c *Consumer) convertSpan(
 		parentID = otelSpan.ParentSpanID().HexString()
 	}
 
+	if otelSpan.StartTimestamp() == 0 || otelSpan.EndTimestamp() < otelSpan.StartTimestamp() {
+		atomic.AddInt64(&c.stats.invalidTimestamps, 1)
+	}
 	startTime := otelSpan.StartTimestamp().AsTime()
 	endTime := otelSpan.EndTimestamp().AsTime()
 	duration := endTime.Sub(startTime)
@@ -152,14 +170,14 @@ func (c *Consumer) convertSpan(
 			Name:    name,
 			Sampled: true,
 		}
-		TranslateTransaction(otelSpan.Attributes(), otelSpan.Status(), otelLibrary, &event)
+		translateTransaction(otelSpan.Attributes(), otelSpan.Status(), otelLibrary, &event, &c.stats.truncatedFields)
 	} else {
 		event.Processor = model.SpanProcessor
 		event.Span = &model.Span{
 			ID:   spanID,
 			Name: name,
 		}
-		TranslateSpan(otelSpan.Kind(), otelSpan.Attributes(), &event)
+		translateSpan(otelSpan.Kind(), otelSpan.Attributes(), &event, &c.stats.truncatedFields)
 	}
 	translateSpanLinks(&event, otelSpan.Links())
 	if len(event.Labels) == 0 {
@@ -175,6 +193,9 @@ func (c *Consumer) convertSpan(
 	event.NumericLabels = baseEvent.NumericLabels // only copy common labels to span events
 	event.Event = model.Event{}                   // don't copy event.* to span events
 	event.Destination = model.Destination{}       // don't set destination for span events
+	if c.config.DropSpanEvents != nil && c.config.DropSpanEvents(otelLibrary) {
+		return
+	}
 	for i := 0; i < events.Len(); i++ {
 		*out = append(*out, c.convertSpanEvent(events.At(i), event, timeDelta))
 	}
@@ -187,6 +208,18 @@ func TranslateTransaction(
 	spanStatus ptrace.Status,
 	library pcommon.InstrumentationScope,
 	event *model.APMEvent,
+) {
+	translateTransaction(attributes, spanStatus, library, event, nil)
+}
+
+// translateTransaction is TranslateTransaction, additionally counting
+// truncated string values in truncated if it is non-nil.
+func translateTransaction(
+	attributes pcommon.Map,
+	spanStatus ptrace.Status,
+	library pcommon.InstrumentationScope,
+	event *model.APMEvent,
+	truncated *int64,
 ) {
 	isJaeger := strings.HasPrefix(event.Agent.Name, "Jaeger")
 
@@ -200,6 +233,8 @@ func TranslateTransaction(
 		httpURL        string
 		httpServerName string
 		httpHost       string
+		urlQuery       string
+		protoVersion   string
 		http           model.HTTP
 		httpRequest    model.HTTPRequest
 		httpResponse   model.HTTPResponse
@@ -222,15 +257,19 @@ func TranslateTransaction(
 		}
 
 		k := replaceDots(kDots)
+		name, ok := resolveAttributeName(kDots, transactionAttributeNames, transactionSupersededNames, attributes)
+		if !ok {
+			return true
+		}
 		switch v.Type() {
 		case pcommon.ValueTypeSlice:
-			setLabel(k, event, ifaceAttributeValue(v))
+			setLabel(k, event, ifaceAttributeValue(v, truncated))
 		case pcommon.ValueTypeBool:
-			setLabel(k, event, ifaceAttributeValue(v))
+			setLabel(k, event, ifaceAttributeValue(v, truncated))
 		case pcommon.ValueTypeDouble:
-			setLabel(k, event, ifaceAttributeValue(v))
+			setLabel(k, event, ifaceAttributeValue(v, truncated))
 		case pcommon.ValueTypeInt:
-			switch kDots {
+			switch name {
 			case semconv.AttributeHTTPStatusCode:
 				foundSpanType = httpSpan
 				httpResponse.StatusCode = int(v.Int())
@@ -242,12 +281,12 @@ func TranslateTransaction(
 			case "rpc.grpc.status_code":
 				event.Transaction.Result = codes.Code(v.Int()).String()
 			default:
-				setLabel(k, event, ifaceAttributeValue(v))
+				setLabel(k, event, ifaceAttributeValue(v, truncated))
 			}
 		case pcommon.ValueTypeMap:
 		case pcommon.ValueTypeStr:
-			stringval := truncate(v.Str())
-			switch kDots {
+			stringval := truncate(v.Str(), truncated)
+			switch name {
 			// http.*
 			case semconv.AttributeHTTPMethod:
 				foundSpanType = httpSpan
@@ -282,6 +321,12 @@ func TranslateTransaction(
 			case semconv.AttributeHTTPServerName:
 				foundSpanType = httpSpan
 				httpServerName = stringval
+			case attributeURLQuery:
+				urlQuery = stringval
+			case attributeNetworkProtocolVersion:
+				// network.protocol.version is not specific to HTTP,
+				// so it does not determine the transaction type.
+				protoVersion = stringval
 			case semconv.AttributeHTTPClientIP:
 				if ip, err := netip.ParseAddr(stringval); err == nil {
 					event.Client.IP = ip
@@ -359,6 +404,9 @@ func TranslateTransaction(
 
 	switch foundSpanType {
 	case httpSpan:
+		if protoVersion != "" {
+			http.Version = protoVersion
+		}
 		event.HTTP = http
 
 		// Set outcome nad result from status code.
@@ -385,10 +433,17 @@ func TranslateTransaction(
 				httpHost = net.JoinHostPort(httpHost, strconv.Itoa(netHostPort))
 			}
 		}
+		if urlQuery != "" && !strings.ContainsRune(httpURL, '?') {
+			// url.path and url.query are recorded separately.
+			httpURL += "?" + urlQuery
+		}
 		event.URL = model.ParseURL(httpURL, httpHost, httpScheme)
 	case messagingSpan:
 		event.Transaction.Message = &message
 	}
+	if protoVersion != "" && foundSpanType != httpSpan {
+		event.Labels.Set(replaceDots(attributeNetworkProtocolVersion), protoVersion)
+	}
 
 	if !event.Client.IP.IsValid() {
 		event.Client = model.Client{IP: event.Source.IP, Port: event.Source.Port, Domain: event.Source.Domain}
@@ -421,6 +476,12 @@ const (
 // TranslateSpan converts incoming otlp/otel trace data into the
 // expected elasticsearch format.
 func TranslateSpan(spanKind ptrace.SpanKind, attributes pcommon.Map, event *model.APMEvent) {
+	translateSpan(spanKind, attributes, event, nil)
+}
+
+// translateSpan is TranslateSpan, additionally counting truncated
+// string values in truncated if it is non-nil.
+func translateSpan(spanKind ptrace.SpanKind, attributes pcommon.Map, event *model.APMEvent, truncated *int64) {
 	isJaeger := strings.HasPrefix(event.Agent.Name, "Jaeger")
 
 	var (
@@ -435,10 +496,12 @@ func TranslateSpan(spanKind ptrace.SpanKind, attributes pcommon.Map, event *mode
 	)
 
 	var (
-		httpURL    string
-		httpHost   string
-		httpTarget string
-		httpScheme = "http"
+		httpURL      string
+		httpHost     string
+		httpTarget   string
+		httpScheme   = "http"
+		urlQuery     string
+		protoVersion string
 	)
 
 	var (
@@ -474,11 +537,15 @@ func TranslateSpan(spanKind ptrace.SpanKind, attributes pcommon.Map, event *mode
 		}
 
 		k := replaceDots(kDots)
+		name, ok := resolveAttributeName(kDots, spanAttributeNames, spanSupersededNames, attributes)
+		if !ok {
+			return true
+		}
 		switch v.Type() {
 		case pcommon.ValueTypeSlice:
-			setLabel(k, event, ifaceAttributeValueSlice(v.Slice()))
+			setLabel(k, event, ifaceAttributeValueSlice(v.Slice(), truncated))
 		case pcommon.ValueTypeBool:
-			switch kDots {
+			switch name {
 			case semconv.AttributeMessagingTempDestination:
 				messageTempDestination = v.Bool()
 				fallthrough
@@ -488,7 +555,7 @@ func TranslateSpan(spanKind ptrace.SpanKind, attributes pcommon.Map, event *mode
 		case pcommon.ValueTypeDouble:
 			setLabel(k, event, v.Double())
 		case pcommon.ValueTypeInt:
-			switch kDots {
+			switch name {
 			case "http.status_code":
 				httpResponse.StatusCode = int(v.Int())
 				http.Response = &httpResponse
@@ -501,9 +568,9 @@ func TranslateSpan(spanKind ptrace.SpanKind, attributes pcommon.Map, event *mode
 				setLabel(k, event, v.Int())
 			}
 		case pcommon.ValueTypeStr:
-			stringval := truncate(v.Str())
+			stringval := truncate(v.Str(), truncated)
 
-			switch kDots {
+			switch name {
 			// http.*
 			case semconv.AttributeHTTPHost:
 				httpHost = stringval
@@ -521,6 +588,12 @@ func TranslateSpan(spanKind ptrace.SpanKind, attributes pcommon.Map, event *mode
 				httpRequest.Method = stringval
 				http.Request = &httpRequest
 				foundSpanType = httpSpan
+			case attributeURLQuery:
+				urlQuery = stringval
+			case attributeNetworkProtocolVersion:
+				// network.protocol.version is not specific to HTTP,
+				// so it does not determine the span type.
+				protoVersion = stringval
 
 			// db.*
 			case "sql.query":
@@ -622,6 +695,10 @@ func TranslateSpan(spanKind ptrace.SpanKind, attributes pcommon.Map, event *mode
 	if httpURL != "" {
 		fullURL, _ = url.Parse(httpURL)
 	} else if httpTarget != "" {
+		if urlQuery != "" && !strings.ContainsRune(httpTarget, '?') {
+			// url.path and url.query are recorded separately.
+			httpTarget += "?" + urlQuery
+		}
 		// Build http.url from http.scheme, http.target, etc.
 		if u, err := url.Parse(httpTarget); err == nil {
 			fullURL = u
@@ -648,7 +725,7 @@ func TranslateSpan(spanKind ptrace.SpanKind, attributes pcommon.Map, event *mode
 
 		// Set destination.{address,port} from the HTTP URL,
 		// replacing peer.* based values to ensure consistency.
-		destAddr = truncate(fullURL.Hostname())
+		destAddr = truncate(fullURL.Hostname(), truncated)
 		if port > 0 {
 			destPort = port
 		}
@@ -671,6 +748,9 @@ func TranslateSpan(spanKind ptrace.SpanKind, attributes pcommon.Map, event *mode
 		event.Span.Type = "external"
 		subtype := "http"
 		event.Span.Subtype = subtype
+		if protoVersion != "" {
+			http.Version = protoVersion
+		}
 		event.HTTP = http
 		event.URL.Original = httpURL
 		serviceTarget.Type = event.Span.Subtype
@@ -761,6 +841,10 @@ func TranslateSpan(spanKind ptrace.SpanKind, attributes pcommon.Map, event *mode
 		}
 	}
 
+	if protoVersion != "" && foundSpanType != httpSpan {
+		event.Labels.Set(replaceDots(attributeNetworkProtocolVersion), protoVersion)
+	}
+
 	if destAddr != "" {
 		event.Destination = model.Destination{Address: destAddr, Port: destPort}
 	}
@@ -840,7 +924,7 @@ func (c *Consumer) convertSpanEvent(
 			case "exception.escaped":
 				exceptionEscaped = v.Bool()
 			default:
-				setLabel(replaceDots(k), &event, ifaceAttributeValue(v))
+				setLabel(replaceDots(k), &event, ifaceAttributeValue(v, &c.stats.truncatedFields))
 			}
 			return true
 		})
@@ -862,15 +946,31 @@ func (c *Consumer) convertSpanEvent(
 	} else {
 		event.Processor = model.LogProcessor
 		event.Message = spanEvent.Name()
+		if !isJaeger {
+			// Jaeger span event names are log messages,
+			// rather than identifying the kind of event.
+			event.Event.Action = spanEvent.Name()
+		}
+		setSpanEventContext(&event, parent)
 		spanEvent.Attributes().Range(func(k string, v pcommon.Value) bool {
-			k = replaceDots(k)
-			if isJaeger && k == "message" {
-				event.Message = truncate(v.Str())
-				return true
+			switch k {
+			case "log.level", "level":
+				if v.Type() == pcommon.ValueTypeStr {
+					event.Log.Level = truncate(v.Str(), &c.stats.truncatedFields)
+					return true
+				}
+			case "message":
+				if isJaeger {
+					event.Message = truncate(v.Str(), &c.stats.truncatedFields)
+					return true
+				}
 			}
-			setLabel(k, &event, ifaceAttributeValue(v))
+			setLabel(replaceDots(k), &event, ifaceAttributeValue(v, &c.stats.truncatedFields))
 			return true
 		})
+		if n := spanEvent.DroppedAttributesCount(); n > 0 {
+			event.NumericLabels.Set("dropped_attributes_count", float64(n))
+		}
 	}
 	return event
 }
@@ -880,7 +980,7 @@ func (c *Consumer) convertJaegerErrorSpanEvent(event ptrace.SpanEvent, apmEvent
 	var exMessage, exType string
 	var logMessage string
 
-	if name := truncate(event.Name()); name == "error" {
+	if name := truncate(event.Name(), &c.stats.truncatedFields); name == "error" {
 		isError = true // according to opentracing spec
 	} else {
 		// Jaeger seems to send the message in the 'event' field.
@@ -894,7 +994,7 @@ func (c *Consumer) convertJaegerErrorSpanEvent(event ptrace.SpanEvent, apmEvent
 		if v.Type() != pcommon.ValueTypeStr {
 			return true
 		}
-		stringval := truncate(v.Str())
+		stringval := truncate(v.Str(), &c.stats.truncatedFields)
 		switch k {
 		case "error", "error.object":
 			exMessage = stringval
@@ -907,7 +1007,7 @@ func (c *Consumer) convertJaegerErrorSpanEvent(event ptrace.SpanEvent, apmEvent
 		case "message":
 			logMessage = stringval
 		default:
-			setLabel(replaceDots(k), apmEvent, ifaceAttributeValue(v))
+			setLabel(replaceDots(k), apmEvent, ifaceAttributeValue(v, &c.stats.truncatedFields))
 		}
 		return true
 	})
@@ -952,6 +1052,20 @@ func setErrorContext(out *model.APMEvent, parent model.APMEvent) {
 	}
 }
 
+// setSpanEventContext links a span event's log event to the span or
+// transaction which recorded it.
+func setSpanEventContext(out *model.APMEvent, parent model.APMEvent) {
+	out.Trace.ID = parent.Trace.ID
+	if parent.Transaction != nil {
+		out.Transaction = &model.Transaction{ID: parent.Transaction.ID}
+		out.Parent.ID = parent.Transaction.ID
+	}
+	if parent.Span != nil {
+		out.Span = &model.Span{ID: parent.Span.ID}
+		out.Parent.ID = parent.Span.ID
+	}
+}
+
 func translateSpanLinks(out *model.APMEvent, in ptrace.SpanLinkSlice) {
 	n := in.Len()
 	if n == 0 {
@@ -1036,10 +1150,14 @@ func clientHTTPStatusCodeOutcome(statusCode int) string {
 }
 
 // truncate returns s truncated at n runes, and the number of runes in the resulting string (<= n).
-func truncate(s string) string {
+// If s is truncated and truncated is non-nil, truncated is incremented.
+func truncate(s string, truncated *int64) string {
 	var j int
 	for i := range s {
 		if j == keywordLength {
+			if truncated != nil {
+				atomic.AddInt64(truncated, 1)
+			}
 			return s[:i]
 		}
 		j++
@@ -37,6 +37,7 @@ package otlp_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/netip"
@@ -71,6 +72,43 @@ func TestConsumer_ConsumeTraces_Empty(t *testing.T) {
 	assert.NoError(t, consumer.ConsumeTraces(context.Background(), traces))
 }
 
+func TestConsumer_ConsumeTracesWithResult(t *testing.T) {
+	traces, spans := newTracesSpans()
+	span := spans.Spans().AppendEmpty()
+	span.SetTraceID(pcommon.TraceID{1})
+	span.SetSpanID(pcommon.SpanID{2})
+	span.SetStartTimestamp(pcommon.NewTimestampFromTime(time.Unix(123, 0)))
+	span.SetEndTimestamp(pcommon.NewTimestampFromTime(time.Unix(124, 0)))
+	span.Attributes().PutStr("long", strings.Repeat("x", 2000))
+	span = spans.Spans().AppendEmpty() // no timestamps
+	span.SetTraceID(pcommon.TraceID{1})
+	span.SetSpanID(pcommon.SpanID{3})
+	span.SetParentSpanID(pcommon.SpanID{2})
+
+	processorErr := errors.New("processor failed")
+	var processor model.ProcessBatchFunc = func(ctx context.Context, batch *model.Batch) error {
+		return processorErr
+	}
+	consumer := otlp.NewConsumer(otlp.ConsumerConfig{Processor: processor})
+
+	result, err := consumer.ConsumeTracesWithResult(context.Background(), traces)
+	assert.Equal(t, processorErr, err)
+	assert.Equal(t, otlp.ConsumeResult{}, result)
+	stats := consumer.Stats()
+	assert.Zero(t, stats.SpansAccepted) // processing failed
+	assert.Equal(t, int64(1), stats.InvalidTimestamps)
+	assert.Equal(t, int64(1), stats.TruncatedFields)
+
+	processor = func(ctx context.Context, batch *model.Batch) error { return nil }
+	consumer = otlp.NewConsumer(otlp.ConsumerConfig{Processor: processor})
+	result, err = consumer.ConsumeTracesWithResult(context.Background(), traces)
+	require.NoError(t, err)
+	assert.Equal(t, otlp.ConsumeResult{Accepted: 2}, result)
+	assert.Equal(t, int64(2), consumer.Stats().SpansAccepted)
+	// Stats are not shared between consumers.
+	assert.Equal(t, int64(1), consumer.Stats().TruncatedFields)
+}
+
 func TestOutcome(t *testing.T) {
 	test := func(t *testing.T, expectedOutcome, expectedResult string, statusCode ptrace.StatusCode) {
 		t.Helper()
@@ -660,6 +698,213 @@ func TestSessionID(t *testing.T) {
 	assert.Equal(t, expected, spanEvent.Session)
 }
 
+func TestSemanticConventionVersions(t *testing.T) {
+	// Each fixture describes the same operation using the attribute
+	// names of different semantic conventions versions, all of which
+	// should produce identical events.
+	type fixture struct {
+		transaction bool
+		kind        ptrace.SpanKind
+		versions    map[string]map[string]interface{}
+	}
+	for name, test := range map[string]fixture{
+		"http_transaction": {
+			transaction: true,
+			kind:        ptrace.SpanKindServer,
+			versions: map[string]map[string]interface{}{
+				"v1.5.0": {
+					"http.method":      "GET",
+					"http.status_code": 200,
+					"http.scheme":      "https",
+					"http.target":      "/foo?bar",
+					"net.host.name":    "testing.invalid",
+					"net.host.port":    8080,
+					"http.client_ip":   "9.10.11.12",
+					"http.user_agent":  "Foo/bar (baz)",
+					"http.flavor":      "1.1",
+				},
+				"v1.20.0": {
+					"http.request.method":       "GET",
+					"http.response.status_code": 200,
+					"url.scheme":                "https",
+					"url.path":                  "/foo",
+					"url.query":                 "bar",
+					"server.address":            "testing.invalid",
+					"server.port":               8080,
+					"client.address":            "9.10.11.12",
+					"user_agent.original":       "Foo/bar (baz)",
+					"network.protocol.version":  "1.1",
+				},
+			},
+		},
+		"http_span": {
+			kind: ptrace.SpanKindClient,
+			versions: map[string]map[string]interface{}{
+				"v1.5.0": {
+					"http.method":      "GET",
+					"http.status_code": 200,
+					"http.url":         "https://testing.invalid:444/foo?bar",
+					"net.peer.name":    "testing.invalid",
+					"net.peer.port":    444,
+				},
+				"v1.20.0": {
+					"http.request.method":       "GET",
+					"http.response.status_code": 200,
+					"url.full":                  "https://testing.invalid:444/foo?bar",
+					"server.address":            "testing.invalid",
+					"server.port":               444,
+				},
+			},
+		},
+		"http_span_path": {
+			kind: ptrace.SpanKindClient,
+			versions: map[string]map[string]interface{}{
+				"v1.5.0": {
+					"http.method":   "GET",
+					"http.scheme":   "https",
+					"http.target":   "/foo?bar",
+					"net.peer.name": "testing.invalid",
+					"net.peer.port": 444,
+				},
+				"v1.20.0": {
+					"http.request.method": "GET",
+					"url.scheme":          "https",
+					"url.path":            "/foo",
+					"url.query":           "bar",
+					"server.address":      "testing.invalid",
+					"server.port":         444,
+				},
+			},
+		},
+		"db_span": {
+			kind: ptrace.SpanKindClient,
+			versions: map[string]map[string]interface{}{
+				"v1.5.0": {
+					"db.system":     "mysql",
+					"db.name":       "ShopDb",
+					"db.statement":  "SELECT * FROM orders",
+					"net.peer.name": "shopdb.example.com",
+					"net.peer.port": 3306,
+				},
+				"v1.26.0": {
+					"db.system":      "mysql",
+					"db.name":        "ShopDb",
+					"db.query.text":  "SELECT * FROM orders",
+					"server.address": "shopdb.example.com",
+					"server.port":    3306,
+				},
+			},
+		},
+		"messaging_span": {
+			kind: ptrace.SpanKindProducer,
+			versions: map[string]map[string]interface{}{
+				"v1.5.0": {
+					"messaging.system":      "kafka",
+					"messaging.destination": "myTopic",
+				},
+				"v1.20.0": {
+					"messaging.system":           "kafka",
+					"messaging.destination.name": "myTopic",
+				},
+			},
+		},
+		"messaging_transaction": {
+			transaction: true,
+			kind:        ptrace.SpanKindConsumer,
+			versions: map[string]map[string]interface{}{
+				"v1.5.0": {
+					"messaging.destination": "myQueue",
+				},
+				"v1.20.0": {
+					"messaging.destination.name": "myQueue",
+				},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			events := make(map[string]model.APMEvent)
+			for version, attrs := range test.versions {
+				setKind := func(s ptrace.Span) { s.SetKind(test.kind) }
+				if test.transaction {
+					events[version] = transformTransactionWithAttributes(t, attrs, setKind)
+				} else {
+					events[version] = transformSpanWithAttributes(t, attrs, setKind)
+				}
+				assert.Empty(t, events[version].Labels, version)
+			}
+			for version, event := range events {
+				assert.Equal(t, events["v1.5.0"], event, version)
+			}
+		})
+	}
+}
+
+func TestSemanticConventionPrecedence(t *testing.T) {
+	// Attributes of newer semantic conventions take precedence over
+	// the semconv v1.5.0 attributes they supersede, regardless of order.
+	oldAttrs := map[string]string{
+		"http.method":   "POST",
+		"http.url":      "https://old.invalid/old",
+		"http.flavor":   "1.0",
+		"net.peer.name": "old.invalid",
+	}
+	newAttrs := map[string]string{
+		"http.request.method":      "GET",
+		"url.full":                 "https://testing.invalid/foo",
+		"network.protocol.version": "2",
+		"server.address":           "testing.invalid",
+	}
+	transform := func(t *testing.T, first, second map[string]string) model.APMEvent {
+		traces, spans := newTracesSpans()
+		otelSpan := spans.Spans().AppendEmpty()
+		otelSpan.SetTraceID(pcommon.TraceID{1})
+		otelSpan.SetSpanID(pcommon.SpanID{2})
+		otelSpan.SetParentSpanID(pcommon.SpanID{3})
+		otelSpan.SetKind(ptrace.SpanKindClient)
+		for _, attrs := range []map[string]string{first, second} {
+			for k, v := range attrs {
+				otelSpan.Attributes().PutStr(k, v)
+			}
+		}
+		return transformTraces(t, traces)[0]
+	}
+	for name, event := range map[string]model.APMEvent{
+		"old_first": transform(t, oldAttrs, newAttrs),
+		"new_first": transform(t, newAttrs, oldAttrs),
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, "GET", event.HTTP.Request.Method)
+			assert.Equal(t, "2", event.HTTP.Version)
+			assert.Equal(t, "https://testing.invalid/foo", event.URL.Original)
+			assert.Equal(t, "testing.invalid", event.Destination.Address)
+			assert.Empty(t, event.Labels)
+		})
+	}
+}
+
+func TestSpanNetworkProtocolVersion(t *testing.T) {
+	event := transformSpanWithAttributes(t, map[string]interface{}{
+		"http.request.method":      "GET",
+		"url.full":                 "https://testing.invalid/foo",
+		"network.protocol.version": "2",
+	}, func(s ptrace.Span) { s.SetKind(ptrace.SpanKindClient) })
+	assert.Equal(t, "external", event.Span.Type)
+	assert.Equal(t, "2", event.HTTP.Version)
+	assert.Empty(t, event.Labels)
+
+	// network.protocol.version is not specific to HTTP, and is
+	// recorded as a label for other spans.
+	event = transformSpanWithAttributes(t, map[string]interface{}{
+		"db.system":                "redis",
+		"network.protocol.version": "3",
+	}, func(s ptrace.Span) { s.SetKind(ptrace.SpanKindClient) })
+	assert.Equal(t, "db", event.Span.Type)
+	assert.Equal(t, model.HTTP{}, event.HTTP)
+	assert.Equal(t, model.Labels{
+		"network_protocol_version": {Value: "3"},
+	}, event.Labels)
+}
+
 func TestArrayLabels(t *testing.T) {
 	stringArray := []interface{}{"string1", "string2"}
 	boolArray := []interface{}{false, true}
@@ -782,6 +1027,58 @@ func TestSpanLinks(t *testing.T) {
 	}
 }
 
+func TestSpanEventLogs(t *testing.T) {
+	traces, spans := newTracesSpans()
+	spans.Scope().SetName("library")
+	otelSpan := spans.Spans().AppendEmpty()
+	otelSpan.SetTraceID(pcommon.TraceID{1})
+	otelSpan.SetSpanID(pcommon.SpanID{2})
+	otelSpan.SetParentSpanID(pcommon.SpanID{3})
+	otelSpan.SetKind(ptrace.SpanKindClient)
+	otelSpanEvent := otelSpan.Events().AppendEmpty()
+	otelSpanEvent.SetTimestamp(pcommon.NewTimestampFromTime(time.Unix(123, 0)))
+	otelSpanEvent.SetName("cache_miss")
+	otelSpanEvent.SetDroppedAttributesCount(2)
+	otelSpanEvent.Attributes().PutStr("log.level", "warn")
+	otelSpanEvent.Attributes().PutStr("cache.key", "abc")
+
+	events := transformTraces(t, traces)
+	require.Len(t, events, 2)
+	assert.Equal(t, model.SpanProcessor, events[0].Processor)
+	event := events[1]
+	assert.Equal(t, model.LogProcessor, event.Processor)
+	assert.Equal(t, time.Unix(123, 0).UTC(), event.Timestamp)
+	assert.Equal(t, "cache_miss", event.Message)
+	assert.Equal(t, model.Event{Action: "cache_miss"}, event.Event)
+	assert.Equal(t, model.Log{Level: "warn"}, event.Log)
+	assert.Equal(t, model.Trace{ID: "01000000000000000000000000000000"}, event.Trace)
+	assert.Equal(t, model.Parent{ID: "0200000000000000"}, event.Parent)
+	assert.Equal(t, &model.Span{ID: "0200000000000000"}, event.Span)
+	assert.Nil(t, event.Transaction)
+	assert.Equal(t, model.Labels{"cache_key": {Value: "abc"}}, event.Labels)
+	assert.Equal(t, model.NumericLabels{"dropped_attributes_count": {Value: 2}}, event.NumericLabels)
+
+	// Span events of transactions are linked to the transaction.
+	_, events = transformTransactionSpanEvents(t, "go", otelSpanEvent)
+	require.Len(t, events, 1)
+	assert.Equal(t, model.Parent{ID: "0200000000000000"}, events[0].Parent)
+	assert.Equal(t, &model.Transaction{ID: "0200000000000000"}, events[0].Transaction)
+	assert.Nil(t, events[0].Span)
+
+	// Span events may be dropped by instrumentation scope.
+	var batches []*model.Batch
+	consumer := otlp.NewConsumer(otlp.ConsumerConfig{
+		Processor: batchRecorderBatchProcessor(&batches),
+		DropSpanEvents: func(scope pcommon.InstrumentationScope) bool {
+			return scope.Name() == "library"
+		},
+	})
+	require.NoError(t, consumer.ConsumeTraces(context.Background(), traces))
+	require.Len(t, batches, 1)
+	require.Len(t, *batches[0], 1)
+	assert.Equal(t, model.SpanProcessor, (*batches[0])[0].Processor)
+}
+
 func TestConsumer_JaegerMetadata(t *testing.T) {
 	jaegerBatch := &jaegermodel.Batch{
 		Spans: []*jaegermodel.Span{{
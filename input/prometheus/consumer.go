@@ -0,0 +1,349 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package prometheus provides support for consuming Prometheus
+// remote-write requests, translating them to the Elastic APM data model.
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	"go.uber.org/zap"
+
+	"github.com/elastic/apm-data/model"
+)
+
+const (
+	agentName = "prometheus"
+
+	labelMetricName = "__name__"
+	labelJob        = "job"
+	labelInstance   = "instance"
+
+	// DefaultMaxRequestSize is the default maximum size of a
+	// compressed remote-write request, in bytes.
+	DefaultMaxRequestSize = 10 * 1024 * 1024
+
+	// DefaultMaxDecodedSize is the default maximum size of a
+	// decompressed remote-write request, in bytes.
+	DefaultMaxDecodedSize = 64 * 1024 * 1024
+)
+
+// ErrRequestTooLarge is returned by Consumer.ConsumeWriteRequest when
+// a request exceeds either the configured compressed or decompressed
+// size limit.
+var ErrRequestTooLarge = errors.New("write request too large")
+
+// ConsumerConfig holds configuration for Consumer.
+type ConsumerConfig struct {
+	// Processor holds the model.BatchProcessor which will be invoked
+	// with event batches when consuming remote-write requests.
+	Processor model.BatchProcessor
+
+	// Logger holds a logger for the consumer. If this is nil, then
+	// no logging will be performed.
+	Logger *zap.Logger
+
+	// MaxRequestSize holds the maximum size of a compressed
+	// remote-write request, in bytes. If this is zero, then
+	// DefaultMaxRequestSize will be used.
+	MaxRequestSize int
+
+	// MaxDecodedSize holds the maximum size of a decompressed
+	// remote-write request, in bytes. If this is zero, then
+	// DefaultMaxDecodedSize will be used.
+	MaxDecodedSize int
+}
+
+// Consumer transforms Prometheus remote-write requests to the Elastic
+// APM data model, sending each request as a batch to the configured
+// BatchProcessor.
+type Consumer struct {
+	config ConsumerConfig
+}
+
+// NewConsumer returns a new Consumer with the given configuration.
+func NewConsumer(config ConsumerConfig) *Consumer {
+	if config.Logger == nil {
+		config.Logger = zap.NewNop()
+	} else {
+		config.Logger = config.Logger.Named("prometheus")
+	}
+	if config.MaxRequestSize <= 0 {
+		config.MaxRequestSize = DefaultMaxRequestSize
+	}
+	if config.MaxDecodedSize <= 0 {
+		config.MaxDecodedSize = DefaultMaxDecodedSize
+	}
+	return &Consumer{config: config}
+}
+
+// ConsumeWriteRequest decodes a snappy-compressed, protobuf-encoded
+// remote-write WriteRequest from r, and processes it as a single batch.
+//
+// Samples and native histograms are grouped into metricsets by their
+// label set and timestamp. The "job" and "instance" labels identify the
+// service and host; all other labels, except for the metric name, are
+// recorded as event labels. Stale markers and other non-finite sample
+// values are dropped.
+//
+// If the request exceeds the configured size limits, either before or
+// after decompression, an error wrapping ErrRequestTooLarge is returned.
+func (c *Consumer) ConsumeWriteRequest(ctx context.Context, r io.Reader) error {
+	compressed, err := io.ReadAll(io.LimitReader(r, int64(c.config.MaxRequestSize)+1))
+	if err != nil {
+		return err
+	}
+	if len(compressed) > c.config.MaxRequestSize {
+		return fmt.Errorf("%w: compressed size exceeds %d bytes", ErrRequestTooLarge, c.config.MaxRequestSize)
+	}
+	decodedLen, err := snappy.DecodedLen(compressed)
+	if err != nil {
+		return fmt.Errorf("failed to decompress write request: %w", err)
+	}
+	if decodedLen > c.config.MaxDecodedSize {
+		return fmt.Errorf("%w: decompressed size %d exceeds %d bytes", ErrRequestTooLarge, decodedLen, c.config.MaxDecodedSize)
+	}
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return fmt.Errorf("failed to decompress write request: %w", err)
+	}
+	var req writeRequest
+	if err := req.decode(data); err != nil {
+		return fmt.Errorf("failed to decode write request: %w", err)
+	}
+	batch := convertWriteRequest(&req)
+	c.config.Logger.Debug("consumed write request",
+		zap.Int("timeseries", len(req.timeseries)),
+		zap.Int("metricsets", len(batch)),
+	)
+	return c.config.Processor.ProcessBatch(ctx, &batch)
+}
+
+type metricsetKey struct {
+	timestamp int64
+	signature string // combination of all labels, except for the metric name
+}
+
+func convertWriteRequest(req *writeRequest) model.Batch {
+	metricTypes := make(map[string]model.MetricType, len(req.metadata))
+	for _, md := range req.metadata {
+		switch md.typ {
+		case metricTypeCounter:
+			metricTypes[md.name] = model.MetricTypeCounter
+		case metricTypeGauge:
+			metricTypes[md.name] = model.MetricTypeGauge
+		}
+	}
+
+	var batch model.Batch
+	indices := make(map[metricsetKey]int)
+	var signature strings.Builder
+	for i := range req.timeseries {
+		ts := &req.timeseries[i]
+		var name string
+		var baseEvent model.APMEvent
+		sort.Slice(ts.labels, func(i, j int) bool {
+			return ts.labels[i].name < ts.labels[j].name
+		})
+		signature.Reset()
+		for _, l := range ts.labels {
+			switch l.name {
+			case labelMetricName:
+				name = l.value
+				continue
+			case labelJob:
+				baseEvent.Service.Name = l.value
+			case labelInstance:
+				baseEvent.Service.Node.Name = l.value
+				baseEvent.Host.Hostname = l.value
+				if host, _, err := net.SplitHostPort(l.value); err == nil {
+					baseEvent.Host.Hostname = host
+				}
+			default:
+				if baseEvent.Labels == nil {
+					baseEvent.Labels = make(model.Labels)
+				}
+				baseEvent.Labels.Set(l.name, l.value)
+			}
+			signature.WriteString(l.name)
+			signature.WriteByte('=')
+			signature.WriteString(l.value)
+			signature.WriteByte(',')
+		}
+		if name == "" {
+			// The metric name is required.
+			continue
+		}
+		if baseEvent.Service.Name == "" {
+			// service.name is a required field.
+			baseEvent.Service.Name = "unknown"
+		}
+		baseEvent.Agent = model.Agent{Name: agentName, Version: "unknown"}
+
+		upsert := func(timestamp int64, sample model.MetricsetSample) {
+			key := metricsetKey{timestamp: timestamp, signature: signature.String()}
+			index, ok := indices[key]
+			if !ok {
+				event := baseEvent
+				if baseEvent.Labels != nil {
+					event.Labels = baseEvent.Labels.Clone()
+				}
+				event.Timestamp = time.Unix(0, timestamp*int64(time.Millisecond)).UTC()
+				event.Processor = model.MetricsetProcessor
+				event.Metricset = &model.Metricset{}
+				index = len(batch)
+				indices[key] = index
+				batch = append(batch, event)
+			}
+			ms := batch[index].Metricset
+			for i := range ms.Samples {
+				if ms.Samples[i].Name == sample.Name {
+					ms.Samples[i] = sample
+					return
+				}
+			}
+			ms.Samples = append(ms.Samples, sample)
+		}
+		for _, s := range ts.samples {
+			if math.IsNaN(s.value) || math.IsInf(s.value, 0) {
+				continue
+			}
+			upsert(s.timestamp, model.MetricsetSample{
+				Name:  name,
+				Type:  metricTypes[name],
+				Value: s.value,
+			})
+		}
+		for i := range ts.histograms {
+			h := &ts.histograms[i]
+			if sample, ok := histogramSample(h); ok {
+				sample.Name = name
+				upsert(h.timestamp, sample)
+			}
+		}
+	}
+	return batch
+}
+
+// histogramSample converts a native histogram to a histogram metric,
+// reporting whether the conversion was possible.
+//
+// The bucket identified by index, a signed integer, contains values
+// that are greater than base^(index-1) and less than or equal to
+// base^index, where base = 2^(2^-schema). Negative buckets hold the
+// corresponding negative values, and values close to zero are counted
+// in the zero bucket.
+//
+// As with OTLP exponential histograms, we use the midpoint between each
+// bucket's boundaries as its value, and record the negative buckets in
+// reverse order, followed by the zero bucket and the positive buckets.
+func histogramSample(h *histogram) (model.MetricsetSample, bool) {
+	if h.schema < -4 || h.schema > 8 {
+		// Only exponential schemas are supported.
+		return model.MetricsetSample{}, false
+	}
+	negativeValues, negativeCounts, ok := histogramBuckets(h, h.negativeSpans, h.negativeDeltas, h.negativeCounts)
+	if !ok {
+		return model.MetricsetSample{}, false
+	}
+	positiveValues, positiveCounts, ok := histogramBuckets(h, h.positiveSpans, h.positiveDeltas, h.positiveCounts)
+	if !ok {
+		return model.MetricsetSample{}, false
+	}
+
+	size := len(negativeValues) + len(positiveValues) + 1
+	values := make([]float64, 0, size)
+	counts := make([]int64, 0, size)
+	for i := len(negativeValues) - 1; i >= 0; i-- {
+		values = append(values, -negativeValues[i])
+		counts = append(counts, negativeCounts[i])
+	}
+	zeroCount := int64(h.zeroCountInt)
+	if h.float {
+		zeroCount = int64(math.Round(h.zeroCountFloat))
+	}
+	if zeroCount > 0 {
+		values = append(values, 0)
+		counts = append(counts, zeroCount)
+	}
+	values = append(values, positiveValues...)
+	counts = append(counts, positiveCounts...)
+	return model.MetricsetSample{
+		Type: model.MetricTypeHistogram,
+		Histogram: model.Histogram{
+			Values: values,
+			Counts: counts,
+		},
+	}, true
+}
+
+// histogramBuckets returns the absolute values and non-zero counts of
+// the buckets described by spans, and either deltas or floatCounts
+// depending on whether h is a float histogram.
+func histogramBuckets(h *histogram, spans []bucketSpan, deltas []int64, floatCounts []float64) ([]float64, []int64, bool) {
+	var values []float64
+	var counts []int64
+	var index, bucket int
+	var count int64
+	scale := math.Exp2(-float64(h.schema))
+	for i, span := range spans {
+		if i == 0 {
+			index = int(span.offset)
+		} else {
+			index += int(span.offset)
+		}
+		for j := uint32(0); j < span.length; j++ {
+			if h.float {
+				if bucket >= len(floatCounts) {
+					return nil, nil, false
+				}
+				count = int64(math.Round(floatCounts[bucket]))
+			} else {
+				if bucket >= len(deltas) {
+					return nil, nil, false
+				}
+				count += deltas[bucket]
+			}
+			if count < 0 {
+				return nil, nil, false
+			}
+			if count > 0 {
+				lower := math.Exp2(float64(index-1) * scale)
+				upper := math.Exp2(float64(index) * scale)
+				value := lower + (upper-lower)/2.0
+				if math.IsInf(value, 0) || math.IsNaN(value) {
+					return nil, nil, false
+				}
+				values = append(values, value)
+				counts = append(counts, count)
+			}
+			index++
+			bucket++
+		}
+	}
+	return values, counts, true
+}
@@ -0,0 +1,243 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package prometheus_test
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/elastic/apm-data/input/prometheus"
+	"github.com/elastic/apm-data/model"
+)
+
+func TestConsumeWriteRequestSamples(t *testing.T) {
+	const timestamp = 1576500418000
+	labels := []string{"__name__", "", "job", "api", "instance", "10.0.0.1:9090", "method", "GET"}
+	withName := func(name string, labels ...string) []string {
+		out := append([]string{}, labels...)
+		out[1] = name
+		return out
+	}
+
+	var req []byte
+	req = appendTimeSeries(req, withName("http_requests_total", labels...), appendSample(nil, 10, timestamp))
+	req = appendTimeSeries(req, withName("http_requests_in_flight", labels...), appendSample(nil, 2, timestamp))
+	req = appendTimeSeries(req, withName("up", "__name__", "", "job", "api", "instance", "10.0.0.1:9090"),
+		appendSample(appendSample(nil, 1, timestamp), math.Float64frombits(0x7ff0000000000002), timestamp+1000),
+	)
+	req = appendMetadata(req, 1, "http_requests_total")
+	req = appendMetadata(req, 2, "http_requests_in_flight")
+
+	batch := consumeWriteRequest(t, req)
+	expectedTimestamp := time.Unix(1576500418, 0).UTC()
+	assert.Equal(t, model.Batch{{
+		Timestamp: expectedTimestamp,
+		Agent:     model.Agent{Name: "prometheus", Version: "unknown"},
+		Service:   model.Service{Name: "api", Node: model.ServiceNode{Name: "10.0.0.1:9090"}},
+		Host:      model.Host{Hostname: "10.0.0.1"},
+		Labels:    model.Labels{"method": {Value: "GET"}},
+		Processor: model.MetricsetProcessor,
+		Metricset: &model.Metricset{Samples: []model.MetricsetSample{
+			{Name: "http_requests_total", Type: model.MetricTypeCounter, Value: 10},
+			{Name: "http_requests_in_flight", Type: model.MetricTypeGauge, Value: 2},
+		}},
+	}, {
+		Timestamp: expectedTimestamp,
+		Agent:     model.Agent{Name: "prometheus", Version: "unknown"},
+		Service:   model.Service{Name: "api", Node: model.ServiceNode{Name: "10.0.0.1:9090"}},
+		Host:      model.Host{Hostname: "10.0.0.1"},
+		Processor: model.MetricsetProcessor,
+		Metricset: &model.Metricset{Samples: []model.MetricsetSample{
+			{Name: "up", Value: 1},
+		}},
+	}}, batch)
+}
+
+func TestConsumeWriteRequestNativeHistogram(t *testing.T) {
+	const timestamp = 1576500418000
+	var intHistogram []byte
+	intHistogram = protowire.AppendTag(intHistogram, 1, protowire.VarintType)
+	intHistogram = protowire.AppendVarint(intHistogram, 9)
+	intHistogram = protowire.AppendTag(intHistogram, 4, protowire.VarintType)
+	intHistogram = protowire.AppendVarint(intHistogram, protowire.EncodeZigZag(0))
+	intHistogram = protowire.AppendTag(intHistogram, 6, protowire.VarintType)
+	intHistogram = protowire.AppendVarint(intHistogram, 2)
+	intHistogram = appendBucketSpan(intHistogram, 8, 1, 1)
+	intHistogram = appendSint64s(intHistogram, 9, 3)
+	intHistogram = appendBucketSpan(intHistogram, 11, 0, 2)
+	intHistogram = appendBucketSpan(intHistogram, 11, 1, 1)
+	intHistogram = appendSint64s(intHistogram, 12, 1, 1, -1)
+	intHistogram = protowire.AppendTag(intHistogram, 15, protowire.VarintType)
+	intHistogram = protowire.AppendVarint(intHistogram, timestamp)
+
+	var floatHistogram []byte
+	floatHistogram = protowire.AppendTag(floatHistogram, 2, protowire.Fixed64Type)
+	floatHistogram = protowire.AppendFixed64(floatHistogram, math.Float64bits(2))
+	floatHistogram = appendBucketSpan(floatHistogram, 11, 0, 1)
+	floatHistogram = protowire.AppendTag(floatHistogram, 13, protowire.BytesType)
+	floatHistogram = protowire.AppendBytes(floatHistogram, protowire.AppendFixed64(nil, math.Float64bits(2)))
+	floatHistogram = protowire.AppendTag(floatHistogram, 15, protowire.VarintType)
+	floatHistogram = protowire.AppendVarint(floatHistogram, timestamp)
+
+	var series []byte
+	series = protowire.AppendTag(series, 4, protowire.BytesType)
+	series = protowire.AppendBytes(series, intHistogram)
+	var floatSeries []byte
+	floatSeries = protowire.AppendTag(floatSeries, 4, protowire.BytesType)
+	floatSeries = protowire.AppendBytes(floatSeries, floatHistogram)
+
+	var req []byte
+	req = appendTimeSeries(req, []string{"__name__", "request_duration_seconds", "job", "api"}, series)
+	req = appendTimeSeries(req, []string{"__name__", "response_size_bytes", "job", "api"}, floatSeries)
+
+	batch := consumeWriteRequest(t, req)
+	require.Len(t, batch, 1)
+	assert.Equal(t, []model.MetricsetSample{{
+		Name: "request_duration_seconds",
+		Type: model.MetricTypeHistogram,
+		Histogram: model.Histogram{
+			Values: []float64{-1.5, 0, 0.75, 1.5, 6},
+			Counts: []int64{3, 2, 1, 2, 1},
+		},
+	}, {
+		Name: "response_size_bytes",
+		Type: model.MetricTypeHistogram,
+		Histogram: model.Histogram{
+			Values: []float64{0.75},
+			Counts: []int64{2},
+		},
+	}}, batch[0].Metricset.Samples)
+}
+
+func TestConsumeWriteRequestInvalid(t *testing.T) {
+	consumer := prometheus.NewConsumer(prometheus.ConsumerConfig{
+		Processor: model.ProcessBatchFunc(func(ctx context.Context, batch *model.Batch) error {
+			panic("unexpected call")
+		}),
+	})
+	err := consumer.ConsumeWriteRequest(context.Background(), strings.NewReader("not snappy"))
+	assert.ErrorContains(t, err, "failed to decompress write request")
+
+	compressed := snappy.Encode(nil, []byte{0x0a, 0xff})
+	err = consumer.ConsumeWriteRequest(context.Background(), bytes.NewReader(compressed))
+	assert.ErrorContains(t, err, "failed to decode write request")
+}
+
+func TestConsumeWriteRequestTooLarge(t *testing.T) {
+	consumer := prometheus.NewConsumer(prometheus.ConsumerConfig{
+		Processor: model.ProcessBatchFunc(func(ctx context.Context, batch *model.Batch) error {
+			panic("unexpected call")
+		}),
+		MaxRequestSize: 100,
+		MaxDecodedSize: 1000,
+	})
+
+	// Highly compressible requests may exceed the decoded size limit
+	// without exceeding the compressed size limit.
+	compressed := snappy.Encode(nil, make([]byte, 1001))
+	require.Less(t, len(compressed), 100)
+	err := consumer.ConsumeWriteRequest(context.Background(), bytes.NewReader(compressed))
+	assert.ErrorIs(t, err, prometheus.ErrRequestTooLarge)
+	assert.EqualError(t, err, "write request too large: decompressed size 1001 exceeds 1000 bytes")
+
+	err = consumer.ConsumeWriteRequest(context.Background(), bytes.NewReader(make([]byte, 101)))
+	assert.ErrorIs(t, err, prometheus.ErrRequestTooLarge)
+	assert.EqualError(t, err, "write request too large: compressed size exceeds 100 bytes")
+}
+
+func consumeWriteRequest(t testing.TB, req []byte) model.Batch {
+	var batches []model.Batch
+	consumer := prometheus.NewConsumer(prometheus.ConsumerConfig{
+		Processor: model.ProcessBatchFunc(func(ctx context.Context, batch *model.Batch) error {
+			batches = append(batches, *batch)
+			return nil
+		}),
+	})
+	compressed := snappy.Encode(nil, req)
+	require.NoError(t, consumer.ConsumeWriteRequest(context.Background(), bytes.NewReader(compressed)))
+	require.Len(t, batches, 1)
+	return batches[0]
+}
+
+// appendTimeSeries appends a TimeSeries with the given label name/value
+// pairs, and encoded samples and histograms, to the WriteRequest in b.
+func appendTimeSeries(b []byte, labels []string, data []byte) []byte {
+	var series []byte
+	for i := 0; i < len(labels); i += 2 {
+		var label []byte
+		label = protowire.AppendTag(label, 1, protowire.BytesType)
+		label = protowire.AppendString(label, labels[i])
+		label = protowire.AppendTag(label, 2, protowire.BytesType)
+		label = protowire.AppendString(label, labels[i+1])
+		series = protowire.AppendTag(series, 1, protowire.BytesType)
+		series = protowire.AppendBytes(series, label)
+	}
+	series = append(series, data...)
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	return protowire.AppendBytes(b, series)
+}
+
+// appendSample appends a Sample to the TimeSeries in b.
+func appendSample(b []byte, value float64, timestamp int64) []byte {
+	var sample []byte
+	sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+	sample = protowire.AppendFixed64(sample, math.Float64bits(value))
+	sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+	sample = protowire.AppendVarint(sample, uint64(timestamp))
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	return protowire.AppendBytes(b, sample)
+}
+
+// appendMetadata appends a MetricMetadata to the WriteRequest in b.
+func appendMetadata(b []byte, metricType uint64, name string) []byte {
+	var md []byte
+	md = protowire.AppendTag(md, 1, protowire.VarintType)
+	md = protowire.AppendVarint(md, metricType)
+	md = protowire.AppendTag(md, 2, protowire.BytesType)
+	md = protowire.AppendString(md, name)
+	b = protowire.AppendTag(b, 3, protowire.BytesType)
+	return protowire.AppendBytes(b, md)
+}
+
+func appendBucketSpan(b []byte, num protowire.Number, offset int64, length uint64) []byte {
+	var span []byte
+	span = protowire.AppendTag(span, 1, protowire.VarintType)
+	span = protowire.AppendVarint(span, protowire.EncodeZigZag(offset))
+	span = protowire.AppendTag(span, 2, protowire.VarintType)
+	span = protowire.AppendVarint(span, length)
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, span)
+}
+
+func appendSint64s(b []byte, num protowire.Number, values ...int64) []byte {
+	var packed []byte
+	for _, v := range values {
+		packed = protowire.AppendVarint(packed, protowire.EncodeZigZag(v))
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, packed)
+}
@@ -0,0 +1,288 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package prometheus
+
+import (
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Metric types defined by prometheus.MetricMetadata.MetricType.
+const (
+	metricTypeCounter = 1
+	metricTypeGauge   = 2
+)
+
+// writeRequest holds a decoded prometheus.WriteRequest.
+//
+// https://github.com/prometheus/prometheus/blob/main/prompb/remote.proto
+type writeRequest struct {
+	timeseries []timeSeries
+	metadata   []metricMetadata
+}
+
+// timeSeries holds a decoded prometheus.TimeSeries.
+//
+// https://github.com/prometheus/prometheus/blob/main/prompb/types.proto
+type timeSeries struct {
+	labels     []label
+	samples    []sample
+	histograms []histogram
+}
+
+type label struct {
+	name  string
+	value string
+}
+
+type sample struct {
+	value     float64
+	timestamp int64 // milliseconds since the Unix epoch
+}
+
+// histogram holds a decoded prometheus.Histogram, which is a native
+// (sparse) histogram. Integer histograms record bucket counts as deltas
+// from the previous bucket, whereas float histograms record absolute
+// bucket counts.
+type histogram struct {
+	float          bool
+	schema         int32
+	zeroCountInt   uint64
+	zeroCountFloat float64
+	negativeSpans  []bucketSpan
+	negativeDeltas []int64
+	negativeCounts []float64
+	positiveSpans  []bucketSpan
+	positiveDeltas []int64
+	positiveCounts []float64
+	timestamp      int64 // milliseconds since the Unix epoch
+}
+
+type bucketSpan struct {
+	offset int32
+	length uint32
+}
+
+type metricMetadata struct {
+	typ  int32
+	name string
+}
+
+func (r *writeRequest) decode(b []byte) error {
+	return decodeFields(b, func(num protowire.Number, typ protowire.Type, v uint64, data []byte) error {
+		switch num {
+		case 1:
+			var ts timeSeries
+			if err := ts.decode(data); err != nil {
+				return fmt.Errorf("invalid timeseries: %w", err)
+			}
+			r.timeseries = append(r.timeseries, ts)
+		case 3:
+			var md metricMetadata
+			if err := md.decode(data); err != nil {
+				return fmt.Errorf("invalid metadata: %w", err)
+			}
+			r.metadata = append(r.metadata, md)
+		}
+		return nil
+	})
+}
+
+func (ts *timeSeries) decode(b []byte) error {
+	return decodeFields(b, func(num protowire.Number, typ protowire.Type, v uint64, data []byte) error {
+		switch num {
+		case 1:
+			var l label
+			if err := l.decode(data); err != nil {
+				return err
+			}
+			ts.labels = append(ts.labels, l)
+		case 2:
+			var s sample
+			if err := s.decode(data); err != nil {
+				return err
+			}
+			ts.samples = append(ts.samples, s)
+		case 4:
+			var h histogram
+			if err := h.decode(data); err != nil {
+				return err
+			}
+			ts.histograms = append(ts.histograms, h)
+		}
+		return nil
+	})
+}
+
+func (l *label) decode(b []byte) error {
+	return decodeFields(b, func(num protowire.Number, typ protowire.Type, v uint64, data []byte) error {
+		switch num {
+		case 1:
+			l.name = string(data)
+		case 2:
+			l.value = string(data)
+		}
+		return nil
+	})
+}
+
+func (s *sample) decode(b []byte) error {
+	return decodeFields(b, func(num protowire.Number, typ protowire.Type, v uint64, data []byte) error {
+		switch num {
+		case 1:
+			s.value = math.Float64frombits(v)
+		case 2:
+			s.timestamp = int64(v)
+		}
+		return nil
+	})
+}
+
+func (h *histogram) decode(b []byte) error {
+	return decodeFields(b, func(num protowire.Number, typ protowire.Type, v uint64, data []byte) error {
+		var err error
+		switch num {
+		case 2, 7:
+			h.float = true
+			if num == 7 {
+				h.zeroCountFloat = math.Float64frombits(v)
+			}
+		case 4:
+			h.schema = int32(protowire.DecodeZigZag(v))
+		case 6:
+			h.zeroCountInt = v
+		case 8:
+			h.negativeSpans, err = appendBucketSpan(h.negativeSpans, data)
+		case 9:
+			h.negativeDeltas, err = appendSint64s(h.negativeDeltas, typ, v, data)
+		case 10:
+			h.float = true
+			h.negativeCounts, err = appendDoubles(h.negativeCounts, typ, v, data)
+		case 11:
+			h.positiveSpans, err = appendBucketSpan(h.positiveSpans, data)
+		case 12:
+			h.positiveDeltas, err = appendSint64s(h.positiveDeltas, typ, v, data)
+		case 13:
+			h.float = true
+			h.positiveCounts, err = appendDoubles(h.positiveCounts, typ, v, data)
+		case 15:
+			h.timestamp = int64(v)
+		}
+		return err
+	})
+}
+
+func (md *metricMetadata) decode(b []byte) error {
+	return decodeFields(b, func(num protowire.Number, typ protowire.Type, v uint64, data []byte) error {
+		switch num {
+		case 1:
+			md.typ = int32(v)
+		case 2:
+			md.name = string(data)
+		}
+		return nil
+	})
+}
+
+func appendBucketSpan(spans []bucketSpan, b []byte) ([]bucketSpan, error) {
+	var span bucketSpan
+	err := decodeFields(b, func(num protowire.Number, typ protowire.Type, v uint64, data []byte) error {
+		switch num {
+		case 1:
+			span.offset = int32(protowire.DecodeZigZag(v))
+		case 2:
+			span.length = uint32(v)
+		}
+		return nil
+	})
+	return append(spans, span), err
+}
+
+// appendSint64s appends the packed or unpacked repeated sint64 field
+// value to out.
+func appendSint64s(out []int64, typ protowire.Type, v uint64, data []byte) ([]int64, error) {
+	if typ != protowire.BytesType {
+		return append(out, protowire.DecodeZigZag(v)), nil
+	}
+	for len(data) > 0 {
+		v, n := protowire.ConsumeVarint(data)
+		if n < 0 {
+			return out, protowire.ParseError(n)
+		}
+		out = append(out, protowire.DecodeZigZag(v))
+		data = data[n:]
+	}
+	return out, nil
+}
+
+// appendDoubles appends the packed or unpacked repeated double field
+// value to out.
+func appendDoubles(out []float64, typ protowire.Type, v uint64, data []byte) ([]float64, error) {
+	if typ != protowire.BytesType {
+		return append(out, math.Float64frombits(v)), nil
+	}
+	for len(data) > 0 {
+		v, n := protowire.ConsumeFixed64(data)
+		if n < 0 {
+			return out, protowire.ParseError(n)
+		}
+		out = append(out, math.Float64frombits(v))
+		data = data[n:]
+	}
+	return out, nil
+}
+
+// decodeFields calls fn for each field of the protobuf message in b.
+// Scalar field values are passed in v, and length-delimited field
+// values are passed in data.
+func decodeFields(b []byte, fn func(num protowire.Number, typ protowire.Type, v uint64, data []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		var v uint64
+		var data []byte
+		switch typ {
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			v, n = protowire.ConsumeFixed64(b)
+		case protowire.Fixed32Type:
+			var v32 uint32
+			v32, n = protowire.ConsumeFixed32(b)
+			v = uint64(v32)
+		case protowire.BytesType:
+			data, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if err := fn(num, typ, v, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package zipkin provides support for consuming Zipkin v2 JSON spans,
+// translating them to the Elastic APM data model.
+//
+// Spans are first converted to OpenTelemetry traces, and then translated
+// with the same rules used for OTLP, so Zipkin spans with OpenTelemetry
+// semantic convention tags are treated identically to OTLP spans.
+package zipkin
+
+import (
+	"context"
+	"io"
+
+	"go.uber.org/zap"
+
+	"github.com/elastic/apm-data/input/otlp"
+	"github.com/elastic/apm-data/model"
+)
+
+// ConsumerConfig holds configuration for Consumer.
+type ConsumerConfig struct {
+	// Processor holds the model.BatchProcessor which will be invoked
+	// with event batches when consuming Zipkin payloads.
+	Processor model.BatchProcessor
+
+	// Logger holds a logger for the consumer. If this is nil, then
+	// no logging will be performed.
+	//
+	// Logging is performed by the underlying OTLP consumer, which
+	// names the logger "otel".
+	Logger *zap.Logger
+}
+
+// Consumer transforms Zipkin spans to the Elastic APM data model,
+// sending each payload as a batch to the configured BatchProcessor.
+type Consumer struct {
+	otlp *otlp.Consumer
+}
+
+// NewConsumer returns a new Consumer with the given configuration.
+func NewConsumer(config ConsumerConfig) *Consumer {
+	return &Consumer{
+		otlp: otlp.NewConsumer(otlp.ConsumerConfig{
+			Processor: config.Processor,
+			Logger:    config.Logger,
+		}),
+	}
+}
+
+// ConsumeJSON decodes a Zipkin v2 JSON array of spans from r, and
+// processes them as a single batch.
+func (c *Consumer) ConsumeJSON(ctx context.Context, r io.Reader) error {
+	traces, err := DecodeJSON(r)
+	if err != nil {
+		return err
+	}
+	return c.otlp.ConsumeTraces(ctx, traces)
+}
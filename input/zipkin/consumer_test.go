@@ -0,0 +1,179 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package zipkin_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/elastic/apm-data/input/zipkin"
+	"github.com/elastic/apm-data/model"
+)
+
+func TestConsumeJSON(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".json")
+		t.Run(name, func(t *testing.T) {
+			f, err := os.Open(path)
+			require.NoError(t, err)
+			defer f.Close()
+
+			var batches []*model.Batch
+			consumer := zipkin.NewConsumer(zipkin.ConsumerConfig{
+				Processor: model.ProcessBatchFunc(func(ctx context.Context, batch *model.Batch) error {
+					batches = append(batches, batch)
+					return nil
+				}),
+			})
+			require.NoError(t, consumer.ConsumeJSON(context.Background(), f))
+			require.Len(t, batches, 1)
+
+			var docs [][]byte
+			for _, event := range *batches[0] {
+				data, err := event.MarshalJSON()
+				require.NoError(t, err)
+				docs = append(docs, data)
+			}
+			approveEventDocs(t, name, docs)
+		})
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	traces, err := zipkin.DecodeJSON(strings.NewReader(`[{
+		"traceId": "48485a3953bb6124",
+		"id": "0040000000000001",
+		"parentId": "0040000000000002",
+		"kind": "CLIENT",
+		"timestamp": 1576500418768068,
+		"duration": 42,
+		"localEndpoint": {"serviceName": "svc"},
+		"annotations": [{"timestamp": 1576500418768070, "value": "sent"}],
+		"tags": {"http.status_code": "200", "error": "boom"}
+	}]`))
+	require.NoError(t, err)
+	require.Equal(t, 1, traces.ResourceSpans().Len())
+
+	resource := traces.ResourceSpans().At(0).Resource()
+	assert.Equal(t, map[string]any{
+		"service.name":       "svc",
+		"telemetry.sdk.name": "zipkin",
+	}, resource.Attributes().AsRaw())
+
+	span := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	assert.Equal(t, "000000000000000048485a3953bb6124", span.TraceID().HexString())
+	assert.Equal(t, "0040000000000001", span.SpanID().HexString())
+	assert.Equal(t, "0040000000000002", span.ParentSpanID().HexString())
+	assert.Equal(t, ptrace.SpanKindClient, span.Kind())
+	assert.Equal(t, int64(42000), int64(span.EndTimestamp()-span.StartTimestamp()))
+	assert.Equal(t, ptrace.StatusCodeError, span.Status().Code())
+	assert.Equal(t, "boom", span.Status().Message())
+	assert.Equal(t, map[string]any{"http.status_code": int64(200)}, span.Attributes().AsRaw())
+	require.Equal(t, 1, span.Events().Len())
+	assert.Equal(t, "sent", span.Events().At(0).Name())
+}
+
+func TestDecodeJSONMissingTimestamp(t *testing.T) {
+	before := time.Now()
+	traces, err := zipkin.DecodeJSON(strings.NewReader(`[{
+		"traceId": "48485a3953bb6124",
+		"id": "0040000000000001",
+		"duration": 42
+	}]`))
+	after := time.Now()
+	require.NoError(t, err)
+
+	// Spans without a timestamp are given the receive time.
+	span := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	start := span.StartTimestamp().AsTime()
+	assert.False(t, start.Before(before), start)
+	assert.False(t, start.After(after), start)
+	assert.Equal(t, int64(42000), int64(span.EndTimestamp()-span.StartTimestamp()))
+}
+
+func TestDecodeJSONInvalid(t *testing.T) {
+	for name, test := range map[string]struct {
+		input string
+		err   string
+	}{
+		"malformed": {
+			input: `{`,
+			err:   "failed to decode spans",
+		},
+		"trace_id": {
+			input: `[{"traceId": "abc", "id": "0040000000000001"}]`,
+			err:   "invalid span 0: invalid traceId",
+		},
+		"span_id": {
+			input: `[{"traceId": "48485a3953bb6124", "id": "zz40000000000001"}]`,
+			err:   "invalid span 0: invalid id",
+		},
+		"parent_id": {
+			input: `[{"traceId": "48485a3953bb6124", "id": "0040000000000001", "parentId": "1"}]`,
+			err:   "invalid span 0: invalid parentId",
+		},
+		"kind": {
+			input: `[{"traceId": "48485a3953bb6124", "id": "0040000000000001", "kind": "PEER"}]`,
+			err:   `invalid span 0: invalid kind "PEER"`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := zipkin.DecodeJSON(strings.NewReader(test.input))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.err)
+		})
+	}
+}
+
+func approveEventDocs(t testing.TB, name string, docs [][]byte) {
+	t.Helper()
+
+	events := make([]any, len(docs))
+	for i, doc := range docs {
+		var event map[string]any
+		if err := json.Unmarshal(doc, &event); err != nil {
+			t.Fatal(err)
+		}
+		events[i] = event
+	}
+	received := map[string]any{"events": events}
+
+	var approved any
+	approvedData, err := os.ReadFile(filepath.Join("test_approved", name+".approved.json"))
+	require.NoError(t, err)
+	if err := json.Unmarshal(approvedData, &approved); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(approved, received); diff != "" {
+		t.Fatalf("%s\n", diff)
+	}
+}
@@ -0,0 +1,245 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package zipkin
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	semconv "go.opentelemetry.io/collector/semconv/v1.5.0"
+)
+
+// agentName is recorded as the telemetry SDK name of translated
+// spans, and ends up in agent.name.
+const agentName = "zipkin"
+
+// intTags holds the names of tags which are recorded as integer
+// attributes when their values are valid integers. Zipkin tags are
+// always strings, whereas the OpenTelemetry semantic conventions
+// define these attributes as integers.
+var intTags = map[string]bool{
+	semconv.AttributeHTTPStatusCode:    true,
+	semconv.AttributeNetPeerPort:       true,
+	semconv.AttributeNetHostPort:       true,
+	semconv.AttributeRPCGRPCStatusCode: true,
+}
+
+// span holds a Zipkin v2 span.
+//
+// https://zipkin.io/zipkin-api/#/default/post_spans
+type span struct {
+	TraceID        string            `json:"traceId"`
+	ID             string            `json:"id"`
+	ParentID       string            `json:"parentId"`
+	Name           string            `json:"name"`
+	Kind           string            `json:"kind"`
+	Timestamp      int64             `json:"timestamp"`
+	Duration       int64             `json:"duration"`
+	LocalEndpoint  *endpoint         `json:"localEndpoint"`
+	RemoteEndpoint *endpoint         `json:"remoteEndpoint"`
+	Annotations    []annotation      `json:"annotations"`
+	Tags           map[string]string `json:"tags"`
+}
+
+// endpoint holds a Zipkin v2 endpoint.
+type endpoint struct {
+	ServiceName string `json:"serviceName"`
+	IPv4        string `json:"ipv4"`
+	IPv6        string `json:"ipv6"`
+	Port        int    `json:"port"`
+}
+
+// ip returns the IPv6 address of the endpoint if it is set,
+// and the IPv4 address otherwise.
+func (e *endpoint) ip() string {
+	if e.IPv6 != "" {
+		return e.IPv6
+	}
+	return e.IPv4
+}
+
+// annotation holds a Zipkin v2 annotation.
+type annotation struct {
+	Timestamp int64  `json:"timestamp"`
+	Value     string `json:"value"`
+}
+
+// DecodeJSON decodes a Zipkin v2 JSON array of spans from r,
+// returning them as OpenTelemetry traces.
+//
+// Spans are grouped into resources by their local endpoint's
+// service name. Annotations are converted to span events, and
+// the "error" tag is converted to an error span status. Spans
+// without a timestamp are given the time at which they are decoded.
+func DecodeJSON(r io.Reader) (ptrace.Traces, error) {
+	now := pcommon.NewTimestampFromTime(time.Now())
+	var spans []span
+	if err := json.NewDecoder(r).Decode(&spans); err != nil {
+		return ptrace.Traces{}, fmt.Errorf("failed to decode spans: %w", err)
+	}
+	traces := ptrace.NewTraces()
+	scopeSpans := make(map[string]ptrace.ScopeSpans)
+	for i := range spans {
+		in := &spans[i]
+		var serviceName string
+		if in.LocalEndpoint != nil {
+			serviceName = in.LocalEndpoint.ServiceName
+		}
+		ss, ok := scopeSpans[serviceName]
+		if !ok {
+			rs := traces.ResourceSpans().AppendEmpty()
+			attrs := rs.Resource().Attributes()
+			if serviceName != "" {
+				attrs.PutStr(semconv.AttributeServiceName, serviceName)
+			}
+			attrs.PutStr(semconv.AttributeTelemetrySDKName, agentName)
+			ss = rs.ScopeSpans().AppendEmpty()
+			scopeSpans[serviceName] = ss
+		}
+		if err := convertSpan(in, ss.Spans().AppendEmpty(), now); err != nil {
+			return ptrace.Traces{}, fmt.Errorf("invalid span %d: %w", i, err)
+		}
+	}
+	return traces, nil
+}
+
+func convertSpan(in *span, out ptrace.Span, now pcommon.Timestamp) error {
+	traceID, err := decodeTraceID(in.TraceID)
+	if err != nil {
+		return fmt.Errorf("invalid traceId: %w", err)
+	}
+	spanID, err := decodeSpanID(in.ID)
+	if err != nil {
+		return fmt.Errorf("invalid id: %w", err)
+	}
+	out.SetTraceID(traceID)
+	out.SetSpanID(spanID)
+	if in.ParentID != "" {
+		parentID, err := decodeSpanID(in.ParentID)
+		if err != nil {
+			return fmt.Errorf("invalid parentId: %w", err)
+		}
+		out.SetParentSpanID(parentID)
+	}
+	out.SetName(in.Name)
+	switch in.Kind {
+	case "CLIENT":
+		out.SetKind(ptrace.SpanKindClient)
+	case "SERVER":
+		out.SetKind(ptrace.SpanKindServer)
+	case "PRODUCER":
+		out.SetKind(ptrace.SpanKindProducer)
+	case "CONSUMER":
+		out.SetKind(ptrace.SpanKindConsumer)
+	case "":
+		out.SetKind(ptrace.SpanKindInternal)
+	default:
+		return fmt.Errorf("invalid kind %q", in.Kind)
+	}
+
+	// The timestamp may be missing, e.g. for spans which are
+	// incomplete, in which case the receive time is used rather
+	// than the epoch.
+	start := now
+	if in.Timestamp > 0 {
+		start = microsTimestamp(in.Timestamp)
+	}
+	out.SetStartTimestamp(start)
+	out.SetEndTimestamp(start + pcommon.Timestamp(in.Duration*int64(time.Microsecond)))
+
+	attrs := out.Attributes()
+	for k, v := range in.Tags {
+		if k == "error" {
+			// Zipkin records errors with an "error" tag, whose
+			// value may be a description of the error.
+			out.Status().SetCode(ptrace.StatusCodeError)
+			if v != "" && v != "true" {
+				out.Status().SetMessage(v)
+			}
+			continue
+		}
+		if intTags[k] {
+			if intv, err := strconv.ParseInt(v, 10, 64); err == nil {
+				attrs.PutInt(k, intv)
+				continue
+			}
+		}
+		attrs.PutStr(k, v)
+	}
+	if e := in.LocalEndpoint; e != nil {
+		if ip := e.ip(); ip != "" {
+			attrs.PutStr(semconv.AttributeNetHostIP, ip)
+		}
+		if e.Port > 0 {
+			attrs.PutInt(semconv.AttributeNetHostPort, int64(e.Port))
+		}
+	}
+	if e := in.RemoteEndpoint; e != nil {
+		if e.ServiceName != "" {
+			attrs.PutStr(semconv.AttributePeerService, e.ServiceName)
+		}
+		if ip := e.ip(); ip != "" {
+			attrs.PutStr(semconv.AttributeNetPeerIP, ip)
+		}
+		if e.Port > 0 {
+			attrs.PutInt(semconv.AttributeNetPeerPort, int64(e.Port))
+		}
+	}
+
+	for _, a := range in.Annotations {
+		event := out.Events().AppendEmpty()
+		event.SetName(a.Value)
+		event.SetTimestamp(microsTimestamp(a.Timestamp))
+	}
+	return nil
+}
+
+// decodeTraceID decodes a 64-bit or 128-bit hex-encoded trace ID.
+// 64-bit trace IDs are left-padded with zeroes.
+func decodeTraceID(s string) (pcommon.TraceID, error) {
+	var id pcommon.TraceID
+	switch len(s) {
+	case 16:
+		_, err := hex.Decode(id[8:], []byte(s))
+		return id, err
+	case 32:
+		_, err := hex.Decode(id[:], []byte(s))
+		return id, err
+	}
+	return id, fmt.Errorf("expected 16 or 32 hex characters, got %d", len(s))
+}
+
+// decodeSpanID decodes a 64-bit hex-encoded span ID.
+func decodeSpanID(s string) (pcommon.SpanID, error) {
+	var id pcommon.SpanID
+	if len(s) != 16 {
+		return id, fmt.Errorf("expected 16 hex characters, got %d", len(s))
+	}
+	_, err := hex.Decode(id[:], []byte(s))
+	return id, err
+}
+
+func microsTimestamp(us int64) pcommon.Timestamp {
+	return pcommon.Timestamp(us * int64(time.Microsecond))
+}
@@ -24,10 +24,31 @@ type Agent struct {
 	EphemeralID string
 }
 
-func (a *Agent) fields() map[string]any {
-	var agent mapStr
-	agent.maybeSetString("name", a.Name)
-	agent.maybeSetString("version", a.Version)
-	agent.maybeSetString("ephemeral_id", a.EphemeralID)
-	return map[string]any(agent)
+func (a *Agent) encodeFields(o *jsonObject) {
+	o.maybeString("name", a.Name)
+	o.maybeString("version", a.Version)
+	o.maybeString("ephemeral_id", a.EphemeralID)
+}
+
+func (a *Agent) decodeFields(f jsonFields) {
+	a.Name = f.string("name")
+	a.Version = f.string("version")
+	a.EphemeralID = f.string("ephemeral_id")
+}
+
+func (a *Agent) encodeProto(e *protoEncoder) {
+	e.string(1, a.Name)
+	e.string(2, a.Version)
+	e.string(3, a.EphemeralID)
+}
+
+func (a *Agent) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		a.Name = f.string()
+	case 2:
+		a.Version = f.string()
+	case 3:
+		a.EphemeralID = f.string()
+	}
 }
@@ -49,6 +49,6 @@ func TestAgentFields(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		assert.Equal(t, test.Fields, test.Agent.fields())
+		assert.Equal(t, test.Fields, encodeFields(&test.Agent))
 	}
 }
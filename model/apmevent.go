@@ -18,6 +18,9 @@
 package model
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"go.elastic.co/fastjson"
@@ -83,26 +86,55 @@ type APMEvent struct {
 	// See https://www.elastic.co/guide/en/ecs/current/ecs-base.html#field-message
 	Message string
 
+	// Body holds the structured body of log events whose body is a map,
+	// such as OpenTelemetry log records. Nested maps are flattened, with
+	// keys joined by dots, so that Body is indexed as a single flat
+	// object rather than dynamically mapped nested fields.
+	Body map[string]any
+
 	Transaction *Transaction
 	Span        *Span
 	Metricset   *Metricset
 	Error       *Error
 }
 
-// TODO(axw) remove this and generate the JSON encoding directly.
-func (e *APMEvent) fields() map[string]any {
-	var fields mapStr
+// MarshalJSON marshals e as JSON.
+func (e *APMEvent) MarshalJSON() ([]byte, error) {
+	var w fastjson.Writer
+	if err := e.MarshalFastJSON(&w); err != nil {
+		return nil, err
+	}
+	return w.Bytes(), nil
+}
+
+// MarshalFastJSON marshals e as JSON, writing the result to w.
+//
+// Fields are written in a fixed order, so that equal events are
+// always encoded identically.
+func (e *APMEvent) MarshalFastJSON(w *fastjson.Writer) error {
+	var err error
+	o := beginObject(w, &err)
+	o.key("@timestamp")
+	w.RawByte('"')
+	w.Time(e.Timestamp, timestampFormat)
+	w.RawByte('"')
 	if e.Transaction != nil {
-		fields.maybeSetMapStr("transaction", e.Transaction.fields())
+		transaction := o.object("transaction")
+		e.Transaction.encodeFields(&transaction)
+		transaction.end()
 	}
 	if e.Span != nil {
-		fields.maybeSetMapStr("span", e.Span.fields())
+		span := o.object("span")
+		e.Span.encodeFields(&span)
+		span.end()
 	}
 	if e.Metricset != nil {
-		e.Metricset.setFields(&fields)
+		e.Metricset.encodeFields(&o)
 	}
 	if e.Error != nil {
-		fields.maybeSetMapStr("error", e.Error.fields())
+		errorFields := o.object("error")
+		e.Error.encodeFields(&errorFields)
+		errorFields.end()
 	}
 
 	// Set high resolution timestamp.
@@ -111,96 +143,411 @@ func (e *APMEvent) fields() map[string]any {
 	if !e.Timestamp.IsZero() {
 		switch e.Processor {
 		case TransactionProcessor, SpanProcessor, ErrorProcessor:
-			fields.set("timestamp", map[string]any{
-				"us": int(e.Timestamp.UnixNano() / 1000),
-			})
+			timestamp := o.object("timestamp")
+			timestamp.int64("us", e.Timestamp.UnixNano()/1000)
+			timestamp.end()
 		}
 	}
 
 	// Set top-level field sets.
-	e.DataStream.setFields(&fields)
-	fields.maybeSetMapStr("service", e.Service.Fields())
-	fields.maybeSetMapStr("agent", e.Agent.fields())
-	fields.maybeSetMapStr("observer", e.Observer.Fields())
-	fields.maybeSetMapStr("host", e.Host.fields())
-	fields.maybeSetMapStr("device", e.Device.fields())
-	fields.maybeSetMapStr("process", e.Process.fields())
-	fields.maybeSetMapStr("user", e.User.fields())
-	fields.maybeSetMapStr("client", e.Client.fields())
-	fields.maybeSetMapStr("source", e.Source.fields())
-	fields.maybeSetMapStr("destination", e.Destination.fields())
-	fields.maybeSetMapStr("user_agent", e.UserAgent.fields())
-	fields.maybeSetMapStr("container", e.Container.fields())
-	fields.maybeSetMapStr("kubernetes", e.Kubernetes.fields())
-	fields.maybeSetMapStr("cloud", e.Cloud.fields())
-	fields.maybeSetMapStr("network", e.Network.fields())
-	fields.maybeSetMapStr("labels", e.Labels.fields())
-	fields.maybeSetMapStr("numeric_labels", e.NumericLabels.fields())
-	fields.maybeSetMapStr("event", e.Event.fields())
-	fields.maybeSetMapStr("url", e.URL.fields())
-	fields.maybeSetMapStr("session", e.Session.fields())
-	fields.maybeSetMapStr("parent", e.Parent.fields())
-	fields.maybeSetMapStr("child", e.Child.fields())
-	fields.maybeSetMapStr("processor", e.Processor.fields())
-	fields.maybeSetMapStr("trace", e.Trace.fields())
-	fields.maybeSetString("message", e.Message)
-	fields.maybeSetMapStr("http", e.HTTP.fields())
-	fields.maybeSetMapStr("faas", e.FAAS.fields())
-	fields.maybeSetMapStr("log", e.Log.fields())
-	return map[string]any(fields)
+	e.DataStream.encodeFields(&o)
+	service := o.object("service")
+	e.Service.encodeFields(&service)
+	service.end()
+	agent := o.object("agent")
+	e.Agent.encodeFields(&agent)
+	agent.end()
+	observer := o.object("observer")
+	e.Observer.encodeFields(&observer)
+	observer.end()
+	host := o.object("host")
+	e.Host.encodeFields(&host)
+	host.end()
+	device := o.object("device")
+	e.Device.encodeFields(&device)
+	device.end()
+	process := o.object("process")
+	e.Process.encodeFields(&process)
+	process.end()
+	user := o.object("user")
+	e.User.encodeFields(&user)
+	user.end()
+	client := o.object("client")
+	e.Client.encodeFields(&client)
+	client.end()
+	source := o.object("source")
+	e.Source.encodeFields(&source)
+	source.end()
+	destination := o.object("destination")
+	e.Destination.encodeFields(&destination)
+	destination.end()
+	userAgent := o.object("user_agent")
+	e.UserAgent.encodeFields(&userAgent)
+	userAgent.end()
+	container := o.object("container")
+	e.Container.encodeFields(&container)
+	container.end()
+	kubernetes := o.object("kubernetes")
+	e.Kubernetes.encodeFields(&kubernetes)
+	kubernetes.end()
+	cloud := o.object("cloud")
+	e.Cloud.encodeFields(&cloud)
+	cloud.end()
+	network := o.object("network")
+	e.Network.encodeFields(&network)
+	network.end()
+	labels := o.object("labels")
+	e.Labels.encodeFields(&labels)
+	labels.end()
+	numericLabels := o.object("numeric_labels")
+	e.NumericLabels.encodeFields(&numericLabels)
+	numericLabels.end()
+	event := o.object("event")
+	e.Event.encodeFields(&event)
+	event.end()
+	url := o.object("url")
+	e.URL.encodeFields(&url)
+	url.end()
+	session := o.object("session")
+	e.Session.encodeFields(&session)
+	session.end()
+	parent := o.object("parent")
+	e.Parent.encodeFields(&parent)
+	parent.end()
+	child := o.object("child")
+	e.Child.encodeFields(&child)
+	child.end()
+	processor := o.object("processor")
+	e.Processor.encodeFields(&processor)
+	processor.end()
+	trace := o.object("trace")
+	e.Trace.encodeFields(&trace)
+	trace.end()
+	o.maybeString("message", e.Message)
+	o.maybeMap("body", e.Body, false)
+	http := o.object("http")
+	e.HTTP.encodeFields(&http)
+	http.end()
+	faas := o.object("faas")
+	e.FAAS.encodeFields(&faas)
+	faas.end()
+	log := o.object("log")
+	e.Log.encodeFields(&log)
+	log.end()
+	o.end()
+	return err
 }
 
-// MarshalJSON marshals e as JSON.
-func (e *APMEvent) MarshalJSON() ([]byte, error) {
-	var w fastjson.Writer
-	if err := e.MarshalFastJSON(&w); err != nil {
-		return nil, err
+// UnmarshalJSON decodes an event from its JSON document encoding,
+// as produced by MarshalJSON, replacing the contents of e.
+//
+// Information which is not encoded, such as whether labels are global,
+// cannot be recovered. Label keys are decoded in their sanitized form.
+func (e *APMEvent) UnmarshalJSON(data []byte) error {
+	m, metricNames, err := decodeDocument(data)
+	if err != nil {
+		return err
 	}
-	return w.Bytes(), nil
+	*e = APMEvent{}
+	f := jsonFields{m: m, err: &err}
+
+	if ts := f.string("@timestamp"); ts != "" {
+		t, err := time.Parse(time.RFC3339Nano, ts)
+		if err != nil {
+			return fmt.Errorf("@timestamp: %w", err)
+		}
+		e.Timestamp = t
+	}
+	if timestamp, ok := f.object("timestamp"); ok && timestamp.has("us") {
+		// Use the high resolution timestamp, retaining
+		// the time zone of @timestamp.
+		us := timestamp.int64("us")
+		e.Timestamp = time.Unix(us/1e6, (us%1e6)*1e3).In(e.Timestamp.Location())
+	}
+
+	processor, _ := f.object("processor")
+	e.Processor.decodeFields(processor)
+	if transaction, ok := f.object("transaction"); ok || e.Processor == TransactionProcessor {
+		e.Transaction = &Transaction{}
+		e.Transaction.decodeFields(transaction)
+	}
+	if span, ok := f.object("span"); ok || e.Processor == SpanProcessor {
+		e.Span = &Span{}
+		e.Span.decodeFields(span)
+	}
+	if e.Processor == MetricsetProcessor || len(metricNames) > 0 || f.has("metricset.name") {
+		e.Metricset = &Metricset{}
+		e.Metricset.decodeFields(f, metricNames)
+	}
+	if errorFields, ok := f.object("error"); ok || e.Processor == ErrorProcessor {
+		e.Error = &Error{}
+		e.Error.decodeFields(errorFields)
+	}
+
+	e.DataStream.decodeFields(f)
+	service, _ := f.object("service")
+	e.Service.decodeFields(service)
+	agent, _ := f.object("agent")
+	e.Agent.decodeFields(agent)
+	observer, _ := f.object("observer")
+	e.Observer.decodeFields(observer)
+	host, _ := f.object("host")
+	e.Host.decodeFields(host)
+	device, _ := f.object("device")
+	e.Device.decodeFields(device)
+	process, _ := f.object("process")
+	e.Process.decodeFields(process)
+	user, _ := f.object("user")
+	e.User.decodeFields(user)
+	client, _ := f.object("client")
+	e.Client.decodeFields(client)
+	source, _ := f.object("source")
+	e.Source.decodeFields(source)
+	destination, _ := f.object("destination")
+	e.Destination.decodeFields(destination)
+	userAgent, _ := f.object("user_agent")
+	e.UserAgent.decodeFields(userAgent)
+	container, _ := f.object("container")
+	e.Container.decodeFields(container)
+	kubernetes, _ := f.object("kubernetes")
+	e.Kubernetes.decodeFields(kubernetes)
+	cloud, _ := f.object("cloud")
+	e.Cloud.decodeFields(cloud)
+	network, _ := f.object("network")
+	e.Network.decodeFields(network)
+	if labels, ok := f.object("labels"); ok {
+		e.Labels = make(Labels, len(labels.m))
+		e.Labels.decodeFields(labels)
+	}
+	if numericLabels, ok := f.object("numeric_labels"); ok {
+		e.NumericLabels = make(NumericLabels, len(numericLabels.m))
+		e.NumericLabels.decodeFields(numericLabels)
+	}
+	event, _ := f.object("event")
+	e.Event.decodeFields(event)
+	url, _ := f.object("url")
+	e.URL.decodeFields(url)
+	session, _ := f.object("session")
+	e.Session.decodeFields(session)
+	parent, _ := f.object("parent")
+	e.Parent.decodeFields(parent)
+	child, _ := f.object("child")
+	e.Child.decodeFields(child)
+	trace, _ := f.object("trace")
+	e.Trace.decodeFields(trace)
+	e.Message = f.string("message")
+	e.Body = f.anyMap("body")
+	http, _ := f.object("http")
+	e.HTTP.decodeFields(http)
+	faas, _ := f.object("faas")
+	e.FAAS.decodeFields(faas)
+	log, _ := f.object("log")
+	e.Log.decodeFields(log)
+	return err
 }
 
-// MarshalFastJSON marshals e as JSON, writing the result to w.
-func (e *APMEvent) MarshalFastJSON(w *fastjson.Writer) error {
-	w.RawByte('{')
-	w.RawString(`"@timestamp":"`)
-	w.Time(e.Timestamp, timestampFormat)
-	w.RawByte('"')
-	for k, v := range e.fields() {
-		w.RawByte(',')
-		w.String(k)
-		w.RawByte(':')
-		if err := encodeAny(v, w); err != nil {
-			return err
+// decodeDocument decodes the top-level fields of a JSON document,
+// along with the names of the metrics described by _metric_descriptions
+// in the order in which they appear. Numbers are decoded as json.Number.
+func decodeDocument(data []byte) (map[string]any, []string, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, err
+	}
+	m := make(map[string]any, len(raw))
+	for k, v := range raw {
+		d := json.NewDecoder(bytes.NewReader(v))
+		d.UseNumber()
+		var value any
+		if err := d.Decode(&value); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", k, err)
 		}
+		m[k] = value
 	}
-	w.RawByte('}')
-	return nil
+	var metricNames []string
+	if descriptions, ok := raw["_metric_descriptions"]; ok {
+		d := json.NewDecoder(bytes.NewReader(descriptions))
+		if tok, err := d.Token(); err != nil {
+			return nil, nil, err
+		} else if tok != json.Delim('{') {
+			return nil, nil, fmt.Errorf("_metric_descriptions: expected object, got %v", tok)
+		}
+		for d.More() {
+			name, err := d.Token()
+			if err != nil {
+				return nil, nil, err
+			}
+			var description json.RawMessage
+			if err := d.Decode(&description); err != nil {
+				return nil, nil, err
+			}
+			metricNames = append(metricNames, name.(string))
+		}
+	}
+	return m, metricNames, nil
 }
 
-func encodeAny(v any, out *fastjson.Writer) error {
-	switch v := v.(type) {
-	case map[string]any:
-		return encodeMap(v, out)
-	default:
-		return fastjson.Marshal(out, v)
+func (e *APMEvent) encodeProto(enc *protoEncoder) {
+	enc.message(1, &e.DataStream)
+	enc.message(2, &e.Event)
+	enc.message(3, &e.Agent)
+	enc.message(4, &e.Observer)
+	enc.message(5, &e.Container)
+	enc.message(6, &e.Kubernetes)
+	enc.message(7, &e.Service)
+	enc.message(8, &e.Process)
+	enc.message(9, &e.Device)
+	enc.message(10, &e.Host)
+	enc.message(11, &e.User)
+	enc.message(12, &e.UserAgent)
+	enc.message(13, &e.Client)
+	enc.message(14, &e.Source)
+	enc.message(15, &e.Destination)
+	enc.message(16, &e.Cloud)
+	enc.message(17, &e.Network)
+	enc.message(18, &e.Session)
+	enc.message(19, &e.URL)
+	enc.message(20, &e.Processor)
+	enc.message(21, &e.Trace)
+	enc.message(22, &e.Parent)
+	enc.message(23, &e.Child)
+	enc.message(24, &e.HTTP)
+	enc.message(25, &e.FAAS)
+	enc.message(26, &e.Log)
+	if !e.Timestamp.IsZero() {
+		_, offset := e.Timestamp.Zone()
+		_, body := enc.beginMessage(27)
+		enc.int64(1, e.Timestamp.UnixNano())
+		enc.sint64(2, int64(offset))
+		enc.endMessage(body)
+	}
+	e.Labels.encodeProto(enc, 28)
+	e.NumericLabels.encodeProto(enc, 29)
+	enc.string(30, e.Message)
+	enc.anyMap(35, e.Body)
+	if e.Transaction != nil {
+		enc.messageKeepEmpty(31, e.Transaction)
+	}
+	if e.Span != nil {
+		enc.messageKeepEmpty(32, e.Span)
+	}
+	if e.Metricset != nil {
+		enc.messageKeepEmpty(33, e.Metricset)
+	}
+	if e.Error != nil {
+		enc.messageKeepEmpty(34, e.Error)
 	}
 }
 
-func encodeMap(v map[string]any, out *fastjson.Writer) error {
-	out.RawByte('{')
-	first := true
-	for k, v := range v {
-		if first {
-			first = false
-		} else {
-			out.RawByte(',')
+func (e *APMEvent) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		f.message(&e.DataStream)
+	case 2:
+		f.message(&e.Event)
+	case 3:
+		f.message(&e.Agent)
+	case 4:
+		f.message(&e.Observer)
+	case 5:
+		f.message(&e.Container)
+	case 6:
+		f.message(&e.Kubernetes)
+	case 7:
+		f.message(&e.Service)
+	case 8:
+		f.message(&e.Process)
+	case 9:
+		f.message(&e.Device)
+	case 10:
+		f.message(&e.Host)
+	case 11:
+		f.message(&e.User)
+	case 12:
+		f.message(&e.UserAgent)
+	case 13:
+		f.message(&e.Client)
+	case 14:
+		f.message(&e.Source)
+	case 15:
+		f.message(&e.Destination)
+	case 16:
+		f.message(&e.Cloud)
+	case 17:
+		f.message(&e.Network)
+	case 18:
+		f.message(&e.Session)
+	case 19:
+		f.message(&e.URL)
+	case 20:
+		f.message(&e.Processor)
+	case 21:
+		f.message(&e.Trace)
+	case 22:
+		f.message(&e.Parent)
+	case 23:
+		f.message(&e.Child)
+	case 24:
+		f.message(&e.HTTP)
+	case 25:
+		f.message(&e.FAAS)
+	case 26:
+		f.message(&e.Log)
+	case 27:
+		var timestamp protoTimestamp
+		f.message(&timestamp)
+		e.Timestamp = time.Unix(0, timestamp.unixNano).UTC()
+		if timestamp.offset != 0 {
+			e.Timestamp = e.Timestamp.In(time.FixedZone("", int(timestamp.offset)))
+		}
+	case 28:
+		var k string
+		var v LabelValue
+		f.mapEntry(&k, &v)
+		if e.Labels == nil {
+			e.Labels = make(Labels)
 		}
-		out.String(k)
-		out.RawByte(':')
-		if err := encodeAny(v, out); err != nil {
-			return err
+		e.Labels[k] = v
+	case 29:
+		var k string
+		var v NumericLabelValue
+		f.mapEntry(&k, &v)
+		if e.NumericLabels == nil {
+			e.NumericLabels = make(NumericLabels)
 		}
+		e.NumericLabels[k] = v
+	case 30:
+		e.Message = f.string()
+	case 35:
+		e.Body = f.anyMap()
+	case 31:
+		e.Transaction = &Transaction{}
+		f.message(e.Transaction)
+	case 32:
+		e.Span = &Span{}
+		f.message(e.Span)
+	case 33:
+		e.Metricset = &Metricset{}
+		f.message(e.Metricset)
+	case 34:
+		e.Error = &Error{}
+		f.message(e.Error)
+	}
+}
+
+// protoTimestamp holds a decoded Timestamp message: nanoseconds since
+// the Unix epoch, and the offset of the original time zone in seconds
+// east of UTC.
+type protoTimestamp struct {
+	unixNano int64
+	offset   int64
+}
+
+func (t *protoTimestamp) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		t.unixNano = f.int64()
+	case 2:
+		t.offset = f.sint64()
 	}
-	out.RawByte('}')
-	return nil
 }
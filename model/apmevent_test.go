@@ -19,11 +19,16 @@ package model
 
 import (
 	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
 	"net/netip"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.elastic.co/fastjson"
 )
 
 func TestAPMEventFields(t *testing.T) {
@@ -66,7 +71,14 @@ func TestAPMEventFields(t *testing.T) {
 				Hostname: hostname,
 				Name:     host,
 			},
-			Client: Client{Domain: "client.domain"},
+			Client: Client{
+				Domain: "client.domain",
+				Geo: Geo{
+					CountryISOCode: "DE",
+					CityName:       "Berlin",
+					Location:       &Location{Lat: 52.52, Lon: 13.4},
+				},
+			},
 			Source: Source{
 				IP:   netip.MustParseAddr("127.0.0.1"),
 				Port: 1234,
@@ -100,6 +112,10 @@ func TestAPMEventFields(t *testing.T) {
 				Request: &HTTPRequest{
 					Method: httpRequestMethod,
 					Body:   httpRequestBody,
+					Proxies: []netip.Addr{
+						netip.MustParseAddr("10.10.10.10"),
+						netip.MustParseAddr("10.10.10.11"),
+					},
 				},
 			},
 			FAAS: FAAS{
@@ -136,8 +152,15 @@ func TestAPMEventFields(t *testing.T) {
 					"version": "1.0",
 				},
 			},
-			"user":   map[string]any{"id": "12321", "email": "user@email.com"},
-			"client": map[string]any{"domain": "client.domain"},
+			"user": map[string]any{"id": "12321", "email": "user@email.com"},
+			"client": map[string]any{
+				"domain": "client.domain",
+				"geo": map[string]any{
+					"country_iso_code": "DE",
+					"city_name":        "Berlin",
+					"location":         map[string]any{"lat": 52.52, "lon": 13.4},
+				},
+			},
 			"source": map[string]any{
 				"ip":   "127.0.0.1",
 				"port": 1234.0,
@@ -180,6 +203,7 @@ func TestAPMEventFields(t *testing.T) {
 					"body": map[string]any{
 						"original": httpRequestBody,
 					},
+					"proxies": []any{"10.10.10.10", "10.10.10.11"},
 				},
 			},
 			"faas": map[string]any{
@@ -233,3 +257,616 @@ func marshalJSONAPMEvent(in APMEvent) []byte {
 	}
 	return out
 }
+
+func BenchmarkMarshalTransaction(b *testing.B) {
+	event := benchmarkAPMEvent()
+	event.Processor = TransactionProcessor
+	event.Transaction = &Transaction{
+		ID:                  "transaction_id",
+		Name:                "GET /foo",
+		Type:                "request",
+		Result:              "HTTP 2xx",
+		Sampled:             true,
+		Root:                true,
+		RepresentativeCount: 1,
+		SpanCount:           SpanCount{Started: newInt(3)},
+		Marks: TransactionMarks{
+			"navigationTiming": TransactionMark{"domComplete": 123.4},
+		},
+	}
+	benchmarkMarshalAPMEvent(b, &event)
+}
+
+func BenchmarkMarshalSpan(b *testing.B) {
+	event := benchmarkAPMEvent()
+	event.Processor = SpanProcessor
+	event.Span = &Span{
+		ID:      "span_id",
+		Name:    "SELECT FROM foo",
+		Type:    "db",
+		Subtype: "postgresql",
+		Action:  "query",
+		DB: &DB{
+			Instance:  "db01",
+			Statement: "SELECT * FROM foo",
+			Type:      "sql",
+		},
+		DestinationService: &DestinationService{Resource: "postgresql"},
+		Stacktrace: Stacktrace{
+			{AbsPath: "/a/b/c.go", Function: "foo", Lineno: newInt(12)},
+			{AbsPath: "/a/b/d.go", Function: "bar", Lineno: newInt(34)},
+		},
+		RepresentativeCount: 1,
+	}
+	benchmarkMarshalAPMEvent(b, &event)
+}
+
+func BenchmarkMarshalMetricset(b *testing.B) {
+	event := benchmarkAPMEvent()
+	event.Processor = MetricsetProcessor
+	event.Metricset = &Metricset{
+		Name: "app",
+		Samples: []MetricsetSample{
+			{Name: "system.cpu.total.norm.pct", Type: MetricTypeGauge, Unit: "percent", Value: 0.5},
+			{Name: "system.memory.total", Type: MetricTypeGauge, Unit: "byte", Value: 1024},
+			{Name: "latency", Type: MetricTypeHistogram, Histogram: Histogram{
+				Values: []float64{1, 2, 3},
+				Counts: []int64{4, 5, 6},
+			}},
+		},
+	}
+	benchmarkMarshalAPMEvent(b, &event)
+}
+
+func BenchmarkMarshalError(b *testing.B) {
+	event := benchmarkAPMEvent()
+	event.Processor = ErrorProcessor
+	event.Error = &Error{
+		ID:          "error_id",
+		GroupingKey: "grouping_key",
+		Culprit:     "culprit",
+		Exception: &Exception{
+			Message: "boom",
+			Type:    "RuntimeError",
+			Stacktrace: Stacktrace{
+				{AbsPath: "/a/b/c.go", Function: "foo", Lineno: newInt(12)},
+			},
+			Cause: []Exception{{Message: "cause", Type: "IOError"}},
+		},
+	}
+	benchmarkMarshalAPMEvent(b, &event)
+}
+
+func benchmarkMarshalAPMEvent(b *testing.B, event *APMEvent) {
+	b.ReportAllocs()
+	var w fastjson.Writer
+	for i := 0; i < b.N; i++ {
+		w.Reset()
+		if err := event.MarshalFastJSON(&w); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.SetBytes(int64(w.Size()))
+}
+
+func benchmarkAPMEvent() APMEvent {
+	return APMEvent{
+		Timestamp: time.Date(2019, 1, 3, 15, 17, 4, 908.596*1e6, time.UTC),
+		Agent:     Agent{Name: "go", Version: "2.0.0"},
+		Observer:  Observer{Type: "apm-server", Version: "8.6.0"},
+		Service: Service{
+			Name:        "myservice",
+			Version:     "1.0",
+			Environment: "production",
+			Language:    Language{Name: "go", Version: "1.19"},
+			Runtime:     Runtime{Name: "gc", Version: "1.19"},
+			Node:        ServiceNode{Name: "node-1"},
+		},
+		Host: Host{
+			Hostname:     "hostname",
+			Architecture: "amd64",
+			IP:           []netip.Addr{netip.MustParseAddr("10.0.0.1")},
+			OS:           OS{Platform: "linux"},
+		},
+		Process:   Process{Pid: 1234, Title: "myservice"},
+		Trace:     Trace{ID: "trace_id"},
+		Parent:    Parent{ID: "parent_id"},
+		Event:     Event{Outcome: "success", Duration: time.Millisecond},
+		Labels:    Labels{"a": {Value: "b"}, "c.d": {Values: []string{"e", "f"}}},
+		URL:       URL{Original: "/foo?bar", Path: "/foo", Query: "bar"},
+		UserAgent: UserAgent{Original: "Mozilla/5.0"},
+		HTTP: HTTP{
+			Version:  "1.1",
+			Request:  &HTTPRequest{Method: "GET", Headers: map[string]any{"Accept": []string{"*/*"}}},
+			Response: &HTTPResponse{StatusCode: 200},
+		},
+	}
+}
+
+func newInt(v int) *int {
+	return &v
+}
+
+func TestAPMEventUnmarshalJSON(t *testing.T) {
+	handled := true
+	in := APMEvent{
+		Timestamp: time.Date(2019, 1, 3, 15, 17, 4, 908596*1e3, time.FixedZone("", 3600)),
+		Processor: ErrorProcessor,
+		Service:   Service{Name: "myservice", Target: &ServiceTarget{Name: "target"}},
+		Labels:    Labels{"a": {Value: "b"}, "c": {Values: []string{"d", "e"}}},
+		NumericLabels: NumericLabels{
+			"f": {Value: 1.5},
+			"g": {Values: []float64{1, 2}},
+		},
+		Error: &Error{
+			ID: "error_id",
+			Exception: &Exception{
+				Message: "message0",
+				Handled: &handled,
+				Stacktrace: Stacktrace{{
+					Filename: "file0",
+					Lineno:   newInt(123),
+				}},
+				Cause: []Exception{{
+					Message: "message1",
+					Cause:   []Exception{{Message: "message2"}},
+				}, {
+					Message:    "message3",
+					Attributes: map[string]any{"a": json.Number("1")},
+				}},
+			},
+		},
+	}
+	var out APMEvent
+	require.NoError(t, json.Unmarshal(marshalJSONAPMEvent(in), &out))
+	assert.Equal(t, in, out)
+
+	require.NoError(t, json.Unmarshal(marshalJSONAPMEvent(APMEvent{
+		Processor: MetricsetProcessor,
+		Metricset: &Metricset{
+			Name: "app",
+			Samples: []MetricsetSample{
+				{Name: "b", Value: 1},
+				{Name: "a", Type: MetricTypeHistogram, Histogram: Histogram{Values: []float64{1}, Counts: []int64{2}}},
+				{Name: "c", Type: MetricTypeSummary, Unit: "ms", SummaryMetric: SummaryMetric{Count: 3, Sum: 4.5}},
+			},
+		},
+	}), &out))
+	assert.Equal(t, &Metricset{
+		Name: "app",
+		Samples: []MetricsetSample{
+			{Name: "b", Value: 1},
+			{Name: "a", Type: MetricTypeHistogram, Histogram: Histogram{Values: []float64{1}, Counts: []int64{2}}},
+			{Name: "c", Type: MetricTypeSummary, Unit: "ms", SummaryMetric: SummaryMetric{Count: 3, Sum: 4.5}},
+		},
+	}, out.Metricset)
+}
+
+func TestAPMEventUnmarshalJSONInvalid(t *testing.T) {
+	var out APMEvent
+	assert.Error(t, out.UnmarshalJSON([]byte(`[]`)))
+	assert.EqualError(t, out.UnmarshalJSON([]byte(`{"service":{"name":123}}`)), "service.name: expected string, got json.Number")
+	assert.EqualError(t, out.UnmarshalJSON([]byte(`{"host":{"ip":["foo"]}}`)), "host.ip: expected IP address, got string")
+	assert.EqualError(t, out.UnmarshalJSON([]byte(`{"_metric_descriptions":[]}`)), "_metric_descriptions: expected object, got [")
+}
+
+func TestAPMEventJSONRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 1000; i++ {
+		in := randomAPMEvent(r)
+		encoded := marshalJSONAPMEvent(in)
+
+		var decoded APMEvent
+		require.NoError(t, decoded.UnmarshalJSON(encoded), string(encoded))
+		require.Equal(t, string(encoded), string(marshalJSONAPMEvent(decoded)))
+	}
+}
+
+// randomAPMEvent returns an APMEvent with randomly populated fields,
+// restricted to values which are encoded without loss of information.
+func randomAPMEvent(r *rand.Rand) APMEvent {
+	maybe := func() bool { return r.Intn(2) == 0 }
+	str := func() string {
+		if maybe() {
+			return ""
+		}
+		const chars = "abcXYZ019 _-/\"\\\n\t<>&é😀"
+		runes := []rune(chars)
+		out := make([]rune, 1+r.Intn(10))
+		for i := range out {
+			out[i] = runes[r.Intn(len(runes))]
+		}
+		return string(out)
+	}
+	key := func() string { return fmt.Sprintf("k%d", r.Intn(5)) }
+	strs := func() []string {
+		if maybe() {
+			return nil
+		}
+		out := make([]string, r.Intn(3))
+		for i := range out {
+			out[i] = str()
+		}
+		return out
+	}
+	intptr := func() *int {
+		if maybe() {
+			return nil
+		}
+		return newInt(r.Intn(1000))
+	}
+	boolptr := func() *bool {
+		if maybe() {
+			return nil
+		}
+		v := maybe()
+		return &v
+	}
+	float := func() float64 {
+		if maybe() {
+			return float64(r.Intn(100))
+		}
+		return r.NormFloat64() * 1e6
+	}
+	anyMap := func() map[string]any {
+		if maybe() {
+			return nil
+		}
+		return map[string]any{
+			key(): str(),
+			key(): json.Number(fmt.Sprint(r.Intn(100))),
+			key(): []any{maybe(), nil, map[string]any{key(): json.Number("1.5")}},
+		}
+	}
+	ip := func() netip.Addr {
+		switch r.Intn(3) {
+		case 0:
+			return netip.AddrFrom4([4]byte{byte(r.Intn(256)), 2, 3, 4})
+		case 1:
+			return netip.MustParseAddr("2001:db8::68")
+		}
+		return netip.Addr{}
+	}
+	stacktrace := func() Stacktrace {
+		var st Stacktrace
+		for i := r.Intn(3); i > 0; i-- {
+			frame := &StacktraceFrame{
+				AbsPath:             str(),
+				Filename:            str(),
+				Classname:           str(),
+				Lineno:              intptr(),
+				Colno:               intptr(),
+				ContextLine:         str(),
+				Module:              str(),
+				Function:            str(),
+				LibraryFrame:        maybe(),
+				Vars:                anyMap(),
+				PreContext:          strs(),
+				PostContext:         strs(),
+				ExcludeFromGrouping: maybe(),
+				SourcemapError:      str(),
+			}
+			if maybe() {
+				frame.SourcemapUpdated = true
+				frame.Original = Original{
+					AbsPath:      str(),
+					Filename:     str(),
+					Classname:    str(),
+					Lineno:       intptr(),
+					Colno:        intptr(),
+					Function:     str(),
+					LibraryFrame: maybe(),
+				}
+			}
+			st = append(st, frame)
+		}
+		return st
+	}
+	message := func() *Message {
+		if maybe() {
+			return nil
+		}
+		m := &Message{Body: str(), AgeMillis: intptr(), QueueName: str(), RoutingKey: str()}
+		if maybe() {
+			m.Headers = http.Header{key(): strs()}
+		}
+		return m
+	}
+	aggregatedDuration := func() AggregatedDuration {
+		return AggregatedDuration{Count: r.Intn(3), Sum: time.Duration(r.Intn(1e6)) * time.Microsecond}
+	}
+	histogram := func() Histogram {
+		var h Histogram
+		for i := r.Intn(3); i > 0; i-- {
+			h.Values = append(h.Values, float())
+			h.Counts = append(h.Counts, int64(r.Intn(100)))
+		}
+		return h
+	}
+	var exception func(depth int) Exception
+	exception = func(depth int) Exception {
+		e := Exception{
+			Message:    str(),
+			Module:     str(),
+			Code:       str(),
+			Type:       str(),
+			Handled:    boolptr(),
+			Stacktrace: stacktrace(),
+		}
+		if attributes := anyMap(); attributes != nil {
+			e.Attributes = attributes
+		}
+		for i := r.Intn(3); depth < 3 && i > 0; i-- {
+			e.Cause = append(e.Cause, exception(depth+1))
+		}
+		return e
+	}
+
+	e := APMEvent{
+		Timestamp:  time.Unix(r.Int63n(9e9), r.Int63n(1e9)).In(time.FixedZone("", 3600*(r.Intn(5)-2))),
+		DataStream: DataStream{Type: str(), Dataset: str(), Namespace: str()},
+		Event: Event{
+			Duration: time.Duration(r.Int63n(1e12)),
+			Outcome:  str(),
+			Severity: r.Int63n(10),
+			Action:   str(),
+			Dataset:  str(),
+		},
+		Agent:     Agent{Name: str(), Version: str(), EphemeralID: str()},
+		Observer:  Observer{Hostname: str(), Name: str(), Type: str(), Version: str()},
+		Container: Container{ID: str(), Name: str(), Runtime: str(), ImageName: str(), ImageTag: str()},
+		Kubernetes: Kubernetes{
+			Namespace: str(), NodeName: str(), PodName: str(), PodUID: str(),
+		},
+		Service: Service{
+			Name:        str(),
+			Version:     str(),
+			Environment: str(),
+			Language:    Language{Name: str(), Version: str()},
+			Runtime:     Runtime{Name: str(), Version: str()},
+			Framework:   Framework{Name: str(), Version: str()},
+			Node:        ServiceNode{Name: str()},
+		},
+		Process: Process{
+			Pid:         r.Intn(1000),
+			Ppid:        intptr(),
+			Title:       str(),
+			Argv:        strs(),
+			CommandLine: str(),
+			Executable:  str(),
+			Thread:      ProcessThread{ID: r.Intn(10), Name: str()},
+		},
+		Device: Device{
+			ID:           str(),
+			Model:        DeviceModel{Name: str(), Identifier: str()},
+			Manufacturer: str(),
+		},
+		Host: Host{
+			Hostname:     str(),
+			Name:         str(),
+			ID:           "", // not encoded
+			Architecture: str(),
+			Type:         str(),
+			OS:           OS{Name: str(), Version: str(), Platform: str(), Full: str(), Type: str()},
+		},
+		User: User{Domain: str(), ID: str(), Email: str(), Name: str()},
+		UserAgent: UserAgent{
+			Original: str(),
+			Name:     str(),
+			Version:  str(),
+			OS:       OS{Name: str(), Version: str(), Full: str()},
+			Device:   UserAgentDevice{Name: str()},
+		},
+		Client:      Client{Domain: str(), IP: ip(), Port: r.Intn(3)},
+		Source:      Source{Domain: str(), IP: ip(), Port: r.Intn(3)},
+		Destination: Destination{Address: str(), Port: r.Intn(3)},
+		Cloud: Cloud{
+			AccountID:        str(),
+			AccountName:      str(),
+			AvailabilityZone: str(),
+			InstanceID:       str(),
+			InstanceName:     str(),
+			MachineType:      str(),
+			ProjectID:        str(),
+			ProjectName:      str(),
+			Provider:         str(),
+			Region:           str(),
+			ServiceName:      str(),
+		},
+		Network: Network{
+			Connection: NetworkConnection{Type: str(), Subtype: str()},
+			Carrier:    NetworkCarrier{Name: str(), MCC: str(), MNC: str(), ICC: str()},
+		},
+		Session: Session{ID: str(), Sequence: r.Intn(3)},
+		URL: URL{
+			Original: str(), Scheme: str(), Full: str(), Domain: str(),
+			Port: r.Intn(3), Path: str(), Query: str(), Fragment: str(),
+		},
+		Trace:   Trace{ID: str()},
+		Parent:  Parent{ID: str()},
+		Child:   Child{ID: strs()},
+		FAAS:    FAAS{ID: str(), Coldstart: boolptr(), Execution: str(), TriggerType: str(), TriggerRequestID: str(), Name: str(), Version: str()},
+		Log:     Log{Level: str(), Logger: str(), Origin: LogOrigin{FunctionName: str(), File: LogOriginFile{Name: str(), Line: r.Intn(3)}}},
+		Message: str(),
+	}
+	if maybe() {
+		e.Host.IP = []netip.Addr{ip(), netip.MustParseAddr("10.0.0.1")}
+		if !e.Host.IP[0].IsValid() {
+			e.Host.IP = e.Host.IP[1:]
+		}
+	}
+	if maybe() {
+		e.Service.Origin = &ServiceOrigin{ID: str(), Name: str(), Version: str()}
+	}
+	if maybe() {
+		e.Service.Target = &ServiceTarget{Name: str(), Type: str()}
+	}
+	if maybe() {
+		e.Cloud.Origin = &CloudOrigin{AccountID: str(), Provider: str(), Region: str(), ServiceName: str()}
+	}
+	if maybe() {
+		e.Source.NAT = &NAT{IP: ip()}
+	}
+	geo := func() Geo {
+		g := Geo{
+			ContinentName:  str(),
+			CountryISOCode: str(),
+			CountryName:    str(),
+			RegionISOCode:  str(),
+			RegionName:     str(),
+			CityName:       str(),
+		}
+		if maybe() {
+			g.Location = &Location{Lat: float(), Lon: float()}
+		}
+		return g
+	}
+	e.Client.Geo = geo()
+	e.Source.Geo = geo()
+	if maybe() {
+		e.Labels = Labels{key(): {Value: str()}, key(): {Values: strs()}}
+	}
+	if maybe() {
+		e.NumericLabels = NumericLabels{key(): {Value: float()}, key(): {Values: []float64{float()}}}
+	}
+	if maybe() {
+		e.HTTP.Version = str()
+		e.HTTP.Request = &HTTPRequest{
+			ID: str(), Method: str(), Referrer: str(),
+			Headers: anyMap(), Env: anyMap(), Cookies: anyMap(),
+		}
+		if proxy := ip(); proxy.IsValid() {
+			e.HTTP.Request.Proxies = []netip.Addr{netip.MustParseAddr("10.0.0.1"), proxy}
+		}
+		if body := anyMap(); body != nil {
+			e.HTTP.Request.Body = body
+		}
+		e.HTTP.Response = &HTTPResponse{
+			StatusCode:      r.Intn(600),
+			Headers:         anyMap(),
+			Finished:        boolptr(),
+			HeadersSent:     boolptr(),
+			TransferSize:    intptr(),
+			EncodedBodySize: intptr(),
+			DecodedBodySize: intptr(),
+		}
+	}
+
+	switch r.Intn(5) {
+	case 0:
+		e.Processor = TransactionProcessor
+		e.Transaction = &Transaction{
+			ID:                  str(),
+			Name:                str(),
+			Type:                str(),
+			Result:              str(),
+			Sampled:             maybe(),
+			DurationHistogram:   histogram(),
+			DurationSummary:     SummaryMetric{Count: r.Int63n(3), Sum: float()},
+			SuccessCount:        SummaryMetric{Count: r.Int63n(3), Sum: float()},
+			Message:             message(),
+			SpanCount:           SpanCount{Dropped: intptr(), Started: intptr()},
+			Custom:              anyMap(),
+			RepresentativeCount: float(),
+			Root:                maybe(),
+		}
+		if maybe() {
+			e.Transaction.Marks = TransactionMarks{key(): {key(): float()}}
+		}
+		if maybe() {
+			e.Transaction.UserExperience = &UserExperience{
+				CumulativeLayoutShift: float(),
+				FirstInputDelay:       float(),
+				TotalBlockingTime:     float(),
+				Longtask:              LongtaskMetrics{Count: r.Intn(3) - 1, Sum: float(), Max: float()},
+			}
+		}
+		for i := r.Intn(3); i > 0; i-- {
+			e.Transaction.DroppedSpansStats = append(e.Transaction.DroppedSpansStats, DroppedSpanStats{
+				DestinationServiceResource: str(),
+				ServiceTargetType:          str(),
+				ServiceTargetName:          str(),
+				Outcome:                    str(),
+				Duration:                   aggregatedDuration(),
+			})
+		}
+	case 1:
+		e.Processor = SpanProcessor
+		e.Span = &Span{
+			ID:                  str(),
+			Name:                str(),
+			Type:                str(),
+			Kind:                str(),
+			Subtype:             str(),
+			Action:              str(),
+			SelfTime:            aggregatedDuration(),
+			Message:             message(),
+			Stacktrace:          stacktrace(),
+			Sync:                boolptr(),
+			RepresentativeCount: float(),
+		}
+		if maybe() {
+			e.Span.DB = &DB{Instance: str(), Statement: str(), Type: str(), UserName: str(), Link: str(), RowsAffected: intptr()}
+		}
+		if maybe() {
+			e.Span.DestinationService = &DestinationService{Type: str(), Name: str(), Resource: str(), ResponseTime: aggregatedDuration()}
+		}
+		if maybe() {
+			e.Span.Composite = &Composite{Count: r.Intn(10), Sum: r.Float64() * 1000, CompressionStrategy: str()}
+		}
+		for i := r.Intn(3); i > 0; i-- {
+			e.Span.Links = append(e.Span.Links, SpanLink{Span: Span{ID: str()}, Trace: Trace{ID: str()}})
+		}
+	case 2:
+		e.Processor = MetricsetProcessor
+		e.Metricset = &Metricset{Name: str(), DocCount: r.Int63n(3)}
+		for i := r.Intn(4); i > 0; i-- {
+			sample := MetricsetSample{Name: fmt.Sprintf("metric.%d", i), Unit: str()}
+			switch r.Intn(4) {
+			case 0:
+				sample.Type = MetricTypeHistogram
+				sample.Histogram = histogram()
+			case 1:
+				sample.Type = MetricTypeSummary
+				sample.SummaryMetric = SummaryMetric{Count: r.Int63n(10), Sum: float()}
+			case 2:
+				sample.Type = MetricTypeCounter
+				sample.Value = float()
+			default:
+				sample.Value = float()
+			}
+			e.Metricset.Samples = append(e.Metricset.Samples, sample)
+		}
+		if maybe() {
+			e.Transaction = &Transaction{Name: str(), DurationHistogram: histogram()}
+		}
+	case 3:
+		e.Processor = ErrorProcessor
+		e.Error = &Error{
+			ID:          str(),
+			GroupingKey: str(),
+			Culprit:     str(),
+			Custom:      anyMap(),
+			StackTrace:  str(),
+			Message:     str(),
+			Type:        str(),
+		}
+		if maybe() {
+			exception := exception(0)
+			e.Error.Exception = &exception
+		}
+		if maybe() {
+			e.Error.Log = &ErrorLog{
+				Message:      str(),
+				Level:        str(),
+				ParamMessage: str(),
+				LoggerName:   str(),
+				Stacktrace:   stacktrace(),
+			}
+		}
+	default:
+		e.Processor = LogProcessor
+		e.Body = anyMap()
+	}
+	return e
+}
@@ -23,10 +23,23 @@ type Child struct {
 	ID []string
 }
 
-func (c *Child) fields() map[string]any {
-	var fields mapStr
+func (c *Child) encodeFields(o *jsonObject) {
 	if len(c.ID) > 0 {
-		fields.set("id", c.ID)
+		o.strings("id", c.ID)
+	}
+}
+
+func (c *Child) decodeFields(f jsonFields) {
+	c.ID = f.strings("id")
+}
+
+func (c *Child) encodeProto(e *protoEncoder) {
+	e.strings(1, c.ID)
+}
+
+func (c *Child) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		c.ID = append(c.ID, f.string())
 	}
-	return map[string]any(fields)
 }
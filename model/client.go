@@ -31,16 +31,48 @@ type Client struct {
 
 	// Port holds the client's IP port.
 	Port int
+
+	// Geo holds geographical information about the client's IP address.
+	Geo Geo
 }
 
-func (c *Client) fields() map[string]any {
-	var fields mapStr
-	fields.maybeSetString("domain", c.Domain)
+func (c *Client) encodeFields(o *jsonObject) {
+	o.maybeString("domain", c.Domain)
 	if c.IP.IsValid() {
-		fields.set("ip", c.IP.String())
+		o.string("ip", c.IP.String())
 	}
 	if c.Port > 0 {
-		fields.set("port", c.Port)
+		o.int("port", c.Port)
+	}
+	geo := o.object("geo")
+	c.Geo.encodeFields(&geo)
+	geo.end()
+}
+
+func (c *Client) decodeFields(f jsonFields) {
+	c.Domain = f.string("domain")
+	c.IP = f.ip("ip")
+	c.Port = f.int("port")
+	geo, _ := f.object("geo")
+	c.Geo.decodeFields(geo)
+}
+
+func (c *Client) encodeProto(e *protoEncoder) {
+	e.string(1, c.Domain)
+	e.ip(2, c.IP)
+	e.int(3, c.Port)
+	e.message(4, &c.Geo)
+}
+
+func (c *Client) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		c.Domain = f.string()
+	case 2:
+		c.IP = f.ip()
+	case 3:
+		c.Port = f.int()
+	case 4:
+		f.message(&c.Geo)
 	}
-	return map[string]any(fields)
 }
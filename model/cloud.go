@@ -42,34 +42,128 @@ type CloudOrigin struct {
 	ServiceName string
 }
 
-func (c *Cloud) fields() map[string]any {
-	var fields mapStr
+func (c *Cloud) encodeFields(o *jsonObject) {
+	account := o.object("account")
+	account.maybeString("id", c.AccountID)
+	account.maybeString("name", c.AccountName)
+	account.end()
+	o.maybeString("availability_zone", c.AvailabilityZone)
+	instance := o.object("instance")
+	instance.maybeString("id", c.InstanceID)
+	instance.maybeString("name", c.InstanceName)
+	instance.end()
+	machine := o.object("machine")
+	machine.maybeString("type", c.MachineType)
+	machine.end()
+	project := o.object("project")
+	project.maybeString("id", c.ProjectID)
+	project.maybeString("name", c.ProjectName)
+	project.end()
+	service := o.object("service")
+	service.maybeString("name", c.ServiceName)
+	service.end()
+	o.maybeString("provider", c.Provider)
+	o.maybeString("region", c.Region)
+	if c.Origin != nil {
+		origin := o.object("origin")
+		origin.maybeString("account.id", c.Origin.AccountID)
+		origin.maybeString("provider", c.Origin.Provider)
+		origin.maybeString("region", c.Origin.Region)
+		origin.maybeString("service.name", c.Origin.ServiceName)
+		origin.end()
+	}
+}
 
-	var account, instance, machine, project, service mapStr
-	account.maybeSetString("id", c.AccountID)
-	account.maybeSetString("name", c.AccountName)
-	instance.maybeSetString("id", c.InstanceID)
-	instance.maybeSetString("name", c.InstanceName)
-	machine.maybeSetString("type", c.MachineType)
-	project.maybeSetString("id", c.ProjectID)
-	project.maybeSetString("name", c.ProjectName)
-	service.maybeSetString("name", c.ServiceName)
+func (c *Cloud) decodeFields(f jsonFields) {
+	account, _ := f.object("account")
+	c.AccountID = account.string("id")
+	c.AccountName = account.string("name")
+	c.AvailabilityZone = f.string("availability_zone")
+	instance, _ := f.object("instance")
+	c.InstanceID = instance.string("id")
+	c.InstanceName = instance.string("name")
+	machine, _ := f.object("machine")
+	c.MachineType = machine.string("type")
+	project, _ := f.object("project")
+	c.ProjectID = project.string("id")
+	c.ProjectName = project.string("name")
+	service, _ := f.object("service")
+	c.ServiceName = service.string("name")
+	c.Provider = f.string("provider")
+	c.Region = f.string("region")
+	if origin, ok := f.object("origin"); ok {
+		c.Origin = &CloudOrigin{
+			AccountID:   origin.string("account.id"),
+			Provider:    origin.string("provider"),
+			Region:      origin.string("region"),
+			ServiceName: origin.string("service.name"),
+		}
+	}
+}
 
-	fields.maybeSetMapStr("account", map[string]any(account))
-	fields.maybeSetString("availability_zone", c.AvailabilityZone)
-	fields.maybeSetMapStr("instance", map[string]any(instance))
-	fields.maybeSetMapStr("machine", map[string]any(machine))
-	fields.maybeSetMapStr("project", map[string]any(project))
-	fields.maybeSetMapStr("service", map[string]any(service))
-	fields.maybeSetString("provider", c.Provider)
-	fields.maybeSetString("region", c.Region)
+func (c *Cloud) encodeProto(e *protoEncoder) {
+	e.string(1, c.AccountID)
+	e.string(2, c.AccountName)
+	e.string(3, c.AvailabilityZone)
+	e.string(4, c.InstanceID)
+	e.string(5, c.InstanceName)
+	e.string(6, c.MachineType)
+	e.string(7, c.ProjectID)
+	e.string(8, c.ProjectName)
+	e.string(9, c.Provider)
+	e.string(10, c.Region)
+	e.string(11, c.ServiceName)
 	if c.Origin != nil {
-		var origin mapStr
-		origin.maybeSetString("account.id", c.Origin.AccountID)
-		origin.maybeSetString("provider", c.Origin.Provider)
-		origin.maybeSetString("region", c.Origin.Region)
-		origin.maybeSetString("service.name", c.Origin.ServiceName)
-		fields.maybeSetMapStr("origin", map[string]any(origin))
+		e.messageKeepEmpty(12, c.Origin)
+	}
+}
+
+func (c *Cloud) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		c.AccountID = f.string()
+	case 2:
+		c.AccountName = f.string()
+	case 3:
+		c.AvailabilityZone = f.string()
+	case 4:
+		c.InstanceID = f.string()
+	case 5:
+		c.InstanceName = f.string()
+	case 6:
+		c.MachineType = f.string()
+	case 7:
+		c.ProjectID = f.string()
+	case 8:
+		c.ProjectName = f.string()
+	case 9:
+		c.Provider = f.string()
+	case 10:
+		c.Region = f.string()
+	case 11:
+		c.ServiceName = f.string()
+	case 12:
+		c.Origin = &CloudOrigin{}
+		f.message(c.Origin)
+	}
+}
+
+func (c *CloudOrigin) encodeProto(e *protoEncoder) {
+	e.string(1, c.AccountID)
+	e.string(2, c.Provider)
+	e.string(3, c.Region)
+	e.string(4, c.ServiceName)
+}
+
+func (c *CloudOrigin) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		c.AccountID = f.string()
+	case 2:
+		c.Provider = f.string()
+	case 3:
+		c.Region = f.string()
+	case 4:
+		c.ServiceName = f.string()
 	}
-	return map[string]any(fields)
 }
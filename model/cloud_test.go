@@ -69,7 +69,7 @@ func TestCloudFields(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		output := test.Cloud.fields()
+		output := encodeFields(&test.Cloud)
 		assert.Equal(t, test.Output, output)
 	}
 }
@@ -25,15 +25,45 @@ type Container struct {
 	ImageTag  string
 }
 
-func (c *Container) fields() map[string]any {
-	var container mapStr
-	container.maybeSetString("name", c.Name)
-	container.maybeSetString("id", c.ID)
-	container.maybeSetString("runtime", c.Runtime)
+func (c *Container) encodeFields(o *jsonObject) {
+	o.maybeString("name", c.Name)
+	o.maybeString("id", c.ID)
+	o.maybeString("runtime", c.Runtime)
 
-	var image mapStr
-	image.maybeSetString("name", c.ImageName)
-	image.maybeSetString("tag", c.ImageTag)
-	container.maybeSetMapStr("image", map[string]any(image))
-	return map[string]any(container)
+	image := o.object("image")
+	image.maybeString("name", c.ImageName)
+	image.maybeString("tag", c.ImageTag)
+	image.end()
+}
+
+func (c *Container) decodeFields(f jsonFields) {
+	c.Name = f.string("name")
+	c.ID = f.string("id")
+	c.Runtime = f.string("runtime")
+	image, _ := f.object("image")
+	c.ImageName = image.string("name")
+	c.ImageTag = image.string("tag")
+}
+
+func (c *Container) encodeProto(e *protoEncoder) {
+	e.string(1, c.ID)
+	e.string(2, c.Name)
+	e.string(3, c.Runtime)
+	e.string(4, c.ImageName)
+	e.string(5, c.ImageTag)
+}
+
+func (c *Container) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		c.ID = f.string()
+	case 2:
+		c.Name = f.string()
+	case 3:
+		c.Runtime = f.string()
+	case 4:
+		c.ImageName = f.string()
+	case 5:
+		c.ImageTag = f.string()
+	}
 }
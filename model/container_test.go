@@ -57,7 +57,7 @@ func TestContainerTransform(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		output := test.Container.fields()
+		output := encodeFields(&test.Container)
 		assert.Equal(t, test.Output, output)
 	}
 }
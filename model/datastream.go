@@ -29,8 +29,31 @@ type DataStream struct {
 	Namespace string
 }
 
-func (d *DataStream) setFields(fields *mapStr) {
-	fields.maybeSetString("data_stream.type", d.Type)
-	fields.maybeSetString("data_stream.dataset", d.Dataset)
-	fields.maybeSetString("data_stream.namespace", d.Namespace)
+func (d *DataStream) encodeFields(o *jsonObject) {
+	o.maybeString("data_stream.type", d.Type)
+	o.maybeString("data_stream.dataset", d.Dataset)
+	o.maybeString("data_stream.namespace", d.Namespace)
+}
+
+func (d *DataStream) decodeFields(f jsonFields) {
+	d.Type = f.string("data_stream.type")
+	d.Dataset = f.string("data_stream.dataset")
+	d.Namespace = f.string("data_stream.namespace")
+}
+
+func (d *DataStream) encodeProto(e *protoEncoder) {
+	e.string(1, d.Type)
+	e.string(2, d.Dataset)
+	e.string(3, d.Namespace)
+}
+
+func (d *DataStream) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		d.Type = f.string()
+	case 2:
+		d.Dataset = f.string()
+	case 3:
+		d.Namespace = f.string()
+	}
 }
@@ -27,19 +27,37 @@ type Destination struct {
 	Port    int
 }
 
-func (d *Destination) fields() map[string]any {
-	var fields mapStr
-	if fields.maybeSetString("address", d.Address) {
+func (d *Destination) encodeFields(o *jsonObject) {
+	if o.maybeString("address", d.Address) {
 		// Copy destination.address to destination.ip if it's a valid IP.
 		//
 		// TODO(axw) move this to a "convert" ingest processor once we
 		// have a high enough minimum supported Elasticsearch version.
 		if ip := net.ParseIP(d.Address); ip != nil {
-			fields.set("ip", d.Address)
+			o.string("ip", d.Address)
 		}
 	}
 	if d.Port > 0 {
-		fields.set("port", d.Port)
+		o.int("port", d.Port)
+	}
+}
+
+func (d *Destination) decodeFields(f jsonFields) {
+	// destination.ip is derived from destination.address.
+	d.Address = f.string("address")
+	d.Port = f.int("port")
+}
+
+func (d *Destination) encodeProto(e *protoEncoder) {
+	e.string(1, d.Address)
+	e.int(2, d.Port)
+}
+
+func (d *Destination) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		d.Address = f.string()
+	case 2:
+		d.Port = f.int()
 	}
-	return map[string]any(fields)
 }
@@ -36,15 +36,52 @@ type DeviceModel struct {
 	Identifier string
 }
 
-func (d *Device) fields() map[string]any {
-	var fields mapStr
-	fields.maybeSetString("id", d.ID)
+func (d *Device) encodeFields(o *jsonObject) {
+	o.maybeString("id", d.ID)
 
-	var model mapStr
-	model.maybeSetString("name", d.Model.Name)
-	model.maybeSetString("identifier", d.Model.Identifier)
-	fields.maybeSetMapStr("model", map[string]any(model))
+	model := o.object("model")
+	model.maybeString("name", d.Model.Name)
+	model.maybeString("identifier", d.Model.Identifier)
+	model.end()
 
-	fields.maybeSetString("manufacturer", d.Manufacturer)
-	return map[string]any(fields)
+	o.maybeString("manufacturer", d.Manufacturer)
+}
+
+func (d *Device) decodeFields(f jsonFields) {
+	d.ID = f.string("id")
+	model, _ := f.object("model")
+	d.Model.Name = model.string("name")
+	d.Model.Identifier = model.string("identifier")
+	d.Manufacturer = f.string("manufacturer")
+}
+
+func (d *Device) encodeProto(e *protoEncoder) {
+	e.string(1, d.ID)
+	e.message(2, &d.Model)
+	e.string(3, d.Manufacturer)
+}
+
+func (d *Device) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		d.ID = f.string()
+	case 2:
+		f.message(&d.Model)
+	case 3:
+		d.Manufacturer = f.string()
+	}
+}
+
+func (m *DeviceModel) encodeProto(e *protoEncoder) {
+	e.string(1, m.Name)
+	e.string(2, m.Identifier)
+}
+
+func (m *DeviceModel) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		m.Name = f.string()
+	case 2:
+		m.Identifier = f.string()
+	}
 }
@@ -53,7 +53,7 @@ func TestDeviceFields(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		output := test.Device.fields()
+		output := encodeFields(&test.Device)
 		assert.Equal(t, test.Output, output)
 	}
 }
@@ -17,6 +17,10 @@
 
 package model
 
+import (
+	"go.elastic.co/fastjson"
+)
+
 var (
 	// ErrorProcessor is the Processor value that should be assigned to error events.
 	ErrorProcessor = Processor{Name: "error", Event: "error"}
@@ -65,65 +69,235 @@ type ErrorLog struct {
 	Stacktrace   Stacktrace
 }
 
-func (e *Error) fields() map[string]any {
-	var errorFields mapStr
-	errorFields.maybeSetString("id", e.ID)
+func (e *Error) encodeFields(o *jsonObject) {
+	o.maybeString("id", e.ID)
+	if e.Exception != nil {
+		o.key("exception")
+		o.w.RawByte('[')
+		e.Exception.encode(o.w, o.err, 0, 0)
+		o.w.RawByte(']')
+	}
+	o.maybeString("message", e.Message)
+	o.maybeString("type", e.Type)
+	if e.Log != nil {
+		log := o.object("log")
+		e.Log.encodeFields(&log)
+		log.end()
+	}
+	o.maybeString("culprit", e.Culprit)
+	o.maybeMap("custom", e.Custom, true)
+	o.maybeString("grouping_key", e.GroupingKey)
+	o.maybeString("stack_trace", e.StackTrace)
+}
+
+func (e *Error) decodeFields(f jsonFields) {
+	e.ID = f.string("id")
+	if exceptions := f.objects("exception"); len(exceptions) > 0 {
+		e.Exception = decodeExceptionTree(exceptions)
+	}
+	e.Message = f.string("message")
+	e.Type = f.string("type")
+	if log, ok := f.object("log"); ok {
+		e.Log = &ErrorLog{}
+		e.Log.decodeFields(log)
+	}
+	e.Culprit = f.string("culprit")
+	e.Custom = f.anyMap("custom")
+	e.GroupingKey = f.string("grouping_key")
+	e.StackTrace = f.string("stack_trace")
+}
+
+func (e *Error) encodeProto(enc *protoEncoder) {
+	enc.string(1, e.ID)
+	enc.string(2, e.GroupingKey)
+	enc.string(3, e.Culprit)
+	enc.anyMap(4, e.Custom)
+	enc.string(5, e.StackTrace)
+	enc.string(6, e.Message)
+	enc.string(7, e.Type)
 	if e.Exception != nil {
-		exceptionFields := e.Exception.appendFields(nil, 0)
-		errorFields.set("exception", exceptionFields)
-	}
-	errorFields.maybeSetString("message", e.Message)
-	errorFields.maybeSetString("type", e.Type)
-	errorFields.maybeSetMapStr("log", e.logFields())
-	errorFields.maybeSetString("culprit", e.Culprit)
-	errorFields.maybeSetMapStr("custom", customFields(e.Custom))
-	errorFields.maybeSetString("grouping_key", e.GroupingKey)
-	errorFields.maybeSetString("stack_trace", e.StackTrace)
-	return map[string]any(errorFields)
-}
-
-func (e *Error) logFields() map[string]any {
-	if e.Log == nil {
-		return nil
-	}
-	var log mapStr
-	log.maybeSetString("message", e.Log.Message)
-	log.maybeSetString("param_message", e.Log.ParamMessage)
-	log.maybeSetString("logger_name", e.Log.LoggerName)
-	log.maybeSetString("level", e.Log.Level)
-	if st := e.Log.Stacktrace.transform(); len(st) > 0 {
-		log.set("stacktrace", st)
-	}
-	return map[string]any(log)
-}
-
-func (e *Exception) appendFields(out []map[string]any, parentOffset int) []map[string]any {
-	offset := len(out)
-	var fields mapStr
-	fields.maybeSetString("message", e.Message)
-	fields.maybeSetString("module", e.Module)
-	fields.maybeSetString("type", e.Type)
-	fields.maybeSetString("code", e.Code)
-	fields.maybeSetBool("handled", e.Handled)
+		enc.messageKeepEmpty(8, e.Exception)
+	}
+	if e.Log != nil {
+		enc.messageKeepEmpty(9, e.Log)
+	}
+}
+
+func (e *Error) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		e.ID = f.string()
+	case 2:
+		e.GroupingKey = f.string()
+	case 3:
+		e.Culprit = f.string()
+	case 4:
+		e.Custom = f.anyMap()
+	case 5:
+		e.StackTrace = f.string()
+	case 6:
+		e.Message = f.string()
+	case 7:
+		e.Type = f.string()
+	case 8:
+		e.Exception = &Exception{}
+		f.message(e.Exception)
+	case 9:
+		e.Log = &ErrorLog{}
+		f.message(e.Log)
+	}
+}
+
+func (e *ErrorLog) encodeFields(o *jsonObject) {
+	o.maybeString("message", e.Message)
+	o.maybeString("param_message", e.ParamMessage)
+	o.maybeString("logger_name", e.LoggerName)
+	o.maybeString("level", e.Level)
+	if len(e.Stacktrace) > 0 {
+		o.key("stacktrace")
+		e.Stacktrace.encode(o.w, o.err)
+	}
+}
+
+func (e *ErrorLog) decodeFields(f jsonFields) {
+	e.Message = f.string("message")
+	e.ParamMessage = f.string("param_message")
+	e.LoggerName = f.string("logger_name")
+	e.Level = f.string("level")
+	e.Stacktrace = decodeStacktrace(f.objects("stacktrace"))
+}
+
+func (e *ErrorLog) encodeProto(enc *protoEncoder) {
+	enc.string(1, e.Message)
+	enc.string(2, e.Level)
+	enc.string(3, e.ParamMessage)
+	enc.string(4, e.LoggerName)
+	e.Stacktrace.encodeProto(enc, 5)
+}
+
+func (e *ErrorLog) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		e.Message = f.string()
+	case 2:
+		e.Level = f.string()
+	case 3:
+		e.ParamMessage = f.string()
+	case 4:
+		e.LoggerName = f.string()
+	case 5:
+		e.Stacktrace = e.Stacktrace.decodeProto(f)
+	}
+}
+
+// encode writes the exception and its causes to w as elements of a
+// flattened JSON array, returning the number of elements written.
+// The exception is written at the given offset within the array.
+func (e *Exception) encode(w *fastjson.Writer, err *error, offset, parentOffset int) int {
+	if offset > 0 {
+		w.RawByte(',')
+	}
+	o := beginObject(w, err)
+	o.maybeString("message", e.Message)
+	o.maybeString("module", e.Module)
+	o.maybeString("type", e.Type)
+	o.maybeString("code", e.Code)
+	o.maybeBool("handled", e.Handled)
 	if offset > parentOffset+1 {
 		// The parent of an exception in the resulting slice is at the offset
 		// indicated by the `parent` field (0 index based), or the preceding
 		// exception in the slice if the `parent` field is not set.
-		fields.set("parent", parentOffset)
+		o.int("parent", parentOffset)
 	}
 	if e.Attributes != nil {
-		fields.set("attributes", e.Attributes)
+		o.any("attributes", e.Attributes)
 	}
-	if n := len(e.Stacktrace); n > 0 {
-		frames := make([]map[string]any, n)
-		for i, frame := range e.Stacktrace {
-			frames[i] = frame.transform()
-		}
-		fields.set("stacktrace", frames)
+	if len(e.Stacktrace) > 0 {
+		o.key("stacktrace")
+		e.Stacktrace.encode(w, err)
 	}
-	out = append(out, map[string]any(fields))
+	o.end()
+	n := 1
 	for _, cause := range e.Cause {
-		out = cause.appendFields(out, offset)
+		n += cause.encode(w, err, offset+n, offset)
+	}
+	return n
+}
+
+// decodeExceptionTree decodes a flattened array of exceptions, as
+// written by Exception.encode, back into a tree of exceptions.
+func decodeExceptionTree(exceptions []jsonFields) *Exception {
+	flat := make([]Exception, len(exceptions))
+	parents := make([]int, len(exceptions))
+	for i, f := range exceptions {
+		flat[i].decodeFields(f)
+		parents[i] = i - 1
+		if f.has("parent") {
+			parents[i] = f.int("parent")
+		}
+		if i > 0 && (parents[i] < 0 || parents[i] >= i) {
+			f.typeError("parent", parents[i], "offset of a preceding exception")
+			parents[i] = i - 1
+		}
+	}
+	// Attach exceptions to their parents in reverse order, so each
+	// exception has all of its causes attached before it is copied
+	// into its parent's causes.
+	for i := len(flat) - 1; i >= 0; i-- {
+		cause := flat[i].Cause
+		for j, k := 0, len(cause)-1; j < k; j, k = j+1, k-1 {
+			cause[j], cause[k] = cause[k], cause[j]
+		}
+		if i > 0 {
+			parent := &flat[parents[i]]
+			parent.Cause = append(parent.Cause, flat[i])
+		}
+	}
+	return &flat[0]
+}
+
+func (e *Exception) decodeFields(f jsonFields) {
+	e.Message = f.string("message")
+	e.Module = f.string("module")
+	e.Type = f.string("type")
+	e.Code = f.string("code")
+	e.Handled = f.boolptr("handled")
+	e.Attributes = f.any("attributes")
+	e.Stacktrace = decodeStacktrace(f.objects("stacktrace"))
+}
+
+func (e *Exception) encodeProto(enc *protoEncoder) {
+	enc.string(1, e.Message)
+	enc.string(2, e.Module)
+	enc.string(3, e.Code)
+	enc.any(4, e.Attributes)
+	e.Stacktrace.encodeProto(enc, 5)
+	enc.string(6, e.Type)
+	enc.boolptr(7, e.Handled)
+	for i := range e.Cause {
+		enc.messageKeepEmpty(8, &e.Cause[i])
+	}
+}
+
+func (e *Exception) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		e.Message = f.string()
+	case 2:
+		e.Module = f.string()
+	case 3:
+		e.Code = f.string()
+	case 4:
+		e.Attributes = f.any()
+	case 5:
+		e.Stacktrace = e.Stacktrace.decodeProto(f)
+	case 6:
+		e.Type = f.string()
+	case 7:
+		e.Handled = f.boolptr()
+	case 8:
+		var cause Exception
+		f.message(&cause)
+		e.Cause = append(e.Cause, cause)
 	}
-	return out
 }
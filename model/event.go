@@ -45,16 +45,45 @@ type Event struct {
 	Dataset string
 }
 
-func (e *Event) fields() map[string]any {
-	var fields mapStr
-	fields.maybeSetString("outcome", e.Outcome)
-	fields.maybeSetString("action", e.Action)
-	fields.maybeSetString("dataset", e.Dataset)
+func (e *Event) encodeFields(o *jsonObject) {
+	o.maybeString("outcome", e.Outcome)
+	o.maybeString("action", e.Action)
+	o.maybeString("dataset", e.Dataset)
 	if e.Severity > 0 {
-		fields.set("severity", e.Severity)
+		o.int64("severity", e.Severity)
 	}
 	if e.Duration > 0 {
-		fields.set("duration", e.Duration.Nanoseconds())
+		o.int64("duration", e.Duration.Nanoseconds())
+	}
+}
+
+func (e *Event) decodeFields(f jsonFields) {
+	e.Outcome = f.string("outcome")
+	e.Action = f.string("action")
+	e.Dataset = f.string("dataset")
+	e.Severity = f.int64("severity")
+	e.Duration = time.Duration(f.int64("duration"))
+}
+
+func (e *Event) encodeProto(enc *protoEncoder) {
+	enc.int64(1, int64(e.Duration))
+	enc.string(2, e.Outcome)
+	enc.int64(3, e.Severity)
+	enc.string(4, e.Action)
+	enc.string(5, e.Dataset)
+}
+
+func (e *Event) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		e.Duration = time.Duration(f.int64())
+	case 2:
+		e.Outcome = f.string()
+	case 3:
+		e.Severity = f.int64()
+	case 4:
+		e.Action = f.string()
+	case 5:
+		e.Dataset = f.string()
 	}
-	return map[string]any(fields)
 }
@@ -71,3 +71,82 @@ func (u *UserExperience) Fields() map[string]any {
 	}
 	return map[string]any(fields)
 }
+
+func (u *UserExperience) encodeFields(o *jsonObject) {
+	if u.CumulativeLayoutShift >= 0 {
+		o.float64("cls", u.CumulativeLayoutShift)
+	}
+	if u.FirstInputDelay >= 0 {
+		o.float64("fid", u.FirstInputDelay)
+	}
+	if u.TotalBlockingTime >= 0 {
+		o.float64("tbt", u.TotalBlockingTime)
+	}
+	if u.Longtask.Count >= 0 {
+		longtask := o.object("longtask")
+		longtask.int("count", u.Longtask.Count)
+		longtask.float64("sum", u.Longtask.Sum)
+		longtask.float64("max", u.Longtask.Max)
+		longtask.end()
+	}
+}
+
+func (u *UserExperience) decodeFields(f jsonFields) {
+	// Unknown metrics are omitted from documents, and
+	// are represented by negative values.
+	u.CumulativeLayoutShift = -1
+	u.FirstInputDelay = -1
+	u.TotalBlockingTime = -1
+	u.Longtask.Count = -1
+	if f.has("cls") {
+		u.CumulativeLayoutShift = f.float64("cls")
+	}
+	if f.has("fid") {
+		u.FirstInputDelay = f.float64("fid")
+	}
+	if f.has("tbt") {
+		u.TotalBlockingTime = f.float64("tbt")
+	}
+	if longtask, ok := f.object("longtask"); ok {
+		u.Longtask.Count = longtask.int("count")
+		u.Longtask.Sum = longtask.float64("sum")
+		u.Longtask.Max = longtask.float64("max")
+	}
+}
+
+func (u *UserExperience) encodeProto(e *protoEncoder) {
+	e.float64(1, u.CumulativeLayoutShift)
+	e.float64(2, u.FirstInputDelay)
+	e.float64(3, u.TotalBlockingTime)
+	e.message(4, &u.Longtask)
+}
+
+func (u *UserExperience) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		u.CumulativeLayoutShift = f.float64()
+	case 2:
+		u.FirstInputDelay = f.float64()
+	case 3:
+		u.TotalBlockingTime = f.float64()
+	case 4:
+		f.message(&u.Longtask)
+	}
+}
+
+func (m *LongtaskMetrics) encodeProto(e *protoEncoder) {
+	e.int(1, m.Count)
+	e.float64(2, m.Sum)
+	e.float64(3, m.Max)
+}
+
+func (m *LongtaskMetrics) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		m.Count = f.int()
+	case 2:
+		m.Sum = f.float64()
+	case 3:
+		m.Max = f.float64()
+	}
+}
@@ -28,14 +28,51 @@ type FAAS struct {
 	Version          string
 }
 
-func (f *FAAS) fields() map[string]any {
-	var fields mapStr
-	fields.maybeSetString("id", f.ID)
-	fields.maybeSetBool("coldstart", f.Coldstart)
-	fields.maybeSetString("execution", f.Execution)
-	fields.maybeSetString("trigger.type", f.TriggerType)
-	fields.maybeSetString("trigger.request_id", f.TriggerRequestID)
-	fields.maybeSetString("name", f.Name)
-	fields.maybeSetString("version", f.Version)
-	return map[string]any(fields)
+func (f *FAAS) encodeFields(o *jsonObject) {
+	o.maybeString("id", f.ID)
+	o.maybeBool("coldstart", f.Coldstart)
+	o.maybeString("execution", f.Execution)
+	o.maybeString("trigger.type", f.TriggerType)
+	o.maybeString("trigger.request_id", f.TriggerRequestID)
+	o.maybeString("name", f.Name)
+	o.maybeString("version", f.Version)
+}
+
+func (faas *FAAS) decodeFields(f jsonFields) {
+	faas.ID = f.string("id")
+	faas.Coldstart = f.boolptr("coldstart")
+	faas.Execution = f.string("execution")
+	faas.TriggerType = f.string("trigger.type")
+	faas.TriggerRequestID = f.string("trigger.request_id")
+	faas.Name = f.string("name")
+	faas.Version = f.string("version")
+}
+
+func (faas *FAAS) encodeProto(e *protoEncoder) {
+	e.string(1, faas.ID)
+	e.boolptr(2, faas.Coldstart)
+	e.string(3, faas.Execution)
+	e.string(4, faas.TriggerType)
+	e.string(5, faas.TriggerRequestID)
+	e.string(6, faas.Name)
+	e.string(7, faas.Version)
+}
+
+func (faas *FAAS) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		faas.ID = f.string()
+	case 2:
+		faas.Coldstart = f.boolptr()
+	case 3:
+		faas.Execution = f.string()
+	case 4:
+		faas.TriggerType = f.string()
+	case 5:
+		faas.TriggerRequestID = f.string()
+	case 6:
+		faas.Name = f.string()
+	case 7:
+		faas.Version = f.string()
+	}
 }
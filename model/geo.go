@@ -0,0 +1,125 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package model
+
+// Geo holds geographical information, typically derived from an IP address.
+type Geo struct {
+	// ContinentName holds the name of the continent, e.g. Europe.
+	ContinentName string
+
+	// CountryISOCode holds the ISO 3166-1 alpha-2 country code, e.g. DE.
+	CountryISOCode string
+
+	// CountryName holds the name of the country, e.g. Germany.
+	CountryName string
+
+	// RegionISOCode holds the ISO 3166-2 region code, e.g. DE-BE.
+	RegionISOCode string
+
+	// RegionName holds the name of the region, e.g. Land Berlin.
+	RegionName string
+
+	// CityName holds the name of the city, e.g. Berlin.
+	CityName string
+
+	// Location holds the approximate longitude and latitude.
+	// This will be nil if the location is unknown.
+	Location *Location
+}
+
+// Location holds a geographic point.
+type Location struct {
+	Lat float64
+	Lon float64
+}
+
+func (g *Geo) encodeFields(o *jsonObject) {
+	o.maybeString("continent_name", g.ContinentName)
+	o.maybeString("country_iso_code", g.CountryISOCode)
+	o.maybeString("country_name", g.CountryName)
+	o.maybeString("region_iso_code", g.RegionISOCode)
+	o.maybeString("region_name", g.RegionName)
+	o.maybeString("city_name", g.CityName)
+	if g.Location != nil {
+		location := o.objectKeepEmpty("location")
+		location.float64("lat", g.Location.Lat)
+		location.float64("lon", g.Location.Lon)
+		location.end()
+	}
+}
+
+func (g *Geo) decodeFields(f jsonFields) {
+	g.ContinentName = f.string("continent_name")
+	g.CountryISOCode = f.string("country_iso_code")
+	g.CountryName = f.string("country_name")
+	g.RegionISOCode = f.string("region_iso_code")
+	g.RegionName = f.string("region_name")
+	g.CityName = f.string("city_name")
+	if location, ok := f.object("location"); ok {
+		g.Location = &Location{
+			Lat: location.float64("lat"),
+			Lon: location.float64("lon"),
+		}
+	}
+}
+
+func (g *Geo) encodeProto(e *protoEncoder) {
+	e.string(1, g.ContinentName)
+	e.string(2, g.CountryISOCode)
+	e.string(3, g.CountryName)
+	e.string(4, g.RegionISOCode)
+	e.string(5, g.RegionName)
+	e.string(6, g.CityName)
+	if g.Location != nil {
+		e.messageKeepEmpty(7, g.Location)
+	}
+}
+
+func (g *Geo) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		g.ContinentName = f.string()
+	case 2:
+		g.CountryISOCode = f.string()
+	case 3:
+		g.CountryName = f.string()
+	case 4:
+		g.RegionISOCode = f.string()
+	case 5:
+		g.RegionName = f.string()
+	case 6:
+		g.CityName = f.string()
+	case 7:
+		g.Location = &Location{}
+		f.message(g.Location)
+	}
+}
+
+func (l *Location) encodeProto(e *protoEncoder) {
+	e.float64(1, l.Lat)
+	e.float64(2, l.Lon)
+}
+
+func (l *Location) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		l.Lat = f.float64()
+	case 2:
+		l.Lon = f.float64()
+	}
+}
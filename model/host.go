@@ -45,22 +45,54 @@ type Host struct {
 	OS OS
 }
 
-func (h *Host) fields() map[string]any {
-	if h == nil {
-		return nil
-	}
-	var fields mapStr
-	fields.maybeSetString("hostname", h.Hostname)
-	fields.maybeSetString("name", h.Name)
-	fields.maybeSetString("architecture", h.Architecture)
-	fields.maybeSetString("type", h.Type)
+func (h *Host) encodeFields(o *jsonObject) {
+	o.maybeString("hostname", h.Hostname)
+	o.maybeString("name", h.Name)
+	o.maybeString("architecture", h.Architecture)
+	o.maybeString("type", h.Type)
 	if len(h.IP) > 0 {
-		ips := make([]string, len(h.IP))
-		for i, ip := range h.IP {
-			ips[i] = ip.String()
-		}
-		fields.set("ip", ips)
+		o.ips("ip", h.IP)
+	}
+	os := o.object("os")
+	h.OS.encodeFields(&os)
+	os.end()
+}
+
+func (h *Host) decodeFields(f jsonFields) {
+	h.Hostname = f.string("hostname")
+	h.Name = f.string("name")
+	h.Architecture = f.string("architecture")
+	h.Type = f.string("type")
+	h.IP = f.ips("ip")
+	os, _ := f.object("os")
+	h.OS.decodeFields(os)
+}
+
+func (h *Host) encodeProto(e *protoEncoder) {
+	e.string(1, h.Hostname)
+	e.string(2, h.Name)
+	e.string(3, h.ID)
+	e.string(4, h.Architecture)
+	e.string(5, h.Type)
+	e.ips(6, h.IP)
+	e.message(7, &h.OS)
+}
+
+func (h *Host) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		h.Hostname = f.string()
+	case 2:
+		h.Name = f.string()
+	case 3:
+		h.ID = f.string()
+	case 4:
+		h.Architecture = f.string()
+	case 5:
+		h.Type = f.string()
+	case 6:
+		h.IP = append(h.IP, f.ip())
+	case 7:
+		f.message(&h.OS)
 	}
-	fields.maybeSetMapStr("os", h.OS.fields())
-	return map[string]any(fields)
 }
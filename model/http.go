@@ -17,6 +17,10 @@
 
 package model
 
+import (
+	"net/netip"
+)
+
 // HTTP holds information about an HTTP request and/or response.
 type HTTP struct {
 	Version  string
@@ -36,6 +40,11 @@ type HTTPRequest struct {
 	Headers map[string]any
 	Env     map[string]any
 	Cookies map[string]any
+
+	// Proxies holds the addresses of the trusted proxies through
+	// which the request was forwarded, ordered from nearest to
+	// furthest. The nearest proxy is also recorded in Source.NAT.
+	Proxies []netip.Addr
 }
 
 // HTTPResponse holds information about an HTTP response.
@@ -52,44 +61,164 @@ type HTTPResponse struct {
 	DecodedBodySize *int
 }
 
-func (h *HTTP) fields() map[string]any {
-	var fields mapStr
-	fields.maybeSetString("version", h.Version)
+func (h *HTTP) encodeFields(o *jsonObject) {
+	o.maybeString("version", h.Version)
+	if h.Request != nil {
+		request := o.object("request")
+		h.Request.encodeFields(&request)
+		request.end()
+	}
+	if h.Response != nil {
+		response := o.object("response")
+		h.Response.encodeFields(&response)
+		response.end()
+	}
+}
+
+func (h *HTTP) decodeFields(f jsonFields) {
+	h.Version = f.string("version")
+	if request, ok := f.object("request"); ok {
+		h.Request = &HTTPRequest{}
+		h.Request.decodeFields(request)
+	}
+	if response, ok := f.object("response"); ok {
+		h.Response = &HTTPResponse{}
+		h.Response.decodeFields(response)
+	}
+}
+
+func (h *HTTP) encodeProto(e *protoEncoder) {
+	e.string(1, h.Version)
 	if h.Request != nil {
-		fields.maybeSetMapStr("request", h.Request.fields())
+		e.messageKeepEmpty(2, h.Request)
 	}
 	if h.Response != nil {
-		fields.maybeSetMapStr("response", h.Response.fields())
+		e.messageKeepEmpty(3, h.Response)
 	}
-	return map[string]any(fields)
 }
 
-func (h *HTTPRequest) fields() map[string]any {
-	var fields mapStr
-	fields.maybeSetString("id", h.ID)
-	fields.maybeSetString("method", h.Method)
-	fields.maybeSetString("referrer", h.Referrer)
-	fields.maybeSetMapStr("headers", h.Headers)
-	fields.maybeSetMapStr("env", h.Env)
-	fields.maybeSetMapStr("cookies", h.Cookies)
+func (h *HTTP) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		h.Version = f.string()
+	case 2:
+		h.Request = &HTTPRequest{}
+		f.message(h.Request)
+	case 3:
+		h.Response = &HTTPResponse{}
+		f.message(h.Response)
+	}
+}
+
+func (h *HTTPRequest) encodeFields(o *jsonObject) {
+	o.maybeString("id", h.ID)
+	o.maybeString("method", h.Method)
+	o.maybeString("referrer", h.Referrer)
+	o.maybeMap("headers", h.Headers, false)
+	o.maybeMap("env", h.Env, false)
+	o.maybeMap("cookies", h.Cookies, false)
+	if len(h.Proxies) > 0 {
+		o.ips("proxies", h.Proxies)
+	}
 	if h.Body != nil {
-		var body mapStr
-		body.set("original", h.Body)
-		fields.set("body", body)
+		body := o.object("body")
+		body.any("original", h.Body)
+		body.end()
 	}
-	return map[string]any(fields)
 }
 
-func (h *HTTPResponse) fields() map[string]any {
-	var fields mapStr
+func (h *HTTPRequest) decodeFields(f jsonFields) {
+	h.ID = f.string("id")
+	h.Method = f.string("method")
+	h.Referrer = f.string("referrer")
+	h.Headers = f.anyMap("headers")
+	h.Env = f.anyMap("env")
+	h.Cookies = f.anyMap("cookies")
+	h.Proxies = f.ips("proxies")
+	if body, ok := f.object("body"); ok {
+		h.Body = body.any("original")
+	}
+}
+
+func (h *HTTPRequest) encodeProto(e *protoEncoder) {
+	e.string(1, h.ID)
+	e.string(2, h.Method)
+	e.string(3, h.Referrer)
+	e.any(4, h.Body)
+	e.anyMap(5, h.Headers)
+	e.anyMap(6, h.Env)
+	e.anyMap(7, h.Cookies)
+	e.ips(8, h.Proxies)
+}
+
+func (h *HTTPRequest) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		h.ID = f.string()
+	case 2:
+		h.Method = f.string()
+	case 3:
+		h.Referrer = f.string()
+	case 4:
+		h.Body = f.any()
+	case 5:
+		h.Headers = f.anyMap()
+	case 6:
+		h.Env = f.anyMap()
+	case 7:
+		h.Cookies = f.anyMap()
+	case 8:
+		h.Proxies = append(h.Proxies, f.ip())
+	}
+}
+
+func (h *HTTPResponse) encodeFields(o *jsonObject) {
 	if h.StatusCode > 0 {
-		fields.set("status_code", h.StatusCode)
+		o.int("status_code", h.StatusCode)
+	}
+	o.maybeMap("headers", h.Headers, false)
+	o.maybeBool("finished", h.Finished)
+	o.maybeBool("headers_sent", h.HeadersSent)
+	o.maybeIntptr("transfer_size", h.TransferSize)
+	o.maybeIntptr("encoded_body_size", h.EncodedBodySize)
+	o.maybeIntptr("decoded_body_size", h.DecodedBodySize)
+}
+
+func (h *HTTPResponse) decodeFields(f jsonFields) {
+	h.StatusCode = f.int("status_code")
+	h.Headers = f.anyMap("headers")
+	h.Finished = f.boolptr("finished")
+	h.HeadersSent = f.boolptr("headers_sent")
+	h.TransferSize = f.intptr("transfer_size")
+	h.EncodedBodySize = f.intptr("encoded_body_size")
+	h.DecodedBodySize = f.intptr("decoded_body_size")
+}
+
+func (h *HTTPResponse) encodeProto(e *protoEncoder) {
+	e.int(1, h.StatusCode)
+	e.anyMap(2, h.Headers)
+	e.boolptr(3, h.Finished)
+	e.boolptr(4, h.HeadersSent)
+	e.intptr(5, h.TransferSize)
+	e.intptr(6, h.EncodedBodySize)
+	e.intptr(7, h.DecodedBodySize)
+}
+
+func (h *HTTPResponse) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		h.StatusCode = f.int()
+	case 2:
+		h.Headers = f.anyMap()
+	case 3:
+		h.Finished = f.boolptr()
+	case 4:
+		h.HeadersSent = f.boolptr()
+	case 5:
+		h.TransferSize = f.intptr()
+	case 6:
+		h.EncodedBodySize = f.intptr()
+	case 7:
+		h.DecodedBodySize = f.intptr()
 	}
-	fields.maybeSetMapStr("headers", h.Headers)
-	fields.maybeSetBool("finished", h.Finished)
-	fields.maybeSetBool("headers_sent", h.HeadersSent)
-	fields.maybeSetIntptr("transfer_size", h.TransferSize)
-	fields.maybeSetIntptr("encoded_body_size", h.EncodedBodySize)
-	fields.maybeSetIntptr("decoded_body_size", h.DecodedBodySize)
-	return map[string]any(fields)
 }
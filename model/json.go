@@ -0,0 +1,559 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"sort"
+
+	"go.elastic.co/fastjson"
+)
+
+// jsonObject encodes a JSON object directly to a fastjson.Writer.
+//
+// Fields are written in the order in which the methods are called,
+// so documents are encoded deterministically. Empty values are omitted
+// by the maybe* methods, and nested objects to which no fields are
+// written are omitted entirely when they are ended.
+type jsonObject struct {
+	w   *fastjson.Writer
+	err *error
+
+	// start holds the writer offset at which the object began,
+	// including its key and any preceding comma. It is negative
+	// for objects which must be written even if they are empty,
+	// such as the top-level object and array elements.
+	start int
+
+	// body holds the writer offset following the opening brace.
+	body int
+}
+
+// beginObject writes the opening brace of an object to w. The
+// returned object will be written in full even if it is empty.
+//
+// The first error encountered while encoding values of unknown
+// type is recorded in *err.
+func beginObject(w *fastjson.Writer, err *error) jsonObject {
+	w.RawByte('{')
+	return jsonObject{w: w, err: err, start: -1, body: w.Size()}
+}
+
+// object begins a nested object with key k. The nested object must be
+// ended before any more fields are written to o.
+func (o *jsonObject) object(k string) jsonObject {
+	start := o.w.Size()
+	o.key(k)
+	o.w.RawByte('{')
+	return jsonObject{w: o.w, err: o.err, start: start, body: o.w.Size()}
+}
+
+// objectKeepEmpty is like object, but the nested object
+// will be written even if it is empty.
+func (o *jsonObject) objectKeepEmpty(k string) jsonObject {
+	obj := o.object(k)
+	obj.start = -1
+	return obj
+}
+
+// end writes the closing brace of the object, or removes the object
+// (including its key) if it is nested and no fields were written.
+func (o *jsonObject) end() {
+	if o.start >= 0 && o.empty() {
+		o.w.Rewind(o.start)
+		return
+	}
+	o.w.RawByte('}')
+}
+
+// empty reports whether no fields have been written to o.
+func (o *jsonObject) empty() bool {
+	return o.w.Size() == o.body
+}
+
+// key writes the key k, preceded by a comma if any fields have
+// already been written to o.
+func (o *jsonObject) key(k string) {
+	if !o.empty() {
+		o.w.RawByte(',')
+	}
+	o.w.String(k)
+	o.w.RawByte(':')
+}
+
+func (o *jsonObject) string(k, v string) {
+	o.key(k)
+	o.w.String(v)
+}
+
+func (o *jsonObject) maybeString(k, v string) bool {
+	if v != "" {
+		o.string(k, v)
+		return true
+	}
+	return false
+}
+
+func (o *jsonObject) bool(k string, v bool) {
+	o.key(k)
+	o.w.Bool(v)
+}
+
+func (o *jsonObject) maybeBool(k string, v *bool) {
+	if v != nil {
+		o.bool(k, *v)
+	}
+}
+
+func (o *jsonObject) int(k string, v int) {
+	o.int64(k, int64(v))
+}
+
+func (o *jsonObject) int64(k string, v int64) {
+	o.key(k)
+	o.w.Int64(v)
+}
+
+func (o *jsonObject) maybeIntptr(k string, v *int) {
+	if v != nil {
+		o.int(k, *v)
+	}
+}
+
+func (o *jsonObject) float64(k string, v float64) {
+	o.key(k)
+	o.w.Float64(v)
+}
+
+func (o *jsonObject) strings(k string, v []string) {
+	o.key(k)
+	encodeStrings(o.w, v)
+}
+
+func (o *jsonObject) ips(k string, v []netip.Addr) {
+	o.key(k)
+	o.w.RawByte('[')
+	for i, ip := range v {
+		if i > 0 {
+			o.w.RawByte(',')
+		}
+		o.w.String(ip.String())
+	}
+	o.w.RawByte(']')
+}
+
+func (o *jsonObject) float64s(k string, v []float64) {
+	o.key(k)
+	encodeFloat64s(o.w, v)
+}
+
+func (o *jsonObject) int64s(k string, v []int64) {
+	o.key(k)
+	o.w.RawByte('[')
+	for i, v := range v {
+		if i > 0 {
+			o.w.RawByte(',')
+		}
+		o.w.Int64(v)
+	}
+	o.w.RawByte(']')
+}
+
+// any writes v with key k. Maps of type map[string]any are written
+// with their keys sorted; other values are encoded with fastjson.
+func (o *jsonObject) any(k string, v any) {
+	o.key(k)
+	if err := encodeAny(v, o.w); err != nil && *o.err == nil {
+		*o.err = err
+	}
+}
+
+// maybeMap writes the map v with key k if it is non-empty, with keys
+// optionally sanitized using sanitizeLabelKey.
+func (o *jsonObject) maybeMap(k string, v map[string]any, sanitize bool) {
+	if len(v) == 0 {
+		return
+	}
+	o.key(k)
+	if err := encodeMap(v, sanitize, o.w); err != nil && *o.err == nil {
+		*o.err = err
+	}
+}
+
+func encodeAny(v any, w *fastjson.Writer) error {
+	switch v := v.(type) {
+	case map[string]any:
+		if v == nil {
+			w.RawString("null")
+			return nil
+		}
+		return encodeMap(v, false, w)
+	case []any:
+		w.RawByte('[')
+		var firstErr error
+		for i, v := range v {
+			if i > 0 {
+				w.RawByte(',')
+			}
+			if err := encodeAny(v, w); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		w.RawByte(']')
+		return firstErr
+	case []string:
+		encodeStrings(w, v)
+		return nil
+	default:
+		return fastjson.Marshal(w, v)
+	}
+}
+
+func encodeMap(m map[string]any, sanitize bool, w *fastjson.Writer) error {
+	var firstErr error
+	var keys, sanitized []string
+	if sanitize {
+		keys, sanitized = sanitizedLabelKeys(m)
+	} else {
+		keys = sortedKeys(m)
+		sanitized = keys
+	}
+	w.RawByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			w.RawByte(',')
+		}
+		v := m[k]
+		w.String(sanitized[i])
+		w.RawByte(':')
+		if err := encodeAny(v, w); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	w.RawByte('}')
+	return firstErr
+}
+
+func encodeHeader(h http.Header, w *fastjson.Writer) {
+	w.RawByte('{')
+	for i, k := range sortedKeys(h) {
+		if i > 0 {
+			w.RawByte(',')
+		}
+		w.String(k)
+		w.RawByte(':')
+		encodeStrings(w, h[k])
+	}
+	w.RawByte('}')
+}
+
+func encodeStrings(w *fastjson.Writer, v []string) {
+	if v == nil {
+		w.RawString("null")
+		return
+	}
+	w.RawByte('[')
+	for i, v := range v {
+		if i > 0 {
+			w.RawByte(',')
+		}
+		w.String(v)
+	}
+	w.RawByte(']')
+}
+
+func encodeFloat64s(w *fastjson.Writer, v []float64) {
+	if v == nil {
+		w.RawString("null")
+		return
+	}
+	w.RawByte('[')
+	for i, v := range v {
+		if i > 0 {
+			w.RawByte(',')
+		}
+		w.Float64(v)
+	}
+	w.RawByte(']')
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// jsonFields holds a decoded JSON object, for decoding into model types.
+//
+// Values of unexpected types are ignored, and the first such error
+// is recorded in *err.
+type jsonFields struct {
+	m    map[string]any
+	err  *error
+	path string
+}
+
+// has reports whether f has a field with key k.
+func (f jsonFields) has(k string) bool {
+	_, ok := f.m[k]
+	return ok
+}
+
+// typeError records an error for the field k having an unexpected type.
+func (f jsonFields) typeError(k string, v any, expected string) {
+	if *f.err == nil {
+		*f.err = fmt.Errorf("%s: expected %s, got %T", f.fieldPath(k), expected, v)
+	}
+}
+
+func (f jsonFields) fieldPath(k string) string {
+	if f.path == "" {
+		return k
+	}
+	return f.path + "." + k
+}
+
+// object returns the nested object with key k, and a boolean
+// indicating whether the field exists.
+func (f jsonFields) object(k string) (jsonFields, bool) {
+	v, ok := f.m[k]
+	if !ok {
+		return jsonFields{err: f.err}, false
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		f.typeError(k, v, "object")
+		return jsonFields{err: f.err}, false
+	}
+	return jsonFields{m: m, err: f.err, path: f.fieldPath(k)}, true
+}
+
+// objects returns the array of objects with key k.
+func (f jsonFields) objects(k string) []jsonFields {
+	v, ok := f.m[k]
+	if !ok {
+		return nil
+	}
+	values, ok := v.([]any)
+	if !ok {
+		f.typeError(k, v, "array")
+		return nil
+	}
+	out := make([]jsonFields, 0, len(values))
+	for i, v := range values {
+		m, ok := v.(map[string]any)
+		if !ok {
+			f.typeError(fmt.Sprintf("%s[%d]", k, i), v, "object")
+			continue
+		}
+		out = append(out, jsonFields{m: m, err: f.err, path: fmt.Sprintf("%s[%d]", f.fieldPath(k), i)})
+	}
+	return out
+}
+
+func (f jsonFields) string(k string) string {
+	v, ok := f.m[k]
+	if !ok {
+		return ""
+	}
+	s, ok := v.(string)
+	if !ok {
+		f.typeError(k, v, "string")
+	}
+	return s
+}
+
+func (f jsonFields) bool(k string) bool {
+	if v := f.boolptr(k); v != nil {
+		return *v
+	}
+	return false
+}
+
+func (f jsonFields) boolptr(k string) *bool {
+	v, ok := f.m[k]
+	if !ok {
+		return nil
+	}
+	b, ok := v.(bool)
+	if !ok {
+		f.typeError(k, v, "boolean")
+		return nil
+	}
+	return &b
+}
+
+func (f jsonFields) number(k string) (json.Number, bool) {
+	v, ok := f.m[k]
+	if !ok {
+		return "", false
+	}
+	n, ok := v.(json.Number)
+	if !ok {
+		f.typeError(k, v, "number")
+	}
+	return n, ok
+}
+
+func (f jsonFields) int(k string) int {
+	return int(f.int64(k))
+}
+
+func (f jsonFields) intptr(k string) *int {
+	if !f.has(k) {
+		return nil
+	}
+	v := f.int(k)
+	return &v
+}
+
+func (f jsonFields) int64(k string) int64 {
+	n, ok := f.number(k)
+	if !ok {
+		return 0
+	}
+	v, err := n.Int64()
+	if err != nil {
+		f.typeError(k, n, "integer")
+	}
+	return v
+}
+
+func (f jsonFields) float64(k string) float64 {
+	n, ok := f.number(k)
+	if !ok {
+		return 0
+	}
+	v, err := n.Float64()
+	if err != nil {
+		f.typeError(k, n, "number")
+	}
+	return v
+}
+
+// any returns the value with key k, as decoded. Numbers are
+// represented as json.Number values.
+func (f jsonFields) any(k string) any {
+	return f.m[k]
+}
+
+// anyMap returns the object with key k as a map[string]any.
+func (f jsonFields) anyMap(k string) map[string]any {
+	obj, _ := f.object(k)
+	return obj.m
+}
+
+func (f jsonFields) strings(k string) []string {
+	values := f.array(k)
+	if values == nil {
+		return nil
+	}
+	out := make([]string, len(values))
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			f.typeError(fmt.Sprintf("%s[%d]", k, i), v, "string")
+		}
+		out[i] = s
+	}
+	return out
+}
+
+func (f jsonFields) float64s(k string) []float64 {
+	values := f.array(k)
+	if values == nil {
+		return nil
+	}
+	out := make([]float64, len(values))
+	for i, v := range values {
+		n, ok := v.(json.Number)
+		if !ok {
+			f.typeError(fmt.Sprintf("%s[%d]", k, i), v, "number")
+			continue
+		}
+		out[i], _ = n.Float64()
+	}
+	return out
+}
+
+func (f jsonFields) int64s(k string) []int64 {
+	values := f.array(k)
+	if values == nil {
+		return nil
+	}
+	out := make([]int64, len(values))
+	for i, v := range values {
+		n, ok := v.(json.Number)
+		if !ok {
+			f.typeError(fmt.Sprintf("%s[%d]", k, i), v, "number")
+			continue
+		}
+		out[i], _ = n.Int64()
+	}
+	return out
+}
+
+func (f jsonFields) array(k string) []any {
+	v, ok := f.m[k]
+	if !ok || v == nil {
+		return nil
+	}
+	values, ok := v.([]any)
+	if !ok {
+		f.typeError(k, v, "array")
+		return nil
+	}
+	if values == nil {
+		values = []any{}
+	}
+	return values
+}
+
+func (f jsonFields) ip(k string) netip.Addr {
+	s := f.string(k)
+	if s == "" {
+		return netip.Addr{}
+	}
+	ip, err := netip.ParseAddr(s)
+	if err != nil {
+		f.typeError(k, s, "IP address")
+	}
+	return ip
+}
+
+func (f jsonFields) ips(k string) []netip.Addr {
+	values := f.strings(k)
+	if len(values) == 0 {
+		return nil
+	}
+	out := make([]netip.Addr, 0, len(values))
+	for _, v := range values {
+		if ip, err := netip.ParseAddr(v); err == nil {
+			out = append(out, ip)
+		} else {
+			f.typeError(k, v, "IP address")
+		}
+	}
+	return out
+}
@@ -0,0 +1,96 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.elastic.co/fastjson"
+)
+
+func TestJSONObjectOmitEmpty(t *testing.T) {
+	var w fastjson.Writer
+	var err error
+	o := beginObject(&w, &err)
+	empty := o.object("empty")
+	nested := empty.object("nested")
+	nested.maybeString("a", "")
+	nested.end()
+	empty.end()
+	kept := o.objectKeepEmpty("kept")
+	kept.end()
+	o.maybeString("b", "c")
+	nonEmpty := o.object("non_empty")
+	nonEmpty.int("d", 1)
+	nonEmpty.end()
+	o.end()
+	require.NoError(t, err)
+	assert.Equal(t, `{"kept":{},"b":"c","non_empty":{"d":1}}`, string(w.Bytes()))
+}
+
+func TestJSONObjectSortedMaps(t *testing.T) {
+	var w fastjson.Writer
+	var err error
+	o := beginObject(&w, &err)
+	o.maybeMap("m", map[string]any{
+		"c.d": 1,
+		"c_d": 2,
+		"a":   map[string]any{"z": true, "y": []any{"x", map[string]any{"w": nil, "v": 1.5}}},
+	}, true)
+	o.end()
+	require.NoError(t, err)
+	assert.Equal(t, `{"m":{"a":{"y":["x",{"v":1.5,"w":null}],"z":true},"c_d":1}}`, string(w.Bytes()))
+}
+
+func TestMarshalFastJSONDeterministic(t *testing.T) {
+	event := benchmarkAPMEvent()
+	event.NumericLabels = NumericLabels{"z": {Value: 1}, "y": {Value: 2}, "x": {Values: []float64{3, 4}}}
+	event.Transaction = &Transaction{
+		ID:     "transaction_id",
+		Custom: map[string]any{"b": 1, "a": 2},
+		Marks:  TransactionMarks{"b": {"y": 1, "x": 2}, "a": {"z": 3}},
+	}
+	expected := marshalJSONAPMEvent(event)
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, string(expected), string(marshalJSONAPMEvent(event)))
+	}
+}
+
+// encodeFields encodes the fields of v as a JSON object, and decodes the
+// result into a map. If no fields are encoded, nil is returned.
+func encodeFields(v interface{ encodeFields(*jsonObject) }) map[string]any {
+	var w fastjson.Writer
+	var err error
+	o := beginObject(&w, &err)
+	v.encodeFields(&o)
+	o.end()
+	if err != nil {
+		panic(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(w.Bytes(), &decoded); err != nil {
+		panic(err)
+	}
+	if len(decoded) == 0 {
+		return nil
+	}
+	return decoded
+}
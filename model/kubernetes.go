@@ -24,21 +24,44 @@ type Kubernetes struct {
 	PodUID    string
 }
 
-func (k *Kubernetes) fields() map[string]any {
-	var kubernetes mapStr
-	kubernetes.maybeSetString("namespace", k.Namespace)
+func (k *Kubernetes) encodeFields(o *jsonObject) {
+	o.maybeString("namespace", k.Namespace)
 
-	var node mapStr
-	if node.maybeSetString("name", k.NodeName) {
-		kubernetes.set("node", map[string]any(node))
-	}
+	node := o.object("node")
+	node.maybeString("name", k.NodeName)
+	node.end()
 
-	var pod mapStr
-	pod.maybeSetString("name", k.PodName)
-	pod.maybeSetString("uid", k.PodUID)
-	if pod != nil {
-		kubernetes.set("pod", map[string]any(pod))
-	}
+	pod := o.object("pod")
+	pod.maybeString("name", k.PodName)
+	pod.maybeString("uid", k.PodUID)
+	pod.end()
+}
+
+func (k *Kubernetes) decodeFields(f jsonFields) {
+	k.Namespace = f.string("namespace")
+	node, _ := f.object("node")
+	k.NodeName = node.string("name")
+	pod, _ := f.object("pod")
+	k.PodName = pod.string("name")
+	k.PodUID = pod.string("uid")
+}
+
+func (k *Kubernetes) encodeProto(e *protoEncoder) {
+	e.string(1, k.Namespace)
+	e.string(2, k.NodeName)
+	e.string(3, k.PodName)
+	e.string(4, k.PodUID)
+}
 
-	return map[string]any(kubernetes)
+func (k *Kubernetes) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		k.Namespace = f.string()
+	case 2:
+		k.NodeName = f.string()
+	case 3:
+		k.PodName = f.string()
+	case 4:
+		k.PodUID = f.string()
+	}
 }
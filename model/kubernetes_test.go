@@ -53,7 +53,7 @@ func TestKubernetesTransform(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		output := test.Kubernetes.fields()
+		output := encodeFields(&test.Kubernetes)
 		assert.Equal(t, test.Output, output)
 	}
 }
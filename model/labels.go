@@ -19,6 +19,8 @@ package model
 
 import (
 	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
 )
 
 // Labels wraps a map[string]string or map[string][]string with utility
@@ -66,16 +68,57 @@ func (l Labels) Clone() Labels {
 	return cp
 }
 
-func (l Labels) fields() map[string]any {
-	result := map[string]any{}
-	for k, v := range l {
+func (l Labels) encodeFields(o *jsonObject) {
+	keys, sanitized := sanitizedLabelKeys(l)
+	for i, k := range keys {
+		v := l[k]
+		k = sanitized[i]
 		if v.Values != nil {
-			result[k] = v.Values
+			o.strings(k, v.Values)
 		} else {
-			result[k] = v.Value
+			o.string(k, v.Value)
 		}
 	}
-	return sanitizeLabels(result)
+}
+
+func (l Labels) decodeFields(f jsonFields) {
+	for k, v := range f.m {
+		if _, ok := v.([]any); ok {
+			l.SetSlice(k, f.strings(k))
+		} else {
+			l.Set(k, f.string(k))
+		}
+	}
+}
+
+// encodeProto writes l as a map field, with entries sorted by key.
+func (l Labels) encodeProto(e *protoEncoder, num protowire.Number) {
+	for _, k := range sortedKeys(l) {
+		v := l[k]
+		e.mapEntry(num, k, &v)
+	}
+}
+
+func (v *LabelValue) encodeProto(e *protoEncoder) {
+	if v.Values != nil {
+		e.messageKeepEmpty(2, (*stringValues)(&v.Values))
+	} else {
+		e.string(1, v.Value)
+	}
+	e.bool(3, v.Global)
+}
+
+func (v *LabelValue) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		v.Value = f.string()
+	case 2:
+		values := stringValues{}
+		f.message(&values)
+		v.Values = values
+	case 3:
+		v.Global = f.bool()
+	}
 }
 
 // NumericLabels wraps a map[string]float64 or map[string][]float64 with utility
@@ -123,34 +166,88 @@ func (l NumericLabels) Clone() NumericLabels {
 	return cp
 }
 
-func (l NumericLabels) fields() map[string]any {
-	result := map[string]any{}
-	for k, v := range l {
+func (l NumericLabels) encodeFields(o *jsonObject) {
+	keys, sanitized := sanitizedLabelKeys(l)
+	for i, k := range keys {
+		v := l[k]
+		k = sanitized[i]
 		if v.Values != nil {
-			result[k] = v.Values
+			o.float64s(k, v.Values)
 		} else {
-			result[k] = v.Value
+			o.float64(k, v.Value)
 		}
 	}
-	return sanitizeLabels(result)
 }
 
-// Label keys are sanitized, replacing the reserved characters '.', '*' and '"'
-// with '_'. Null-valued labels are omitted.
-func sanitizeLabels(labels map[string]any) map[string]any {
-	for k, v := range labels {
-		if v == nil {
-			delete(labels, k)
-			continue
+func (l NumericLabels) decodeFields(f jsonFields) {
+	for k, v := range f.m {
+		if _, ok := v.([]any); ok {
+			l.SetSlice(k, f.float64s(k))
+		} else {
+			l.Set(k, f.float64(k))
+		}
+	}
+}
+
+// encodeProto writes l as a map field, with entries sorted by key.
+func (l NumericLabels) encodeProto(e *protoEncoder, num protowire.Number) {
+	for _, k := range sortedKeys(l) {
+		v := l[k]
+		e.mapEntry(num, k, &v)
+	}
+}
+
+func (v *NumericLabelValue) encodeProto(e *protoEncoder) {
+	if v.Values != nil {
+		e.messageKeepEmpty(1, (*float64Values)(&v.Values))
+	} else {
+		e.float64(2, v.Value)
+	}
+	e.bool(3, v.Global)
+}
+
+func (v *NumericLabelValue) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		values := float64Values{}
+		f.message(&values)
+		v.Values = values
+	case 2:
+		v.Value = f.float64()
+	case 3:
+		v.Global = f.bool()
+	}
+}
+
+// sanitizedLabelKeys returns the keys of m in sorted order, along with
+// their sanitized equivalents. If multiple keys are sanitized to the same
+// key, only the first in sorted order is returned.
+func sanitizedLabelKeys[V any](m map[string]V) (keys, sanitized []string) {
+	keys = sortedKeys(m)
+	sanitized = make([]string, 0, len(keys))
+	var seen map[string]struct{}
+	for _, k := range keys {
+		sk := sanitizeLabelKey(k)
+		if sk != k && seen == nil {
+			seen = make(map[string]struct{}, len(keys))
+			for _, k := range sanitized {
+				seen[k] = struct{}{}
+			}
 		}
-		if k2 := sanitizeLabelKey(k); k != k2 {
-			delete(labels, k)
-			labels[k2] = v
+		if seen != nil {
+			if _, ok := seen[sk]; ok {
+				continue
+			}
+			seen[sk] = struct{}{}
 		}
+		keys[len(sanitized)] = k
+		sanitized = append(sanitized, sk)
 	}
-	return labels
+	return keys[:len(sanitized)], sanitized
 }
 
+// Label keys are sanitized, replacing the reserved characters '.', '*' and '"'
+// with '_'.
 func sanitizeLabelKey(k string) string {
 	return strings.Map(replaceReservedLabelKeyRune, k)
 }
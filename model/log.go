@@ -45,16 +45,71 @@ type LogOriginFile struct {
 	Line int
 }
 
-func (e Log) fields() map[string]any {
-	var fields, origin, file mapStr
-	fields.maybeSetString("level", e.Level)
-	fields.maybeSetString("logger", e.Logger)
-	origin.maybeSetString("function", e.Origin.FunctionName)
-	file.maybeSetString("name", e.Origin.File.Name)
+func (e *Log) encodeFields(o *jsonObject) {
+	o.maybeString("level", e.Level)
+	o.maybeString("logger", e.Logger)
+	origin := o.object("origin")
+	origin.maybeString("function", e.Origin.FunctionName)
+	file := origin.object("file")
+	file.maybeString("name", e.Origin.File.Name)
 	if e.Origin.File.Line > 0 {
-		file.set("line", e.Origin.File.Line)
+		file.int("line", e.Origin.File.Line)
+	}
+	file.end()
+	origin.end()
+}
+
+func (e *Log) decodeFields(f jsonFields) {
+	e.Level = f.string("level")
+	e.Logger = f.string("logger")
+	origin, _ := f.object("origin")
+	e.Origin.FunctionName = origin.string("function")
+	file, _ := origin.object("file")
+	e.Origin.File.Name = file.string("name")
+	e.Origin.File.Line = file.int("line")
+}
+
+func (e *Log) encodeProto(enc *protoEncoder) {
+	enc.string(1, e.Level)
+	enc.string(2, e.Logger)
+	enc.message(3, &e.Origin)
+}
+
+func (e *Log) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		e.Level = f.string()
+	case 2:
+		e.Logger = f.string()
+	case 3:
+		f.message(&e.Origin)
+	}
+}
+
+func (o *LogOrigin) encodeProto(e *protoEncoder) {
+	e.message(1, &o.File)
+	e.string(2, o.FunctionName)
+}
+
+func (o *LogOrigin) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		f.message(&o.File)
+	case 2:
+		o.FunctionName = f.string()
+	}
+}
+
+func (o *LogOriginFile) encodeProto(e *protoEncoder) {
+	e.string(1, o.Name)
+	e.int(2, o.Line)
+}
+
+func (o *LogOriginFile) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		o.Name = f.string()
+	case 2:
+		o.Line = f.int()
 	}
-	origin.maybeSetMapStr("file", map[string]any(file))
-	fields.maybeSetMapStr("origin", map[string]any(origin))
-	return map[string]any(fields)
 }
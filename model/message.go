@@ -50,3 +50,89 @@ func (m *Message) Fields() map[string]any {
 
 	return map[string]any(fields)
 }
+
+func (m *Message) encodeFields(o *jsonObject) {
+	if m.QueueName != "" {
+		queue := o.object("queue")
+		queue.string("name", m.QueueName)
+		queue.end()
+	}
+	if m.AgeMillis != nil {
+		age := o.object("age")
+		age.int("ms", *m.AgeMillis)
+		age.end()
+	}
+	if len(m.Headers) > 0 {
+		o.key("headers")
+		encodeHeader(m.Headers, o.w)
+	}
+	o.maybeString("body", m.Body)
+	o.maybeString("routing_key", m.RoutingKey)
+}
+
+func (m *Message) decodeFields(f jsonFields) {
+	queue, _ := f.object("queue")
+	m.QueueName = queue.string("name")
+	age, _ := f.object("age")
+	m.AgeMillis = age.intptr("ms")
+	if headers, ok := f.object("headers"); ok {
+		m.Headers = make(http.Header, len(headers.m))
+		for k := range headers.m {
+			m.Headers[k] = headers.strings(k)
+		}
+	}
+	m.Body = f.string("body")
+	m.RoutingKey = f.string("routing_key")
+}
+
+func (m *Message) encodeProto(e *protoEncoder) {
+	e.string(1, m.Body)
+	for _, k := range sortedKeys(m.Headers) {
+		var value protoEncodable
+		if v := m.Headers[k]; v != nil {
+			value = (*stringValues)(&v)
+		}
+		e.mapEntry(2, k, value)
+	}
+	e.intptr(3, m.AgeMillis)
+	e.string(4, m.QueueName)
+	e.string(5, m.RoutingKey)
+}
+
+func (m *Message) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		m.Body = f.string()
+	case 2:
+		var entry headerEntry
+		f.message(&entry)
+		if m.Headers == nil {
+			m.Headers = make(http.Header)
+		}
+		m.Headers[entry.key] = entry.values
+	case 3:
+		m.AgeMillis = f.intptr()
+	case 4:
+		m.QueueName = f.string()
+	case 5:
+		m.RoutingKey = f.string()
+	}
+}
+
+// headerEntry holds a decoded header map entry. The entry's value is
+// omitted for nil header values.
+type headerEntry struct {
+	key    string
+	values []string
+}
+
+func (e *headerEntry) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		e.key = f.string()
+	case 2:
+		values := stringValues{}
+		f.message(&values)
+		e.values = values
+	}
+}
@@ -40,6 +40,9 @@ const (
 // Metricset describes a set of metrics and associated metadata.
 type Metricset struct {
 	// Samples holds the metrics in the set.
+	//
+	// Sample names should be unique. If multiple samples have the same
+	// name, only the last of them is encoded as JSON.
 	Samples []MetricsetSample
 
 	// Name holds an optional name for the metricset.
@@ -102,14 +105,31 @@ type Histogram struct {
 	Counts []int64
 }
 
-func (h *Histogram) fields() map[string]any {
+func (h *Histogram) encodeFields(o *jsonObject) {
 	if len(h.Counts) == 0 {
-		return nil
+		return
+	}
+	o.int64s("counts", h.Counts)
+	o.float64s("values", h.Values)
+}
+
+func (h *Histogram) decodeFields(f jsonFields) {
+	h.Counts = f.int64s("counts")
+	h.Values = f.float64s("values")
+}
+
+func (h *Histogram) encodeProto(e *protoEncoder) {
+	e.float64s(1, h.Values)
+	e.int64s(2, h.Counts)
+}
+
+func (h *Histogram) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		h.Values = f.float64s(h.Values)
+	case 2:
+		h.Counts = f.int64s(h.Counts)
 	}
-	var fields mapStr
-	fields.set("counts", h.Counts)
-	fields.set("values", h.Values)
-	return map[string]any(fields)
 }
 
 // SummaryMetric holds summary metrics (count and sum).
@@ -121,8 +141,28 @@ type SummaryMetric struct {
 	Sum float64
 }
 
-func (s *SummaryMetric) fields() map[string]any {
-	return map[string]any{"value_count": s.Count, "sum": s.Sum}
+func (s *SummaryMetric) encodeFields(o *jsonObject) {
+	o.int64("value_count", s.Count)
+	o.float64("sum", s.Sum)
+}
+
+func (s *SummaryMetric) decodeFields(f jsonFields) {
+	s.Count = f.int64("value_count")
+	s.Sum = f.float64("sum")
+}
+
+func (s *SummaryMetric) encodeProto(e *protoEncoder) {
+	e.int64(1, s.Count)
+	e.float64(2, s.Sum)
+}
+
+func (s *SummaryMetric) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		s.Count = f.int64()
+	case 2:
+		s.Sum = f.float64()
+	}
 }
 
 // AggregatedDuration holds a count and sum of aggregated durations.
@@ -134,41 +174,172 @@ type AggregatedDuration struct {
 	Sum time.Duration
 }
 
-func (a *AggregatedDuration) fields() map[string]any {
+func (a *AggregatedDuration) encodeFields(o *jsonObject) {
 	if a.Count == 0 {
-		return nil
+		return
+	}
+	o.int("count", a.Count)
+	o.int64("sum.us", a.Sum.Microseconds())
+}
+
+func (a *AggregatedDuration) decodeFields(f jsonFields) {
+	a.Count = f.int("count")
+	a.Sum = time.Duration(f.int64("sum.us")) * time.Microsecond
+}
+
+func (a *AggregatedDuration) encodeProto(e *protoEncoder) {
+	e.int(1, a.Count)
+	e.int64(2, int64(a.Sum))
+}
+
+func (a *AggregatedDuration) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		a.Count = f.int()
+	case 2:
+		a.Sum = time.Duration(f.int64())
 	}
-	var fields mapStr
-	fields.set("count", a.Count)
-	fields.set("sum.us", a.Sum.Microseconds())
-	return map[string]any(fields)
 }
 
-func (me *Metricset) setFields(fields *mapStr) {
+func (me *Metricset) encodeFields(o *jsonObject) {
 	if me.DocCount > 0 {
-		fields.set("_doc_count", me.DocCount)
+		o.int64("_doc_count", me.DocCount)
+	}
+	o.maybeString("metricset.name", me.Name)
+
+	shadowed := me.shadowedSamples()
+	for i := range me.Samples {
+		if shadowed == nil || !shadowed[i] {
+			me.Samples[i].encode(o)
+		}
+	}
+	if len(me.Samples) > 0 {
+		descriptions := o.object("_metric_descriptions")
+		for i, sample := range me.Samples {
+			if shadowed != nil && shadowed[i] {
+				continue
+			}
+			description := descriptions.objectKeepEmpty(sample.Name)
+			description.maybeString("type", string(sample.Type))
+			description.maybeString("unit", sample.Unit)
+			description.end()
+		}
+		descriptions.end()
+	}
+}
+
+// shadowedSamples reports for each sample whether it is followed by
+// another sample with the same name, which takes precedence when
+// encoding the samples as JSON fields. If no samples are shadowed,
+// shadowedSamples returns nil.
+func (me *Metricset) shadowedSamples() []bool {
+	if len(me.Samples) < 2 {
+		return nil
+	}
+	var shadowed []bool
+	seen := make(map[string]struct{}, len(me.Samples))
+	for i := len(me.Samples) - 1; i >= 0; i-- {
+		name := me.Samples[i].Name
+		if _, ok := seen[name]; !ok {
+			seen[name] = struct{}{}
+			continue
+		}
+		if shadowed == nil {
+			shadowed = make([]bool, len(me.Samples))
+		}
+		shadowed[i] = true
+	}
+	return shadowed
+}
+
+// decodeFields decodes the metricset from the top-level document fields f.
+// The metric names are taken from _metric_descriptions, in the order given
+// by names, as the metrics are otherwise indistinguishable from other
+// top-level fields.
+func (me *Metricset) decodeFields(f jsonFields, names []string) {
+	me.DocCount = f.int64("_doc_count")
+	me.Name = f.string("metricset.name")
+	descriptions, _ := f.object("_metric_descriptions")
+	if len(names) > 0 {
+		me.Samples = make([]MetricsetSample, len(names))
+	}
+	for i, name := range names {
+		description, _ := descriptions.object(name)
+		sample := &me.Samples[i]
+		sample.Name = name
+		sample.Type = MetricType(description.string("type"))
+		sample.Unit = description.string("unit")
+		switch sample.Type {
+		case MetricTypeHistogram:
+			histogram, _ := f.object(name)
+			sample.Histogram.decodeFields(histogram)
+		case MetricTypeSummary:
+			summary, _ := f.object(name)
+			sample.SummaryMetric.decodeFields(summary)
+		default:
+			sample.Value = f.float64(name)
+		}
 	}
-	fields.maybeSetString("metricset.name", me.Name)
+}
 
-	var metricDescriptions mapStr
-	for _, sample := range me.Samples {
-		sample.set(sample.Name, fields)
+func (me *Metricset) encodeProto(e *protoEncoder) {
+	for i := range me.Samples {
+		e.messageKeepEmpty(1, &me.Samples[i])
+	}
+	e.string(2, me.Name)
+	e.int64(3, me.DocCount)
+}
+
+func (me *Metricset) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		var sample MetricsetSample
+		f.message(&sample)
+		me.Samples = append(me.Samples, sample)
+	case 2:
+		me.Name = f.string()
+	case 3:
+		me.DocCount = f.int64()
+	}
+}
+
+func (s *MetricsetSample) encodeProto(e *protoEncoder) {
+	e.string(1, string(s.Type))
+	e.string(2, s.Name)
+	e.string(3, s.Unit)
+	e.float64(4, s.Value)
+	e.message(5, &s.Histogram)
+	e.message(6, &s.SummaryMetric)
+}
 
-		var md mapStr
-		md.maybeSetString("type", string(sample.Type))
-		md.maybeSetString("unit", sample.Unit)
-		metricDescriptions.set(sample.Name, map[string]any(md))
+func (s *MetricsetSample) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		s.Type = MetricType(f.string())
+	case 2:
+		s.Name = f.string()
+	case 3:
+		s.Unit = f.string()
+	case 4:
+		s.Value = f.float64()
+	case 5:
+		f.message(&s.Histogram)
+	case 6:
+		f.message(&s.SummaryMetric)
 	}
-	fields.maybeSetMapStr("_metric_descriptions", map[string]any(metricDescriptions))
 }
 
-func (s *MetricsetSample) set(name string, fields *mapStr) {
+func (s *MetricsetSample) encode(o *jsonObject) {
 	switch s.Type {
 	case MetricTypeHistogram:
-		fields.set(name, s.Histogram.fields())
+		histogram := o.objectKeepEmpty(s.Name)
+		s.Histogram.encodeFields(&histogram)
+		histogram.end()
 	case MetricTypeSummary:
-		fields.set(name, s.SummaryMetric.fields())
+		summary := o.object(s.Name)
+		s.SummaryMetric.encodeFields(&summary)
+		summary.end()
 	default:
-		fields.set(name, s.Value)
+		o.float64(s.Name, s.Value)
 	}
 }
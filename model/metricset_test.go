@@ -19,6 +19,7 @@ package model
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -139,6 +140,31 @@ func TestMetricset(t *testing.T) {
 	}
 }
 
+func TestMetricsetDuplicateSampleNames(t *testing.T) {
+	event := APMEvent{Metricset: &Metricset{
+		Samples: []MetricsetSample{
+			{Name: "a", Type: "counter", Value: 1},
+			{Name: "b", Value: 2},
+			{Name: "a", Type: "gauge", Value: 3},
+		},
+	}}
+	encoded := string(marshalJSONAPMEvent(event))
+	assert.Equal(t, 1, strings.Count(encoded, `"a":3`), encoded)
+	assert.NotContains(t, encoded, `"a":1`)
+
+	// The last sample with a given name wins.
+	m := transformAPMEvent(event)
+	delete(m, "@timestamp")
+	assert.Equal(t, map[string]any{
+		"a": 3.0,
+		"b": 2.0,
+		"_metric_descriptions": map[string]any{
+			"a": map[string]any{"type": "gauge"},
+			"b": map[string]any{},
+		},
+	}, m)
+}
+
 func TestTransformMetricsetTransaction(t *testing.T) {
 	m := transformAPMEvent(APMEvent{
 		Processor: MetricsetProcessor,
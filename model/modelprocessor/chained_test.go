@@ -0,0 +1,52 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package modelprocessor_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/apm-data/model"
+	"github.com/elastic/apm-data/model/modelprocessor"
+)
+
+func TestChained(t *testing.T) {
+	var calls []string
+	processor := func(name string, err error) model.BatchProcessor {
+		return model.ProcessBatchFunc(func(ctx context.Context, b *model.Batch) error {
+			calls = append(calls, name)
+			*b = append(*b, model.APMEvent{Message: name})
+			return err
+		})
+	}
+
+	var batch model.Batch
+	err := modelprocessor.Chained{processor("a", nil), processor("b", nil)}.ProcessBatch(context.Background(), &batch)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, calls)
+	assert.Equal(t, model.Batch{{Message: "a"}, {Message: "b"}}, batch)
+
+	calls = nil
+	errBoom := errors.New("boom")
+	err = modelprocessor.Chained{processor("a", errBoom), processor("b", nil)}.ProcessBatch(context.Background(), &batch)
+	assert.Equal(t, errBoom, err)
+	assert.Equal(t, []string{"a"}, calls)
+}
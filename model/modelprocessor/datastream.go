@@ -0,0 +1,155 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package modelprocessor
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/elastic/apm-data/model"
+)
+
+const (
+	tracesType  = "traces"
+	metricsType = "metrics"
+	logsType    = "logs"
+
+	tracesDataset          = "apm"
+	rumTracesDataset       = "apm.rum"
+	internalMetricsDataset = "apm.internal"
+	appMetricsDataset      = "apm.app"
+	errorsDataset          = "apm.error"
+	appLogsDataset         = "apm.app"
+
+	// defaultNamespace is the data stream namespace used when
+	// SetDataStream.Namespace is empty.
+	defaultNamespace = "default"
+
+	// maxDatasetLength is the maximum length of data_stream.dataset,
+	// in bytes.
+	maxDatasetLength = 100
+)
+
+// SetDataStream is a model.BatchProcessor that routes events to data
+// streams according to Elastic APM's conventions:
+//
+//   - transactions and spans go to traces-apm-<namespace>, or
+//     traces-apm.rum-<namespace> for events from RUM agents
+//   - errors go to logs-apm.error-<namespace>
+//   - logs go to logs-apm.app-<namespace>
+//   - internal metrics, such as breakdown and aggregated metrics, go to
+//     metrics-apm.internal-<namespace>
+//   - application metrics go to metrics-apm.app.<service>-<namespace>,
+//     where <service> is the normalized service name
+//
+// Events which already have a data stream type and dataset are left
+// unmodified, other than having their namespace set. Events with an
+// unknown processor are left unmodified.
+type SetDataStream struct {
+	// Namespace holds the data_stream.namespace to set.
+	// If Namespace is empty, "default" will be used.
+	Namespace string
+}
+
+// ProcessBatch sets data stream fields for each event in b.
+func (s *SetDataStream) ProcessBatch(ctx context.Context, b *model.Batch) error {
+	for i := range *b {
+		s.setDataStream(&(*b)[i])
+	}
+	return nil
+}
+
+func (s *SetDataStream) setDataStream(event *model.APMEvent) {
+	if event.DataStream.Type == "" || event.DataStream.Dataset == "" {
+		switch event.Processor {
+		case model.TransactionProcessor, model.SpanProcessor:
+			event.DataStream.Type = tracesType
+			event.DataStream.Dataset = tracesDataset
+			// RUM traces are sent to a separate data stream, so they
+			// may have a different lifecycle policy.
+			if isRUMAgentName(event.Agent.Name) {
+				event.DataStream.Dataset = rumTracesDataset
+			}
+		case model.MetricsetProcessor:
+			event.DataStream.Type = metricsType
+			event.DataStream.Dataset = metricsetDataset(event)
+		case model.ErrorProcessor:
+			event.DataStream.Type = logsType
+			event.DataStream.Dataset = errorsDataset
+		case model.LogProcessor:
+			event.DataStream.Type = logsType
+			event.DataStream.Dataset = appLogsDataset
+		default:
+			return
+		}
+	}
+	event.DataStream.Namespace = s.Namespace
+	if event.DataStream.Namespace == "" {
+		event.DataStream.Namespace = defaultNamespace
+	}
+}
+
+func metricsetDataset(event *model.APMEvent) string {
+	if event.Transaction != nil || event.Span != nil || event.Service.Name == "" {
+		// Metrics that include well-defined transaction/span fields
+		// (i.e. breakdown metrics, transaction and span metrics) will
+		// be stored separately from application and runtime metrics.
+		return internalMetricsDataset
+	}
+	if event.Metricset != nil && isInternalMetricsetName(event.Metricset.Name) {
+		return internalMetricsDataset
+	}
+	serviceName := NormalizeServiceName(event.Service.Name)
+	maxServiceNameLength := maxDatasetLength - len(appMetricsDataset) - 1
+	if len(serviceName) > maxServiceNameLength {
+		serviceName = serviceName[:maxServiceNameLength]
+		for !utf8.ValidString(serviceName) {
+			serviceName = serviceName[:len(serviceName)-1]
+		}
+	}
+	return appMetricsDataset + "." + serviceName
+}
+
+// isInternalMetricsetName reports whether name is the name of a metricset
+// produced by APM agents or the APM Server for internal use, rather than
+// holding application metrics.
+func isInternalMetricsetName(name string) bool {
+	switch name {
+	case "transaction", "service_transaction", "service_destination", "service_summary", "span_breakdown":
+		return true
+	}
+	return false
+}
+
+// NormalizeServiceName translates serviceName into a string suitable
+// for inclusion in a data stream dataset: it is lowercased, and
+// characters which are not permitted in data stream names, as well
+// as "-" which separates data stream name components, are replaced
+// with "_".
+func NormalizeServiceName(serviceName string) string {
+	return strings.Map(replaceReservedRune, strings.ToLower(serviceName))
+}
+
+func replaceReservedRune(r rune) rune {
+	switch r {
+	case '\\', '/', '*', '?', '"', '<', '>', '|', ' ', ',', '#', ':', '-':
+		return '_'
+	}
+	return r
+}
@@ -15,17 +15,6 @@
 // specific language governing permissions and limitations
 // under the License.
 
-package model
-
-// customFields transforms in, returning a copy with sanitized keys,
-// suitable for storing as "custom" in transaction and error documents.
-func customFields(in map[string]any) map[string]any {
-	if len(in) == 0 {
-		return nil
-	}
-	out := make(map[string]any, len(in))
-	for k, v := range in {
-		out[sanitizeLabelKey(k)] = v
-	}
-	return out
-}
+// Package modelprocessor provides reusable model.BatchProcessor
+// implementations, which may be composed with Chained.
+package modelprocessor
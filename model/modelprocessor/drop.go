@@ -0,0 +1,45 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package modelprocessor
+
+import (
+	"context"
+
+	"github.com/elastic/apm-data/model"
+)
+
+// DropEvents is a model.BatchProcessor that removes events from the
+// batch for which the function returns true. The order of the
+// remaining events is preserved.
+type DropEvents func(*model.APMEvent) bool
+
+// ProcessBatch removes events from b for which f returns true.
+func (f DropEvents) ProcessBatch(ctx context.Context, b *model.Batch) error {
+	events := (*b)[:0]
+	for i := range *b {
+		if !f(&(*b)[i]) {
+			events = append(events, (*b)[i])
+		}
+	}
+	// Zero the remainder so dropped events may be garbage collected.
+	for i := len(events); i < len(*b); i++ {
+		(*b)[i] = model.APMEvent{}
+	}
+	*b = events
+	return nil
+}
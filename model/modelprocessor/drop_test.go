@@ -0,0 +1,50 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package modelprocessor_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/apm-data/model"
+	"github.com/elastic/apm-data/model/modelprocessor"
+)
+
+func TestDropEvents(t *testing.T) {
+	batch := model.Batch{
+		{Processor: model.TransactionProcessor, Message: "a"},
+		{Processor: model.SpanProcessor, Message: "b"},
+		{Processor: model.TransactionProcessor, Message: "c"},
+		{Processor: model.ErrorProcessor, Message: "d"},
+	}
+	processor := modelprocessor.DropEvents(func(event *model.APMEvent) bool {
+		return event.Processor == model.TransactionProcessor
+	})
+	err := processor.ProcessBatch(context.Background(), &batch)
+	assert.NoError(t, err)
+	assert.Equal(t, model.Batch{
+		{Processor: model.SpanProcessor, Message: "b"},
+		{Processor: model.ErrorProcessor, Message: "d"},
+	}, batch)
+
+	// Dropped events are cleared from the backing array.
+	assert.Equal(t, model.APMEvent{}, batch[:4][2])
+	assert.Equal(t, model.APMEvent{}, batch[:4][3])
+}
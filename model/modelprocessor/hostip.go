@@ -0,0 +1,55 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package modelprocessor
+
+import (
+	"context"
+	"net/netip"
+
+	"github.com/elastic/apm-data/model"
+)
+
+// SetHostIP is a model.BatchProcessor that sets host.ip for events
+// which do not already have one, using the event's source.ip. The
+// source address is that of the agent which sent the events, which
+// is the monitored host unless there are proxies in between.
+//
+// Events from RUM agents are left unmodified, as their source address
+// is that of the user's browser or device rather than of a host.
+type SetHostIP struct{}
+
+// ProcessBatch sets host.ip for each event in b which has no host.ip.
+func (SetHostIP) ProcessBatch(ctx context.Context, b *model.Batch) error {
+	for i := range *b {
+		event := &(*b)[i]
+		if len(event.Host.IP) > 0 || !event.Source.IP.IsValid() || isRUMAgentName(event.Agent.Name) {
+			continue
+		}
+		event.Host.IP = []netip.Addr{event.Source.IP}
+	}
+	return nil
+}
+
+func isRUMAgentName(agentName string) bool {
+	switch agentName {
+	// These are all the known agents that send "RUM" data to the APM Server.
+	case "rum-js", "js-base", "android/java", "iOS/swift":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package modelprocessor_test
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/apm-data/model"
+	"github.com/elastic/apm-data/model/modelprocessor"
+)
+
+func TestSetHostIP(t *testing.T) {
+	sourceIP := netip.MustParseAddr("10.1.1.1")
+	hostIP := netip.MustParseAddr("10.2.2.2")
+
+	tests := []struct {
+		input  model.APMEvent
+		output []netip.Addr
+	}{{
+		input:  model.APMEvent{},
+		output: nil,
+	}, {
+		input:  model.APMEvent{Source: model.Source{IP: sourceIP}},
+		output: []netip.Addr{sourceIP},
+	}, {
+		input: model.APMEvent{
+			Source: model.Source{IP: sourceIP},
+			Host:   model.Host{IP: []netip.Addr{hostIP}},
+		},
+		output: []netip.Addr{hostIP},
+	}, {
+		input: model.APMEvent{
+			Agent:  model.Agent{Name: "rum-js"},
+			Source: model.Source{IP: sourceIP},
+		},
+		output: nil,
+	}}
+
+	for _, test := range tests {
+		batch := model.Batch{test.input}
+		err := modelprocessor.SetHostIP{}.ProcessBatch(context.Background(), &batch)
+		assert.NoError(t, err)
+		assert.Equal(t, test.output, batch[0].Host.IP)
+	}
+}
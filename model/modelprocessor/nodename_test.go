@@ -0,0 +1,58 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package modelprocessor_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/apm-data/model"
+	"github.com/elastic/apm-data/model/modelprocessor"
+)
+
+func TestSetServiceNodeName(t *testing.T) {
+	withServiceNodeName := model.APMEvent{
+		Service: model.Service{Node: model.ServiceNode{Name: "node_name"}},
+	}
+	withConfiguredHostname := model.APMEvent{
+		Host: model.Host{Name: "configured_hostname"},
+	}
+	withContainerID := withConfiguredHostname
+	withContainerID.Container.ID = "container_id"
+
+	testProcessBatchMetadata(t, modelprocessor.SetServiceNodeName{}, withServiceNodeName, withServiceNodeName)
+	testProcessBatchMetadata(t, modelprocessor.SetServiceNodeName{}, withConfiguredHostname, model.APMEvent{
+		Host:    model.Host{Name: "configured_hostname"},
+		Service: model.Service{Node: model.ServiceNode{Name: "configured_hostname"}},
+	})
+	testProcessBatchMetadata(t, modelprocessor.SetServiceNodeName{}, withContainerID, model.APMEvent{
+		Host:      model.Host{Name: "configured_hostname"},
+		Container: model.Container{ID: "container_id"},
+		Service:   model.Service{Node: model.ServiceNode{Name: "container_id"}},
+	})
+}
+
+func testProcessBatchMetadata(t *testing.T, processor model.BatchProcessor, in, out model.APMEvent) {
+	t.Helper()
+	batch := model.Batch{in}
+	err := processor.ProcessBatch(context.Background(), &batch)
+	assert.NoError(t, err)
+	assert.Equal(t, model.Batch{out}, batch)
+}
@@ -0,0 +1,54 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package modelprocessor
+
+import (
+	"context"
+
+	"github.com/elastic/apm-data/model"
+)
+
+// SetObserver is a model.BatchProcessor that sets observer fields,
+// identifying the process which observed the events, e.g. the server
+// which received them.
+//
+// Only the non-empty fields of Observer are set; other observer fields
+// of events are left unmodified.
+type SetObserver struct {
+	Observer model.Observer
+}
+
+// ProcessBatch sets observer fields for each event in b.
+func (s *SetObserver) ProcessBatch(ctx context.Context, b *model.Batch) error {
+	for i := range *b {
+		observer := &(*b)[i].Observer
+		if s.Observer.Hostname != "" {
+			observer.Hostname = s.Observer.Hostname
+		}
+		if s.Observer.Name != "" {
+			observer.Name = s.Observer.Name
+		}
+		if s.Observer.Type != "" {
+			observer.Type = s.Observer.Type
+		}
+		if s.Observer.Version != "" {
+			observer.Version = s.Observer.Version
+		}
+	}
+	return nil
+}
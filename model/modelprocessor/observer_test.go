@@ -0,0 +1,42 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package modelprocessor_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/apm-data/model"
+	"github.com/elastic/apm-data/model/modelprocessor"
+)
+
+func TestSetObserver(t *testing.T) {
+	batch := model.Batch{
+		{},
+		{Observer: model.Observer{Hostname: "agent-host", Name: "existing"}},
+	}
+	processor := modelprocessor.SetObserver{
+		Observer: model.Observer{Hostname: "server-host", Type: "apm-server", Version: "8.7.0"},
+	}
+	err := processor.ProcessBatch(context.Background(), &batch)
+	assert.NoError(t, err)
+	assert.Equal(t, model.Observer{Hostname: "server-host", Type: "apm-server", Version: "8.7.0"}, batch[0].Observer)
+	assert.Equal(t, model.Observer{Hostname: "server-host", Name: "existing", Type: "apm-server", Version: "8.7.0"}, batch[1].Observer)
+}
@@ -52,25 +52,82 @@ type NetworkCarrier struct {
 	ICC string
 }
 
-func (n *Network) fields() map[string]any {
-	var network mapStr
-	network.maybeSetMapStr("connection", n.Connection.fields())
-	network.maybeSetMapStr("carrier", n.Carrier.fields())
-	return map[string]any(network)
+func (n *Network) encodeFields(o *jsonObject) {
+	connection := o.object("connection")
+	n.Connection.encodeFields(&connection)
+	connection.end()
+	carrier := o.object("carrier")
+	n.Carrier.encodeFields(&carrier)
+	carrier.end()
 }
 
-func (c *NetworkConnection) fields() map[string]any {
-	var connection mapStr
-	connection.maybeSetString("type", c.Type)
-	connection.maybeSetString("subtype", c.Subtype)
-	return map[string]any(connection)
+func (n *Network) decodeFields(f jsonFields) {
+	connection, _ := f.object("connection")
+	n.Connection.Type = connection.string("type")
+	n.Connection.Subtype = connection.string("subtype")
+	carrier, _ := f.object("carrier")
+	n.Carrier.MCC = carrier.string("mcc")
+	n.Carrier.MNC = carrier.string("mnc")
+	n.Carrier.ICC = carrier.string("icc")
+	n.Carrier.Name = carrier.string("name")
 }
 
-func (c *NetworkCarrier) fields() map[string]any {
-	var carrier mapStr
-	carrier.maybeSetString("mcc", c.MCC)
-	carrier.maybeSetString("mnc", c.MNC)
-	carrier.maybeSetString("icc", c.ICC)
-	carrier.maybeSetString("name", c.Name)
-	return map[string]any(carrier)
+func (n *Network) encodeProto(e *protoEncoder) {
+	e.message(1, &n.Connection)
+	e.message(2, &n.Carrier)
+}
+
+func (n *Network) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		f.message(&n.Connection)
+	case 2:
+		f.message(&n.Carrier)
+	}
+}
+
+func (c *NetworkConnection) encodeProto(e *protoEncoder) {
+	e.string(1, c.Type)
+	e.string(2, c.Subtype)
+}
+
+func (c *NetworkConnection) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		c.Type = f.string()
+	case 2:
+		c.Subtype = f.string()
+	}
+}
+
+func (c *NetworkCarrier) encodeProto(e *protoEncoder) {
+	e.string(1, c.Name)
+	e.string(2, c.MCC)
+	e.string(3, c.MNC)
+	e.string(4, c.ICC)
+}
+
+func (c *NetworkCarrier) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		c.Name = f.string()
+	case 2:
+		c.MCC = f.string()
+	case 3:
+		c.MNC = f.string()
+	case 4:
+		c.ICC = f.string()
+	}
+}
+
+func (c *NetworkConnection) encodeFields(o *jsonObject) {
+	o.maybeString("type", c.Type)
+	o.maybeString("subtype", c.Subtype)
+}
+
+func (c *NetworkCarrier) encodeFields(o *jsonObject) {
+	o.maybeString("mcc", c.MCC)
+	o.maybeString("mnc", c.MNC)
+	o.maybeString("icc", c.ICC)
+	o.maybeString("name", c.Name)
 }
@@ -61,7 +61,7 @@ func TestNetworkTransform(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		output := test.Network.fields()
+		output := encodeFields(&test.Network)
 		assert.Equal(t, test.Output, output)
 	}
 }
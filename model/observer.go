@@ -36,3 +36,37 @@ func (o *Observer) Fields() map[string]any {
 	fields.maybeSetString("version", o.Version)
 	return map[string]any(fields)
 }
+
+func (obs *Observer) encodeFields(o *jsonObject) {
+	o.maybeString("hostname", obs.Hostname)
+	o.maybeString("name", obs.Name)
+	o.maybeString("type", obs.Type)
+	o.maybeString("version", obs.Version)
+}
+
+func (obs *Observer) decodeFields(f jsonFields) {
+	obs.Hostname = f.string("hostname")
+	obs.Name = f.string("name")
+	obs.Type = f.string("type")
+	obs.Version = f.string("version")
+}
+
+func (obs *Observer) encodeProto(e *protoEncoder) {
+	e.string(1, obs.Hostname)
+	e.string(2, obs.Name)
+	e.string(3, obs.Type)
+	e.string(4, obs.Version)
+}
+
+func (obs *Observer) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		obs.Hostname = f.string()
+	case 2:
+		obs.Name = f.string()
+	case 3:
+		obs.Type = f.string()
+	case 4:
+		obs.Version = f.string()
+	}
+}
@@ -38,12 +38,41 @@ type OS struct {
 	Type string
 }
 
-func (o *OS) fields() map[string]any {
-	var fields mapStr
-	fields.maybeSetString("name", o.Name)
-	fields.maybeSetString("version", o.Version)
-	fields.maybeSetString("platform", o.Platform)
-	fields.maybeSetString("full", o.Full)
-	fields.maybeSetString("type", o.Type)
-	return map[string]any(fields)
+func (os *OS) encodeFields(o *jsonObject) {
+	o.maybeString("name", os.Name)
+	o.maybeString("version", os.Version)
+	o.maybeString("platform", os.Platform)
+	o.maybeString("full", os.Full)
+	o.maybeString("type", os.Type)
+}
+
+func (os *OS) decodeFields(f jsonFields) {
+	os.Name = f.string("name")
+	os.Version = f.string("version")
+	os.Platform = f.string("platform")
+	os.Full = f.string("full")
+	os.Type = f.string("type")
+}
+
+func (os *OS) encodeProto(e *protoEncoder) {
+	e.string(1, os.Name)
+	e.string(2, os.Version)
+	e.string(3, os.Platform)
+	e.string(4, os.Full)
+	e.string(5, os.Type)
+}
+
+func (os *OS) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		os.Name = f.string()
+	case 2:
+		os.Version = f.string()
+	case 3:
+		os.Platform = f.string()
+	case 4:
+		os.Full = f.string()
+	case 5:
+		os.Type = f.string()
+	}
 }
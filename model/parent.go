@@ -23,8 +23,21 @@ type Parent struct {
 	ID string
 }
 
-func (p *Parent) fields() map[string]any {
-	var fields mapStr
-	fields.maybeSetString("id", p.ID)
-	return map[string]any(fields)
+func (p *Parent) encodeFields(o *jsonObject) {
+	o.maybeString("id", p.ID)
+}
+
+func (p *Parent) decodeFields(f jsonFields) {
+	p.ID = f.string("id")
+}
+
+func (p *Parent) encodeProto(e *protoEncoder) {
+	e.string(1, p.ID)
+}
+
+func (p *Parent) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		p.ID = f.string()
+	}
 }
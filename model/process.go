@@ -27,22 +27,80 @@ type Process struct {
 	Thread      ProcessThread
 }
 
-func (p *Process) fields() map[string]any {
-	var proc mapStr
+func (p *Process) encodeFields(o *jsonObject) {
 	if p.Pid != 0 {
-		proc.set("pid", p.Pid)
+		o.int("pid", p.Pid)
 	}
 	if p.Ppid != nil {
-		proc.set("parent", map[string]any{"pid": *p.Ppid})
+		parent := o.object("parent")
+		parent.int("pid", *p.Ppid)
+		parent.end()
 	}
 	if len(p.Argv) > 0 {
-		proc.set("args", p.Argv)
+		o.strings("args", p.Argv)
+	}
+	o.maybeString("title", p.Title)
+	o.maybeString("command_line", p.CommandLine)
+	o.maybeString("executable", p.Executable)
+	thread := o.object("thread")
+	p.Thread.encodeFields(&thread)
+	thread.end()
+}
+
+func (p *Process) decodeFields(f jsonFields) {
+	p.Pid = f.int("pid")
+	parent, _ := f.object("parent")
+	p.Ppid = parent.intptr("pid")
+	p.Argv = f.strings("args")
+	p.Title = f.string("title")
+	p.CommandLine = f.string("command_line")
+	p.Executable = f.string("executable")
+	thread, _ := f.object("thread")
+	p.Thread.ID = thread.int("id")
+	p.Thread.Name = thread.string("name")
+}
+
+func (p *Process) encodeProto(e *protoEncoder) {
+	e.int(1, p.Pid)
+	e.intptr(2, p.Ppid)
+	e.string(3, p.Title)
+	e.strings(4, p.Argv)
+	e.string(5, p.CommandLine)
+	e.string(6, p.Executable)
+	e.message(7, &p.Thread)
+}
+
+func (p *Process) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		p.Pid = f.int()
+	case 2:
+		p.Ppid = f.intptr()
+	case 3:
+		p.Title = f.string()
+	case 4:
+		p.Argv = append(p.Argv, f.string())
+	case 5:
+		p.CommandLine = f.string()
+	case 6:
+		p.Executable = f.string()
+	case 7:
+		f.message(&p.Thread)
+	}
+}
+
+func (t *ProcessThread) encodeProto(e *protoEncoder) {
+	e.int(1, t.ID)
+	e.string(2, t.Name)
+}
+
+func (t *ProcessThread) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		t.ID = f.int()
+	case 2:
+		t.Name = f.string()
 	}
-	proc.maybeSetString("title", p.Title)
-	proc.maybeSetString("command_line", p.CommandLine)
-	proc.maybeSetString("executable", p.Executable)
-	proc.maybeSetMapStr("thread", p.Thread.fields())
-	return map[string]any(proc)
 }
 
 // ProcessThread represents the thread information.
@@ -51,11 +109,9 @@ type ProcessThread struct {
 	Name string
 }
 
-func (t *ProcessThread) fields() map[string]any {
-	var fields mapStr
+func (t *ProcessThread) encodeFields(o *jsonObject) {
 	if t.ID != 0 {
-		fields.set("id", t.ID)
+		o.int("id", t.ID)
 	}
-	fields.maybeSetString("name", t.Name)
-	return map[string]any(fields)
+	o.maybeString("name", t.Name)
 }
@@ -55,16 +55,16 @@ func TestProcessTransform(t *testing.T) {
 				},
 			},
 			Output: map[string]any{
-				"pid": 123,
+				"pid": 123.0,
 				"parent": map[string]any{
-					"pid": 456,
+					"pid": 456.0,
 				},
 				"title":        processTitle,
-				"args":         argv,
+				"args":         []any{"node", "server.js"},
 				"command_line": commandLine,
 				"executable":   executablePath,
 				"thread": map[string]any{
-					"id":   1,
+					"id":   1.0,
 					"name": "testThread",
 				},
 			},
@@ -72,7 +72,7 @@ func TestProcessTransform(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		output := test.Process.fields()
+		output := encodeFields(&test.Process)
 		assert.Equal(t, test.Output, output)
 	}
 }
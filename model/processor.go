@@ -26,9 +26,26 @@ type Processor struct {
 	Event string
 }
 
-func (p *Processor) fields() map[string]any {
-	var fields mapStr
-	fields.maybeSetString("name", p.Name)
-	fields.maybeSetString("event", p.Event)
-	return map[string]any(fields)
+func (p *Processor) encodeFields(o *jsonObject) {
+	o.maybeString("name", p.Name)
+	o.maybeString("event", p.Event)
+}
+
+func (p *Processor) decodeFields(f jsonFields) {
+	p.Name = f.string("name")
+	p.Event = f.string("event")
+}
+
+func (p *Processor) encodeProto(e *protoEncoder) {
+	e.string(1, p.Name)
+	e.string(2, p.Event)
+}
+
+func (p *Processor) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		p.Name = f.string()
+	case 2:
+		p.Event = f.string()
+	}
 }
@@ -0,0 +1,538 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/netip"
+
+	"go.elastic.co/fastjson"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// MarshalProto marshals e in the protobuf wire format described by
+// apmevent.proto.
+func (e *APMEvent) MarshalProto() ([]byte, error) {
+	var enc protoEncoder
+	e.encodeProto(&enc)
+	return enc.b, enc.err
+}
+
+// UnmarshalProto decodes an event from the protobuf wire format described
+// by apmevent.proto, replacing the contents of e.
+func (e *APMEvent) UnmarshalProto(data []byte) error {
+	*e = APMEvent{}
+	return unmarshalProto(data, e)
+}
+
+// MarshalProto marshals b in the protobuf wire format described by
+// apmevent.proto.
+func (b *Batch) MarshalProto() ([]byte, error) {
+	var enc protoEncoder
+	b.encodeProto(&enc)
+	return enc.b, enc.err
+}
+
+// UnmarshalProto decodes a batch from the protobuf wire format described
+// by apmevent.proto, replacing the contents of b.
+func (b *Batch) UnmarshalProto(data []byte) error {
+	*b = (*b)[:0]
+	return unmarshalProto(data, b)
+}
+
+func (b *Batch) encodeProto(e *protoEncoder) {
+	for i := range *b {
+		e.messageKeepEmpty(1, &(*b)[i])
+	}
+}
+
+func (b *Batch) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		var event APMEvent
+		f.message(&event)
+		*b = append(*b, event)
+	}
+}
+
+type protoEncodable interface {
+	encodeProto(*protoEncoder)
+}
+
+type protoDecodable interface {
+	decodeProto(*protoField)
+}
+
+// protoEncoder appends protobuf-encoded fields to a byte slice.
+//
+// Scalar fields with zero values are omitted, as in proto3. The first
+// error encountered, e.g. when encoding a dynamically typed value, is
+// recorded in err.
+type protoEncoder struct {
+	b    []byte
+	err  error
+	json fastjson.Writer
+}
+
+func (e *protoEncoder) tag(num protowire.Number, typ protowire.Type) {
+	e.b = protowire.AppendTag(e.b, num, typ)
+}
+
+func (e *protoEncoder) string(num protowire.Number, v string) {
+	if v != "" {
+		e.tag(num, protowire.BytesType)
+		e.b = protowire.AppendString(e.b, v)
+	}
+}
+
+// strings writes each element of v as a repeated string field.
+func (e *protoEncoder) strings(num protowire.Number, v []string) {
+	for _, v := range v {
+		e.tag(num, protowire.BytesType)
+		e.b = protowire.AppendString(e.b, v)
+	}
+}
+
+func (e *protoEncoder) bool(num protowire.Number, v bool) {
+	if v {
+		e.tag(num, protowire.VarintType)
+		e.b = protowire.AppendVarint(e.b, 1)
+	}
+}
+
+// boolptr writes *v if v is non-nil, even if *v is false.
+func (e *protoEncoder) boolptr(num protowire.Number, v *bool) {
+	if v != nil {
+		e.tag(num, protowire.VarintType)
+		e.b = protowire.AppendVarint(e.b, protowire.EncodeBool(*v))
+	}
+}
+
+func (e *protoEncoder) int(num protowire.Number, v int) {
+	e.int64(num, int64(v))
+}
+
+func (e *protoEncoder) int64(num protowire.Number, v int64) {
+	if v != 0 {
+		e.tag(num, protowire.VarintType)
+		e.b = protowire.AppendVarint(e.b, uint64(v))
+	}
+}
+
+// intptr writes *v if v is non-nil, even if *v is zero.
+func (e *protoEncoder) intptr(num protowire.Number, v *int) {
+	if v != nil {
+		e.tag(num, protowire.VarintType)
+		e.b = protowire.AppendVarint(e.b, uint64(*v))
+	}
+}
+
+func (e *protoEncoder) sint64(num protowire.Number, v int64) {
+	if v != 0 {
+		e.tag(num, protowire.VarintType)
+		e.b = protowire.AppendVarint(e.b, protowire.EncodeZigZag(v))
+	}
+}
+
+func (e *protoEncoder) float64(num protowire.Number, v float64) {
+	// Compare the bits rather than the value, so -0 is preserved.
+	if bits := math.Float64bits(v); bits != 0 {
+		e.tag(num, protowire.Fixed64Type)
+		e.b = protowire.AppendFixed64(e.b, bits)
+	}
+}
+
+// float64s writes v as a packed repeated double field.
+func (e *protoEncoder) float64s(num protowire.Number, v []float64) {
+	if len(v) == 0 {
+		return
+	}
+	e.tag(num, protowire.BytesType)
+	e.b = protowire.AppendVarint(e.b, uint64(len(v)*8))
+	for _, v := range v {
+		e.b = protowire.AppendFixed64(e.b, math.Float64bits(v))
+	}
+}
+
+// int64s writes v as a packed repeated int64 field.
+func (e *protoEncoder) int64s(num protowire.Number, v []int64) {
+	if len(v) == 0 {
+		return
+	}
+	var size int
+	for _, v := range v {
+		size += protowire.SizeVarint(uint64(v))
+	}
+	e.tag(num, protowire.BytesType)
+	e.b = protowire.AppendVarint(e.b, uint64(size))
+	for _, v := range v {
+		e.b = protowire.AppendVarint(e.b, uint64(v))
+	}
+}
+
+// ip writes v in its binary form, if v is valid.
+func (e *protoEncoder) ip(num protowire.Number, v netip.Addr) {
+	if v.IsValid() {
+		e.ipKeepEmpty(num, v)
+	}
+}
+
+// ips writes each element of v as a repeated bytes field. Invalid
+// addresses are written as empty bytes.
+func (e *protoEncoder) ips(num protowire.Number, v []netip.Addr) {
+	for _, v := range v {
+		e.ipKeepEmpty(num, v)
+	}
+}
+
+func (e *protoEncoder) ipKeepEmpty(num protowire.Number, v netip.Addr) {
+	data, _ := v.MarshalBinary() // never fails
+	e.tag(num, protowire.BytesType)
+	e.b = protowire.AppendBytes(e.b, data)
+}
+
+// any writes v as a JSON-encoded bytes field, if v is non-nil.
+func (e *protoEncoder) any(num protowire.Number, v any) {
+	if v == nil {
+		return
+	}
+	e.json.Reset()
+	if err := encodeAny(v, &e.json); err != nil && e.err == nil {
+		e.err = err
+	}
+	e.tag(num, protowire.BytesType)
+	e.b = protowire.AppendBytes(e.b, e.json.Bytes())
+}
+
+// anyMap writes v as a JSON-encoded bytes field, if v is non-empty.
+func (e *protoEncoder) anyMap(num protowire.Number, v map[string]any) {
+	if len(v) > 0 {
+		e.any(num, v)
+	}
+}
+
+// message writes v as an embedded message field, omitting it if all of
+// its fields are omitted.
+func (e *protoEncoder) message(num protowire.Number, v protoEncodable) {
+	start, body := e.beginMessage(num)
+	v.encodeProto(e)
+	if len(e.b) == body {
+		e.b = e.b[:start]
+		return
+	}
+	e.endMessage(body)
+}
+
+// messageKeepEmpty writes v as an embedded message field, even if all
+// of its fields are omitted. This is used for recording the presence
+// of pointer and repeated fields.
+func (e *protoEncoder) messageKeepEmpty(num protowire.Number, v protoEncodable) {
+	_, body := e.beginMessage(num)
+	v.encodeProto(e)
+	e.endMessage(body)
+}
+
+// beginMessage writes the tag for an embedded message field, followed
+// by a one byte placeholder for the message length. The offsets of the
+// tag and the message body are returned.
+func (e *protoEncoder) beginMessage(num protowire.Number) (start, body int) {
+	start = len(e.b)
+	e.tag(num, protowire.BytesType)
+	e.b = append(e.b, 0)
+	return start, len(e.b)
+}
+
+// endMessage writes the length of the embedded message starting at
+// offset body, moving the body along if the length does not fit in
+// the placeholder.
+func (e *protoEncoder) endMessage(body int) {
+	n := len(e.b) - body
+	if size := protowire.SizeVarint(uint64(n)); size > 1 {
+		e.b = append(e.b, make([]byte, size-1)...)
+		copy(e.b[body+size-1:], e.b[body:body+n])
+	}
+	protowire.AppendVarint(e.b[:body-1], uint64(n))
+}
+
+// mapEntry writes a map entry message with the given key and value.
+// The value is omitted if v is nil.
+func (e *protoEncoder) mapEntry(num protowire.Number, k string, v protoEncodable) {
+	_, body := e.beginMessage(num)
+	e.string(1, k)
+	if v != nil {
+		e.messageKeepEmpty(2, v)
+	}
+	e.endMessage(body)
+}
+
+// protoField holds a field decoded from a protobuf message.
+//
+// Fields with unexpected wire types are ignored, and the first such
+// error is recorded in *err.
+type protoField struct {
+	num protowire.Number
+	typ protowire.Type
+	v   uint64
+	b   []byte
+	err *error
+}
+
+func unmarshalProto(data []byte, v protoDecodable) error {
+	var err error
+	decodeProto(data, v, &err)
+	return err
+}
+
+// decodeProto decodes each field of the protobuf message in data,
+// passing them to v. Unknown fields are skipped by v.
+func decodeProto(data []byte, v protoDecodable, err *error) {
+	f := protoField{err: err}
+	for len(data) > 0 && *err == nil {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			f.setError(protowire.ParseError(n))
+			return
+		}
+		data = data[n:]
+		f.num, f.typ, f.v, f.b = num, typ, 0, nil
+		switch typ {
+		case protowire.VarintType:
+			f.v, n = protowire.ConsumeVarint(data)
+		case protowire.Fixed64Type:
+			f.v, n = protowire.ConsumeFixed64(data)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(data)
+			f.v = uint64(v)
+		case protowire.BytesType:
+			f.b, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			f.setError(protowire.ParseError(n))
+			return
+		}
+		data = data[n:]
+		v.decodeProto(&f)
+	}
+}
+
+func (f *protoField) setError(err error) {
+	if *f.err == nil {
+		*f.err = err
+	}
+}
+
+func (f *protoField) expect(typ protowire.Type) bool {
+	if f.typ != typ {
+		f.setError(fmt.Errorf("field %d: unexpected wire type %d", f.num, f.typ))
+		return false
+	}
+	return true
+}
+
+func (f *protoField) string() string {
+	if !f.expect(protowire.BytesType) {
+		return ""
+	}
+	return string(f.b)
+}
+
+func (f *protoField) bool() bool {
+	if !f.expect(protowire.VarintType) {
+		return false
+	}
+	return protowire.DecodeBool(f.v)
+}
+
+func (f *protoField) boolptr() *bool {
+	if !f.expect(protowire.VarintType) {
+		return nil
+	}
+	v := protowire.DecodeBool(f.v)
+	return &v
+}
+
+func (f *protoField) int() int {
+	return int(f.int64())
+}
+
+func (f *protoField) int64() int64 {
+	if !f.expect(protowire.VarintType) {
+		return 0
+	}
+	return int64(f.v)
+}
+
+func (f *protoField) intptr() *int {
+	if !f.expect(protowire.VarintType) {
+		return nil
+	}
+	v := int(int64(f.v))
+	return &v
+}
+
+func (f *protoField) sint64() int64 {
+	if !f.expect(protowire.VarintType) {
+		return 0
+	}
+	return protowire.DecodeZigZag(f.v)
+}
+
+func (f *protoField) float64() float64 {
+	if !f.expect(protowire.Fixed64Type) {
+		return 0
+	}
+	return math.Float64frombits(f.v)
+}
+
+// float64s appends the packed or unpacked repeated double field to out.
+func (f *protoField) float64s(out []float64) []float64 {
+	if f.typ == protowire.Fixed64Type {
+		return append(out, math.Float64frombits(f.v))
+	}
+	if !f.expect(protowire.BytesType) {
+		return out
+	}
+	for b := f.b; len(b) > 0; {
+		v, n := protowire.ConsumeFixed64(b)
+		if n < 0 {
+			f.setError(protowire.ParseError(n))
+			return out
+		}
+		out = append(out, math.Float64frombits(v))
+		b = b[n:]
+	}
+	return out
+}
+
+// int64s appends the packed or unpacked repeated int64 field to out.
+func (f *protoField) int64s(out []int64) []int64 {
+	if f.typ == protowire.VarintType {
+		return append(out, int64(f.v))
+	}
+	if !f.expect(protowire.BytesType) {
+		return out
+	}
+	for b := f.b; len(b) > 0; {
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			f.setError(protowire.ParseError(n))
+			return out
+		}
+		out = append(out, int64(v))
+		b = b[n:]
+	}
+	return out
+}
+
+func (f *protoField) ip() netip.Addr {
+	var ip netip.Addr
+	if f.expect(protowire.BytesType) {
+		if err := ip.UnmarshalBinary(f.b); err != nil {
+			f.setError(fmt.Errorf("field %d: %w", f.num, err))
+		}
+	}
+	return ip
+}
+
+// any decodes a JSON-encoded bytes field. Numbers are decoded as
+// json.Number, as in APMEvent.UnmarshalJSON.
+func (f *protoField) any() any {
+	if !f.expect(protowire.BytesType) {
+		return nil
+	}
+	d := json.NewDecoder(bytes.NewReader(f.b))
+	d.UseNumber()
+	var v any
+	if err := d.Decode(&v); err != nil {
+		f.setError(fmt.Errorf("field %d: %w", f.num, err))
+	}
+	return v
+}
+
+func (f *protoField) anyMap() map[string]any {
+	v := f.any()
+	m, ok := v.(map[string]any)
+	if !ok && v != nil {
+		f.setError(fmt.Errorf("field %d: expected JSON object, got %T", f.num, v))
+	}
+	return m
+}
+
+// message decodes an embedded message field into v.
+func (f *protoField) message(v protoDecodable) {
+	if f.expect(protowire.BytesType) {
+		decodeProto(f.b, v, f.err)
+	}
+}
+
+// mapEntry decodes a map entry message, setting *k to its key and
+// decoding its value into v.
+func (f *protoField) mapEntry(k *string, v protoDecodable) {
+	f.message(protoMapEntry{key: k, value: v})
+}
+
+type protoMapEntry struct {
+	key   *string
+	value protoDecodable
+}
+
+func (e protoMapEntry) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		*e.key = f.string()
+	case 2:
+		f.message(e.value)
+	}
+}
+
+// stringValues is a list of strings, encoded as a message with a single
+// repeated string field so that its presence may be recorded.
+type stringValues []string
+
+func (v *stringValues) encodeProto(e *protoEncoder) {
+	e.strings(1, *v)
+}
+
+func (v *stringValues) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		*v = append(*v, f.string())
+	}
+}
+
+// float64Values is a list of float64s, encoded as a message with a
+// single packed repeated double field so that its presence may be
+// recorded.
+type float64Values []float64
+
+func (v *float64Values) encodeProto(e *protoEncoder) {
+	e.float64s(1, *v)
+}
+
+func (v *float64Values) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		*v = f.float64s(*v)
+	}
+}
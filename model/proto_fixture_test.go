@@ -0,0 +1,307 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package model
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "write missing fixture files")
+
+// protoFixtures holds hand-built batches whose protobuf and JSON
+// encodings are stored in testdata/<name>.pb and testdata/<name>.ndjson.
+//
+// The stored files are written once, by running the tests with -update
+// when a fixture is added, and must never be regenerated: they check
+// that data encoded by earlier versions of this package can still be
+// decoded, and is still encoded identically. The fixtures must likewise
+// not be modified; to cover new fields, add a new fixture.
+var protoFixtures = []struct {
+	name  string
+	batch func() Batch
+}{
+	{name: "fixture_v1", batch: protoFixtureV1},
+}
+
+func TestProtoFixtures(t *testing.T) {
+	for _, fixture := range protoFixtures {
+		t.Run(fixture.name, func(t *testing.T) {
+			batch := fixture.batch()
+			pbPath := filepath.Join("testdata", fixture.name+".pb")
+			jsonPath := filepath.Join("testdata", fixture.name+".ndjson")
+			if *updateGolden {
+				writeFixtureFile(t, pbPath, func() ([]byte, error) { return batch.MarshalProto() })
+				writeFixtureFile(t, jsonPath, func() ([]byte, error) { return marshalJSONBatch(batch), nil })
+			}
+
+			// The stored encoding decodes to the fixture,
+			// and the fixture still encodes identically.
+			data, err := os.ReadFile(pbPath)
+			require.NoError(t, err)
+			var decoded Batch
+			require.NoError(t, decoded.UnmarshalProto(data))
+			assert.Equal(t, batch, decoded)
+			encoded, err := batch.MarshalProto()
+			require.NoError(t, err)
+			assert.Equal(t, data, encoded)
+
+			// The decoded events are encoded as JSON identically
+			// to the stored JSON documents.
+			expectedJSON, err := os.ReadFile(jsonPath)
+			require.NoError(t, err)
+			assert.Equal(t, string(expectedJSON), string(marshalJSONBatch(decoded)))
+		})
+	}
+}
+
+// writeFixtureFile writes the data returned by encode to path,
+// unless the file already exists.
+func writeFixtureFile(t testing.TB, path string, encode func() ([]byte, error)) {
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		require.NoError(t, err)
+		return
+	}
+	data, err := encode()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0644))
+}
+
+// marshalJSONBatch encodes the events in batch as newline-delimited JSON.
+func marshalJSONBatch(batch Batch) []byte {
+	var buf bytes.Buffer
+	for _, event := range batch {
+		buf.Write(marshalJSONAPMEvent(event))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// protoFixtureV1 returns a batch covering the types introduced with the
+// protobuf encoding. It must not be modified.
+func protoFixtureV1() Batch {
+	handled := false
+	sync := true
+	rowsAffected := 5
+	ageMillis := 1500
+	timestamp := time.Date(2019, 1, 3, 15, 17, 4, 908596000, time.UTC)
+	base := APMEvent{
+		Timestamp:  timestamp,
+		DataStream: DataStream{Type: "traces", Dataset: "apm", Namespace: "default"},
+		Agent:      Agent{Name: "go", Version: "2.0.0", EphemeralID: "agent_id"},
+		Observer:   Observer{Hostname: "observer", Type: "apm-server", Version: "8.6.0"},
+		Service: Service{
+			Name:        "myservice",
+			Version:     "1.0",
+			Environment: "production",
+			Language:    Language{Name: "go", Version: "1.19"},
+			Runtime:     Runtime{Name: "gc", Version: "1.19"},
+			Framework:   Framework{Name: "gin", Version: "1.8"},
+			Node:        ServiceNode{Name: "node-1"},
+			Origin:      &ServiceOrigin{ID: "origin_id", Name: "origin", Version: "1"},
+			Target:      &ServiceTarget{Name: "target", Type: "db"},
+		},
+		Host: Host{
+			Hostname:     "hostname",
+			Name:         "host",
+			Architecture: "amd64",
+			IP:           []netip.Addr{netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("::1")},
+			OS:           OS{Platform: "linux", Full: "Ubuntu 22.04"},
+		},
+		Process:   Process{Pid: 1234, Ppid: newInt(1), Title: "myservice", Argv: []string{"myservice", "-v"}},
+		Container: Container{ID: "container_id", Name: "container"},
+		Kubernetes: Kubernetes{
+			Namespace: "namespace", NodeName: "node", PodName: "pod", PodUID: "pod_uid",
+		},
+		Cloud: Cloud{
+			Provider: "gcp", Region: "europe-west1", AccountID: "account_id",
+		},
+		Labels: Labels{"a": {Value: "b"}, "c": {Values: []string{"d", "e"}}},
+		NumericLabels: NumericLabels{
+			"f": {Value: 1.5},
+			"g": {Values: []float64{1, 2}},
+		},
+		Client:    Client{IP: netip.MustParseAddr("192.0.2.1"), Port: 8080, Domain: "client.example"},
+		Source:    Source{IP: netip.MustParseAddr("192.0.2.2"), Port: 4321},
+		User:      User{ID: "user_id", Name: "user", Email: "user@example.com"},
+		UserAgent: UserAgent{Original: "Mozilla/5.0", Name: "Firefox"},
+		Trace:     Trace{ID: "trace_id"},
+	}
+
+	transaction := base
+	transaction.Processor = TransactionProcessor
+	transaction.Event = Event{Outcome: "success", Duration: time.Millisecond, Severity: 1}
+	transaction.URL = URL{Original: "/foo?bar", Path: "/foo", Query: "bar", Scheme: "https", Domain: "example.com", Port: 443}
+	transaction.HTTP = HTTP{
+		Version:  "1.1",
+		Request:  &HTTPRequest{Method: "GET", Headers: map[string]any{"Accept": []any{"*/*"}}},
+		Response: &HTTPResponse{StatusCode: 200},
+	}
+	transaction.Transaction = &Transaction{
+		ID:      "transaction_id",
+		Name:    "GET /foo",
+		Type:    "request",
+		Result:  "HTTP 2xx",
+		Sampled: true,
+		Root:    true,
+		Marks: TransactionMarks{
+			"navigationTiming": {"domComplete": 1.5},
+		},
+		Message: &Message{
+			QueueName:  "queue",
+			RoutingKey: "key",
+			AgeMillis:  &ageMillis,
+			Headers:    map[string][]string{"A": {"b", "c"}},
+		},
+		SpanCount: SpanCount{Started: newInt(3), Dropped: newInt(1)},
+		UserExperience: &UserExperience{
+			CumulativeLayoutShift: 0.5,
+			FirstInputDelay:       1.5,
+			TotalBlockingTime:     2.5,
+			Longtask:              LongtaskMetrics{Count: 2, Sum: 3.5, Max: 2},
+		},
+		DroppedSpansStats: []DroppedSpanStats{{
+			DestinationServiceResource: "mysql",
+			Outcome:                    "success",
+			Duration:                   AggregatedDuration{Count: 2, Sum: time.Second},
+		}},
+		RepresentativeCount: 2,
+	}
+
+	span := base
+	span.Processor = SpanProcessor
+	span.Parent = Parent{ID: "transaction_id"}
+	span.Transaction = &Transaction{ID: "transaction_id"}
+	span.Event = Event{Outcome: "failure", Duration: 2 * time.Millisecond}
+	span.Destination = Destination{Address: "db.example", Port: 3306}
+	span.Span = &Span{
+		ID:      "span_id",
+		Name:    "SELECT",
+		Type:    "db",
+		Subtype: "mysql",
+		Action:  "query",
+		Kind:    "CLIENT",
+		Sync:    &sync,
+		DB: &DB{
+			Instance:     "db",
+			Statement:    "SELECT 1",
+			Type:         "sql",
+			UserName:     "root",
+			RowsAffected: &rowsAffected,
+		},
+		DestinationService: &DestinationService{
+			Type:         "db",
+			Name:         "mysql",
+			Resource:     "mysql",
+			ResponseTime: AggregatedDuration{Count: 1, Sum: 2 * time.Millisecond},
+		},
+		Composite: &Composite{CompressionStrategy: "exact_match", Count: 2, Sum: 3},
+		Links: []SpanLink{
+			{Trace: Trace{ID: "linked_trace_id"}, Span: Span{ID: "linked_span_id"}},
+		},
+		Stacktrace: Stacktrace{{
+			AbsPath:      "/src/main.go",
+			Filename:     "main.go",
+			Function:     "main",
+			Module:       "main",
+			Lineno:       newInt(10),
+			Colno:        newInt(2),
+			ContextLine:  "db.Query()",
+			PreContext:   []string{"func main() {"},
+			PostContext:  []string{"}"},
+			LibraryFrame: true,
+			Vars:         map[string]any{"a": "b"},
+		}},
+		RepresentativeCount: 1,
+	}
+
+	errorEvent := base
+	errorEvent.Processor = ErrorProcessor
+	errorEvent.Parent = Parent{ID: "span_id"}
+	errorEvent.Error = &Error{
+		ID:          "error_id",
+		GroupingKey: "grouping_key",
+		Culprit:     "main.go",
+		Custom:      map[string]any{"a": "b"},
+		Exception: &Exception{
+			Message: "message0",
+			Module:  "module",
+			Code:    "500",
+			Type:    "type",
+			Handled: &handled,
+			Stacktrace: Stacktrace{{
+				Filename: "file0",
+				Lineno:   newInt(123),
+			}},
+			Cause: []Exception{{
+				Message: "message1",
+				Cause:   []Exception{{Message: "message2"}},
+			}, {
+				Message: "message3",
+			}},
+		},
+		Log: &ErrorLog{
+			Message:      "log message",
+			Level:        "error",
+			ParamMessage: "log %s",
+			LoggerName:   "logger",
+		},
+	}
+
+	metricset := base
+	metricset.Processor = MetricsetProcessor
+	metricset.Metricset = &Metricset{
+		Name:     "app",
+		DocCount: 3,
+		Samples: []MetricsetSample{
+			{Name: "gauge", Type: MetricTypeGauge, Value: 1.5},
+			{Name: "counter", Type: MetricTypeCounter, Unit: "byte", Value: 2},
+			{
+				Name:      "histogram",
+				Type:      MetricTypeHistogram,
+				Histogram: Histogram{Values: []float64{1, 2}, Counts: []int64{3, 4}},
+			},
+			{
+				Name:          "summary",
+				Type:          MetricTypeSummary,
+				SummaryMetric: SummaryMetric{Count: 5, Sum: 6.5},
+			},
+		},
+	}
+
+	log := base
+	log.Processor = LogProcessor
+	log.Message = "log message"
+	log.Event = Event{Action: "action", Dataset: "app.log"}
+	log.Log = Log{
+		Level:  "info",
+		Logger: "logger",
+		Origin: LogOrigin{FunctionName: "main", File: LogOriginFile{Name: "main.go", Line: 10}},
+	}
+
+	return Batch{transaction, span, errorEvent, metricset, log}
+}
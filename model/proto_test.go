@@ -0,0 +1,134 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package model
+
+import (
+	"math/rand"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestAPMEventProtoRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 1000; i++ {
+		in := randomAPMEvent(r)
+		data, err := in.MarshalProto()
+		require.NoError(t, err)
+
+		var decoded APMEvent
+		require.NoError(t, decoded.UnmarshalProto(data))
+		require.Equal(t, string(marshalJSONAPMEvent(in)), string(marshalJSONAPMEvent(decoded)))
+
+		// Encoding is deterministic.
+		data2, err := decoded.MarshalProto()
+		require.NoError(t, err)
+		require.Equal(t, data, data2)
+	}
+}
+
+func TestAPMEventProtoPresence(t *testing.T) {
+	handled := false
+	in := APMEvent{
+		Timestamp:     time.Unix(0, 0).UTC(),
+		Labels:        Labels{"a": {Values: []string{}}, "b": {Value: ""}},
+		NumericLabels: NumericLabels{"c": {Values: []float64{}}, "d": {Value: 0, Global: true}},
+		Host:          Host{IP: []netip.Addr{{}, netip.MustParseAddr("::1")}},
+		Transaction:   &Transaction{},
+		Error: &Error{Exception: &Exception{
+			Handled: &handled,
+			Cause:   []Exception{{}, {Cause: []Exception{{}}}},
+		}},
+		HTTP: HTTP{Response: &HTTPResponse{TransferSize: newInt(0)}},
+	}
+	data, err := in.MarshalProto()
+	require.NoError(t, err)
+
+	var out APMEvent
+	require.NoError(t, out.UnmarshalProto(data))
+	assert.Equal(t, in, out)
+}
+
+func TestAPMEventUnmarshalProtoUnknownFields(t *testing.T) {
+	in := APMEvent{Agent: Agent{Name: "go"}, Message: "hello"}
+	data, err := in.MarshalProto()
+	require.NoError(t, err)
+
+	// Fields added in future versions of the schema are ignored.
+	data = protowire.AppendTag(data, 1000, protowire.VarintType)
+	data = protowire.AppendVarint(data, 123)
+	data = protowire.AppendTag(data, 1001, protowire.BytesType)
+	data = protowire.AppendString(data, "foo")
+
+	var out APMEvent
+	require.NoError(t, out.UnmarshalProto(data))
+	assert.Equal(t, in, out)
+}
+
+func TestAPMEventUnmarshalProtoInvalid(t *testing.T) {
+	var out APMEvent
+	assert.Error(t, out.UnmarshalProto([]byte{0xff}))
+
+	// message (field 30) must be a string.
+	data := protowire.AppendTag(nil, 30, protowire.VarintType)
+	data = protowire.AppendVarint(data, 1)
+	assert.EqualError(t, out.UnmarshalProto(data), "field 30: unexpected wire type 0")
+
+	// error.custom (field 34.4) must be a JSON object.
+	var custom []byte
+	custom = protowire.AppendTag(custom, 4, protowire.BytesType)
+	custom = protowire.AppendString(custom, "[]")
+	data = protowire.AppendTag(nil, 34, protowire.BytesType)
+	data = protowire.AppendBytes(data, custom)
+	assert.EqualError(t, out.UnmarshalProto(data), "field 4: expected JSON object, got []interface {}")
+}
+
+func BenchmarkMarshalProto(b *testing.B) {
+	event := benchmarkAPMEvent()
+	event.Processor = TransactionProcessor
+	event.Transaction = &Transaction{ID: "transaction_id", Name: "GET /", Type: "request", Sampled: true}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := event.MarshalProto(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalProto(b *testing.B) {
+	event := benchmarkAPMEvent()
+	event.Processor = TransactionProcessor
+	event.Transaction = &Transaction{ID: "transaction_id", Name: "GET /", Type: "request", Sampled: true}
+	data, err := event.MarshalProto()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var out APMEvent
+		if err := out.UnmarshalProto(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
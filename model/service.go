@@ -128,3 +128,197 @@ func (n *ServiceNode) fields() map[string]any {
 	}
 	return nil
 }
+
+func (s *Service) encodeFields(o *jsonObject) {
+	o.maybeString("name", s.Name)
+	o.maybeString("version", s.Version)
+	o.maybeString("environment", s.Environment)
+	node := o.object("node")
+	node.maybeString("name", s.Node.Name)
+	node.end()
+
+	language := o.object("language")
+	language.maybeString("name", s.Language.Name)
+	language.maybeString("version", s.Language.Version)
+	language.end()
+
+	runtime := o.object("runtime")
+	runtime.maybeString("name", s.Runtime.Name)
+	runtime.maybeString("version", s.Runtime.Version)
+	runtime.end()
+
+	framework := o.object("framework")
+	framework.maybeString("name", s.Framework.Name)
+	framework.maybeString("version", s.Framework.Version)
+	framework.end()
+
+	if s.Origin != nil {
+		origin := o.object("origin")
+		origin.maybeString("name", s.Origin.Name)
+		origin.maybeString("version", s.Origin.Version)
+		origin.maybeString("id", s.Origin.ID)
+		origin.end()
+	}
+
+	if s.Target != nil {
+		target := o.object("target")
+		target.maybeString("name", s.Target.Name)
+		target.string("type", s.Target.Type)
+		target.end()
+	}
+}
+
+func (s *Service) decodeFields(f jsonFields) {
+	s.Name = f.string("name")
+	s.Version = f.string("version")
+	s.Environment = f.string("environment")
+	node, _ := f.object("node")
+	s.Node.Name = node.string("name")
+	language, _ := f.object("language")
+	s.Language.Name = language.string("name")
+	s.Language.Version = language.string("version")
+	runtime, _ := f.object("runtime")
+	s.Runtime.Name = runtime.string("name")
+	s.Runtime.Version = runtime.string("version")
+	framework, _ := f.object("framework")
+	s.Framework.Name = framework.string("name")
+	s.Framework.Version = framework.string("version")
+	if origin, ok := f.object("origin"); ok {
+		s.Origin = &ServiceOrigin{
+			Name:    origin.string("name"),
+			Version: origin.string("version"),
+			ID:      origin.string("id"),
+		}
+	}
+	if target, ok := f.object("target"); ok {
+		s.Target = &ServiceTarget{
+			Name: target.string("name"),
+			Type: target.string("type"),
+		}
+	}
+}
+
+func (s *Service) encodeProto(e *protoEncoder) {
+	e.string(1, s.Name)
+	e.string(2, s.Version)
+	e.string(3, s.Environment)
+	e.message(4, &s.Language)
+	e.message(5, &s.Runtime)
+	e.message(6, &s.Framework)
+	e.message(7, &s.Node)
+	if s.Origin != nil {
+		e.messageKeepEmpty(8, s.Origin)
+	}
+	if s.Target != nil {
+		e.messageKeepEmpty(9, s.Target)
+	}
+}
+
+func (s *Service) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		s.Name = f.string()
+	case 2:
+		s.Version = f.string()
+	case 3:
+		s.Environment = f.string()
+	case 4:
+		f.message(&s.Language)
+	case 5:
+		f.message(&s.Runtime)
+	case 6:
+		f.message(&s.Framework)
+	case 7:
+		f.message(&s.Node)
+	case 8:
+		s.Origin = &ServiceOrigin{}
+		f.message(s.Origin)
+	case 9:
+		s.Target = &ServiceTarget{}
+		f.message(s.Target)
+	}
+}
+
+func (o *ServiceOrigin) encodeProto(e *protoEncoder) {
+	e.string(1, o.ID)
+	e.string(2, o.Name)
+	e.string(3, o.Version)
+}
+
+func (o *ServiceOrigin) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		o.ID = f.string()
+	case 2:
+		o.Name = f.string()
+	case 3:
+		o.Version = f.string()
+	}
+}
+
+func (t *ServiceTarget) encodeProto(e *protoEncoder) {
+	e.string(1, t.Name)
+	e.string(2, t.Type)
+}
+
+func (t *ServiceTarget) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		t.Name = f.string()
+	case 2:
+		t.Type = f.string()
+	}
+}
+
+func (l *Language) encodeProto(e *protoEncoder) {
+	e.string(1, l.Name)
+	e.string(2, l.Version)
+}
+
+func (l *Language) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		l.Name = f.string()
+	case 2:
+		l.Version = f.string()
+	}
+}
+
+func (r *Runtime) encodeProto(e *protoEncoder) {
+	e.string(1, r.Name)
+	e.string(2, r.Version)
+}
+
+func (r *Runtime) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		r.Name = f.string()
+	case 2:
+		r.Version = f.string()
+	}
+}
+
+func (fw *Framework) encodeProto(e *protoEncoder) {
+	e.string(1, fw.Name)
+	e.string(2, fw.Version)
+}
+
+func (fw *Framework) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		fw.Name = f.string()
+	case 2:
+		fw.Version = f.string()
+	}
+}
+
+func (n *ServiceNode) encodeProto(e *protoEncoder) {
+	e.string(1, n.Name)
+}
+
+func (n *ServiceNode) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		n.Name = f.string()
+	}
+}
@@ -29,13 +29,31 @@ type Session struct {
 	Sequence int
 }
 
-func (s *Session) fields() map[string]any {
+func (s *Session) encodeFields(o *jsonObject) {
 	if s.ID == "" {
-		return nil
+		return
 	}
-	out := map[string]any{"id": s.ID}
+	o.string("id", s.ID)
 	if s.Sequence > 0 {
-		out["sequence"] = s.Sequence
+		o.int("sequence", s.Sequence)
+	}
+}
+
+func (s *Session) decodeFields(f jsonFields) {
+	s.ID = f.string("id")
+	s.Sequence = f.int("sequence")
+}
+
+func (s *Session) encodeProto(e *protoEncoder) {
+	e.string(1, s.ID)
+	e.int(2, s.Sequence)
+}
+
+func (s *Session) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		s.ID = f.string()
+	case 2:
+		s.Sequence = f.int()
 	}
-	return out
 }
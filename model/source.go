@@ -34,23 +34,75 @@ type Source struct {
 
 	// NAT holds the translated source based NAT sessions.
 	NAT *NAT
+
+	// Geo holds geographical information about the source IP address.
+	Geo Geo
 }
 
-func (s *Source) fields() map[string]any {
-	var fields mapStr
-	fields.maybeSetString("domain", s.Domain)
+func (s *Source) encodeFields(o *jsonObject) {
+	o.maybeString("domain", s.Domain)
 	if s.IP.IsValid() {
-		fields.set("ip", s.IP.String())
+		o.string("ip", s.IP.String())
 	}
 	if s.Port > 0 {
-		fields.set("port", s.Port)
+		o.int("port", s.Port)
+	}
+	if s.NAT != nil {
+		nat := o.object("nat")
+		s.NAT.encodeFields(&nat)
+		nat.end()
+	}
+	geo := o.object("geo")
+	s.Geo.encodeFields(&geo)
+	geo.end()
+}
+
+func (s *Source) decodeFields(f jsonFields) {
+	s.Domain = f.string("domain")
+	s.IP = f.ip("ip")
+	s.Port = f.int("port")
+	if nat, ok := f.object("nat"); ok {
+		s.NAT = &NAT{IP: nat.ip("ip")}
 	}
+	geo, _ := f.object("geo")
+	s.Geo.decodeFields(geo)
+}
+
+func (s *Source) encodeProto(e *protoEncoder) {
+	e.string(1, s.Domain)
+	e.ip(2, s.IP)
+	e.int(3, s.Port)
 	if s.NAT != nil {
-		if nat := s.NAT.fields(); len(nat) > 0 {
-			fields.set("nat", nat)
-		}
+		e.messageKeepEmpty(4, s.NAT)
+	}
+	e.message(5, &s.Geo)
+}
+
+func (s *Source) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		s.Domain = f.string()
+	case 2:
+		s.IP = f.ip()
+	case 3:
+		s.Port = f.int()
+	case 4:
+		s.NAT = &NAT{}
+		f.message(s.NAT)
+	case 5:
+		f.message(&s.Geo)
+	}
+}
+
+func (n *NAT) encodeProto(e *protoEncoder) {
+	e.ip(1, n.IP)
+}
+
+func (n *NAT) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		n.IP = f.ip()
 	}
-	return map[string]any(fields)
 }
 
 // NAT holds information about the translated source of a network exchange.
@@ -59,10 +111,8 @@ type NAT struct {
 	IP netip.Addr
 }
 
-func (n *NAT) fields() map[string]any {
-	var fields mapStr
+func (n *NAT) encodeFields(o *jsonObject) {
 	if n.IP.IsValid() {
-		fields.set("ip", n.IP.String())
+		o.string("ip", n.IP.String())
 	}
-	return map[string]any(fields)
 }
@@ -18,6 +18,7 @@
 package model
 
 import (
+	"math"
 	"time"
 )
 
@@ -89,80 +90,279 @@ type Composite struct {
 	CompressionStrategy string
 }
 
-func (db *DB) fields() map[string]any {
-	if db == nil {
-		return nil
-	}
-	var fields, user mapStr
-	fields.maybeSetString("instance", db.Instance)
-	fields.maybeSetString("statement", db.Statement)
-	fields.maybeSetString("type", db.Type)
-	fields.maybeSetString("link", db.Link)
-	fields.maybeSetIntptr("rows_affected", db.RowsAffected)
-	if user.maybeSetString("name", db.UserName) {
-		fields.set("user", map[string]any(user))
-	}
-	return map[string]any(fields)
-}
-
-func (d *DestinationService) fields() map[string]any {
-	if d == nil {
-		return nil
-	}
-	var fields mapStr
-	fields.maybeSetString("type", d.Type)
-	fields.maybeSetString("name", d.Name)
-	fields.maybeSetString("resource", d.Resource)
-	fields.maybeSetMapStr("response_time", d.ResponseTime.fields())
-	return map[string]any(fields)
-}
-
-func (c *Composite) fields() map[string]any {
-	if c == nil {
-		return nil
-	}
-	var fields mapStr
-	sumDuration := time.Duration(c.Sum * float64(time.Millisecond))
-	fields.set("sum", map[string]any{"us": int(sumDuration.Microseconds())})
-	fields.set("count", c.Count)
-	fields.set("compression_strategy", c.CompressionStrategy)
-
-	return map[string]any(fields)
-}
-
-func (e *Span) fields() map[string]any {
-	var span mapStr
-	span.maybeSetString("name", e.Name)
-	span.maybeSetString("type", e.Type)
-	span.maybeSetString("id", e.ID)
-	span.maybeSetString("kind", e.Kind)
-	span.maybeSetString("subtype", e.Subtype)
-	span.maybeSetString("action", e.Action)
-	span.maybeSetBool("sync", e.Sync)
-	span.maybeSetMapStr("db", e.DB.fields())
-	span.maybeSetMapStr("message", e.Message.Fields())
-	span.maybeSetMapStr("composite", e.Composite.fields())
-	if destinationServiceFields := e.DestinationService.fields(); len(destinationServiceFields) > 0 {
-		destinationMap, ok := span["destination"].(map[string]any)
-		if !ok {
-			destinationMap = make(map[string]any)
-			span.set("destination", destinationMap)
-		}
-		destinationMap["service"] = destinationServiceFields
+func (db *DB) encodeFields(o *jsonObject) {
+	o.maybeString("instance", db.Instance)
+	o.maybeString("statement", db.Statement)
+	o.maybeString("type", db.Type)
+	o.maybeString("link", db.Link)
+	o.maybeIntptr("rows_affected", db.RowsAffected)
+	user := o.object("user")
+	user.maybeString("name", db.UserName)
+	user.end()
+}
+
+func (db *DB) decodeFields(f jsonFields) {
+	db.Instance = f.string("instance")
+	db.Statement = f.string("statement")
+	db.Type = f.string("type")
+	db.Link = f.string("link")
+	db.RowsAffected = f.intptr("rows_affected")
+	user, _ := f.object("user")
+	db.UserName = user.string("name")
+}
+
+func (db *DB) encodeProto(e *protoEncoder) {
+	e.string(1, db.Instance)
+	e.string(2, db.Statement)
+	e.string(3, db.Type)
+	e.string(4, db.UserName)
+	e.string(5, db.Link)
+	e.intptr(6, db.RowsAffected)
+}
+
+func (db *DB) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		db.Instance = f.string()
+	case 2:
+		db.Statement = f.string()
+	case 3:
+		db.Type = f.string()
+	case 4:
+		db.UserName = f.string()
+	case 5:
+		db.Link = f.string()
+	case 6:
+		db.RowsAffected = f.intptr()
+	}
+}
+
+func (d *DestinationService) encodeFields(o *jsonObject) {
+	o.maybeString("type", d.Type)
+	o.maybeString("name", d.Name)
+	o.maybeString("resource", d.Resource)
+	responseTime := o.object("response_time")
+	d.ResponseTime.encodeFields(&responseTime)
+	responseTime.end()
+}
+
+func (d *DestinationService) decodeFields(f jsonFields) {
+	d.Type = f.string("type")
+	d.Name = f.string("name")
+	d.Resource = f.string("resource")
+	responseTime, _ := f.object("response_time")
+	d.ResponseTime.decodeFields(responseTime)
+}
+
+func (d *DestinationService) encodeProto(e *protoEncoder) {
+	e.string(1, d.Type)
+	e.string(2, d.Name)
+	e.string(3, d.Resource)
+	e.message(4, &d.ResponseTime)
+}
+
+func (d *DestinationService) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		d.Type = f.string()
+	case 2:
+		d.Name = f.string()
+	case 3:
+		d.Resource = f.string()
+	case 4:
+		f.message(&d.ResponseTime)
+	}
+}
+
+func (c *Composite) encodeFields(o *jsonObject) {
+	sumDuration := time.Duration(math.Round(c.Sum * float64(time.Millisecond)))
+	sum := o.object("sum")
+	sum.int64("us", sumDuration.Microseconds())
+	sum.end()
+	o.int("count", c.Count)
+	o.string("compression_strategy", c.CompressionStrategy)
+}
+
+func (c *Composite) decodeFields(f jsonFields) {
+	sum, _ := f.object("sum")
+	c.Sum = float64(sum.int64("us")) / 1000
+	c.Count = f.int("count")
+	c.CompressionStrategy = f.string("compression_strategy")
+}
+
+func (c *Composite) encodeProto(e *protoEncoder) {
+	e.int(1, c.Count)
+	e.float64(2, c.Sum)
+	e.string(3, c.CompressionStrategy)
+}
+
+func (c *Composite) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		c.Count = f.int()
+	case 2:
+		c.Sum = f.float64()
+	case 3:
+		c.CompressionStrategy = f.string()
+	}
+}
+
+func (e *Span) encodeFields(o *jsonObject) {
+	o.maybeString("name", e.Name)
+	o.maybeString("type", e.Type)
+	o.maybeString("id", e.ID)
+	o.maybeString("kind", e.Kind)
+	o.maybeString("subtype", e.Subtype)
+	o.maybeString("action", e.Action)
+	o.maybeBool("sync", e.Sync)
+	if e.DB != nil {
+		db := o.object("db")
+		e.DB.encodeFields(&db)
+		db.end()
+	}
+	if e.Message != nil {
+		message := o.object("message")
+		e.Message.encodeFields(&message)
+		message.end()
+	}
+	if e.Composite != nil {
+		composite := o.object("composite")
+		e.Composite.encodeFields(&composite)
+		composite.end()
 	}
-	if st := e.Stacktrace.transform(); len(st) > 0 {
-		span.set("stacktrace", st)
+	if e.DestinationService != nil {
+		destination := o.object("destination")
+		service := destination.object("service")
+		e.DestinationService.encodeFields(&service)
+		service.end()
+		destination.end()
 	}
-	span.maybeSetMapStr("self_time", e.SelfTime.fields())
-	if n := len(e.Links); n > 0 {
-		links := make([]map[string]any, n)
+	if len(e.Stacktrace) > 0 {
+		o.key("stacktrace")
+		e.Stacktrace.encode(o.w, o.err)
+	}
+	selfTime := o.object("self_time")
+	e.SelfTime.encodeFields(&selfTime)
+	selfTime.end()
+	if len(e.Links) > 0 {
+		o.key("links")
+		o.w.RawByte('[')
 		for i, link := range e.Links {
-			links[i] = link.fields()
+			if i > 0 {
+				o.w.RawByte(',')
+			}
+			l := beginObject(o.w, o.err)
+			link.encodeFields(&l)
+			l.end()
 		}
-		span.set("links", links)
+		o.w.RawByte(']')
 	}
 	if e.RepresentativeCount > 0 {
-		span.set("representative_count", e.RepresentativeCount)
+		o.float64("representative_count", e.RepresentativeCount)
+	}
+}
+
+func (e *Span) decodeFields(f jsonFields) {
+	e.Name = f.string("name")
+	e.Type = f.string("type")
+	e.ID = f.string("id")
+	e.Kind = f.string("kind")
+	e.Subtype = f.string("subtype")
+	e.Action = f.string("action")
+	e.Sync = f.boolptr("sync")
+	if db, ok := f.object("db"); ok {
+		e.DB = &DB{}
+		e.DB.decodeFields(db)
+	}
+	if message, ok := f.object("message"); ok {
+		e.Message = &Message{}
+		e.Message.decodeFields(message)
+	}
+	if composite, ok := f.object("composite"); ok {
+		e.Composite = &Composite{}
+		e.Composite.decodeFields(composite)
+	}
+	destination, _ := f.object("destination")
+	if service, ok := destination.object("service"); ok {
+		e.DestinationService = &DestinationService{}
+		e.DestinationService.decodeFields(service)
+	}
+	e.Stacktrace = decodeStacktrace(f.objects("stacktrace"))
+	selfTime, _ := f.object("self_time")
+	e.SelfTime.decodeFields(selfTime)
+	for _, link := range f.objects("links") {
+		var l SpanLink
+		l.decodeFields(link)
+		e.Links = append(e.Links, l)
+	}
+	e.RepresentativeCount = f.float64("representative_count")
+}
+
+func (e *Span) encodeProto(enc *protoEncoder) {
+	enc.string(1, e.ID)
+	enc.string(2, e.Name)
+	enc.string(3, e.Type)
+	enc.string(4, e.Kind)
+	enc.string(5, e.Subtype)
+	enc.string(6, e.Action)
+	enc.message(7, &e.SelfTime)
+	if e.Message != nil {
+		enc.messageKeepEmpty(8, e.Message)
+	}
+	e.Stacktrace.encodeProto(enc, 9)
+	enc.boolptr(10, e.Sync)
+	for i := range e.Links {
+		enc.messageKeepEmpty(11, &e.Links[i])
+	}
+	if e.DB != nil {
+		enc.messageKeepEmpty(12, e.DB)
+	}
+	if e.DestinationService != nil {
+		enc.messageKeepEmpty(13, e.DestinationService)
+	}
+	if e.Composite != nil {
+		enc.messageKeepEmpty(14, e.Composite)
+	}
+	enc.float64(15, e.RepresentativeCount)
+}
+
+func (e *Span) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		e.ID = f.string()
+	case 2:
+		e.Name = f.string()
+	case 3:
+		e.Type = f.string()
+	case 4:
+		e.Kind = f.string()
+	case 5:
+		e.Subtype = f.string()
+	case 6:
+		e.Action = f.string()
+	case 7:
+		f.message(&e.SelfTime)
+	case 8:
+		e.Message = &Message{}
+		f.message(e.Message)
+	case 9:
+		e.Stacktrace = e.Stacktrace.decodeProto(f)
+	case 10:
+		e.Sync = f.boolptr()
+	case 11:
+		var link SpanLink
+		f.message(&link)
+		e.Links = append(e.Links, link)
+	case 12:
+		e.DB = &DB{}
+		f.message(e.DB)
+	case 13:
+		e.DestinationService = &DestinationService{}
+		f.message(e.DestinationService)
+	case 14:
+		e.Composite = &Composite{}
+		f.message(e.Composite)
+	case 15:
+		e.RepresentativeCount = f.float64()
 	}
-	return map[string]any(span)
 }
@@ -27,9 +27,32 @@ type SpanLink struct {
 	Trace Trace
 }
 
-func (l *SpanLink) fields() map[string]any {
-	var fields mapStr
-	fields.maybeSetMapStr("span", l.Span.fields())
-	fields.maybeSetMapStr("trace", l.Trace.fields())
-	return map[string]any(fields)
+func (l *SpanLink) encodeFields(o *jsonObject) {
+	span := o.object("span")
+	l.Span.encodeFields(&span)
+	span.end()
+	trace := o.object("trace")
+	l.Trace.encodeFields(&trace)
+	trace.end()
+}
+
+func (l *SpanLink) decodeFields(f jsonFields) {
+	span, _ := f.object("span")
+	l.Span.decodeFields(span)
+	trace, _ := f.object("trace")
+	l.Trace.decodeFields(trace)
+}
+
+func (l *SpanLink) encodeProto(e *protoEncoder) {
+	e.message(1, &l.Span)
+	e.message(2, &l.Trace)
+}
+
+func (l *SpanLink) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		f.message(&l.Span)
+	case 2:
+		f.message(&l.Trace)
+	}
 }
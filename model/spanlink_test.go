@@ -55,7 +55,7 @@ func TestSpanLinkFields(t *testing.T) {
 		},
 	}}
 	for _, test := range tests {
-		output := test.Input.fields()
+		output := encodeFields(&test.Input)
 		assert.Equal(t, test.Expected, output)
 	}
 }
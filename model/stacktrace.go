@@ -17,6 +17,11 @@
 
 package model
 
+import (
+	"go.elastic.co/fastjson"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
 type Stacktrace []*StacktraceFrame
 
 type StacktraceFrame struct {
@@ -50,66 +55,212 @@ type Original struct {
 	LibraryFrame bool
 }
 
-func (st Stacktrace) transform() []map[string]any {
-	if len(st) == 0 {
+func (st Stacktrace) encode(w *fastjson.Writer, err *error) {
+	w.RawByte('[')
+	for i, frame := range st {
+		if i > 0 {
+			w.RawByte(',')
+		}
+		o := beginObject(w, err)
+		frame.encodeFields(&o)
+		o.end()
+	}
+	w.RawByte(']')
+}
+
+func decodeStacktrace(frames []jsonFields) Stacktrace {
+	if len(frames) == 0 {
 		return nil
 	}
-	frames := make([]map[string]any, len(st))
-	for i, frame := range st {
-		frames[i] = frame.transform()
+	st := make(Stacktrace, len(frames))
+	for i, f := range frames {
+		st[i] = &StacktraceFrame{}
+		st[i].decodeFields(f)
 	}
-	return frames
+	return st
 }
 
-func (s *StacktraceFrame) transform() map[string]any {
-	var m mapStr
-	m.maybeSetString("filename", s.Filename)
-	m.maybeSetString("classname", s.Classname)
-	m.maybeSetString("abs_path", s.AbsPath)
-	m.maybeSetString("module", s.Module)
-	m.maybeSetString("function", s.Function)
-	m.maybeSetMapStr("vars", s.Vars)
+// encodeProto writes st as a repeated message field.
+func (st Stacktrace) encodeProto(e *protoEncoder, num protowire.Number) {
+	for _, frame := range st {
+		if frame == nil {
+			frame = &StacktraceFrame{}
+		}
+		e.messageKeepEmpty(num, frame)
+	}
+}
+
+// decodeProto decodes a frame from f, returning st with the frame appended.
+func (st Stacktrace) decodeProto(f *protoField) Stacktrace {
+	var frame StacktraceFrame
+	f.message(&frame)
+	return append(st, &frame)
+}
+
+func (s *StacktraceFrame) encodeFields(o *jsonObject) {
+	o.maybeString("filename", s.Filename)
+	o.maybeString("classname", s.Classname)
+	o.maybeString("abs_path", s.AbsPath)
+	o.maybeString("module", s.Module)
+	o.maybeString("function", s.Function)
+	o.maybeMap("vars", s.Vars, false)
 
 	if s.LibraryFrame {
-		m.set("library_frame", s.LibraryFrame)
+		o.bool("library_frame", s.LibraryFrame)
 	}
-	m.set("exclude_from_grouping", s.ExcludeFromGrouping)
+	o.bool("exclude_from_grouping", s.ExcludeFromGrouping)
 
-	var context mapStr
+	context := o.object("context")
 	if len(s.PreContext) > 0 {
-		context.set("pre", s.PreContext)
+		context.strings("pre", s.PreContext)
 	}
 	if len(s.PostContext) > 0 {
-		context.set("post", s.PostContext)
+		context.strings("post", s.PostContext)
 	}
-	m.maybeSetMapStr("context", map[string]any(context))
+	context.end()
 
-	var line mapStr
-	line.maybeSetIntptr("number", s.Lineno)
-	line.maybeSetIntptr("column", s.Colno)
-	line.maybeSetString("context", s.ContextLine)
-	m.maybeSetMapStr("line", map[string]any(line))
+	line := o.object("line")
+	line.maybeIntptr("number", s.Lineno)
+	line.maybeIntptr("column", s.Colno)
+	line.maybeString("context", s.ContextLine)
+	line.end()
 
-	var sm mapStr
+	sm := o.object("sourcemap")
 	if s.SourcemapUpdated {
-		sm.set("updated", true)
+		sm.bool("updated", true)
 	}
-	sm.maybeSetString("error", s.SourcemapError)
-	m.maybeSetMapStr("sourcemap", map[string]any(sm))
+	sm.maybeString("error", s.SourcemapError)
+	sm.end()
 
-	var orig mapStr
+	orig := o.object("original")
 	if s.Original.LibraryFrame {
-		orig.set("library_frame", s.Original.LibraryFrame)
+		orig.bool("library_frame", s.Original.LibraryFrame)
 	}
 	if s.SourcemapUpdated {
-		orig.maybeSetString("filename", s.Original.Filename)
-		orig.maybeSetString("classname", s.Original.Classname)
-		orig.maybeSetString("abs_path", s.Original.AbsPath)
-		orig.maybeSetString("function", s.Original.Function)
-		orig.maybeSetIntptr("colno", s.Original.Colno)
-		orig.maybeSetIntptr("lineno", s.Original.Lineno)
+		orig.maybeString("filename", s.Original.Filename)
+		orig.maybeString("classname", s.Original.Classname)
+		orig.maybeString("abs_path", s.Original.AbsPath)
+		orig.maybeString("function", s.Original.Function)
+		orig.maybeIntptr("colno", s.Original.Colno)
+		orig.maybeIntptr("lineno", s.Original.Lineno)
+	}
+	orig.end()
+}
+
+func (s *StacktraceFrame) decodeFields(f jsonFields) {
+	s.Filename = f.string("filename")
+	s.Classname = f.string("classname")
+	s.AbsPath = f.string("abs_path")
+	s.Module = f.string("module")
+	s.Function = f.string("function")
+	s.Vars = f.anyMap("vars")
+	s.LibraryFrame = f.bool("library_frame")
+	s.ExcludeFromGrouping = f.bool("exclude_from_grouping")
+
+	context, _ := f.object("context")
+	s.PreContext = context.strings("pre")
+	s.PostContext = context.strings("post")
+
+	line, _ := f.object("line")
+	s.Lineno = line.intptr("number")
+	s.Colno = line.intptr("column")
+	s.ContextLine = line.string("context")
+
+	sm, _ := f.object("sourcemap")
+	s.SourcemapUpdated = sm.bool("updated")
+	s.SourcemapError = sm.string("error")
+
+	orig, _ := f.object("original")
+	s.Original.LibraryFrame = orig.bool("library_frame")
+	s.Original.Filename = orig.string("filename")
+	s.Original.Classname = orig.string("classname")
+	s.Original.AbsPath = orig.string("abs_path")
+	s.Original.Function = orig.string("function")
+	s.Original.Colno = orig.intptr("colno")
+	s.Original.Lineno = orig.intptr("lineno")
+}
+
+func (s *StacktraceFrame) encodeProto(e *protoEncoder) {
+	e.string(1, s.AbsPath)
+	e.string(2, s.Filename)
+	e.string(3, s.Classname)
+	e.intptr(4, s.Lineno)
+	e.intptr(5, s.Colno)
+	e.string(6, s.ContextLine)
+	e.string(7, s.Module)
+	e.string(8, s.Function)
+	e.bool(9, s.LibraryFrame)
+	e.anyMap(10, s.Vars)
+	e.strings(11, s.PreContext)
+	e.strings(12, s.PostContext)
+	e.bool(13, s.ExcludeFromGrouping)
+	e.bool(14, s.SourcemapUpdated)
+	e.string(15, s.SourcemapError)
+	e.message(16, &s.Original)
+}
+
+func (s *StacktraceFrame) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		s.AbsPath = f.string()
+	case 2:
+		s.Filename = f.string()
+	case 3:
+		s.Classname = f.string()
+	case 4:
+		s.Lineno = f.intptr()
+	case 5:
+		s.Colno = f.intptr()
+	case 6:
+		s.ContextLine = f.string()
+	case 7:
+		s.Module = f.string()
+	case 8:
+		s.Function = f.string()
+	case 9:
+		s.LibraryFrame = f.bool()
+	case 10:
+		s.Vars = f.anyMap()
+	case 11:
+		s.PreContext = append(s.PreContext, f.string())
+	case 12:
+		s.PostContext = append(s.PostContext, f.string())
+	case 13:
+		s.ExcludeFromGrouping = f.bool()
+	case 14:
+		s.SourcemapUpdated = f.bool()
+	case 15:
+		s.SourcemapError = f.string()
+	case 16:
+		f.message(&s.Original)
 	}
-	m.maybeSetMapStr("original", map[string]any(orig))
+}
 
-	return map[string]any(m)
+func (o *Original) encodeProto(e *protoEncoder) {
+	e.string(1, o.AbsPath)
+	e.string(2, o.Filename)
+	e.string(3, o.Classname)
+	e.intptr(4, o.Lineno)
+	e.intptr(5, o.Colno)
+	e.string(6, o.Function)
+	e.bool(7, o.LibraryFrame)
+}
+
+func (o *Original) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		o.AbsPath = f.string()
+	case 2:
+		o.Filename = f.string()
+	case 3:
+		o.Classname = f.string()
+	case 4:
+		o.Lineno = f.intptr()
+	case 5:
+		o.Colno = f.intptr()
+	case 6:
+		o.Function = f.string()
+	case 7:
+		o.LibraryFrame = f.bool()
+	}
 }
@@ -18,10 +18,12 @@
 package model
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"go.elastic.co/fastjson"
 )
 
 func TestStacktraceTransform(t *testing.T) {
@@ -80,12 +82,12 @@ func TestStacktraceTransform(t *testing.T) {
 				"classname": "original classname",
 				"module":    "original module",
 				"line": map[string]any{
-					"number": 111,
-					"column": 222,
+					"number": 111.0,
+					"column": 222.0,
 				},
 				"exclude_from_grouping": false,
 				"library_frame":         true,
-				"vars":                  vars,
+				"vars":                  map[string]any{"a": "abc", "b": 123.0},
 			}},
 			Msg: "unmapped stacktrace",
 		},
@@ -118,21 +120,21 @@ func TestStacktraceTransform(t *testing.T) {
 				"function":  "mapped function",
 				"classname": "mapped classname",
 				"line": map[string]any{
-					"number":  333,
-					"column":  444,
+					"number":  333.0,
+					"column":  444.0,
 					"context": "context line",
 				},
 				"context": map[string]any{
-					"pre":  preContext,
-					"post": postContext,
+					"pre":  []any{"before1", "before2"},
+					"post": []any{"after1", "after2"},
 				},
 				"original": map[string]any{
 					"abs_path":  "original path",
 					"filename":  "original filename",
 					"function":  "original function",
 					"classname": "original classname",
-					"lineno":    111,
-					"colno":     222,
+					"lineno":    111.0,
+					"colno":     222.0,
 				},
 				"exclude_from_grouping": true,
 				"sourcemap": map[string]any{
@@ -145,7 +147,26 @@ func TestStacktraceTransform(t *testing.T) {
 	}
 
 	for idx, test := range tests {
-		output := test.Stacktrace.transform()
+		output := encodeStacktrace(test.Stacktrace)
 		assert.Equal(t, test.Output, output, fmt.Sprintf("Failed at idx %v; %s", idx, test.Msg))
 	}
 }
+
+// encodeStacktrace encodes st as a JSON array, and decodes the result
+// into a slice of maps. If st is empty, nil is returned.
+func encodeStacktrace(st Stacktrace) []map[string]any {
+	if len(st) == 0 {
+		return nil
+	}
+	var w fastjson.Writer
+	var err error
+	st.encode(&w, &err)
+	if err != nil {
+		panic(err)
+	}
+	var decoded []map[string]any
+	if err := json.Unmarshal(w.Bytes(), &decoded); err != nil {
+		panic(err)
+	}
+	return decoded
+}
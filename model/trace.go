@@ -23,8 +23,21 @@ type Trace struct {
 	ID string
 }
 
-func (t *Trace) fields() map[string]any {
-	var fields mapStr
-	fields.maybeSetString("id", t.ID)
-	return map[string]any(fields)
+func (t *Trace) encodeFields(o *jsonObject) {
+	o.maybeString("id", t.ID)
+}
+
+func (t *Trace) decodeFields(f jsonFields) {
+	t.ID = f.string("id")
+}
+
+func (t *Trace) encodeProto(e *protoEncoder) {
+	e.string(1, t.ID)
+}
+
+func (t *Trace) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		t.ID = f.string()
+	}
 }
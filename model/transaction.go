@@ -88,76 +88,251 @@ type SpanCount struct {
 	Started *int
 }
 
-func (e *Transaction) fields() map[string]any {
-	var transaction mapStr
-	transaction.maybeSetString("id", e.ID)
-	transaction.maybeSetString("type", e.Type)
-	transaction.maybeSetMapStr("duration.histogram", e.DurationHistogram.fields())
+func (e *Transaction) encodeFields(o *jsonObject) {
+	o.maybeString("id", e.ID)
+	o.maybeString("type", e.Type)
+	histogram := o.object("duration.histogram")
+	e.DurationHistogram.encodeFields(&histogram)
+	histogram.end()
 	if e.DurationSummary.Count != 0 {
-		transaction.maybeSetMapStr("duration.summary", e.DurationSummary.fields())
+		summary := o.object("duration.summary")
+		e.DurationSummary.encodeFields(&summary)
+		summary.end()
 	}
 	if e.SuccessCount.Count != 0 {
-		transaction.maybeSetMapStr("success_count", e.SuccessCount.fields())
-	}
-	transaction.maybeSetString("name", e.Name)
-	transaction.maybeSetString("result", e.Result)
-	transaction.maybeSetMapStr("marks", e.Marks.fields())
-	transaction.maybeSetMapStr("custom", customFields(e.Custom))
-	transaction.maybeSetMapStr("message", e.Message.Fields())
-	transaction.maybeSetMapStr("experience", e.UserExperience.Fields())
+		successCount := o.object("success_count")
+		e.SuccessCount.encodeFields(&successCount)
+		successCount.end()
+	}
+	o.maybeString("name", e.Name)
+	o.maybeString("result", e.Result)
+	marks := o.object("marks")
+	e.Marks.encodeFields(&marks)
+	marks.end()
+	o.maybeMap("custom", e.Custom, true)
+	if e.Message != nil {
+		message := o.object("message")
+		e.Message.encodeFields(&message)
+		message.end()
+	}
+	if e.UserExperience != nil {
+		experience := o.object("experience")
+		e.UserExperience.encodeFields(&experience)
+		experience.end()
+	}
 	if e.SpanCount.Dropped != nil || e.SpanCount.Started != nil {
-		spanCount := map[string]any{}
-		if e.SpanCount.Dropped != nil {
-			spanCount["dropped"] = *e.SpanCount.Dropped
-		}
-		if e.SpanCount.Started != nil {
-			spanCount["started"] = *e.SpanCount.Started
-		}
-		transaction.set("span_count", spanCount)
+		spanCount := o.object("span_count")
+		spanCount.maybeIntptr("dropped", e.SpanCount.Dropped)
+		spanCount.maybeIntptr("started", e.SpanCount.Started)
+		spanCount.end()
 	}
 	if e.Sampled {
-		transaction.set("sampled", e.Sampled)
+		o.bool("sampled", e.Sampled)
 	}
 	if e.Root {
-		transaction.set("root", e.Root)
+		o.bool("root", e.Root)
 	}
 	if e.RepresentativeCount > 0 {
-		transaction.set("representative_count", e.RepresentativeCount)
+		o.float64("representative_count", e.RepresentativeCount)
+	}
+	if len(e.DroppedSpansStats) > 0 {
+		o.key("dropped_spans_stats")
+		o.w.RawByte('[')
+		for i, v := range e.DroppedSpansStats {
+			if i > 0 {
+				o.w.RawByte(',')
+			}
+			stat := beginObject(o.w, o.err)
+			v.encodeFields(&stat)
+			stat.end()
+		}
+		o.w.RawByte(']')
+	}
+}
+
+func (e *Transaction) decodeFields(f jsonFields) {
+	e.ID = f.string("id")
+	e.Type = f.string("type")
+	histogram, _ := f.object("duration.histogram")
+	e.DurationHistogram.decodeFields(histogram)
+	summary, _ := f.object("duration.summary")
+	e.DurationSummary.decodeFields(summary)
+	successCount, _ := f.object("success_count")
+	e.SuccessCount.decodeFields(successCount)
+	e.Name = f.string("name")
+	e.Result = f.string("result")
+	if marks, ok := f.object("marks"); ok {
+		e.Marks = make(TransactionMarks, len(marks.m))
+		for k := range marks.m {
+			mark, _ := marks.object(k)
+			e.Marks[k] = make(TransactionMark, len(mark.m))
+			for k2 := range mark.m {
+				e.Marks[k][k2] = mark.float64(k2)
+			}
+		}
+	}
+	e.Custom = f.anyMap("custom")
+	if message, ok := f.object("message"); ok {
+		e.Message = &Message{}
+		e.Message.decodeFields(message)
 	}
-	var dss []map[string]any
-	for _, v := range e.DroppedSpansStats {
-		dss = append(dss, v.fields())
+	if experience, ok := f.object("experience"); ok {
+		e.UserExperience = &UserExperience{}
+		e.UserExperience.decodeFields(experience)
 	}
-	if len(dss) > 0 {
-		transaction.set("dropped_spans_stats", dss)
+	spanCount, _ := f.object("span_count")
+	e.SpanCount.Dropped = spanCount.intptr("dropped")
+	e.SpanCount.Started = spanCount.intptr("started")
+	e.Sampled = f.bool("sampled")
+	e.Root = f.bool("root")
+	e.RepresentativeCount = f.float64("representative_count")
+	for _, stat := range f.objects("dropped_spans_stats") {
+		var dss DroppedSpanStats
+		dss.decodeFields(stat)
+		e.DroppedSpansStats = append(e.DroppedSpansStats, dss)
 	}
-	return map[string]any(transaction)
 }
 
-type TransactionMarks map[string]TransactionMark
+func (e *Transaction) encodeProto(enc *protoEncoder) {
+	enc.string(1, e.ID)
+	enc.string(2, e.Name)
+	enc.string(3, e.Type)
+	enc.string(4, e.Result)
+	enc.bool(5, e.Sampled)
+	enc.message(6, &e.DurationHistogram)
+	enc.message(7, &e.DurationSummary)
+	enc.message(8, &e.SuccessCount)
+	for _, k := range sortedKeys(e.Marks) {
+		enc.mapEntry(9, k, e.Marks[k])
+	}
+	if e.Message != nil {
+		enc.messageKeepEmpty(10, e.Message)
+	}
+	enc.message(11, &e.SpanCount)
+	enc.anyMap(12, e.Custom)
+	if e.UserExperience != nil {
+		enc.messageKeepEmpty(13, e.UserExperience)
+	}
+	for i := range e.DroppedSpansStats {
+		enc.messageKeepEmpty(14, &e.DroppedSpansStats[i])
+	}
+	enc.float64(15, e.RepresentativeCount)
+	enc.bool(16, e.Root)
+}
 
-func (m TransactionMarks) fields() map[string]any {
-	if len(m) == 0 {
-		return nil
+func (e *Transaction) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		e.ID = f.string()
+	case 2:
+		e.Name = f.string()
+	case 3:
+		e.Type = f.string()
+	case 4:
+		e.Result = f.string()
+	case 5:
+		e.Sampled = f.bool()
+	case 6:
+		f.message(&e.DurationHistogram)
+	case 7:
+		f.message(&e.DurationSummary)
+	case 8:
+		f.message(&e.SuccessCount)
+	case 9:
+		var k string
+		mark := make(TransactionMark)
+		f.mapEntry(&k, mark)
+		if e.Marks == nil {
+			e.Marks = make(TransactionMarks)
+		}
+		e.Marks[k] = mark
+	case 10:
+		e.Message = &Message{}
+		f.message(e.Message)
+	case 11:
+		f.message(&e.SpanCount)
+	case 12:
+		e.Custom = f.anyMap()
+	case 13:
+		e.UserExperience = &UserExperience{}
+		f.message(e.UserExperience)
+	case 14:
+		var stats DroppedSpanStats
+		f.message(&stats)
+		e.DroppedSpansStats = append(e.DroppedSpansStats, stats)
+	case 15:
+		e.RepresentativeCount = f.float64()
+	case 16:
+		e.Root = f.bool()
 	}
-	out := make(mapStr, len(m))
-	for k, v := range m {
-		out.maybeSetMapStr(sanitizeLabelKey(k), v.fields())
+}
+
+// encodeProto writes m as a message with a single map field, with
+// entries sorted by key.
+func (m TransactionMark) encodeProto(e *protoEncoder) {
+	for _, k := range sortedKeys(m) {
+		_, body := e.beginMessage(1)
+		e.string(1, k)
+		e.float64(2, m[k])
+		e.endMessage(body)
 	}
-	return map[string]any(out)
 }
 
-type TransactionMark map[string]float64
+func (m TransactionMark) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		var entry transactionMarkEntry
+		f.message(&entry)
+		m[entry.key] = entry.value
+	}
+}
 
-func (m TransactionMark) fields() map[string]any {
-	if len(m) == 0 {
-		return nil
+type transactionMarkEntry struct {
+	key   string
+	value float64
+}
+
+func (e *transactionMarkEntry) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		e.key = f.string()
+	case 2:
+		e.value = f.float64()
+	}
+}
+
+func (c *SpanCount) encodeProto(e *protoEncoder) {
+	e.intptr(1, c.Dropped)
+	e.intptr(2, c.Started)
+}
+
+func (c *SpanCount) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		c.Dropped = f.intptr()
+	case 2:
+		c.Started = f.intptr()
 	}
-	out := make(map[string]any, len(m))
-	for k, v := range m {
-		out[sanitizeLabelKey(k)] = v
+}
+
+type TransactionMarks map[string]TransactionMark
+
+func (m TransactionMarks) encodeFields(o *jsonObject) {
+	keys, sanitized := sanitizedLabelKeys(m)
+	for i, k := range keys {
+		mark := o.object(sanitized[i])
+		m[k].encodeFields(&mark)
+		mark.end()
+	}
+}
+
+type TransactionMark map[string]float64
+
+func (m TransactionMark) encodeFields(o *jsonObject) {
+	keys, sanitized := sanitizedLabelKeys(m)
+	for i, k := range keys {
+		o.float64(sanitized[i], m[k])
 	}
-	return out
 }
 
 type DroppedSpanStats struct {
@@ -168,14 +343,46 @@ type DroppedSpanStats struct {
 	Duration                   AggregatedDuration
 }
 
-func (stat DroppedSpanStats) fields() map[string]any {
-	var out mapStr
-	out.maybeSetString("destination_service_resource",
+func (stat *DroppedSpanStats) encodeFields(o *jsonObject) {
+	o.maybeString("destination_service_resource",
 		stat.DestinationServiceResource,
 	)
-	out.maybeSetString("service_target_type", stat.ServiceTargetType)
-	out.maybeSetString("service_target_name", stat.ServiceTargetName)
-	out.maybeSetString("outcome", stat.Outcome)
-	out.maybeSetMapStr("duration", stat.Duration.fields())
-	return map[string]any(out)
+	o.maybeString("service_target_type", stat.ServiceTargetType)
+	o.maybeString("service_target_name", stat.ServiceTargetName)
+	o.maybeString("outcome", stat.Outcome)
+	duration := o.object("duration")
+	stat.Duration.encodeFields(&duration)
+	duration.end()
+}
+
+func (stat *DroppedSpanStats) decodeFields(f jsonFields) {
+	stat.DestinationServiceResource = f.string("destination_service_resource")
+	stat.ServiceTargetType = f.string("service_target_type")
+	stat.ServiceTargetName = f.string("service_target_name")
+	stat.Outcome = f.string("outcome")
+	duration, _ := f.object("duration")
+	stat.Duration.decodeFields(duration)
+}
+
+func (stat *DroppedSpanStats) encodeProto(e *protoEncoder) {
+	e.string(1, stat.DestinationServiceResource)
+	e.string(2, stat.ServiceTargetType)
+	e.string(3, stat.ServiceTargetName)
+	e.string(4, stat.Outcome)
+	e.message(5, &stat.Duration)
+}
+
+func (stat *DroppedSpanStats) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		stat.DestinationServiceResource = f.string()
+	case 2:
+		stat.ServiceTargetType = f.string()
+	case 3:
+		stat.ServiceTargetName = f.string()
+	case 4:
+		stat.Outcome = f.string()
+	case 5:
+		f.message(&stat.Duration)
+	}
 }
@@ -78,18 +78,58 @@ func truncate(s string) string {
 	return s
 }
 
-// fields returns map[string]any holding transformed data for attribute url.
-func (url *URL) fields() map[string]any {
-	var fields mapStr
-	fields.maybeSetString("full", url.Full)
-	fields.maybeSetString("fragment", url.Fragment)
-	fields.maybeSetString("domain", url.Domain)
-	fields.maybeSetString("path", url.Path)
+func (url *URL) encodeFields(o *jsonObject) {
+	o.maybeString("full", url.Full)
+	o.maybeString("fragment", url.Fragment)
+	o.maybeString("domain", url.Domain)
+	o.maybeString("path", url.Path)
 	if url.Port > 0 {
-		fields.set("port", url.Port)
+		o.int("port", url.Port)
+	}
+	o.maybeString("original", url.Original)
+	o.maybeString("scheme", url.Scheme)
+	o.maybeString("query", url.Query)
+}
+
+func (url *URL) decodeFields(f jsonFields) {
+	url.Full = f.string("full")
+	url.Fragment = f.string("fragment")
+	url.Domain = f.string("domain")
+	url.Path = f.string("path")
+	url.Port = f.int("port")
+	url.Original = f.string("original")
+	url.Scheme = f.string("scheme")
+	url.Query = f.string("query")
+}
+
+func (url *URL) encodeProto(e *protoEncoder) {
+	e.string(1, url.Original)
+	e.string(2, url.Scheme)
+	e.string(3, url.Full)
+	e.string(4, url.Domain)
+	e.int(5, url.Port)
+	e.string(6, url.Path)
+	e.string(7, url.Query)
+	e.string(8, url.Fragment)
+}
+
+func (url *URL) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		url.Original = f.string()
+	case 2:
+		url.Scheme = f.string()
+	case 3:
+		url.Full = f.string()
+	case 4:
+		url.Domain = f.string()
+	case 5:
+		url.Port = f.int()
+	case 6:
+		url.Path = f.string()
+	case 7:
+		url.Query = f.string()
+	case 8:
+		url.Fragment = f.string()
 	}
-	fields.maybeSetString("original", url.Original)
-	fields.maybeSetString("scheme", url.Scheme)
-	fields.maybeSetString("query", url.Query)
-	return map[string]any(fields)
 }
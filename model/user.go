@@ -24,11 +24,36 @@ type User struct {
 	Name   string
 }
 
-func (u *User) fields() map[string]any {
-	var user mapStr
-	user.maybeSetString("domain", u.Domain)
-	user.maybeSetString("id", u.ID)
-	user.maybeSetString("email", u.Email)
-	user.maybeSetString("name", u.Name)
-	return map[string]any(user)
+func (u *User) encodeFields(o *jsonObject) {
+	o.maybeString("domain", u.Domain)
+	o.maybeString("id", u.ID)
+	o.maybeString("email", u.Email)
+	o.maybeString("name", u.Name)
+}
+
+func (u *User) decodeFields(f jsonFields) {
+	u.Domain = f.string("domain")
+	u.ID = f.string("id")
+	u.Email = f.string("email")
+	u.Name = f.string("name")
+}
+
+func (u *User) encodeProto(e *protoEncoder) {
+	e.string(1, u.Domain)
+	e.string(2, u.ID)
+	e.string(3, u.Email)
+	e.string(4, u.Name)
+}
+
+func (u *User) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		u.Domain = f.string()
+	case 2:
+		u.ID = f.string()
+	case 3:
+		u.Email = f.string()
+	case 4:
+		u.Name = f.string()
+	}
 }
@@ -54,7 +54,7 @@ func TestUserFields(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		output := test.User.fields()
+		output := encodeFields(&test.User)
 		assert.Equal(t, test.Output, output)
 	}
 }
@@ -21,15 +21,78 @@ type UserAgent struct {
 	// Original holds the original, full, User-Agent string.
 	Original string
 
-	// Name holds the user_agent.name value from the parsed User-Agent string.
-	// If Original is set, then this should typically not be set, as the full
-	// User-Agent string can be parsed by ingest node.
+	// Name holds the user_agent.name value from the parsed User-Agent string,
+	// e.g. Chrome. This may be set by parsing Original with package useragent.
 	Name string
+
+	// Version holds the version of the user agent, e.g. 112.0.5615.49.
+	Version string
+
+	// OS holds information about the operating system of the user agent.
+	OS OS
+
+	// Device holds information about the device of the user agent.
+	Device UserAgentDevice
+}
+
+// UserAgentDevice holds information about the device of a user agent.
+type UserAgentDevice struct {
+	// Name holds the device name, e.g. iPhone.
+	Name string
+}
+
+func (u *UserAgent) encodeFields(o *jsonObject) {
+	o.maybeString("original", u.Original)
+	o.maybeString("name", u.Name)
+	o.maybeString("version", u.Version)
+	os := o.object("os")
+	u.OS.encodeFields(&os)
+	os.end()
+	device := o.object("device")
+	device.maybeString("name", u.Device.Name)
+	device.end()
+}
+
+func (u *UserAgent) decodeFields(f jsonFields) {
+	u.Original = f.string("original")
+	u.Name = f.string("name")
+	u.Version = f.string("version")
+	os, _ := f.object("os")
+	u.OS.decodeFields(os)
+	device, _ := f.object("device")
+	u.Device.Name = device.string("name")
+}
+
+func (u *UserAgent) encodeProto(e *protoEncoder) {
+	e.string(1, u.Original)
+	e.string(2, u.Name)
+	e.string(3, u.Version)
+	e.message(4, &u.OS)
+	e.message(5, &u.Device)
+}
+
+func (u *UserAgent) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		u.Original = f.string()
+	case 2:
+		u.Name = f.string()
+	case 3:
+		u.Version = f.string()
+	case 4:
+		f.message(&u.OS)
+	case 5:
+		f.message(&u.Device)
+	}
+}
+
+func (d *UserAgentDevice) encodeProto(e *protoEncoder) {
+	e.string(1, d.Name)
 }
 
-func (u *UserAgent) fields() map[string]any {
-	var fields mapStr
-	fields.maybeSetString("original", u.Original)
-	fields.maybeSetString("name", u.Name)
-	return map[string]any(fields)
+func (d *UserAgentDevice) decodeProto(f *protoField) {
+	switch f.num {
+	case 1:
+		d.Name = f.string()
+	}
 }
@@ -36,10 +36,23 @@ func TestUserAgentFields(t *testing.T) {
 	}, {
 		UserAgent: UserAgent{Name: "mosaic"},
 		Output:    map[string]any{"name": "mosaic"},
+	}, {
+		UserAgent: UserAgent{
+			Name:    "Chrome",
+			Version: "112.0.5615.49",
+			OS:      OS{Name: "Android", Version: "13", Full: "Android 13"},
+			Device:  UserAgentDevice{Name: "Pixel 7"},
+		},
+		Output: map[string]any{
+			"name":    "Chrome",
+			"version": "112.0.5615.49",
+			"os":      map[string]any{"name": "Android", "version": "13", "full": "Android 13"},
+			"device":  map[string]any{"name": "Pixel 7"},
+		},
 	}}
 
 	for _, test := range tests {
-		output := test.UserAgent.fields()
+		output := encodeFields(&test.UserAgent)
 		assert.Equal(t, test.Output, output)
 	}
 }
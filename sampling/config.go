@@ -0,0 +1,129 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sampling
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/elastic/apm-data/model"
+)
+
+// Config holds configuration for creating a Processor.
+type Config struct {
+	// Storage holds the local storage for buffering trace events and
+	// recording sampling decisions.
+	Storage Storage
+
+	// Policies holds the tail-sampling policies, in order of
+	// precedence. The first policy matching a root transaction
+	// determines the sample rate of its trace.
+	//
+	// The final policy must match all traces, i.e. it must have no
+	// criteria.
+	Policies []Policy
+
+	// FlushInterval is the interval at which expired trace events and
+	// sampling decisions are deleted from Storage.
+	FlushInterval time.Duration
+
+	// Logger holds a logger for the processor. If this is nil, then
+	// no logging will be performed.
+	Logger *zap.Logger
+}
+
+// Policy holds a tail-sampling policy: criteria for matching root
+// transactions, and the sample rate for traces whose root transaction
+// matches.
+type Policy struct {
+	PolicyCriteria
+
+	// SampleRate holds the fraction of matching traces to sample,
+	// in the range [0,1].
+	SampleRate float64
+}
+
+// PolicyCriteria holds the criteria for matching root transactions
+// to a tail-sampling policy.
+//
+// Empty criteria match any value.
+type PolicyCriteria struct {
+	// ServiceName holds the name of the root transaction's service.
+	ServiceName string
+
+	// ServiceEnvironment holds the root transaction's service
+	// environment.
+	ServiceEnvironment string
+
+	// TraceName holds the name of the root transaction.
+	TraceName string
+
+	// TraceOutcome holds the root transaction's event outcome:
+	// "success", "failure", or "unknown".
+	TraceOutcome string
+}
+
+// Validate validates the sampling config.
+func (config Config) Validate() error {
+	if config.Storage == nil {
+		return errors.New("Storage unspecified")
+	}
+	if len(config.Policies) == 0 {
+		return errors.New("Policies unspecified")
+	}
+	for i, policy := range config.Policies {
+		if err := policy.validate(); err != nil {
+			return fmt.Errorf("Policies[%d] invalid: %w", i, err)
+		}
+	}
+	if criteria := config.Policies[len(config.Policies)-1].PolicyCriteria; criteria != (PolicyCriteria{}) {
+		return errors.New("Policies invalid: final policy must have no criteria")
+	}
+	if config.FlushInterval <= 0 {
+		return errors.New("FlushInterval unspecified or negative")
+	}
+	return nil
+}
+
+func (p Policy) validate() error {
+	if p.SampleRate < 0 || p.SampleRate > 1 {
+		return fmt.Errorf("SampleRate (%v) outside range [0,1]", p.SampleRate)
+	}
+	return nil
+}
+
+// match reports whether the root transaction event matches the
+// policy criteria.
+func (c PolicyCriteria) match(event *model.APMEvent) bool {
+	if c.ServiceName != "" && c.ServiceName != event.Service.Name {
+		return false
+	}
+	if c.ServiceEnvironment != "" && c.ServiceEnvironment != event.Service.Environment {
+		return false
+	}
+	if c.TraceName != "" && c.TraceName != event.Transaction.Name {
+		return false
+	}
+	if c.TraceOutcome != "" && c.TraceOutcome != event.Event.Outcome {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sampling
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigInvalid(t *testing.T) {
+	storage := NewMemoryStorage(time.Minute, 0)
+	type test struct {
+		config Config
+		err    string
+	}
+	for _, test := range []test{{
+		config: Config{},
+		err:    "Storage unspecified",
+	}, {
+		config: Config{Storage: storage},
+		err:    "Policies unspecified",
+	}, {
+		config: Config{Storage: storage, Policies: []Policy{{SampleRate: 1.5}}},
+		err:    "Policies[0] invalid: SampleRate (1.5) outside range [0,1]",
+	}, {
+		config: Config{Storage: storage, Policies: []Policy{{
+			PolicyCriteria: PolicyCriteria{ServiceName: "foo"},
+			SampleRate:     1,
+		}}},
+		err: "Policies invalid: final policy must have no criteria",
+	}, {
+		config: Config{Storage: storage, Policies: []Policy{{SampleRate: 1}}},
+		err:    "FlushInterval unspecified or negative",
+	}} {
+		err := test.config.Validate()
+		assert.EqualError(t, err, test.err)
+	}
+}
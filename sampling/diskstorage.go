@@ -0,0 +1,274 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sampling
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/elastic/apm-data/model"
+)
+
+// DiskStorage is a Storage which buffers trace events on disk, with a
+// file per trace, and holds sampling decisions in memory.
+//
+// Trace files are named by the hex-encoded SHA-256 hash of the trace
+// ID, as trace IDs are not guaranteed to be valid file names, and may
+// be too long to use as file names even when encoded.
+//
+// Events are encoded in the protobuf wire format described by
+// model/apmevent.proto, each prefixed by its varint-encoded length.
+type DiskStorage struct {
+	dir      string
+	ttl      time.Duration
+	maxBytes int64
+	now      func() time.Time
+
+	mu        sync.Mutex
+	traces    map[string]diskTrace // keyed by trace file name
+	size      int64                // total size of trace files
+	decisions map[string]memoryDecision
+}
+
+type diskTrace struct {
+	created time.Time
+	size    int64
+}
+
+// NewDiskStorage returns a new DiskStorage which buffers trace events
+// in files under dir, creating it if necessary. Trace events and
+// sampling decisions expire after ttl.
+//
+// At most maxBytes bytes of trace events are buffered at any time,
+// across all traces; once reached, WriteTraceEvent returns
+// ErrLimitReached until buffered events are deleted. If maxBytes is
+// zero, the size of buffered events is unlimited.
+//
+// Trace events buffered in dir by a previous DiskStorage are retained,
+// count towards maxBytes, and expire ttl after their files were last
+// modified.
+func NewDiskStorage(dir string, ttl time.Duration, maxBytes int64) (*DiskStorage, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	s := &DiskStorage{
+		dir:       dir,
+		ttl:       ttl,
+		maxBytes:  maxBytes,
+		now:       time.Now,
+		traces:    make(map[string]diskTrace),
+		decisions: make(map[string]memoryDecision),
+	}
+	for _, entry := range entries {
+		if !isTraceFileName(entry.Name()) || !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		s.traces[entry.Name()] = diskTrace{created: info.ModTime(), size: info.Size()}
+		s.size += info.Size()
+	}
+	return s, nil
+}
+
+// WriteTraceEvent appends event to the trace's file.
+func (s *DiskStorage) WriteTraceEvent(traceID string, event *model.APMEvent) error {
+	data, err := event.MarshalProto()
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, binary.MaxVarintLen64+len(data))
+	n := binary.PutUvarint(buf, uint64(len(data)))
+	n += copy(buf[n:], data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := traceFileName(traceID)
+	trace, ok := s.traces[name]
+	if ok && s.expired(trace.created) {
+		if err := s.deleteTraceFile(name); err != nil {
+			return err
+		}
+		ok = false
+	}
+	if s.maxBytes > 0 && s.size+int64(n) > s.maxBytes {
+		return ErrLimitReached
+	}
+	flag := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if !ok {
+		flag |= os.O_TRUNC
+		trace = diskTrace{created: s.now()}
+	}
+	f, err := os.OpenFile(filepath.Join(s.dir, name), flag, 0600)
+	if err != nil {
+		return err
+	}
+	// Account for the file even if writing fails, as part of
+	// the record may have been written, so that it is deleted.
+	written, err := f.Write(buf[:n])
+	trace.size += int64(written)
+	s.size += int64(written)
+	s.traces[name] = trace
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadTraceEvents decodes the events in the trace's file, appending
+// them to out.
+func (s *DiskStorage) ReadTraceEvents(traceID string, out *model.Batch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := traceFileName(traceID)
+	if trace, ok := s.traces[name]; !ok || s.expired(trace.created) {
+		return nil
+	}
+	f, err := os.Open(filepath.Join(s.dir, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	r := bufio.NewReader(f)
+	var data []byte
+	for {
+		size, err := binary.ReadUvarint(r)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("error reading trace %q: %w", traceID, err)
+		}
+		if size > uint64(info.Size()) {
+			// The length prefix is corrupt: no record can be
+			// larger than the file containing it.
+			return fmt.Errorf("error reading trace %q: invalid event size %d", traceID, size)
+		}
+		if uint64(cap(data)) < size {
+			data = make([]byte, size)
+		}
+		data = data[:size]
+		if _, err := io.ReadFull(r, data); err != nil {
+			return fmt.Errorf("error reading trace %q: %w", traceID, err)
+		}
+		var event model.APMEvent
+		if err := event.UnmarshalProto(data); err != nil {
+			return fmt.Errorf("error decoding trace %q event: %w", traceID, err)
+		}
+		*out = append(*out, event)
+	}
+}
+
+// DeleteTraceEvents deletes the trace's file.
+func (s *DiskStorage) DeleteTraceEvents(traceID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.deleteTraceFile(traceFileName(traceID))
+}
+
+func (s *DiskStorage) deleteTraceFile(name string) error {
+	trace, ok := s.traces[name]
+	if !ok {
+		return nil
+	}
+	delete(s.traces, name)
+	s.size -= trace.size
+	if err := os.Remove(filepath.Join(s.dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// WriteTraceSampled records the trace's sampling decision.
+func (s *DiskStorage) WriteTraceSampled(traceID string, sampled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.decisions[traceID] = memoryDecision{created: s.now(), sampled: sampled}
+	return nil
+}
+
+// IsTraceSampled returns the trace's sampling decision.
+func (s *DiskStorage) IsTraceSampled(traceID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	decision, ok := s.decisions[traceID]
+	if !ok || s.expired(decision.created) {
+		return false, ErrNotFound
+	}
+	return decision.sampled, nil
+}
+
+// DeleteExpired deletes the files of expired traces, and expired
+// sampling decisions.
+func (s *DiskStorage) DeleteExpired() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var firstErr error
+	for name, trace := range s.traces {
+		if s.expired(trace.created) {
+			if err := s.deleteTraceFile(name); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	for traceID, decision := range s.decisions {
+		if s.expired(decision.created) {
+			delete(s.decisions, traceID)
+		}
+	}
+	return firstErr
+}
+
+func (s *DiskStorage) expired(created time.Time) bool {
+	return s.now().Sub(created) >= s.ttl
+}
+
+// traceFileName returns the name of the trace's file.
+func traceFileName(traceID string) string {
+	sum := sha256.Sum256([]byte(traceID))
+	return hex.EncodeToString(sum[:])
+}
+
+// isTraceFileName reports whether name is a name returned by
+// traceFileName.
+func isTraceFileName(name string) bool {
+	if len(name) != hex.EncodedLen(sha256.Size) {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil
+}
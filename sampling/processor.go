@@ -0,0 +1,253 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package sampling provides a model.BatchProcessor for tail-based
+// sampling: buffering the events of traces until their root transaction
+// is observed, and then sampling or dropping the whole trace according
+// to policies evaluated against the root transaction.
+package sampling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/elastic/apm-data/model"
+)
+
+// Processor is a tail-sampling model.BatchProcessor.
+//
+// Transaction and span events are buffered in Storage, keyed by trace
+// ID, until the trace's root transaction is processed. The root
+// transaction is then matched against the configured policies, and the
+// trace is sampled according to the first matching policy's sample
+// rate. If the trace is sampled, its buffered events are released into
+// the batch containing the root transaction; otherwise they are dropped.
+//
+// Sampling decisions are recorded in Storage, so that events of the
+// trace processed after the root transaction are published or dropped
+// immediately. Trace events and sampling decisions expire after the
+// Storage's TTL, after which events of undecided traces are dropped.
+//
+// Sampling decisions are made deterministically from trace IDs, so
+// that multiple processors with the same policies make the same
+// decisions for a trace.
+//
+// Events other than transactions and spans, and events with no trace
+// ID, are not modified.
+type Processor struct {
+	config Config
+
+	stopMu   sync.Mutex
+	stopping chan struct{}
+	stopped  chan struct{}
+
+	// mu serialises processing, ensuring trace events are not written
+	// after the trace's sampling decision has been made.
+	mu sync.Mutex
+}
+
+// NewProcessor returns a new Processor with the given config.
+func NewProcessor(config Config) (*Processor, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid tail-sampling config: %w", err)
+	}
+	if config.Logger == nil {
+		config.Logger = zap.NewNop()
+	} else {
+		config.Logger = config.Logger.Named("sampling")
+	}
+	return &Processor{
+		config:   config,
+		stopping: make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}, nil
+}
+
+// Run runs the Processor, periodically deleting expired trace events
+// and sampling decisions from storage. Run returns when the
+// Processor's Stop method is invoked.
+func (p *Processor) Run() error {
+	ticker := time.NewTicker(p.config.FlushInterval)
+	defer ticker.Stop()
+	defer close(p.stopped)
+	for {
+		select {
+		case <-p.stopping:
+			return nil
+		case <-ticker.C:
+		}
+		if err := p.config.Storage.DeleteExpired(); err != nil {
+			p.config.Logger.Warn("deleting expired trace events failed", zap.Error(err))
+		}
+	}
+}
+
+// Stop stops the Processor if running, waiting for Run to return or
+// for ctx to be canceled, whichever happens first.
+//
+// Events buffered for undecided traces are left in storage.
+func (p *Processor) Stop(ctx context.Context) error {
+	p.stopMu.Lock()
+	select {
+	case <-p.stopping:
+	default:
+		close(p.stopping)
+	}
+	p.stopMu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.stopped:
+	}
+	return nil
+}
+
+// ProcessBatch tail-samples transactions and spans in b.
+//
+// Events of sampled traces are retained in b, and the buffered events
+// of traces sampled by root transactions in b are appended to b.
+// Events of unsampled traces are removed from b, as are events of
+// undecided traces, which are buffered in storage. If the storage limit
+// has been reached, events of undecided traces are instead retained in
+// b. The order of the retained events is preserved.
+//
+// If an error is returned, the events preceding the failing event have
+// been processed as described above, while the failing event and those
+// following it are retained in b unprocessed. Buffered events released
+// before the error are still appended to b, so that no events are lost
+// or duplicated.
+func (p *Processor) ProcessBatch(ctx context.Context, b *model.Batch) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	keep := make([]bool, len(*b))
+	var released model.Batch
+	var err error
+	for i := range *b {
+		if keep[i], err = p.processEvent(&(*b)[i], &released); err != nil {
+			for ; i < len(keep); i++ {
+				keep[i] = true
+			}
+			break
+		}
+	}
+	events := (*b)[:0]
+	for i := range *b {
+		if keep[i] {
+			events = append(events, (*b)[i])
+		}
+	}
+	// Zero the remainder so dropped events may be garbage collected.
+	for i := len(events); i < len(*b); i++ {
+		(*b)[i] = model.APMEvent{}
+	}
+	*b = append(events, released...)
+	return err
+}
+
+// processEvent processes a single event, reporting whether it should be
+// kept in the batch. Buffered events released by sampling a trace are
+// appended to released.
+func (p *Processor) processEvent(event *model.APMEvent, released *model.Batch) (bool, error) {
+	traceID := event.Trace.ID
+	switch {
+	case traceID == "":
+		return true, nil
+	case event.Processor == model.TransactionProcessor && event.Transaction != nil:
+	case event.Processor == model.SpanProcessor && event.Span != nil:
+	default:
+		return true, nil
+	}
+
+	sampled, err := p.config.Storage.IsTraceSampled(traceID)
+	switch {
+	case err == nil:
+		return sampled, nil
+	case !errors.Is(err, ErrNotFound):
+		return false, fmt.Errorf("error reading sampling decision: %w", err)
+	}
+
+	if event.Processor != model.TransactionProcessor || event.Parent.ID != "" {
+		// The trace's root transaction has not been processed yet,
+		// so buffer the event until a sampling decision is made.
+		if err := p.config.Storage.WriteTraceEvent(traceID, event); err != nil {
+			if errors.Is(err, ErrLimitReached) {
+				// The event cannot be buffered, so keep it
+				// rather than dropping it.
+				p.config.Logger.Debug("storage limit reached, keeping event", zap.String("trace.id", traceID))
+				return true, nil
+			}
+			return false, fmt.Errorf("error writing trace event: %w", err)
+		}
+		return false, nil
+	}
+
+	sampled = p.sample(event)
+	if err := p.config.Storage.WriteTraceSampled(traceID, sampled); err != nil {
+		return false, fmt.Errorf("error writing sampling decision: %w", err)
+	}
+	if sampled {
+		// Read into a separate batch, so that no events are
+		// released if reading fails part way through.
+		var events model.Batch
+		if err := p.config.Storage.ReadTraceEvents(traceID, &events); err != nil {
+			return false, fmt.Errorf("error reading trace events: %w", err)
+		}
+		*released = append(*released, events...)
+	}
+	if err := p.config.Storage.DeleteTraceEvents(traceID); err != nil {
+		// The released events will not be released again,
+		// as the sampling decision has been recorded.
+		return false, fmt.Errorf("error deleting trace events: %w", err)
+	}
+	return sampled, nil
+}
+
+// sample makes a sampling decision for the trace of event, a root
+// transaction, using the sample rate of the first matching policy.
+func (p *Processor) sample(event *model.APMEvent) bool {
+	for _, policy := range p.config.Policies {
+		if policy.match(event) {
+			return traceIDRatio(event.Trace.ID) < policy.SampleRate
+		}
+	}
+	return false
+}
+
+// traceIDRatio maps a trace ID to a value uniformly distributed in
+// the range [0,1).
+func traceIDRatio(traceID string) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(traceID))
+	// FNV-1a's high bits are poorly distributed for inputs differing
+	// only in their final bytes, so mix them with the MurmurHash3
+	// 64-bit finalizer.
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return float64(x>>11) / (1 << 53)
+}
@@ -0,0 +1,266 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sampling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/apm-data/model"
+)
+
+func TestProcessorSampleTrace(t *testing.T) {
+	for _, sampled := range []bool{true, false} {
+		t.Run(fmt.Sprint(sampled), func(t *testing.T) {
+			sampleRate := 0.0
+			if sampled {
+				sampleRate = 1
+			}
+			processor := newTestProcessor(t, Policy{SampleRate: sampleRate})
+
+			span := newSpan("trace_id", "span_id", "root_id")
+			child := newTransaction("trace_id", "child_id", "span_id")
+			root := newTransaction("trace_id", "root_id", "")
+			metricset := model.APMEvent{
+				Trace:     model.Trace{ID: "trace_id"},
+				Processor: model.MetricsetProcessor,
+				Metricset: &model.Metricset{Name: "app"},
+			}
+
+			// Events of undecided traces are buffered, and
+			// non-trace events are left alone.
+			batch := model.Batch{span, metricset, child}
+			require.NoError(t, processor.ProcessBatch(context.Background(), &batch))
+			assert.Equal(t, model.Batch{metricset}, batch)
+
+			// The root transaction decides the trace, releasing the
+			// buffered events if sampled.
+			batch = model.Batch{root}
+			require.NoError(t, processor.ProcessBatch(context.Background(), &batch))
+			if sampled {
+				assert.Equal(t, model.Batch{root, span, child}, batch)
+			} else {
+				assert.Empty(t, batch)
+			}
+
+			// Events of decided traces are kept or dropped immediately.
+			late := newSpan("trace_id", "late_id", "root_id")
+			batch = model.Batch{late}
+			require.NoError(t, processor.ProcessBatch(context.Background(), &batch))
+			if sampled {
+				assert.Equal(t, model.Batch{late}, batch)
+			} else {
+				assert.Empty(t, batch)
+			}
+		})
+	}
+}
+
+func TestProcessorPolicies(t *testing.T) {
+	processor := newTestProcessor(t, Policy{
+		PolicyCriteria: PolicyCriteria{TraceOutcome: "failure"},
+		SampleRate:     1,
+	}, Policy{
+		PolicyCriteria: PolicyCriteria{ServiceName: "service", ServiceEnvironment: "production", TraceName: "GET /"},
+		SampleRate:     1,
+	}, Policy{
+		SampleRate: 0,
+	})
+
+	root := func(traceID, name, environment, outcome string) model.APMEvent {
+		event := newTransaction(traceID, "transaction_id", "")
+		event.Transaction.Name = name
+		event.Service.Environment = environment
+		event.Event.Outcome = outcome
+		return event
+	}
+	batch := model.Batch{
+		root("1", "GET /", "production", "success"),
+		root("2", "GET /", "staging", "success"),
+		root("3", "POST /", "production", "success"),
+		root("4", "POST /", "staging", "failure"),
+	}
+	require.NoError(t, processor.ProcessBatch(context.Background(), &batch))
+	var traceIDs []string
+	for _, event := range batch {
+		traceIDs = append(traceIDs, event.Trace.ID)
+	}
+	assert.Equal(t, []string{"1", "4"}, traceIDs)
+}
+
+func TestProcessorSampleRate(t *testing.T) {
+	const n = 10000
+	const sampleRate = 0.25
+	processor := newTestProcessor(t, Policy{SampleRate: sampleRate})
+
+	batch := make(model.Batch, n)
+	for i := range batch {
+		batch[i] = newTransaction(fmt.Sprintf("%032x", i), "transaction_id", "")
+	}
+	require.NoError(t, processor.ProcessBatch(context.Background(), &batch))
+	assert.InDelta(t, n*sampleRate, len(batch), n*0.02)
+
+	// Sampling decisions are deterministic for a given trace ID.
+	other := newTestProcessor(t, Policy{SampleRate: sampleRate})
+	for _, event := range batch {
+		assert.True(t, other.sample(&event))
+	}
+}
+
+func TestProcessorExpiry(t *testing.T) {
+	storage := NewMemoryStorage(time.Minute, 0)
+	processor, err := NewProcessor(Config{
+		Storage:       storage,
+		Policies:      []Policy{{SampleRate: 1}},
+		FlushInterval: time.Millisecond,
+	})
+	require.NoError(t, err)
+	go processor.Run()
+	defer processor.Stop(context.Background())
+
+	batch := model.Batch{newSpan("trace_id", "span_id", "root_id")}
+	require.NoError(t, processor.ProcessBatch(context.Background(), &batch))
+	assert.Empty(t, batch)
+
+	// Once expired, buffered events are deleted by Run, and are
+	// not released by the root transaction.
+	storage.mu.Lock()
+	storage.now = func() time.Time { return time.Now().Add(time.Minute) }
+	storage.mu.Unlock()
+	assert.Eventually(t, func() bool {
+		storage.mu.Lock()
+		defer storage.mu.Unlock()
+		return len(storage.events) == 0
+	}, 10*time.Second, time.Millisecond)
+
+	storage.mu.Lock()
+	storage.now = time.Now
+	storage.mu.Unlock()
+	root := newTransaction("trace_id", "root_id", "")
+	batch = model.Batch{root}
+	require.NoError(t, processor.ProcessBatch(context.Background(), &batch))
+	assert.Equal(t, model.Batch{root}, batch)
+}
+
+func TestProcessorStorageLimit(t *testing.T) {
+	processor, err := NewProcessor(Config{
+		Storage:       NewMemoryStorage(time.Minute, 1),
+		Policies:      []Policy{{SampleRate: 0}},
+		FlushInterval: time.Minute,
+	})
+	require.NoError(t, err)
+
+	// Events of undecided traces which cannot be buffered are kept.
+	span1 := newSpan("trace_id", "span1_id", "root_id")
+	span2 := newSpan("trace_id", "span2_id", "root_id")
+	batch := model.Batch{span1, span2}
+	require.NoError(t, processor.ProcessBatch(context.Background(), &batch))
+	assert.Equal(t, model.Batch{span2}, batch)
+}
+
+func TestProcessorStorageError(t *testing.T) {
+	storage := errorStorage{
+		Storage:      NewMemoryStorage(time.Minute, 0),
+		errorTraceID: "error_trace_id",
+	}
+	processor, err := NewProcessor(Config{
+		Storage:       storage,
+		Policies:      []Policy{{SampleRate: 1}},
+		FlushInterval: time.Minute,
+	})
+	require.NoError(t, err)
+
+	buffered := newSpan("sampled_trace_id", "buffered_id", "root_id")
+	require.NoError(t, storage.WriteTraceEvent("sampled_trace_id", &buffered))
+
+	// Events preceding the failing event are processed, and those
+	// released are appended; the failing event and those following
+	// it are retained unprocessed.
+	batch := model.Batch{
+		newSpan("trace_id", "span_id", "root_id"),
+		newTransaction("sampled_trace_id", "root_id", ""),
+		newSpan("error_trace_id", "span_id", "root_id"),
+		newSpan("other_trace_id", "span_id", "root_id"),
+	}
+	err = processor.ProcessBatch(context.Background(), &batch)
+	assert.EqualError(t, err, "error writing trace event: storage error")
+	assert.Equal(t, model.Batch{
+		newTransaction("sampled_trace_id", "root_id", ""),
+		newSpan("error_trace_id", "span_id", "root_id"),
+		newSpan("other_trace_id", "span_id", "root_id"),
+		buffered,
+	}, batch)
+
+	// The buffered event was released, and is not released again,
+	// while the event preceding the failure remains buffered.
+	var stored model.Batch
+	require.NoError(t, storage.ReadTraceEvents("sampled_trace_id", &stored))
+	assert.Empty(t, stored)
+	require.NoError(t, storage.ReadTraceEvents("trace_id", &stored))
+	assert.Equal(t, model.Batch{newSpan("trace_id", "span_id", "root_id")}, stored)
+}
+
+// errorStorage is a Storage which fails to write
+// events of the trace with ID errorTraceID.
+type errorStorage struct {
+	Storage
+	errorTraceID string
+}
+
+func (s errorStorage) WriteTraceEvent(traceID string, event *model.APMEvent) error {
+	if traceID == s.errorTraceID {
+		return errors.New("storage error")
+	}
+	return s.Storage.WriteTraceEvent(traceID, event)
+}
+
+func newTestProcessor(t testing.TB, policies ...Policy) *Processor {
+	processor, err := NewProcessor(Config{
+		Storage:       NewMemoryStorage(time.Minute, 0),
+		Policies:      policies,
+		FlushInterval: time.Minute,
+	})
+	require.NoError(t, err)
+	return processor
+}
+
+func newTransaction(traceID, id, parentID string) model.APMEvent {
+	return model.APMEvent{
+		Service:     model.Service{Name: "service"},
+		Trace:       model.Trace{ID: traceID},
+		Parent:      model.Parent{ID: parentID},
+		Processor:   model.TransactionProcessor,
+		Transaction: &model.Transaction{ID: id, Sampled: true, RepresentativeCount: 1},
+	}
+}
+
+func newSpan(traceID, id, parentID string) model.APMEvent {
+	return model.APMEvent{
+		Service:   model.Service{Name: "service"},
+		Trace:     model.Trace{ID: traceID},
+		Parent:    model.Parent{ID: parentID},
+		Processor: model.SpanProcessor,
+		Span:      &model.Span{ID: id, RepresentativeCount: 1},
+	}
+}
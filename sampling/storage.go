@@ -0,0 +1,198 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sampling
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/elastic/apm-data/model"
+)
+
+// ErrNotFound is returned by Storage.IsTraceSampled when no sampling
+// decision has been recorded for a trace.
+var ErrNotFound = errors.New("key not found")
+
+// ErrLimitReached is returned by Storage.WriteTraceEvent when the
+// storage has reached its limit, and cannot buffer any more events.
+var ErrLimitReached = errors.New("storage limit reached")
+
+// Storage provides local storage for buffering the events of traces
+// pending a sampling decision, and for recording sampling decisions.
+//
+// Trace events and sampling decisions expire after a TTL, specified
+// when the storage is created. Expired entries must not be observable,
+// and are deleted by calls to DeleteExpired.
+//
+// Storage implementations must be safe for concurrent use.
+type Storage interface {
+	// WriteTraceEvent buffers a copy of event, belonging to the
+	// trace with the given ID. If the storage is full, WriteTraceEvent
+	// returns ErrLimitReached.
+	WriteTraceEvent(traceID string, event *model.APMEvent) error
+
+	// ReadTraceEvents appends all buffered events of the trace with
+	// the given ID to out.
+	ReadTraceEvents(traceID string, out *model.Batch) error
+
+	// DeleteTraceEvents deletes all buffered events of the trace with
+	// the given ID.
+	DeleteTraceEvents(traceID string) error
+
+	// WriteTraceSampled records the sampling decision for the trace
+	// with the given ID.
+	WriteTraceSampled(traceID string, sampled bool) error
+
+	// IsTraceSampled returns the sampling decision recorded for the
+	// trace with the given ID, or ErrNotFound if there is none.
+	IsTraceSampled(traceID string) (bool, error)
+
+	// DeleteExpired deletes expired trace events and sampling
+	// decisions.
+	DeleteExpired() error
+}
+
+// MemoryStorage is a Storage which holds trace events and sampling
+// decisions in memory.
+type MemoryStorage struct {
+	ttl       time.Duration
+	maxEvents int
+	now       func() time.Time
+
+	mu        sync.Mutex
+	numEvents int
+	events    map[string]*memoryTrace
+	decisions map[string]memoryDecision
+}
+
+type memoryTrace struct {
+	created time.Time
+	events  model.Batch
+}
+
+type memoryDecision struct {
+	created time.Time
+	sampled bool
+}
+
+// NewMemoryStorage returns a new MemoryStorage, whose trace events and
+// sampling decisions expire after ttl.
+//
+// At most maxEvents trace events are buffered at any time, across all
+// traces; once reached, WriteTraceEvent returns ErrLimitReached until
+// buffered events are deleted. If maxEvents is zero, the number of
+// buffered events is unlimited.
+func NewMemoryStorage(ttl time.Duration, maxEvents int) *MemoryStorage {
+	return &MemoryStorage{
+		ttl:       ttl,
+		maxEvents: maxEvents,
+		now:       time.Now,
+		events:    make(map[string]*memoryTrace),
+		decisions: make(map[string]memoryDecision),
+	}
+}
+
+// WriteTraceEvent buffers a copy of event in memory.
+//
+// Only the event itself is copied: the caller must not modify any
+// data referenced by the event after calling WriteTraceEvent.
+func (s *MemoryStorage) WriteTraceEvent(traceID string, event *model.APMEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	trace, ok := s.events[traceID]
+	if ok && s.expired(trace.created) {
+		s.deleteTraceEvents(traceID)
+		ok = false
+	}
+	if s.maxEvents > 0 && s.numEvents >= s.maxEvents {
+		return ErrLimitReached
+	}
+	if !ok {
+		trace = &memoryTrace{created: s.now()}
+		s.events[traceID] = trace
+	}
+	trace.events = append(trace.events, *event)
+	s.numEvents++
+	return nil
+}
+
+// ReadTraceEvents appends the trace's buffered events to out.
+func (s *MemoryStorage) ReadTraceEvents(traceID string, out *model.Batch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if trace, ok := s.events[traceID]; ok && !s.expired(trace.created) {
+		*out = append(*out, trace.events...)
+	}
+	return nil
+}
+
+// DeleteTraceEvents deletes the trace's buffered events.
+func (s *MemoryStorage) DeleteTraceEvents(traceID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleteTraceEvents(traceID)
+	return nil
+}
+
+func (s *MemoryStorage) deleteTraceEvents(traceID string) {
+	if trace, ok := s.events[traceID]; ok {
+		s.numEvents -= len(trace.events)
+		delete(s.events, traceID)
+	}
+}
+
+// WriteTraceSampled records the trace's sampling decision.
+func (s *MemoryStorage) WriteTraceSampled(traceID string, sampled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.decisions[traceID] = memoryDecision{created: s.now(), sampled: sampled}
+	return nil
+}
+
+// IsTraceSampled returns the trace's sampling decision.
+func (s *MemoryStorage) IsTraceSampled(traceID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	decision, ok := s.decisions[traceID]
+	if !ok || s.expired(decision.created) {
+		return false, ErrNotFound
+	}
+	return decision.sampled, nil
+}
+
+// DeleteExpired deletes expired trace events and sampling decisions.
+func (s *MemoryStorage) DeleteExpired() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for traceID, trace := range s.events {
+		if s.expired(trace.created) {
+			s.deleteTraceEvents(traceID)
+		}
+	}
+	for traceID, decision := range s.decisions {
+		if s.expired(decision.created) {
+			delete(s.decisions, traceID)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStorage) expired(created time.Time) bool {
+	return s.now().Sub(created) >= s.ttl
+}
@@ -0,0 +1,227 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sampling
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/apm-data/model"
+)
+
+func TestStorage(t *testing.T) {
+	for name, newStorage := range map[string]func(t *testing.T, now func() time.Time) Storage{
+		"memory": func(t *testing.T, now func() time.Time) Storage {
+			s := NewMemoryStorage(time.Minute, 0)
+			s.now = now
+			return s
+		},
+		"disk": func(t *testing.T, now func() time.Time) Storage {
+			s, err := NewDiskStorage(t.TempDir(), time.Minute, 0)
+			require.NoError(t, err)
+			s.now = now
+			return s
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			now := time.Unix(1000, 0)
+			s := newStorage(t, func() time.Time { return now })
+
+			transaction := model.APMEvent{
+				Trace:       model.Trace{ID: "trace_id"},
+				Processor:   model.TransactionProcessor,
+				Transaction: &model.Transaction{ID: "transaction_id"},
+			}
+			span := model.APMEvent{
+				Trace:     model.Trace{ID: "trace_id"},
+				Processor: model.SpanProcessor,
+				Span:      &model.Span{ID: "span_id"},
+			}
+			require.NoError(t, s.WriteTraceEvent("trace_id", &transaction))
+			require.NoError(t, s.WriteTraceEvent("trace_id", &span))
+			require.NoError(t, s.WriteTraceEvent("other/trace", &span))
+
+			var batch model.Batch
+			require.NoError(t, s.ReadTraceEvents("trace_id", &batch))
+			assert.Equal(t, model.Batch{transaction, span}, batch)
+
+			_, err := s.IsTraceSampled("trace_id")
+			assert.Equal(t, ErrNotFound, err)
+			require.NoError(t, s.WriteTraceSampled("trace_id", true))
+			require.NoError(t, s.WriteTraceSampled("other/trace", false))
+			sampled, err := s.IsTraceSampled("trace_id")
+			require.NoError(t, err)
+			assert.True(t, sampled)
+			sampled, err = s.IsTraceSampled("other/trace")
+			require.NoError(t, err)
+			assert.False(t, sampled)
+
+			require.NoError(t, s.DeleteTraceEvents("trace_id"))
+			batch = batch[:0]
+			require.NoError(t, s.ReadTraceEvents("trace_id", &batch))
+			assert.Empty(t, batch)
+			require.NoError(t, s.ReadTraceEvents("other/trace", &batch))
+			assert.Len(t, batch, 1)
+
+			// Trace events and sampling decisions are not observable
+			// once expired, even before they are deleted.
+			now = now.Add(time.Minute)
+			batch = batch[:0]
+			require.NoError(t, s.ReadTraceEvents("other/trace", &batch))
+			assert.Empty(t, batch)
+			_, err = s.IsTraceSampled("trace_id")
+			assert.Equal(t, ErrNotFound, err)
+			require.NoError(t, s.DeleteExpired())
+
+			// Writing to an expired trace starts it afresh.
+			require.NoError(t, s.WriteTraceEvent("other/trace", &transaction))
+			require.NoError(t, s.ReadTraceEvents("other/trace", &batch))
+			assert.Equal(t, model.Batch{transaction}, batch)
+		})
+	}
+}
+
+func TestMemoryStorageLimit(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := NewMemoryStorage(time.Minute, 2)
+	s.now = func() time.Time { return now }
+
+	event := model.APMEvent{Processor: model.SpanProcessor, Span: &model.Span{ID: "span_id"}}
+	require.NoError(t, s.WriteTraceEvent("trace1", &event))
+	require.NoError(t, s.WriteTraceEvent("trace2", &event))
+	assert.Equal(t, ErrLimitReached, s.WriteTraceEvent("trace3", &event))
+	assert.Equal(t, ErrLimitReached, s.WriteTraceEvent("trace1", &event))
+
+	// Deleting buffered events makes room for more.
+	require.NoError(t, s.DeleteTraceEvents("trace1"))
+	require.NoError(t, s.WriteTraceEvent("trace3", &event))
+	assert.Equal(t, ErrLimitReached, s.WriteTraceEvent("trace3", &event))
+
+	// Expired events do not count towards the limit.
+	now = now.Add(time.Minute)
+	require.NoError(t, s.WriteTraceEvent("trace3", &event))
+	require.NoError(t, s.DeleteExpired())
+	require.NoError(t, s.WriteTraceEvent("trace4", &event))
+	assert.Equal(t, ErrLimitReached, s.WriteTraceEvent("trace5", &event))
+}
+
+func TestDiskStorageLimit(t *testing.T) {
+	dir := t.TempDir()
+	event := model.APMEvent{Processor: model.SpanProcessor, Span: &model.Span{ID: "span_id"}}
+	data, err := event.MarshalProto()
+	require.NoError(t, err)
+	recordSize := int64(len(data) + 1) // 1 byte length prefix
+
+	// Files written by a previous DiskStorage expire relative to their
+	// modification time, so the clock must start at the current time.
+	now := time.Now()
+	s, err := NewDiskStorage(dir, time.Minute, 2*recordSize)
+	require.NoError(t, err)
+	s.now = func() time.Time { return now }
+	require.NoError(t, s.WriteTraceEvent("trace1", &event))
+	require.NoError(t, s.WriteTraceEvent("trace2", &event))
+	assert.Equal(t, ErrLimitReached, s.WriteTraceEvent("trace3", &event))
+	assert.Equal(t, ErrLimitReached, s.WriteTraceEvent("trace1", &event))
+
+	// Deleting buffered events makes room for more.
+	require.NoError(t, s.DeleteTraceEvents("trace1"))
+	require.NoError(t, s.WriteTraceEvent("trace3", &event))
+	assert.Equal(t, ErrLimitReached, s.WriteTraceEvent("trace3", &event))
+
+	// Files written by a previous DiskStorage count towards the limit.
+	s, err = NewDiskStorage(dir, time.Minute, 2*recordSize)
+	require.NoError(t, err)
+	s.now = func() time.Time { return now }
+	assert.Equal(t, ErrLimitReached, s.WriteTraceEvent("trace4", &event))
+
+	// Expired events do not count towards the limit.
+	now = now.Add(time.Hour)
+	require.NoError(t, s.WriteTraceEvent("trace3", &event))
+	require.NoError(t, s.DeleteExpired())
+	require.NoError(t, s.WriteTraceEvent("trace4", &event))
+	assert.Equal(t, ErrLimitReached, s.WriteTraceEvent("trace5", &event))
+}
+
+func TestDiskStorageCorruptFile(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewDiskStorage(dir, time.Minute, 0)
+	require.NoError(t, err)
+	event := model.APMEvent{Processor: model.SpanProcessor, Span: &model.Span{ID: "span_id"}}
+	require.NoError(t, s.WriteTraceEvent("trace_id", &event))
+
+	// A corrupt length prefix must not cause a huge allocation.
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, 1<<62)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, traceFileName("trace_id")), buf[:n], 0600))
+	var batch model.Batch
+	err = s.ReadTraceEvents("trace_id", &batch)
+	assert.EqualError(t, err, `error reading trace "trace_id": invalid event size 4611686018427387904`)
+	assert.Empty(t, batch)
+}
+
+func TestDiskStorageReopen(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewDiskStorage(dir, time.Minute, 0)
+	require.NoError(t, err)
+	event := model.APMEvent{
+		Trace:     model.Trace{ID: "trace_id"},
+		Processor: model.SpanProcessor,
+		Span:      &model.Span{ID: "span_id", Name: "span"},
+	}
+	require.NoError(t, s.WriteTraceEvent("trace_id", &event))
+
+	// Trace events written by a previous DiskStorage are retained.
+	s, err = NewDiskStorage(dir, time.Minute, 0)
+	require.NoError(t, err)
+	var batch model.Batch
+	require.NoError(t, s.ReadTraceEvents("trace_id", &batch))
+	assert.Equal(t, model.Batch{event}, batch)
+
+	s.now = func() time.Time { return time.Now().Add(time.Minute) }
+	require.NoError(t, s.DeleteExpired())
+	s, err = NewDiskStorage(dir, time.Minute, 0)
+	require.NoError(t, err)
+	batch = batch[:0]
+	require.NoError(t, s.ReadTraceEvents("trace_id", &batch))
+	assert.Empty(t, batch)
+}
+
+func TestDiskStorageLongTraceID(t *testing.T) {
+	s, err := NewDiskStorage(t.TempDir(), time.Minute, 0)
+	require.NoError(t, err)
+
+	// Trace IDs may be longer than the maximum file name length.
+	traceID := strings.Repeat("x", 1024)
+	event := model.APMEvent{
+		Trace:     model.Trace{ID: traceID},
+		Processor: model.SpanProcessor,
+		Span:      &model.Span{ID: "span_id"},
+	}
+	require.NoError(t, s.WriteTraceEvent(traceID, &event))
+	var batch model.Batch
+	require.NoError(t, s.ReadTraceEvents(traceID, &batch))
+	assert.Equal(t, model.Batch{event}, batch)
+	require.NoError(t, s.DeleteTraceEvents(traceID))
+}
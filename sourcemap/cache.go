@@ -0,0 +1,92 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sourcemap
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// cache is a fixed-size LRU cache of parsed source maps, with expiry.
+type cache struct {
+	mu         sync.Mutex
+	size       int
+	expiration time.Duration
+	ll         *list.List
+	entries    map[Identifier]*list.Element
+}
+
+type cacheEntry struct {
+	id        Identifier
+	sourceMap *SourceMap // nil if there is no source map
+	err       error      // non-nil if the source map is invalid
+	expires   time.Time
+}
+
+func newCache(size int, expiration time.Duration) *cache {
+	return &cache{
+		size:       size,
+		expiration: expiration,
+		ll:         list.New(),
+		entries:    make(map[Identifier]*list.Element),
+	}
+}
+
+// get returns the cached entry for id, if any and unexpired.
+func (c *cache) get(id Identifier, now time.Time) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !now.Before(entry.expires) {
+		c.ll.Remove(elem)
+		delete(c.entries, id)
+		return nil, false
+	}
+	c.ll.MoveToFront(elem)
+	return entry, true
+}
+
+// add adds an entry for id, evicting the least recently used
+// entry if the cache is full.
+func (c *cache) add(id Identifier, sourceMap *SourceMap, err error, now time.Time) *cacheEntry {
+	entry := &cacheEntry{
+		id:        id,
+		sourceMap: sourceMap,
+		err:       err,
+		expires:   now.Add(c.expiration),
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[id]; ok {
+		elem.Value = entry
+		c.ll.MoveToFront(elem)
+		return entry
+	}
+	c.entries[id] = c.ll.PushFront(entry)
+	for c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).id)
+	}
+	return entry
+}
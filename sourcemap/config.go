@@ -0,0 +1,58 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sourcemap
+
+import (
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Config holds configuration for creating a BatchProcessor.
+type Config struct {
+	// Fetcher holds the Fetcher used for fetching source maps
+	// which are not cached.
+	Fetcher Fetcher
+
+	// CacheSize holds the maximum number of parsed source maps
+	// to cache, including the absence of a source map for a bundle.
+	CacheSize int
+
+	// CacheExpiration holds the duration after which cached source
+	// maps expire, and are fetched again.
+	CacheExpiration time.Duration
+
+	// Logger holds a logger for the processor. If this is nil, then
+	// no logging will be performed.
+	Logger *zap.Logger
+}
+
+// Validate validates the source map config.
+func (config Config) Validate() error {
+	if config.Fetcher == nil {
+		return errors.New("Fetcher unspecified")
+	}
+	if config.CacheSize <= 0 {
+		return errors.New("CacheSize unspecified or negative")
+	}
+	if config.CacheExpiration <= 0 {
+		return errors.New("CacheExpiration unspecified or negative")
+	}
+	return nil
+}
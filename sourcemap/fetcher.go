@@ -0,0 +1,120 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sourcemap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is returned by Fetcher implementations when there
+// is no source map for the requested bundle.
+var ErrNotFound = errors.New("source map not found")
+
+// Identifier identifies the source map for a bundle of a service version.
+type Identifier struct {
+	// ServiceName and ServiceVersion hold the name and version of the
+	// service whose events reference the bundle.
+	ServiceName    string
+	ServiceVersion string
+
+	// BundleFilepath holds the path of the generated bundle, as it
+	// appears in stack frames' abs_path. This may be a full URL,
+	// or just the URL path.
+	BundleFilepath string
+}
+
+// Fetcher is an interface for fetching source maps.
+type Fetcher interface {
+	// Fetch returns the source map for the bundle identified by id,
+	// or ErrNotFound if there is none.
+	Fetch(ctx context.Context, id Identifier) ([]byte, error)
+}
+
+// MemoryFetcher is a Fetcher which holds source maps in memory.
+type MemoryFetcher struct {
+	mu   sync.RWMutex
+	maps map[Identifier][]byte
+}
+
+// NewMemoryFetcher returns a new, empty, MemoryFetcher.
+func NewMemoryFetcher() *MemoryFetcher {
+	return &MemoryFetcher{maps: make(map[Identifier][]byte)}
+}
+
+// Add adds or replaces the source map for the bundle identified by id.
+func (f *MemoryFetcher) Add(id Identifier, sourceMap []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.maps[id] = sourceMap
+}
+
+// Fetch returns the source map added for id, or ErrNotFound.
+func (f *MemoryFetcher) Fetch(ctx context.Context, id Identifier) ([]byte, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	sourceMap, ok := f.maps[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return sourceMap, nil
+}
+
+// DirectoryFetcher is a Fetcher which reads source maps from a local
+// directory, laid out as <Dir>/<service name>/<service version>/<bundle path>.map.
+//
+// Only the path of the bundle is used: bundles identified by full URLs
+// are looked up by the URL path.
+type DirectoryFetcher struct {
+	Dir string
+}
+
+// Fetch reads the source map for id from the directory, returning
+// ErrNotFound if there is no such file.
+func (f DirectoryFetcher) Fetch(ctx context.Context, id Identifier) ([]byte, error) {
+	for _, elem := range []string{id.ServiceName, id.ServiceVersion} {
+		if elem == "" || elem == "." || elem == ".." || strings.ContainsAny(elem, `/\`) {
+			return nil, ErrNotFound
+		}
+	}
+	bundlePath := bundleURLPath(id.BundleFilepath)
+	if bundlePath == "" {
+		return nil, ErrNotFound
+	}
+	// Cleaning the path with a leading slash ensures that
+	// it cannot refer to files outside the directory.
+	bundlePath = path.Clean("/" + bundlePath)
+	filename := filepath.Join(
+		f.Dir, id.ServiceName, id.ServiceVersion,
+		filepath.FromSlash(bundlePath)+".map",
+	)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to read source map: %w", err)
+	}
+	return data, nil
+}
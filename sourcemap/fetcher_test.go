@@ -0,0 +1,76 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sourcemap
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryFetcher(t *testing.T) {
+	fetcher := NewMemoryFetcher()
+	id := Identifier{ServiceName: "frontend", ServiceVersion: "1.0.0", BundleFilepath: testBundleURL}
+	_, err := fetcher.Fetch(context.Background(), id)
+	assert.Equal(t, ErrNotFound, err)
+
+	fetcher.Add(id, []byte("{}"))
+	data, err := fetcher.Fetch(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("{}"), data)
+}
+
+func TestDirectoryFetcher(t *testing.T) {
+	dir := t.TempDir()
+	bundleDir := filepath.Join(dir, "frontend", "1.0.0", "static")
+	require.NoError(t, os.MkdirAll(bundleDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(bundleDir, "bundle.min.js.map"), []byte("{}"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secret.map"), []byte("secret"), 0644))
+	fetcher := DirectoryFetcher{Dir: dir}
+
+	for _, test := range []struct {
+		id    Identifier
+		found bool
+	}{{
+		id:    Identifier{ServiceName: "frontend", ServiceVersion: "1.0.0", BundleFilepath: testBundleURL},
+		found: true,
+	}, {
+		id:    Identifier{ServiceName: "frontend", ServiceVersion: "1.0.0", BundleFilepath: "/static/bundle.min.js"},
+		found: true,
+	}, {
+		id: Identifier{ServiceName: "frontend", ServiceVersion: "2.0.0", BundleFilepath: "/static/bundle.min.js"},
+	}, {
+		id: Identifier{ServiceName: "frontend", ServiceVersion: "1.0.0", BundleFilepath: "/../../secret"},
+	}, {
+		id: Identifier{ServiceName: "..", ServiceVersion: "..", BundleFilepath: "/secret"},
+	}, {
+		id: Identifier{ServiceName: "frontend", ServiceVersion: "1.0.0"},
+	}} {
+		data, err := fetcher.Fetch(context.Background(), test.id)
+		if test.found {
+			assert.NoError(t, err, test.id)
+			assert.Equal(t, []byte("{}"), data)
+		} else {
+			assert.Equal(t, ErrNotFound, err, test.id)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package sourcemap provides a model.BatchProcessor which applies source
+// maps to the stack traces of events from RUM and Node.js agents, resolving
+// minified or transpiled stack frames to their original source locations.
+package sourcemap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/elastic/apm-data/model"
+)
+
+const (
+	// contextLines holds the number of lines of original source
+	// to include before and after the line of a mapped frame.
+	contextLines = 5
+
+	anonymousFunction = "<anonymous>"
+	unknownFunction   = "<unknown>"
+)
+
+// BatchProcessor is a model.BatchProcessor that applies source maps to
+// the stack frames of errors and spans from RUM and Node.js agents.
+//
+// Source maps are identified by the event's service name and version,
+// and the frame's abs_path. Mapped frames have their original location
+// recorded in the frame's Original field, and SourcemapUpdated set.
+type BatchProcessor struct {
+	config Config
+	cache  *cache
+}
+
+// NewBatchProcessor returns a new BatchProcessor with the given config.
+func NewBatchProcessor(config Config) (*BatchProcessor, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid source map config: %w", err)
+	}
+	if config.Logger == nil {
+		config.Logger = zap.NewNop()
+	} else {
+		config.Logger = config.Logger.Named("sourcemap")
+	}
+	return &BatchProcessor{
+		config: config,
+		cache:  newCache(config.CacheSize, config.CacheExpiration),
+	}, nil
+}
+
+// ProcessBatch applies source maps to the stack traces of events in b.
+func (p *BatchProcessor) ProcessBatch(ctx context.Context, b *model.Batch) error {
+	for i := range *b {
+		event := &(*b)[i]
+		if !isSourceMappedAgentName(event.Agent.Name) || event.Service.Name == "" || event.Service.Version == "" {
+			continue
+		}
+		if event.Span != nil {
+			p.processStacktrace(ctx, &event.Service, event.Span.Stacktrace)
+		}
+		if event.Error != nil {
+			p.processError(ctx, &event.Service, event.Error)
+		}
+	}
+	return nil
+}
+
+func (p *BatchProcessor) processError(ctx context.Context, service *model.Service, e *model.Error) {
+	var mapped bool
+	if e.Log != nil {
+		mapped = p.processStacktrace(ctx, service, e.Log.Stacktrace) || mapped
+	}
+	if e.Exception != nil {
+		mapped = p.processException(ctx, service, e.Exception) || mapped
+	}
+	if !mapped {
+		return
+	}
+	// Update the culprit to refer to the original source,
+	// preferring the exception's stack trace.
+	var stacktrace model.Stacktrace
+	if e.Exception != nil && len(e.Exception.Stacktrace) > 0 {
+		stacktrace = e.Exception.Stacktrace
+	} else if e.Log != nil {
+		stacktrace = e.Log.Stacktrace
+	}
+	if culprit := culprit(stacktrace); culprit != "" {
+		e.Culprit = culprit
+	}
+}
+
+func (p *BatchProcessor) processException(ctx context.Context, service *model.Service, e *model.Exception) bool {
+	mapped := p.processStacktrace(ctx, service, e.Stacktrace)
+	for i := range e.Cause {
+		mapped = p.processException(ctx, service, &e.Cause[i]) || mapped
+	}
+	return mapped
+}
+
+// processStacktrace applies source maps to the frames of stacktrace,
+// reporting whether any frames were mapped.
+func (p *BatchProcessor) processStacktrace(ctx context.Context, service *model.Service, stacktrace model.Stacktrace) bool {
+	var mapped bool
+	// The original function name of a frame is given by the name
+	// mapped at the call site in the calling frame, so frames are
+	// processed from the outermost frame inwards.
+	function := anonymousFunction
+	for i := len(stacktrace) - 1; i >= 0; i-- {
+		frame := stacktrace[i]
+		if frame == nil {
+			continue
+		}
+		pos, sourceLines, ok := p.processFrame(ctx, service, frame)
+		if !ok {
+			// The function name of the next frame can only
+			// be resolved from the call site in this frame.
+			function = unknownFunction
+			continue
+		}
+		mapFrame(frame, pos, function, sourceLines)
+		mapped = true
+		if pos.Name != "" {
+			function = pos.Name
+		} else {
+			function = unknownFunction
+		}
+	}
+	return mapped
+}
+
+// processFrame returns the original position of frame, and the lines of
+// the original source file if known. If the frame cannot be mapped due to
+// an error, the error is recorded in frame.SourcemapError.
+func (p *BatchProcessor) processFrame(
+	ctx context.Context, service *model.Service, frame *model.StacktraceFrame,
+) (Position, []string, bool) {
+	if frame.SourcemapUpdated || frame.AbsPath == "" {
+		return Position{}, nil, false
+	}
+	sourceMap, err := p.fetch(ctx, service, frame.AbsPath)
+	if err != nil {
+		frame.SourcemapError = err.Error()
+		return Position{}, nil, false
+	}
+	if sourceMap == nil {
+		return Position{}, nil, false
+	}
+	if frame.Lineno == nil || frame.Colno == nil {
+		frame.SourcemapError = "lineno and colno are required for source mapping"
+		return Position{}, nil, false
+	}
+	pos, ok := sourceMap.Lookup(*frame.Lineno, *frame.Colno)
+	if !ok {
+		frame.SourcemapError = fmt.Sprintf(
+			"no source mapping for line %d column %d", *frame.Lineno, *frame.Colno,
+		)
+		return Position{}, nil, false
+	}
+	return pos, sourceMap.SourceLines(pos.Source), true
+}
+
+// mapFrame rewrites frame to refer to the original source position,
+// preserving the generated location in frame.Original.
+func mapFrame(frame *model.StacktraceFrame, pos Position, function string, sourceLines []string) {
+	frame.Original = model.Original{
+		AbsPath:      frame.AbsPath,
+		Filename:     frame.Filename,
+		Classname:    frame.Classname,
+		Lineno:       frame.Lineno,
+		Colno:        frame.Colno,
+		Function:     frame.Function,
+		LibraryFrame: frame.LibraryFrame,
+	}
+	line, column := pos.Line, pos.Column
+	frame.AbsPath = pos.Source
+	frame.Filename = pos.Source
+	frame.Classname = ""
+	frame.Function = function
+	frame.Lineno = &line
+	frame.Colno = &column
+	frame.SourcemapUpdated = true
+	frame.SourcemapError = ""
+
+	frame.ContextLine = ""
+	frame.PreContext = nil
+	frame.PostContext = nil
+	if line >= 1 && line <= len(sourceLines) {
+		frame.ContextLine = sourceLines[line-1]
+		start := line - 1 - contextLines
+		if start < 0 {
+			start = 0
+		}
+		end := line + contextLines
+		if end > len(sourceLines) {
+			end = len(sourceLines)
+		}
+		frame.PreContext = append([]string(nil), sourceLines[start:line-1]...)
+		frame.PostContext = append([]string(nil), sourceLines[line:end]...)
+	}
+}
+
+// fetch returns the parsed source map for the bundle at absPath, or nil
+// if there is none. Source maps, and their absence, are cached.
+//
+// Bundles are first looked up by their full abs_path, excluding any
+// query or fragment, and then by the URL path alone.
+func (p *BatchProcessor) fetch(ctx context.Context, service *model.Service, absPath string) (*SourceMap, error) {
+	bundleFilepath := cleanBundleFilepath(absPath)
+	ids := []Identifier{{
+		ServiceName:    service.Name,
+		ServiceVersion: service.Version,
+		BundleFilepath: bundleFilepath,
+	}}
+	if urlPath := bundleURLPath(bundleFilepath); urlPath != bundleFilepath {
+		id := ids[0]
+		id.BundleFilepath = urlPath
+		ids = append(ids, id)
+	}
+	for _, id := range ids {
+		sourceMap, err := p.fetchIdentifier(ctx, id)
+		if sourceMap != nil || err != nil {
+			return sourceMap, err
+		}
+	}
+	return nil, nil
+}
+
+func (p *BatchProcessor) fetchIdentifier(ctx context.Context, id Identifier) (*SourceMap, error) {
+	now := time.Now()
+	if entry, ok := p.cache.get(id, now); ok {
+		return entry.sourceMap, entry.err
+	}
+	data, err := p.config.Fetcher.Fetch(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			p.cache.add(id, nil, nil, now)
+			return nil, nil
+		}
+		// Fetch errors may be transient, so are not cached.
+		p.config.Logger.Error(
+			"failed to fetch source map",
+			zap.String("service.name", id.ServiceName),
+			zap.String("service.version", id.ServiceVersion),
+			zap.String("bundle_filepath", id.BundleFilepath),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("failed to fetch source map: %w", err)
+	}
+	sourceMap, err := Parse(data)
+	if err != nil {
+		err = fmt.Errorf("invalid source map for %q: %w", id.BundleFilepath, err)
+	}
+	entry := p.cache.add(id, sourceMap, err, now)
+	return entry.sourceMap, entry.err
+}
+
+// culprit returns a culprit describing the first mapped,
+// non-library frame in stacktrace, if any.
+func culprit(stacktrace model.Stacktrace) string {
+	for _, frame := range stacktrace {
+		if frame == nil || !frame.SourcemapUpdated || frame.LibraryFrame {
+			continue
+		}
+		if frame.Function == "" {
+			return frame.Filename
+		}
+		return frame.Filename + " in " + frame.Function
+	}
+	return ""
+}
+
+// cleanBundleFilepath removes any query or fragment from a bundle URL.
+func cleanBundleFilepath(absPath string) string {
+	if i := strings.IndexAny(absPath, "?#"); i >= 0 {
+		return absPath[:i]
+	}
+	return absPath
+}
+
+// bundleURLPath returns the path of a bundle URL, or
+// the bundle file path if it is not an absolute URL.
+func bundleURLPath(bundleFilepath string) string {
+	if !strings.Contains(bundleFilepath, "://") {
+		return bundleFilepath
+	}
+	u, err := url.Parse(bundleFilepath)
+	if err != nil || u.Host == "" {
+		return bundleFilepath
+	}
+	return u.Path
+}
+
+func isSourceMappedAgentName(agentName string) bool {
+	switch agentName {
+	case "rum-js", "js-base", "nodejs":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,309 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sourcemap
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/apm-data/model"
+)
+
+const testBundleURL = "http://localhost:8000/static/bundle.min.js"
+
+func TestBatchProcessor(t *testing.T) {
+	fetcher := NewMemoryFetcher()
+	fetcher.Add(Identifier{
+		ServiceName:    "frontend",
+		ServiceVersion: "1.0.0",
+		BundleFilepath: testBundleURL,
+	}, testSourceMap(t))
+	processor := newTestBatchProcessor(t, fetcher)
+
+	stacktrace := model.Stacktrace{
+		testFrame(testBundleURL+"?v=1", 1, strings.Index(testBundle, "throw")+1),
+		testFrame(testBundleURL, 1, strings.Index(testBundle, `a("boom")`)+1),
+		{AbsPath: "http://localhost:8000/static/vendor.js", Lineno: newInt(1), Colno: newInt(1)},
+		testFrame(testBundleURL, 1, strings.Index(testBundle, "c();")+1),
+	}
+	batch := model.Batch{{
+		Agent:   model.Agent{Name: "rum-js"},
+		Service: model.Service{Name: "frontend", Version: "1.0.0"},
+		Error: &model.Error{
+			Culprit:   "bundle.min.js",
+			Exception: &model.Exception{Message: "boom", Stacktrace: stacktrace},
+		},
+	}}
+	require.NoError(t, processor.ProcessBatch(context.Background(), &batch))
+
+	sourceLines := strings.Split(testSource, "\n")
+	assert.Equal(t, &model.StacktraceFrame{
+		AbsPath:          "webpack:///src/app.js",
+		Filename:         "webpack:///src/app.js",
+		Function:         "fail",
+		Lineno:           newInt(2),
+		Colno:            newInt(3),
+		ContextLine:      sourceLines[1],
+		PreContext:       sourceLines[:1],
+		PostContext:      sourceLines[2:7],
+		SourcemapUpdated: true,
+		Original: model.Original{
+			AbsPath:  testBundleURL + "?v=1",
+			Filename: "bundle.min.js",
+			Function: "a",
+			Lineno:   newInt(1),
+			Colno:    newInt(strings.Index(testBundle, "throw") + 1),
+		},
+	}, stacktrace[0])
+
+	// The function name of the second frame cannot be resolved,
+	// as the calling frame is from a bundle with no source map.
+	assert.True(t, stacktrace[1].SourcemapUpdated)
+	assert.Equal(t, "<unknown>", stacktrace[1].Function)
+	assert.Equal(t, newInt(6), stacktrace[1].Lineno)
+
+	assert.False(t, stacktrace[2].SourcemapUpdated)
+	assert.Empty(t, stacktrace[2].SourcemapError)
+
+	assert.True(t, stacktrace[3].SourcemapUpdated)
+	assert.Equal(t, "<anonymous>", stacktrace[3].Function)
+	assert.Equal(t, newInt(9), stacktrace[3].Lineno)
+
+	assert.Equal(t, "webpack:///src/app.js in fail", batch[0].Error.Culprit)
+}
+
+func TestBatchProcessorFunctionNames(t *testing.T) {
+	fetcher := NewMemoryFetcher()
+	fetcher.Add(Identifier{
+		ServiceName:    "backend",
+		ServiceVersion: "1.0.0",
+		BundleFilepath: "/static/bundle.min.js",
+	}, testSourceMap(t))
+	processor := newTestBatchProcessor(t, fetcher)
+
+	// Bundles are looked up by URL path if there is no
+	// source map for the full URL.
+	stacktrace := model.Stacktrace{
+		testFrame(testBundleURL, 1, strings.Index(testBundle, "throw")+1),
+		testFrame(testBundleURL, 1, strings.Index(testBundle, `a("boom")`)+1),
+		testFrame(testBundleURL, 1, strings.Index(testBundle, "c();")+1),
+	}
+	batch := model.Batch{{
+		Agent:   model.Agent{Name: "nodejs"},
+		Service: model.Service{Name: "backend", Version: "1.0.0"},
+		Span:    &model.Span{Stacktrace: stacktrace},
+	}}
+	require.NoError(t, processor.ProcessBatch(context.Background(), &batch))
+
+	var functions []string
+	for _, frame := range stacktrace {
+		assert.True(t, frame.SourcemapUpdated)
+		functions = append(functions, frame.Function)
+	}
+	assert.Equal(t, []string{"fail", "run", "<anonymous>"}, functions)
+}
+
+func TestBatchProcessorSkipped(t *testing.T) {
+	fetcher := &countingFetcher{Fetcher: NewMemoryFetcher()}
+	processor := newTestBatchProcessor(t, fetcher)
+
+	frame := testFrame(testBundleURL, 1, 1)
+	for _, event := range []model.APMEvent{{
+		// Not a RUM or Node.js agent.
+		Agent:   model.Agent{Name: "java"},
+		Service: model.Service{Name: "frontend", Version: "1.0.0"},
+		Span:    &model.Span{Stacktrace: model.Stacktrace{frame}},
+	}, {
+		// No service version.
+		Agent:   model.Agent{Name: "rum-js"},
+		Service: model.Service{Name: "frontend"},
+		Span:    &model.Span{Stacktrace: model.Stacktrace{frame}},
+	}} {
+		batch := model.Batch{event}
+		require.NoError(t, processor.ProcessBatch(context.Background(), &batch))
+	}
+	assert.Zero(t, fetcher.calls)
+	assert.Equal(t, testFrame(testBundleURL, 1, 1), frame)
+}
+
+func TestBatchProcessorErrors(t *testing.T) {
+	fetcher := NewMemoryFetcher()
+	fetcher.Add(Identifier{
+		ServiceName:    "frontend",
+		ServiceVersion: "1.0.0",
+		BundleFilepath: testBundleURL,
+	}, testSourceMap(t))
+	fetcher.Add(Identifier{
+		ServiceName:    "frontend",
+		ServiceVersion: "1.0.0",
+		BundleFilepath: "http://localhost:8000/static/invalid.js",
+	}, []byte(`{"version": 2}`))
+	fetcher.Add(Identifier{
+		ServiceName:    "frontend",
+		ServiceVersion: "1.0.0",
+		BundleFilepath: "http://localhost:8000/static/negative.js",
+	}, []byte(`{"version": 3, "sources": ["a.js"], "sourcesContent": ["a"], "mappings": "AADA"}`))
+	processor := newTestBatchProcessor(t, fetcher)
+
+	stacktrace := model.Stacktrace{
+		testFrame(testBundleURL, 2, 1),
+		{AbsPath: testBundleURL},
+		testFrame("http://localhost:8000/static/invalid.js", 1, 1),
+		testFrame("http://localhost:8000/static/negative.js", 1, 1),
+	}
+	batch := model.Batch{{
+		Agent:   model.Agent{Name: "rum-js"},
+		Service: model.Service{Name: "frontend", Version: "1.0.0"},
+		Error:   &model.Error{Log: &model.ErrorLog{Stacktrace: stacktrace}},
+	}}
+	require.NoError(t, processor.ProcessBatch(context.Background(), &batch))
+
+	var errors []string
+	for _, frame := range stacktrace {
+		assert.False(t, frame.SourcemapUpdated)
+		errors = append(errors, frame.SourcemapError)
+	}
+	assert.Equal(t, []string{
+		"no source mapping for line 2 column 1",
+		"lineno and colno are required for source mapping",
+		`invalid source map for "http://localhost:8000/static/invalid.js": unsupported source map version 2`,
+		`invalid source map for "http://localhost:8000/static/negative.js": failed to parse mappings: line 0: invalid original position -1:0`,
+	}, errors)
+}
+
+func TestBatchProcessorCache(t *testing.T) {
+	memoryFetcher := NewMemoryFetcher()
+	memoryFetcher.Add(Identifier{
+		ServiceName:    "frontend",
+		ServiceVersion: "1.0.0",
+		BundleFilepath: testBundleURL,
+	}, testSourceMap(t))
+	fetcher := &countingFetcher{Fetcher: memoryFetcher}
+	processor := newTestBatchProcessor(t, fetcher)
+
+	process := func(version string) {
+		batch := model.Batch{{
+			Agent:   model.Agent{Name: "rum-js"},
+			Service: model.Service{Name: "frontend", Version: version},
+			Span:    &model.Span{Stacktrace: model.Stacktrace{testFrame(testBundleURL, 1, 1)}},
+		}}
+		require.NoError(t, processor.ProcessBatch(context.Background(), &batch))
+	}
+	process("1.0.0")
+	process("1.0.0")
+	assert.Equal(t, int64(1), fetcher.calls)
+
+	// The absence of a source map is cached too, for both
+	// the full bundle URL and the bundle URL path.
+	process("2.0.0")
+	process("2.0.0")
+	assert.Equal(t, int64(3), fetcher.calls)
+
+	// The cache size is 2, so 1.0.0 has been evicted.
+	process("1.0.0")
+	assert.Equal(t, int64(4), fetcher.calls)
+}
+
+func TestBatchProcessorFetchError(t *testing.T) {
+	fetcher := fetcherFunc(func(ctx context.Context, id Identifier) ([]byte, error) {
+		return nil, errors.New("connection refused")
+	})
+	processor := newTestBatchProcessor(t, fetcher)
+
+	frame := testFrame(testBundleURL, 1, 1)
+	batch := model.Batch{{
+		Agent:   model.Agent{Name: "rum-js"},
+		Service: model.Service{Name: "frontend", Version: "1.0.0"},
+		Span:    &model.Span{Stacktrace: model.Stacktrace{frame}},
+	}}
+	require.NoError(t, processor.ProcessBatch(context.Background(), &batch))
+	assert.False(t, frame.SourcemapUpdated)
+	assert.Equal(t, "failed to fetch source map: connection refused", frame.SourcemapError)
+}
+
+func TestCacheExpiration(t *testing.T) {
+	c := newCache(10, time.Minute)
+	id := Identifier{ServiceName: "frontend"}
+	now := time.Now()
+	c.add(id, nil, nil, now)
+	_, ok := c.get(id, now.Add(time.Minute-1))
+	assert.True(t, ok)
+	_, ok = c.get(id, now.Add(time.Minute))
+	assert.False(t, ok)
+}
+
+func TestConfigValidate(t *testing.T) {
+	for _, test := range []struct {
+		config Config
+		err    string
+	}{
+		{config: Config{}, err: "Fetcher unspecified"},
+		{config: Config{Fetcher: NewMemoryFetcher()}, err: "CacheSize unspecified or negative"},
+		{config: Config{Fetcher: NewMemoryFetcher(), CacheSize: 1}, err: "CacheExpiration unspecified or negative"},
+	} {
+		_, err := NewBatchProcessor(test.config)
+		assert.EqualError(t, err, "invalid source map config: "+test.err)
+	}
+}
+
+func newTestBatchProcessor(t testing.TB, fetcher Fetcher) *BatchProcessor {
+	processor, err := NewBatchProcessor(Config{
+		Fetcher:         fetcher,
+		CacheSize:       2,
+		CacheExpiration: time.Minute,
+	})
+	require.NoError(t, err)
+	return processor
+}
+
+func testFrame(absPath string, lineno, colno int) *model.StacktraceFrame {
+	return &model.StacktraceFrame{
+		AbsPath:  absPath,
+		Filename: "bundle.min.js",
+		Function: "a",
+		Lineno:   newInt(lineno),
+		Colno:    newInt(colno),
+	}
+}
+
+func newInt(v int) *int {
+	return &v
+}
+
+type countingFetcher struct {
+	Fetcher
+	calls int64
+}
+
+func (f *countingFetcher) Fetch(ctx context.Context, id Identifier) ([]byte, error) {
+	atomic.AddInt64(&f.calls, 1)
+	return f.Fetcher.Fetch(ctx, id)
+}
+
+type fetcherFunc func(context.Context, Identifier) ([]byte, error)
+
+func (f fetcherFunc) Fetch(ctx context.Context, id Identifier) ([]byte, error) {
+	return f(ctx, id)
+}
@@ -0,0 +1,231 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sourcemap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SourceMap holds a parsed source map, as described by the
+// Source Map Revision 3 Proposal.
+type SourceMap struct {
+	sources        []string
+	sourceIndex    map[string]int
+	sourcesContent [][]string
+	names          []string
+
+	// lines holds the mappings for each generated line,
+	// ordered by generated column.
+	lines [][]mapping
+}
+
+type mapping struct {
+	generatedColumn int
+	source          int
+	line            int
+	column          int
+	name            int
+}
+
+// Position holds an original source position resolved from a SourceMap.
+type Position struct {
+	// Source holds the original source file path.
+	Source string
+
+	// Name holds the original name of the symbol at the generated
+	// position, if known.
+	Name string
+
+	// Line and Column hold the 1-based original line and column.
+	Line   int
+	Column int
+}
+
+type rawSourceMap struct {
+	Version        int               `json:"version"`
+	SourceRoot     string            `json:"sourceRoot"`
+	Sources        []string          `json:"sources"`
+	SourcesContent []*string         `json:"sourcesContent"`
+	Names          []string          `json:"names"`
+	Mappings       string            `json:"mappings"`
+	Sections       []json.RawMessage `json:"sections"`
+}
+
+// Parse parses a version 3 source map. Index maps, which
+// hold sections rather than mappings, are not supported.
+func Parse(data []byte) (*SourceMap, error) {
+	var raw rawSourceMap
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode source map: %w", err)
+	}
+	if raw.Version != 3 {
+		return nil, fmt.Errorf("unsupported source map version %d", raw.Version)
+	}
+	if len(raw.Sections) > 0 {
+		return nil, errors.New("index source maps are not supported")
+	}
+
+	sm := &SourceMap{
+		sources:        make([]string, len(raw.Sources)),
+		sourceIndex:    make(map[string]int, len(raw.Sources)),
+		sourcesContent: make([][]string, len(raw.Sources)),
+		names:          raw.Names,
+	}
+	sourceRoot := raw.SourceRoot
+	if sourceRoot != "" && !strings.HasSuffix(sourceRoot, "/") {
+		sourceRoot += "/"
+	}
+	for i, source := range raw.Sources {
+		sm.sources[i] = sourceRoot + source
+		sm.sourceIndex[sm.sources[i]] = i
+		if i < len(raw.SourcesContent) && raw.SourcesContent[i] != nil {
+			sm.sourcesContent[i] = strings.Split(*raw.SourcesContent[i], "\n")
+		}
+	}
+	if err := sm.parseMappings(raw.Mappings); err != nil {
+		return nil, fmt.Errorf("failed to parse mappings: %w", err)
+	}
+	return sm, nil
+}
+
+func (sm *SourceMap) parseMappings(mappings string) error {
+	// source, line, column, and name are relative to the previous
+	// segment's values, across lines; the generated column is
+	// relative to the previous segment on the same line.
+	var source, line, column, name int
+	for lineIndex, lineMappings := range strings.Split(mappings, ";") {
+		var segments []mapping
+		var generatedColumn int
+		for _, segment := range strings.Split(lineMappings, ",") {
+			if segment == "" {
+				continue
+			}
+			fields, err := decodeVLQ(segment)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", lineIndex, err)
+			}
+			generatedColumn += fields[0]
+			if generatedColumn < 0 {
+				return fmt.Errorf("line %d: invalid generated column %d", lineIndex, generatedColumn)
+			}
+			m := mapping{generatedColumn: generatedColumn, source: -1, name: -1}
+			switch len(fields) {
+			case 1:
+			case 4, 5:
+				source += fields[1]
+				line += fields[2]
+				column += fields[3]
+				if source < 0 || source >= len(sm.sources) {
+					return fmt.Errorf("line %d: invalid source index %d", lineIndex, source)
+				}
+				if line < 0 || column < 0 {
+					return fmt.Errorf("line %d: invalid original position %d:%d", lineIndex, line, column)
+				}
+				m.source, m.line, m.column = source, line, column
+				if len(fields) == 5 {
+					name += fields[4]
+					if name < 0 || name >= len(sm.names) {
+						return fmt.Errorf("line %d: invalid name index %d", lineIndex, name)
+					}
+					m.name = name
+				}
+			default:
+				return fmt.Errorf("line %d: invalid segment %q", lineIndex, segment)
+			}
+			segments = append(segments, m)
+		}
+		sort.SliceStable(segments, func(i, j int) bool {
+			return segments[i].generatedColumn < segments[j].generatedColumn
+		})
+		sm.lines = append(sm.lines, segments)
+	}
+	return nil
+}
+
+// Lookup returns the original position for the given 1-based generated
+// line and column, reporting whether the position could be resolved.
+func (sm *SourceMap) Lookup(line, column int) (Position, bool) {
+	if line < 1 || line > len(sm.lines) || column < 1 {
+		return Position{}, false
+	}
+	segments := sm.lines[line-1]
+	i := sort.Search(len(segments), func(i int) bool {
+		return segments[i].generatedColumn > column-1
+	})
+	if i == 0 {
+		return Position{}, false
+	}
+	m := segments[i-1]
+	if m.source == -1 {
+		return Position{}, false
+	}
+	pos := Position{
+		Source: sm.sources[m.source],
+		Line:   m.line + 1,
+		Column: m.column + 1,
+	}
+	if m.name != -1 {
+		pos.Name = sm.names[m.name]
+	}
+	return pos, true
+}
+
+// SourceLines returns the lines of the original source file, if
+// the source map includes its content.
+func (sm *SourceMap) SourceLines(source string) []string {
+	if i, ok := sm.sourceIndex[source]; ok {
+		return sm.sourcesContent[i]
+	}
+	return nil
+}
+
+const base64Chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// decodeVLQ decodes a sequence of Base64 VLQ values.
+func decodeVLQ(s string) ([]int, error) {
+	var values []int
+	var value, shift int
+	for i := 0; i < len(s); i++ {
+		digit := strings.IndexByte(base64Chars, s[i])
+		if digit == -1 {
+			return nil, fmt.Errorf("invalid base64 character %q", s[i])
+		}
+		value += (digit & 0x1f) << shift
+		if digit&0x20 != 0 {
+			shift += 5
+			if shift > 30 {
+				return nil, errors.New("VLQ value overflows")
+			}
+			continue
+		}
+		if value&1 != 0 {
+			values = append(values, -(value >> 1))
+		} else {
+			values = append(values, value>>1)
+		}
+		value, shift = 0, 0
+	}
+	if shift != 0 {
+		return nil, errors.New("truncated VLQ value")
+	}
+	return values, nil
+}
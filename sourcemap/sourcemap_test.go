@@ -0,0 +1,198 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sourcemap
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testBundle = `function a(b){throw new Error(b)}function c(){a("boom")}c();`
+	testSource = `function fail(message) {
+  throw new Error(message);
+}
+
+function run() {
+  fail("boom");
+}
+
+run();`
+)
+
+// testSourceMap returns a source map for testBundle, with testSource
+// as its only source.
+func testSourceMap(t testing.TB) []byte {
+	column := func(s string) int {
+		i := strings.Index(testBundle, s)
+		require.NotEqual(t, -1, i, s)
+		return i
+	}
+	// Segments are [generated column, source, line, column, (name)],
+	// with 0-based absolute values.
+	mappings := encodeMappings([][][]int{{
+		{0, 0, 0, 0},
+		{column("a(b)"), 0, 0, 9, 0},
+		{column("throw"), 0, 1, 2},
+		{column("function c"), 0, 4, 0},
+		{column("c(){"), 0, 4, 9, 1},
+		{column(`a("boom")`), 0, 5, 2, 0},
+		{column("c();"), 0, 8, 0, 1},
+	}})
+	data, err := json.Marshal(map[string]interface{}{
+		"version":        3,
+		"file":           "bundle.min.js",
+		"sourceRoot":     "webpack:///",
+		"sources":        []string{"src/app.js"},
+		"sourcesContent": []string{testSource},
+		"names":          []string{"fail", "run"},
+		"mappings":       mappings,
+	})
+	require.NoError(t, err)
+	return data
+}
+
+// encodeMappings encodes absolute segments as a source map "mappings" value.
+func encodeMappings(lines [][][]int) string {
+	var sb strings.Builder
+	var prev [5]int
+	for i, segments := range lines {
+		if i > 0 {
+			sb.WriteByte(';')
+		}
+		prev[0] = 0
+		for j, segment := range segments {
+			if j > 0 {
+				sb.WriteByte(',')
+			}
+			for k, value := range segment {
+				encodeVLQ(&sb, value-prev[k])
+				prev[k] = value
+			}
+		}
+	}
+	return sb.String()
+}
+
+func encodeVLQ(sb *strings.Builder, value int) {
+	if value < 0 {
+		value = (-value << 1) | 1
+	} else {
+		value <<= 1
+	}
+	for {
+		digit := value & 0x1f
+		value >>= 5
+		if value > 0 {
+			digit |= 0x20
+		}
+		sb.WriteByte(base64Chars[digit])
+		if value == 0 {
+			return
+		}
+	}
+}
+
+func TestParseLookup(t *testing.T) {
+	sm, err := Parse(testSourceMap(t))
+	require.NoError(t, err)
+
+	column := strings.Index(testBundle, "throw") + 1
+	for _, test := range []struct {
+		line, column int
+		expected     Position
+		ok           bool
+	}{
+		{line: 1, column: 1, expected: Position{Source: "webpack:///src/app.js", Line: 1, Column: 1}, ok: true},
+		{line: 1, column: column, expected: Position{Source: "webpack:///src/app.js", Line: 2, Column: 3}, ok: true},
+		// Columns between segments resolve to the preceding segment.
+		{line: 1, column: column + 3, expected: Position{Source: "webpack:///src/app.js", Line: 2, Column: 3}, ok: true},
+		{
+			line: 1, column: strings.Index(testBundle, `a("boom")`) + 1,
+			expected: Position{Source: "webpack:///src/app.js", Name: "fail", Line: 6, Column: 3},
+			ok:       true,
+		},
+		{line: 2, column: 1},
+		{line: 0, column: 1},
+		{line: 1, column: 0},
+	} {
+		pos, ok := sm.Lookup(test.line, test.column)
+		assert.Equal(t, test.ok, ok, "%d:%d", test.line, test.column)
+		assert.Equal(t, test.expected, pos, "%d:%d", test.line, test.column)
+	}
+
+	lines := sm.SourceLines("webpack:///src/app.js")
+	assert.Equal(t, strings.Split(testSource, "\n"), lines)
+	assert.Nil(t, sm.SourceLines("src/other.js"))
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, test := range []struct {
+		sourceMap string
+		err       string
+	}{
+		{sourceMap: `{`, err: "failed to decode source map: unexpected end of JSON input"},
+		{sourceMap: `{"version": 2}`, err: "unsupported source map version 2"},
+		{sourceMap: `{"version": 3, "sections": [{}]}`, err: "index source maps are not supported"},
+		{
+			sourceMap: `{"version": 3, "sources": ["a.js"], "mappings": "A!"}`,
+			err:       `failed to parse mappings: line 0: invalid base64 character '!'`,
+		},
+		{
+			sourceMap: `{"version": 3, "sources": ["a.js"], "mappings": "AAAA;AC"}`,
+			err:       `failed to parse mappings: line 1: invalid segment "AC"`,
+		},
+		{
+			sourceMap: `{"version": 3, "sources": ["a.js"], "mappings": "ACAA"}`,
+			err:       "failed to parse mappings: line 0: invalid source index 1",
+		},
+		{
+			sourceMap: `{"version": 3, "sources": ["a.js"], "mappings": "AADA"}`,
+			err:       "failed to parse mappings: line 0: invalid original position -1:0",
+		},
+		{
+			sourceMap: `{"version": 3, "sources": ["a.js"], "mappings": "AAAA;AAAD"}`,
+			err:       "failed to parse mappings: line 1: invalid original position 0:-1",
+		},
+		{
+			sourceMap: `{"version": 3, "sources": ["a.js"], "mappings": "D"}`,
+			err:       "failed to parse mappings: line 0: invalid generated column -1",
+		},
+		{
+			sourceMap: `{"version": 3, "sources": ["a.js"], "mappings": "g"}`,
+			err:       "failed to parse mappings: line 0: truncated VLQ value",
+		},
+	} {
+		_, err := Parse([]byte(test.sourceMap))
+		assert.EqualError(t, err, test.err, test.sourceMap)
+	}
+}
+
+func TestDecodeVLQ(t *testing.T) {
+	for _, value := range []int{0, 1, -1, 15, 16, -16, 1000, -123456} {
+		var sb strings.Builder
+		encodeVLQ(&sb, value)
+		values, err := decodeVLQ(sb.String())
+		require.NoError(t, err)
+		assert.Equal(t, []int{value}, values)
+	}
+}
@@ -0,0 +1,156 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package useragent parses User-Agent strings into structured ECS
+// user_agent fields, using a database of regular expressions. A default
+// database is embedded, so parsing requires no external resources.
+package useragent
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/elastic/apm-data/model"
+)
+
+//go:embed regexes.json
+var defaultDatabase []byte
+
+var defaultParser = mustNewParser(defaultDatabase)
+
+// Parse parses original using the embedded regular expression database,
+// returning a model.UserAgent with Original set to original.
+//
+// Parse is safe for concurrent use.
+func Parse(original string) model.UserAgent {
+	return defaultParser.Parse(original)
+}
+
+// Parser parses User-Agent strings using a database of regular expressions.
+//
+// A Parser is safe for concurrent use.
+type Parser struct {
+	userAgents []matcher
+	oses       []matcher
+	devices    []matcher
+}
+
+// NewParser returns a new Parser for the given JSON-encoded database.
+//
+// The database is an object with "user_agent_parsers", "os_parsers", and
+// "device_parsers" arrays. Each entry holds a "regex", and "name" and
+// "version" templates which may refer to capture groups as $1, $2, etc.
+// Entries are matched in order, and the first matching entry wins. An
+// entry whose name expands to an empty string stops matching, leaving
+// the associated fields unset.
+func NewParser(database []byte) (*Parser, error) {
+	var db struct {
+		UserAgentParsers []rule `json:"user_agent_parsers"`
+		OSParsers        []rule `json:"os_parsers"`
+		DeviceParsers    []rule `json:"device_parsers"`
+	}
+	if err := json.Unmarshal(database, &db); err != nil {
+		return nil, fmt.Errorf("failed to decode user-agent database: %w", err)
+	}
+	var p Parser
+	var err error
+	if p.userAgents, err = compileRules(db.UserAgentParsers); err != nil {
+		return nil, err
+	}
+	if p.oses, err = compileRules(db.OSParsers); err != nil {
+		return nil, err
+	}
+	if p.devices, err = compileRules(db.DeviceParsers); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func mustNewParser(database []byte) *Parser {
+	p, err := NewParser(database)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Parse parses original, returning a model.UserAgent with Original set
+// to original, and any other fields that could be parsed from it.
+func (p *Parser) Parse(original string) model.UserAgent {
+	ua := model.UserAgent{Original: original}
+	if original == "" {
+		return ua
+	}
+	ua.Name, ua.Version = match(p.userAgents, original)
+	ua.OS.Name, ua.OS.Version = match(p.oses, original)
+	if ua.OS.Name != "" {
+		// Some operating systems, e.g. iOS and macOS,
+		// use underscores as version separators.
+		ua.OS.Version = strings.ReplaceAll(ua.OS.Version, "_", ".")
+		ua.OS.Full = ua.OS.Name
+		if ua.OS.Version != "" {
+			ua.OS.Full += " " + ua.OS.Version
+		}
+	}
+	ua.Device.Name, _ = match(p.devices, original)
+	return ua
+}
+
+type rule struct {
+	Regex   string `json:"regex"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type matcher struct {
+	re      *regexp.Regexp
+	name    string
+	version string
+}
+
+func compileRules(rules []rule) ([]matcher, error) {
+	matchers := make([]matcher, len(rules))
+	for i, rule := range rules {
+		re, err := regexp.Compile(rule.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user-agent regex %q: %w", rule.Regex, err)
+		}
+		matchers[i] = matcher{re: re, name: rule.Name, version: rule.Version}
+	}
+	return matchers, nil
+}
+
+// match returns the expanded name and version templates of
+// the first matcher whose regular expression matches s.
+func match(matchers []matcher, s string) (name, version string) {
+	for _, m := range matchers {
+		submatches := m.re.FindStringSubmatchIndex(s)
+		if submatches == nil {
+			continue
+		}
+		name = string(m.re.ExpandString(nil, m.name, s, submatches))
+		if name == "" {
+			return "", ""
+		}
+		version = string(m.re.ExpandString(nil, m.version, s, submatches))
+		return strings.TrimSpace(name), version
+	}
+	return "", ""
+}
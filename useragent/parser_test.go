@@ -0,0 +1,164 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package useragent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/apm-data/model"
+)
+
+func TestParse(t *testing.T) {
+	for _, test := range []struct {
+		original string
+		name     string
+		version  string
+		os       model.OS
+		device   string
+	}{{
+		original: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/112.0.5615.49 Safari/537.36",
+		name:     "Chrome",
+		version:  "112.0.5615.49",
+		os:       model.OS{Name: "Mac OS X", Version: "10.15.7", Full: "Mac OS X 10.15.7"},
+		device:   "Mac",
+	}, {
+		original: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/112.0.0.0 Safari/537.36 Edg/112.0.1722.48",
+		name:     "Edge",
+		version:  "112.0.1722.48",
+		os:       model.OS{Name: "Windows", Version: "10", Full: "Windows 10"},
+	}, {
+		original: "Mozilla/5.0 (Windows NT 6.1; WOW64; rv:102.0) Gecko/20100101 Firefox/102.0",
+		name:     "Firefox",
+		version:  "102.0",
+		os:       model.OS{Name: "Windows", Version: "7", Full: "Windows 7"},
+	}, {
+		original: "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:109.0) Gecko/20100101 Firefox/112.0",
+		name:     "Firefox",
+		version:  "112.0",
+		os:       model.OS{Name: "Ubuntu", Full: "Ubuntu"},
+	}, {
+		original: "Mozilla/5.0 (iPhone; CPU iPhone OS 16_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.4 Mobile/15E148 Safari/604.1",
+		name:     "Mobile Safari",
+		version:  "16.4",
+		os:       model.OS{Name: "iOS", Version: "16.4", Full: "iOS 16.4"},
+		device:   "iPhone",
+	}, {
+		original: "Mozilla/5.0 (iPad; CPU OS 15_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/112.0.5615.46 Mobile/15E148 Safari/604.1",
+		name:     "Chrome Mobile iOS",
+		version:  "112.0.5615.46",
+		os:       model.OS{Name: "iOS", Version: "15.6", Full: "iOS 15.6"},
+		device:   "iPad",
+	}, {
+		original: "Mozilla/5.0 (Macintosh; Intel Mac OS X 13_3_1) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.4 Safari/605.1.15",
+		name:     "Safari",
+		version:  "16.4",
+		os:       model.OS{Name: "Mac OS X", Version: "13.3.1", Full: "Mac OS X 13.3.1"},
+		device:   "Mac",
+	}, {
+		original: "Mozilla/5.0 (Linux; Android 13; Pixel 7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/112.0.0.0 Mobile Safari/537.36",
+		name:     "Chrome Mobile",
+		version:  "112.0.0.0",
+		os:       model.OS{Name: "Android", Version: "13", Full: "Android 13"},
+		device:   "Pixel 7",
+	}, {
+		original: "Mozilla/5.0 (Linux; Android 12; SM-S906N Build/QP1A.190711.020; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/80.0.3987.119 Mobile Safari/537.36",
+		name:     "Chrome Mobile WebView",
+		version:  "80.0.3987.119",
+		os:       model.OS{Name: "Android", Version: "12", Full: "Android 12"},
+		device:   "SM-S906N",
+	}, {
+		original: "Mozilla/5.0 (Linux; Android 13; SAMSUNG SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/21.0 Chrome/110.0.5481.154 Mobile Safari/537.36",
+		name:     "Samsung Internet",
+		version:  "21.0",
+		os:       model.OS{Name: "Android", Version: "13", Full: "Android 13"},
+		device:   "SAMSUNG SM-S918B",
+	}, {
+		original: "Mozilla/5.0 (Android 13; Mobile; rv:109.0) Gecko/112.0 Firefox/112.0",
+		name:     "Firefox Mobile",
+		version:  "112.0",
+		os:       model.OS{Name: "Android", Version: "13", Full: "Android 13"},
+		device:   "Generic Smartphone",
+	}, {
+		original: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/112.0.0.0 Safari/537.36 OPR/98.0.0.0",
+		name:     "Opera",
+		version:  "98.0.0.0",
+		os:       model.OS{Name: "Windows", Version: "10", Full: "Windows 10"},
+	}, {
+		original: "Mozilla/5.0 (Windows NT 6.3; Trident/7.0; rv:11.0) like Gecko",
+		name:     "IE",
+		version:  "11.0",
+		os:       model.OS{Name: "Windows", Version: "8.1", Full: "Windows 8.1"},
+	}, {
+		original: "Mozilla/5.0 (X11; CrOS x86_64 15359.58.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/112.0.5615.134 Safari/537.36",
+		name:     "Chrome",
+		version:  "112.0.5615.134",
+		os:       model.OS{Name: "Chrome OS", Version: "15359.58.0", Full: "Chrome OS 15359.58.0"},
+	}, {
+		original: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+		name:     "Googlebot",
+		version:  "2.1",
+		device:   "Spider",
+	}, {
+		original: "curl/7.88.1",
+		name:     "curl",
+		version:  "7.88.1",
+	}, {
+		original: "elasticapm-go/2.4.1 go/go1.20.3",
+		name:     "elasticapm-go",
+		version:  "2.4.1",
+	}, {
+		original: "Mozilla/5.0 (X11; Linux x86_64) UnknownBrowser",
+		os:       model.OS{Name: "Linux", Full: "Linux"},
+	}, {
+		original: "",
+	}} {
+		t.Run(test.original, func(t *testing.T) {
+			assert.Equal(t, model.UserAgent{
+				Original: test.original,
+				Name:     test.name,
+				Version:  test.version,
+				OS:       test.os,
+				Device:   model.UserAgentDevice{Name: test.device},
+			}, Parse(test.original))
+		})
+	}
+}
+
+func TestNewParser(t *testing.T) {
+	p, err := NewParser([]byte(`{
+		"user_agent_parsers": [{"regex": "(Foo)Browser/(\\d+)", "name": "$1", "version": "$2"}],
+		"os_parsers": [{"regex": "FooOS", "name": "Foo OS"}],
+		"device_parsers": [{"regex": "; (\\w+)Phone", "name": "$1 Phone"}]
+	}`))
+	assert.NoError(t, err)
+	assert.Equal(t, model.UserAgent{
+		Original: "FooBrowser/12 (FooOS; BarPhone)",
+		Name:     "Foo",
+		Version:  "12",
+		OS:       model.OS{Name: "Foo OS", Full: "Foo OS"},
+		Device:   model.UserAgentDevice{Name: "Bar Phone"},
+	}, p.Parse("FooBrowser/12 (FooOS; BarPhone)"))
+
+	_, err = NewParser([]byte(`{"os_parsers": [{"regex": "("}]}`))
+	assert.EqualError(t, err, "invalid user-agent regex \"(\": error parsing regexp: missing closing ): `(`")
+
+	_, err = NewParser([]byte(`[]`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,63 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package useragent
+
+import (
+	"context"
+
+	"github.com/elastic/apm-data/model"
+)
+
+// BatchProcessor is a model.BatchProcessor that parses the original
+// User-Agent string of events, setting the structured user_agent fields.
+//
+// Events without an original User-Agent string, or which already have
+// a user_agent.name, are left unmodified.
+type BatchProcessor struct {
+	// Parser holds the Parser used for parsing User-Agent strings.
+	// If Parser is nil, the embedded database will be used.
+	Parser *Parser
+}
+
+// ProcessBatch parses the original User-Agent strings of events in b.
+func (p BatchProcessor) ProcessBatch(ctx context.Context, b *model.Batch) error {
+	parser := p.Parser
+	if parser == nil {
+		parser = defaultParser
+	}
+	// Events in a batch commonly share a User-Agent,
+	// so avoid parsing the same string multiple times.
+	var parsed map[string]model.UserAgent
+	for i := range *b {
+		event := &(*b)[i]
+		original := event.UserAgent.Original
+		if original == "" || event.UserAgent.Name != "" {
+			continue
+		}
+		ua, ok := parsed[original]
+		if !ok {
+			ua = parser.Parse(original)
+			if parsed == nil {
+				parsed = make(map[string]model.UserAgent)
+			}
+			parsed[original] = ua
+		}
+		event.UserAgent = ua
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package useragent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/apm-data/model"
+)
+
+func TestBatchProcessor(t *testing.T) {
+	const chrome = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/112.0.5615.49 Safari/537.36"
+	batch := model.Batch{
+		{UserAgent: model.UserAgent{Original: chrome}},
+		{UserAgent: model.UserAgent{Original: chrome}},
+		{UserAgent: model.UserAgent{Original: chrome, Name: "Custom"}},
+		{},
+	}
+	err := BatchProcessor{}.ProcessBatch(context.Background(), &batch)
+	assert.NoError(t, err)
+
+	parsed := model.UserAgent{
+		Original: chrome,
+		Name:     "Chrome",
+		Version:  "112.0.5615.49",
+		OS:       model.OS{Name: "Mac OS X", Version: "10.15.7", Full: "Mac OS X 10.15.7"},
+		Device:   model.UserAgentDevice{Name: "Mac"},
+	}
+	assert.Equal(t, model.Batch{
+		{UserAgent: parsed},
+		{UserAgent: parsed},
+		{UserAgent: model.UserAgent{Original: chrome, Name: "Custom"}},
+		{},
+	}, batch)
+}